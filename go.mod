@@ -7,7 +7,10 @@ require (
 	golang.org/x/crypto v0.28.0
 )
 
-require github.com/pkg/sftp v1.13.6
+require (
+	github.com/pkg/sftp v1.13.6
+	golang.org/x/term v0.25.0
+)
 
 require (
 	github.com/kr/fs v0.1.0 // indirect
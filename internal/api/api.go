@@ -0,0 +1,415 @@
+// Package api serves the JSON API of --daemon (api_listen), so orchestration tools can drive mysqlbackup across
+// a fleet: trigger a backup run, query status, catalog and run reports, fetch backups from the remote target
+// (like --getfile), and Prometheus metrics at /metrics. Every request needs api_password as bearer token. With
+// servers the query parameter server selects the server. With controller_dir it is also the controller of a
+// fleet (see package fleet); the requests of the agents use controller_password instead.
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/catalog"
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/errcode"
+	"github.com/janmz/mysqlbackup/internal/fleet"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/logger"
+	"github.com/janmz/mysqlbackup/internal/metrics"
+	"github.com/janmz/mysqlbackup/internal/remote"
+	"github.com/janmz/mysqlbackup/internal/runreport"
+	"github.com/janmz/mysqlbackup/internal/state"
+)
+
+// Options connects the API to the daemon.
+type Options struct {
+	Config  func() *config.Config    // current config (follows the reloads of the daemon)
+	Trigger func(server string) bool // queues a backup run of server ("" = all); false if a run is already queued or in progress
+	Running func() bool              // a backup run is in progress
+	Log     *logger.Logger
+}
+
+// Status is the response of GET /api/v1/status.
+type Status struct {
+	Running     bool              `json:"running"`
+	NextRun     *time.Time        `json:"next_run,omitempty"` // fehlt ohne Zeitplan
+	LastStart   *time.Time        `json:"last_start,omitempty"`
+	LastSuccess *time.Time        `json:"last_success,omitempty"`
+	LastError   string            `json:"last_error,omitempty"`
+	LastRun     *runreport.Report `json:"last_run"` // last_run.json, null vor dem ersten Lauf
+}
+
+// Run is one entry of GET /api/v1/runs.
+type Run struct {
+	ID       string    `json:"id"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Status   string    `json:"status"`
+	Code     string    `json:"code,omitempty"`
+}
+
+// Handler returns the API:
+//
+//	GET  /api/v1/status       state of the daemon and report of the last run
+//	POST /api/v1/backup       starts a backup run (202; 409 while one is queued or in progress)
+//	GET  /api/v1/catalog      catalog.json of backup_dir
+//	GET  /api/v1/runs         run reports of the history, newest first
+//	GET  /api/v1/runs/{id}    one report ("last" = last_run.json)
+//	POST /api/v1/getfile      {"pattern": "..."} downloads from the remote target into backup_dir (like --getfile)
+//	GET  /metrics             Prometheus metrics (like metrics_file, plus running and next run)
+//
+// With controller_dir (controller of a fleet):
+//
+//	GET  /api/v1/agents                 agents with last contact and last run report
+//	GET  /api/v1/agents/{name}/policy   policy of the agent (controller_password)
+//	POST /api/v1/agents/{name}/report   run report of the agent (controller_password)
+//
+// With servers every request except POST /api/v1/backup (without server: all servers) needs ?server=<name>.
+func Handler(opt Options) http.Handler {
+	s := &server{opt: opt}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/status", s.status)
+	mux.HandleFunc("POST /api/v1/backup", s.backup)
+	mux.HandleFunc("GET /api/v1/catalog", s.catalog)
+	mux.HandleFunc("GET /api/v1/runs", s.runs)
+	mux.HandleFunc("GET /api/v1/runs/{id}", s.run)
+	mux.HandleFunc("POST /api/v1/getfile", s.getfile)
+	mux.HandleFunc("GET /metrics", s.metrics)
+	mux.HandleFunc("GET /api/v1/agents", s.agents)
+	mux.HandleFunc("GET /api/v1/agents/{name}/policy", s.agentPolicy)
+	mux.HandleFunc("POST /api/v1/agents/{name}/report", s.agentReport)
+	return s.auth(mux)
+}
+
+// ListenAndServe serves h on api_listen of cfg (HTTPS with api_tls_cert and api_tls_key) until ctx is done.
+func ListenAndServe(ctx context.Context, cfg *config.Config, h http.Handler) error {
+	srv := &http.Server{Addr: cfg.APIListen, Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		c, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(c)
+	}()
+	var err error
+	if cfg.APITLSCert != "" {
+		err = srv.ListenAndServeTLS(cfg.APITLSCert, cfg.APITLSKey)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+type server struct {
+	opt   Options
+	getMu sync.Mutex // ein Download zur Zeit
+}
+
+// auth rejects requests without "Authorization: Bearer <api_password>" (constant-time comparison); the requests
+// of agents (/api/v1/agents/...) need controller_password.
+func (s *server) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		want := s.opt.Config().APIPassword
+		if strings.HasPrefix(r.URL.Path, agentPrefix) {
+			want = s.opt.Config().ControllerPassword
+		}
+		if !ok || want == "" || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(want)) != 1 {
+			s.opt.Log.Warn(i18n.Tf("log.warn.api_auth", r.RemoteAddr, r.URL.Path))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mysqlbackup"`)
+			writeError(w, http.StatusUnauthorized, "", i18n.T("err.api_unauthorized"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) status(w http.ResponseWriter, r *http.Request) {
+	cfg, ok := s.config(w, r)
+	if !ok {
+		return
+	}
+	st := Status{Running: s.opt.Running()}
+	if spec, err := cfg.ScheduleSpec(); err == nil {
+		st.NextRun = optTime(spec.Next(time.Now()))
+	}
+	if ss, err := state.Load(cfg.BackupDir); err == nil {
+		st.LastStart, st.LastSuccess, st.LastError = optTime(ss.LastStart), optTime(ss.LastSuccess), ss.LastError
+	}
+	rep, err := runreport.Load(cfg.BackupDir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	st.LastRun = rep
+	writeJSON(w, http.StatusOK, st)
+}
+
+func (s *server) backup(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("server")
+	if name != "" {
+		if _, ok := s.config(w, r); !ok {
+			return
+		}
+	}
+	if s.opt.Running() || !s.opt.Trigger(name) {
+		writeError(w, http.StatusConflict, errcode.Locked, i18n.T("err.api_busy"))
+		return
+	}
+	s.opt.Log.Info(i18n.Tf("log.msg.api_backup", r.RemoteAddr))
+	writeJSON(w, http.StatusAccepted, map[string]bool{"queued": true})
+}
+
+func (s *server) catalog(w http.ResponseWriter, r *http.Request) {
+	cfg, ok := s.config(w, r)
+	if !ok {
+		return
+	}
+	cat, err := catalog.Load(cfg.BackupDir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, cat)
+}
+
+func (s *server) runs(w http.ResponseWriter, r *http.Request) {
+	cfg, ok := s.config(w, r)
+	if !ok {
+		return
+	}
+	dir := cfg.BackupDir
+	ids, err := runreport.History(dir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	list := []Run{}
+	for _, id := range ids {
+		rep, err := runreport.LoadHistory(dir, id)
+		if err != nil {
+			continue // gerade von prune entfernt oder unlesbar
+		}
+		list = append(list, Run{ID: id, Started: rep.Started, Finished: rep.Finished, Status: rep.Status, Code: rep.Code})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *server) run(w http.ResponseWriter, r *http.Request) {
+	cfg, ok := s.config(w, r)
+	if !ok {
+		return
+	}
+	dir, id := cfg.BackupDir, r.PathValue("id")
+	var rep *runreport.Report
+	var err error
+	if id == "last" {
+		if rep, err = runreport.Load(dir); err == nil && rep == nil {
+			err = os.ErrNotExist
+		}
+	} else {
+		rep, err = runreport.LoadHistory(dir, id)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, "", i18n.Tf("err.api_run_not_found", id))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, rep)
+}
+
+func (s *server) getfile(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Pattern string `json:"pattern"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "", i18n.Tf("err.api_request", err))
+		return
+	}
+	if req.Pattern == "" {
+		writeError(w, http.StatusBadRequest, "", i18n.T("err.api_pattern"))
+		return
+	}
+	if filepath.Base(req.Pattern) != req.Pattern || strings.ContainsAny(req.Pattern, `/\`) || strings.Contains(req.Pattern, "..") {
+		writeError(w, http.StatusBadRequest, "", i18n.T("err.getfile_no_path"))
+		return
+	}
+	cfg, ok := s.config(w, r)
+	if !ok {
+		return
+	}
+	if !s.getMu.TryLock() {
+		writeError(w, http.StatusConflict, "", i18n.T("err.api_getfile_busy"))
+		return
+	}
+	defer s.getMu.Unlock()
+	s.opt.Log.Info(i18n.Tf("log.msg.api_getfile", req.Pattern, r.RemoteAddr))
+	saved, err := remote.GetFile(cfg, req.Pattern, cfg.BackupDir, s.opt.Log)
+	if err != nil {
+		s.opt.Log.Error(i18n.Tf("log.error.api_getfile", err))
+		writeError(w, http.StatusBadGateway, errcode.Of(err), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"files": saved})
+}
+
+func (s *server) metrics(w http.ResponseWriter, r *http.Request) {
+	cfg, ok := s.config(w, r)
+	if !ok {
+		return
+	}
+	m := metrics.Run{
+		RemoteConfigured: cfg.RemoteBackupDir != "" && cfg.RemoteSSHHost != "",
+		Daemon:           &metrics.Daemon{Running: s.opt.Running()},
+	}
+	if spec, err := cfg.ScheduleSpec(); err == nil {
+		m.Daemon.NextRun = spec.Next(time.Now())
+	}
+	if ss, err := state.Load(cfg.BackupDir); err == nil {
+		m.LastSuccess, m.Failures = ss.LastSuccess, ss.Failures
+	}
+	if rep, err := runreport.Load(cfg.BackupDir); err == nil && rep != nil {
+		m.Started, m.Finished, m.Success, m.RemoteOK = rep.Started, rep.Finished, rep.Error == "", rep.Remote.OK
+	}
+	if cat, err := catalog.Load(cfg.BackupDir); err == nil {
+		m.Backups = cat.Backups
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(metrics.Format(m))
+}
+
+func (s *server) agents(w http.ResponseWriter, r *http.Request) {
+	dir, ok := s.controllerDir(w)
+	if !ok {
+		return
+	}
+	list, err := fleet.Agents(dir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	if list == nil {
+		list = []fleet.Agent{}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *server) agentPolicy(w http.ResponseWriter, r *http.Request) {
+	dir, name, ok := s.agent(w, r)
+	if !ok {
+		return
+	}
+	policy, err := fleet.Policy(dir, name)
+	if err == nil {
+		err = fleet.Seen(dir, name, r.RemoteAddr, nil)
+	}
+	if err != nil {
+		s.opt.Log.Error(i18n.Tf("log.error.fleet_agent", name, err))
+		writeError(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, policy)
+}
+
+func (s *server) agentReport(w http.ResponseWriter, r *http.Request) {
+	dir, name, ok := s.agent(w, r)
+	if !ok {
+		return
+	}
+	rep := &runreport.Report{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(rep); err != nil {
+		writeError(w, http.StatusBadRequest, "", i18n.Tf("err.api_request", err))
+		return
+	}
+	if err := fleet.Seen(dir, name, r.RemoteAddr, rep); err != nil {
+		s.opt.Log.Error(i18n.Tf("log.error.fleet_agent", name, err))
+		writeError(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	s.opt.Log.Info(i18n.Tf("log.msg.fleet_report", name, rep.Status, r.RemoteAddr))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// agentPrefix is the path prefix of the requests of agents.
+const agentPrefix = "/api/v1/agents/"
+
+// agent returns controller_dir and the valid agent name of the request, or answers with an error.
+func (s *server) agent(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	dir, ok := s.controllerDir(w)
+	if !ok {
+		return "", "", false
+	}
+	name := r.PathValue("name")
+	if !config.ValidAgentName(name) {
+		writeError(w, http.StatusBadRequest, "", i18n.Tf("err.api_agent_name", name))
+		return "", "", false
+	}
+	return dir, name, true
+}
+
+// controllerDir returns controller_dir, or answers 404 when this instance is no controller.
+func (s *server) controllerDir(w http.ResponseWriter) (string, bool) {
+	dir := s.opt.Config().ControllerDir
+	if dir == "" {
+		writeError(w, http.StatusNotFound, "", i18n.T("err.api_no_controller"))
+		return "", false
+	}
+	return dir, true
+}
+
+// config returns the config the request is about: the server of ?server=, without it the main config. Without
+// server while servers are configured, or for an unknown server, it answers with an error and returns false.
+func (s *server) config(w http.ResponseWriter, r *http.Request) (*config.Config, bool) {
+	cfg := s.opt.Config()
+	name := r.URL.Query().Get("server")
+	if name == "" && len(cfg.ServerConfigs()) == 0 {
+		return cfg, true
+	}
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "", i18n.T("err.api_server_required"))
+		return nil, false
+	}
+	srv, err := cfg.Server(name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "", err.Error())
+		return nil, false
+	}
+	return srv, true
+}
+
+// optTime returns nil for the zero time (omitted in the JSON).
+func optTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// writeError answers with {"error": msg, "code": code}.
+func writeError(w http.ResponseWriter, status int, code errcode.Code, msg string) {
+	writeJSON(w, status, struct {
+		Error string       `json:"error"`
+		Code  errcode.Code `json:"code,omitempty"`
+	}{msg, code})
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/logger"
+	"github.com/janmz/mysqlbackup/internal/runreport"
+)
+
+func TestHandler(t *testing.T) {
+	dir := t.TempDir()
+	log, err := logger.New(filepath.Join(dir, "test.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	log.SetLevel(logger.SinkConsole, logger.LevelOff)
+	cfg := config.DefaultConfig()
+	cfg.BackupDir = dir
+	cfg.APIPassword = "s3cret"
+	if err := runreport.Save(dir, &runreport.Report{Started: time.Date(2026, 10, 15, 22, 0, 0, 0, time.UTC), Status: runreport.StatusSuccess}); err != nil {
+		t.Fatal(err)
+	}
+	queued := 0
+	h := Handler(Options{
+		Config:  func() *config.Config { return cfg },
+		Trigger: func(string) bool { queued++; return queued == 1 },
+		Running: func() bool { return false },
+		Log:     log,
+	})
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		if rec := do("GET", "/api/v1/status", token, ""); rec.Code != http.StatusUnauthorized {
+			t.Errorf("token %q: status %d, want 401", token, rec.Code)
+		}
+	}
+	rec := do("GET", "/api/v1/status", "s3cret", "")
+	var st Status
+	if err := json.Unmarshal(rec.Body.Bytes(), &st); rec.Code != http.StatusOK || err != nil || st.LastRun == nil || st.LastRun.Status != runreport.StatusSuccess {
+		t.Errorf("status: %d %s", rec.Code, rec.Body)
+	}
+	if rec := do("POST", "/api/v1/backup", "s3cret", ""); rec.Code != http.StatusAccepted {
+		t.Errorf("first backup request: %d, want 202", rec.Code)
+	}
+	if rec := do("POST", "/api/v1/backup", "s3cret", ""); rec.Code != http.StatusConflict {
+		t.Errorf("second backup request: %d, want 409", rec.Code)
+	}
+	rec = do("GET", "/api/v1/runs", "s3cret", "")
+	var runs []Run
+	if err := json.Unmarshal(rec.Body.Bytes(), &runs); err != nil || len(runs) != 1 || runs[0].ID != "run_20261015_220000" {
+		t.Errorf("runs: %d %s", rec.Code, rec.Body)
+	}
+	for path, want := range map[string]int{
+		"/api/v1/runs/run_20261015_220000": http.StatusOK,
+		"/api/v1/runs/last":                http.StatusOK,
+		"/api/v1/runs/run_20261014_220000": http.StatusNotFound,
+		"/api/v1/runs/..%2Flast_run":       http.StatusNotFound,
+	} {
+		if rec := do("GET", path, "s3cret", ""); rec.Code != want {
+			t.Errorf("GET %s: %d, want %d", path, rec.Code, want)
+		}
+	}
+	if rec := do("GET", "/metrics", "s3cret", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "mysqlbackup_last_run_success 1\n") {
+		t.Errorf("metrics: %d %s", rec.Code, rec.Body)
+	}
+	if rec := do("POST", "/api/v1/getfile", "s3cret", `{"pattern": "../etc/passwd"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("getfile with path: %d, want 400", rec.Code)
+	}
+
+	// Controller: Agents mit controller_password, die Übersicht mit api_password
+	if rec := do("GET", "/api/v1/agents", "s3cret", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("agents without controller_dir: %d, want 404", rec.Code)
+	}
+	cfg.ControllerDir, cfg.ControllerPassword = filepath.Join(dir, "fleet"), "agent"
+	if rec := do("GET", "/api/v1/agents/club1/policy", "s3cret", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("policy with api_password: %d, want 401", rec.Code)
+	}
+	if rec := do("GET", "/api/v1/agents/club1/policy", "agent", ""); rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "{}" {
+		t.Errorf("policy: %d %s", rec.Code, rec.Body)
+	}
+	if rec := do("POST", "/api/v1/agents/club1/report", "agent", `{"status": "success"}`); rec.Code != http.StatusNoContent {
+		t.Errorf("report: %d %s", rec.Code, rec.Body)
+	}
+	if rec := do("GET", "/api/v1/agents", "agent", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("agents with controller_password: %d, want 401", rec.Code)
+	}
+	rec = do("GET", "/api/v1/agents", "s3cret", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"name": "club1"`) || !strings.Contains(rec.Body.String(), `"status": "success"`) {
+		t.Errorf("agents: %d %s", rec.Code, rec.Body)
+	}
+}
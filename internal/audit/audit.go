@@ -0,0 +1,105 @@
+// Package audit appends the destructive and administrative actions of mysqlbackup (deleted and archived backups,
+// restores, schedule changes, config migrations, pins, controller policies) to the audit file (audit_file), one
+// JSON object per line. The file is only ever appended to, never rotated or rewritten, and is separate from the
+// operational log.
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// Actions recorded in the audit file.
+const (
+	RetentionDelete  = "retention_delete"  // backup deleted by retention or size cap (backup_dir)
+	RetentionArchive = "retention_archive" // backup moved to archive_dir by retention
+	RemoteDelete     = "remote_delete"     // backup deleted on the remote target
+	RemoteArchive    = "remote_archive"    // backup moved to remote_archive_dir
+	ParityRepair     = "parity_repair"     // damaged backup repaired from its parity files (--verify)
+	Restore          = "restore"           // --restore (also --tui)
+	RestoreUsers     = "restore_users"     // --restore-users
+	RestoreFull      = "restore_full"      // --restorefull
+	ScheduleInstall  = "schedule_install"  // scheduled job created or updated
+	ScheduleRemove   = "schedule_remove"   // scheduled job removed (--remove)
+	ConfigMigration  = "config_migration"  // config file migrated to a new version
+	ConfigClean      = "config_clean"      // --cleanconfig wrote the passwords in plaintext
+	Pin              = "pin"
+	Unpin            = "unpin"
+	PolicyUpdate     = "policy_update" // new policy of the controller saved (agent)
+)
+
+// Entry is one line of the audit file.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	User     string    `json:"user"`
+	SudoUser string    `json:"sudo_user,omitempty"` // user that ran sudo
+	Host     string    `json:"host"`
+	PID      int       `json:"pid"`
+	Action   string    `json:"action"`
+	Target   string    `json:"target"`
+	Detail   string    `json:"detail,omitempty"`
+}
+
+var mu sync.Mutex // eine Zeile zur Zeit (Daemon: API und Läufe gleichzeitig)
+
+// Record appends action on target to the audit file path; without path it does nothing. Every entry is one
+// write with O_APPEND, so entries of concurrent processes do not interleave.
+func Record(path, action, target, detail string) error {
+	if path == "" {
+		return nil
+	}
+	e := Entry{
+		Time:     time.Now().UTC(),
+		User:     currentUser(),
+		SudoUser: os.Getenv("SUDO_USER"),
+		PID:      os.Getpid(),
+		Action:   action,
+		Target:   target,
+		Detail:   detail,
+	}
+	e.Host, _ = os.Hostname()
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// currentUser returns the name of the user running the process.
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	for _, env := range []string{"USER", "USERNAME"} {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+	}
+	return "?"
+}
+
+// Note records like Record and logs a failure as warning on log, for callers that go on regardless.
+func Note(path string, log interface{ Warn(string, ...interface{}) }, action, target, detail string) {
+	if err := Record(path, action, target, detail); err != nil {
+		log.Warn(i18n.Tf("log.warn.audit", path, err))
+	}
+}
@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecord(t *testing.T) {
+	if err := Record("", RetentionDelete, "x.zip", ""); err != nil {
+		t.Fatalf("Record without path: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "sub", "audit.log")
+	if err := Record(path, RetentionDelete, "mysql_backup_20261014.zip", "retention"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Record(path, Restore, "mysql_backup_20261015.zip", "ok"); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []Entry
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var e Entry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			t.Fatalf("line %q: %v", s.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	e := entries[0]
+	if e.Action != RetentionDelete || e.Target != "mysql_backup_20261014.zip" || e.Detail != "retention" ||
+		e.User == "" || e.PID != os.Getpid() || e.Time.IsZero() {
+		t.Errorf("entry 0 = %+v", e)
+	}
+	if entries[1].Action != Restore || entries[1].Detail != "ok" {
+		t.Errorf("entry 1 = %+v", entries[1])
+	}
+}
@@ -3,6 +3,10 @@ package backup
 
 import (
 	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -11,9 +15,17 @@ import (
 	"strings"
 	"time"
 
+	"golang.org/x/crypto/ssh"
+
+	"github.com/janmz/mysqlbackup/internal/catalog"
 	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/errcode"
 	"github.com/janmz/mysqlbackup/internal/i18n"
 	"github.com/janmz/mysqlbackup/internal/mysql"
+	"github.com/janmz/mysqlbackup/internal/parity"
+	"github.com/janmz/mysqlbackup/internal/retention"
+	"github.com/janmz/mysqlbackup/internal/retry"
+	"github.com/janmz/mysqlbackup/internal/signing"
 )
 
 // hostnameForFile returns a safe filename part for backup names (no slashes, colons, etc.).
@@ -26,63 +38,360 @@ func hostnameForFile(host string) string {
 	return host
 }
 
+// DatabaseError is an error of the backup of one database (pre_hook, dump, ZIP); used to notify the
+// mail_to of that database (databases[]).
+type DatabaseError struct {
+	DB  string
+	Err error
+}
+
+func (e *DatabaseError) Error() string { return e.Err.Error() }
+
+func (e *DatabaseError) Unwrap() error { return e.Err }
+
+// PartialError is returned by Run with dump_continue_on_error when some databases failed; the ZIPs of the
+// others are returned with it.
+type PartialError struct {
+	Failed []*DatabaseError
+	Total  int // databases attempted (without skip)
+}
+
+func (e *PartialError) Error() string {
+	names := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		names[i] = f.DB
+	}
+	return fmt.Sprintf(i18n.T("err.dump_partial"), len(e.Failed), e.Total, strings.Join(names, ", "))
+}
+
+func (e *PartialError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, f := range e.Failed {
+		errs[i] = f
+	}
+	return errs
+}
+
 // Run performs full backup: export users, parse, for each DB dump+append users+zip.
+// Per-database settings (databases[]): skip, exclude_tables, pre_hook and post_hook (placeholders as in config.Expand).
 // isMariaDB: bei true wird --set-gtid-purged=OFF nicht an mysqldump übergeben (MariaDB kennt die Option nicht).
-func Run(cfg *config.Config, conn *mysql.Conn, userSQL []byte, dbs []string, isMariaDB bool, log interface {
+// Returns one catalog entry per created ZIP (size, SHA-256 computed while writing, dump duration).
+// With dump_continue_on_error a failed database (pre_hook, dump, ZIP) is logged and the next one backed up; the
+// failures are returned together as *PartialError after the last database.
+// When ctx ends (max_run_duration, SIGINT/SIGTERM), the running dump is stopped, its ZIP removed and an older
+// one restored (cancel), and the ZIPs created so far are returned with ctx.Err().
+// After the databases the directories of file_backups are archived into their own ZIPs (see writeFilesZIP); a
+// failure counts like that of a database.
+func Run(ctx context.Context, cfg *config.Config, conn *mysql.Conn, userSQL []byte, dbs []string, isMariaDB bool, log interface {
 	Info(string, ...interface{})
 	Warn(string, ...interface{})
 	Error(string, ...interface{})
-}) (createdFiles []string, err error) {
+}) (created []catalog.Entry, err error) {
 	backupDir := filepath.FromSlash(cfg.BackupDir)
 	if err := os.MkdirAll(backupDir, 0755); err != nil {
 		return nil, fmt.Errorf(i18n.T("err.create_backup_dir"), err)
 	}
 
+	// Signaturschlüssel vor dem ersten Dump laden: ein fehlender Schlüssel soll nicht erst beim Restore auffallen
+	var signer ssh.Signer
+	if cfg.SigningKeyFile != "" {
+		if signer, err = signing.LoadKey(cfg.SigningKeyFile); err != nil {
+			return nil, errcode.Wrap(errcode.SigningKey, err)
+		}
+	}
+
 	recoverSavFiles(backupDir, log)
+	workDir := filepath.FromSlash(cfg.WorkDir)
+	if workDir != "" {
+		if err := os.MkdirAll(workDir, 0755); err != nil {
+			return nil, fmt.Errorf(i18n.T("err.create_work_dir"), err)
+		}
+		removePartFiles(workDir, log)
+	}
 
-	dateStr := time.Now().Format("20060102")
+	policy, _ := cfg.RetryPolicy("dump")
+	dateStr := cfg.Now().Format("20060102")
 	hostPart := hostnameForFile(cfg.HostnameForBackup())
 	dbToUserSQL, userNames := ParseUserSQL(userSQL, log.Warn)
 	if len(userNames) > 0 {
 		log.Info(i18n.Tf("log.msg.users_found", len(userNames), strings.Join(userNames, ", ")))
 	}
 
+	// Logger mit Datenbank-Kontext (JSON-Log: Feld db)
+	dbLog, _ := log.(interface{ SetDB(string) })
+	if dbLog != nil {
+		defer dbLog.SetDB("")
+	}
+	// dump_continue_on_error: Fehler einer Datenbank merken und mit der nächsten weitermachen
+	var failed []*DatabaseError
+	total := 0
+	skip := func(e *DatabaseError) bool {
+		if !cfg.DumpContinueOnError {
+			return false
+		}
+		log.Error(i18n.Tf("log.error.db_failed", e.DB, e.Err))
+		failed = append(failed, e)
+		return true
+	}
+	// event_scheduler einmal je Lauf; ins Manifest der Datenbanken mit Events
+	scheduler, err := conn.EventScheduler()
+	if err != nil {
+		log.Warn(i18n.Tf("log.warn.event_scheduler", err))
+	}
+	eta := newEstimate(cfg, dbs, log)
 	for _, db := range dbs {
-		zipName := fmt.Sprintf("mysql_backup_%s_%s_%s.zip", dateStr, hostPart, db)
+		if err := ctx.Err(); err != nil {
+			return created, err
+		}
+		if dbLog != nil {
+			dbLog.SetDB(db)
+		}
+		dc := cfg.Database(db)
+		if dc == nil {
+			dc = &config.DatabaseConfig{Name: db}
+		}
+		if dc.Skip {
+			log.Info(i18n.Tf("log.msg.db_skipped", db))
+			continue
+		}
+		eta.progress(total, log)
+		total++
+		if dc.PreHook != "" {
+			if err := runHook("pre_hook", config.Expand(dc.PreHook, cfg.Now(), db), db, "", log); err != nil {
+				if dbErr := (&DatabaseError{DB: db, Err: errcode.Wrap(errcode.Dump, err)}); !skip(dbErr) {
+					return nil, dbErr
+				}
+				continue
+			}
+		}
+		zipName := zipFileName(dateStr, hostPart, db)
 		zipPath := filepath.Join(backupDir, zipName)
-		entryWriter, finish, cancel, err := safeWriteZIPStreaming(zipPath, db+".sql", log)
+		var started time.Time
+		var sum string
+		// dump_retries: ein fehlgeschlagener Dump wird nach einer Pause wiederholt (alte ZIP ist per cancel wiederhergestellt)
+		err := retry.Do(ctx, policy, func() error {
+			started = time.Now()
+			manifest := buildManifest(conn, db, scheduler, len(cfg.GaleraNodes) > 0, log)
+			var err error
+			sum, err = writeDatabaseZIP(ctx, conn, db, isMariaDB, dc.ExcludeTables, dbToUserSQL[db], manifest, zipPath, workDir, log)
+			return err
+		}, func(n int, wait time.Duration, err error) {
+			log.Warn(i18n.Tf("log.warn.retry_dump", db, n, policy.Retries, wait, err))
+		})
+		if err != nil && ctx.Err() != nil {
+			log.Warn(i18n.Tf("log.warn.dump_aborted", db))
+			return created, ctx.Err()
+		}
 		if err != nil {
-			return nil, fmt.Errorf(i18n.Tf("err.zip_db", db), err)
+			if dbErr := (&DatabaseError{DB: db, Err: errcode.Wrap(errcode.Dump, err)}); !skip(dbErr) {
+				return nil, dbErr
+			}
+			continue
 		}
-		if err := conn.DumpDatabase(db, isMariaDB, entryWriter); err != nil {
-			cancel()
-			return nil, fmt.Errorf(i18n.Tf("err.dump_db", db), err)
+		entry := catalog.Entry{
+			File:       zipName,
+			Database:   db,
+			Date:       dateStr,
+			SHA256:     sum,
+			DurationMS: time.Since(started).Milliseconds(),
+			Created:    time.Now(),
 		}
-		log.Info(i18n.Tf("log.msg.dumped_db", db))
-		userBlock, _ := dbToUserSQL[db]
-		if userBlock != "" {
-			if _, err := io.WriteString(entryWriter, "\n\n"); err != nil {
-				cancel()
-				return nil, fmt.Errorf(i18n.Tf("err.zip_user_block", db), err)
-			}
-			if _, err := io.WriteString(entryWriter, userBlock); err != nil {
-				cancel()
-				return nil, fmt.Errorf(i18n.Tf("err.zip_user_block", db), err)
+		created = append(created, completeZIP(cfg, signer, zipPath, entry, log))
+		log.Info(i18n.Tf("log.msg.created_zip", zipName))
+		if dc.PostHook != "" {
+			if err := runHook("post_hook", config.Expand(dc.PostHook, cfg.Now(), db), db, zipPath, log); err != nil {
+				log.Warn(i18n.Tf("log.warn.post_hook", db, err))
 			}
-			if _, err := io.WriteString(entryWriter, "\n\nFLUSH PRIVILEGES;\n"); err != nil {
-				cancel()
-				return nil, fmt.Errorf(i18n.Tf("err.zip_user_block", db), err)
+		}
+	}
+	// file_backups: Verzeichnisse nach den Dumps in eigene ZIPs, sonst wie eine Datenbank behandelt
+	var skipDirs []string
+	for _, d := range []string{backupDir, workDir} {
+		if abs, err := filepath.Abs(d); err == nil && d != "" {
+			skipDirs = append(skipDirs, abs)
+		}
+	}
+	for _, fb := range cfg.FileBackups {
+		if err := ctx.Err(); err != nil {
+			return created, err
+		}
+		label := fb.Label()
+		if dbLog != nil {
+			dbLog.SetDB(label)
+		}
+		total++
+		zipName := fileZIPName(dateStr, hostPart, fb.BackupName())
+		zipPath := filepath.Join(backupDir, zipName)
+		started := time.Now()
+		sum, err := writeFilesZIP(ctx, fb, zipPath, workDir, skipDirs, log)
+		if err != nil && ctx.Err() != nil {
+			log.Warn(i18n.Tf("log.warn.dump_aborted", label))
+			return created, ctx.Err()
+		}
+		if err != nil {
+			if dbErr := (&DatabaseError{DB: label, Err: errcode.Wrap(errcode.Dump, err)}); !skip(dbErr) {
+				return nil, dbErr
 			}
+			continue
 		}
-		// Nur im Erfolgsfall: ZIP schließen und .sav löschen
-		if err := finish(); err != nil {
-			cancel()
-			return nil, fmt.Errorf(i18n.Tf("err.zip_db", db), err)
+		entry := catalog.Entry{
+			File:       zipName,
+			Database:   label,
+			Date:       dateStr,
+			SHA256:     sum,
+			DurationMS: time.Since(started).Milliseconds(),
+			Created:    time.Now(),
 		}
-		createdFiles = append(createdFiles, zipPath)
+		created = append(created, completeZIP(cfg, signer, zipPath, entry, log))
 		log.Info(i18n.Tf("log.msg.created_zip", zipName))
 	}
-	return createdFiles, nil
+	if len(failed) > 0 {
+		return created, &PartialError{Failed: failed, Total: total}
+	}
+	return created, nil
+}
+
+// completeZIP writes the checksum sidecar, the signature (signing_key_file) and the parity files (parity_percent)
+// of the new ZIP at zipPath and returns entry with its size.
+func completeZIP(cfg *config.Config, signer ssh.Signer, zipPath string, entry catalog.Entry, log interface {
+	Info(string, ...interface{})
+	Warn(string, ...interface{})
+}) catalog.Entry {
+	if info, err := os.Stat(zipPath); err == nil {
+		entry.Size = info.Size()
+	}
+	if err := catalog.WriteSidecar(zipPath, entry.SHA256); err != nil {
+		log.Warn(i18n.Tf("log.warn.sidecar", entry.File, err))
+	}
+	if signer != nil {
+		if err := signing.WriteFile(signer, zipPath, entry.SHA256); err != nil {
+			log.Warn(i18n.Tf("log.warn.signature", entry.File, err))
+		}
+	}
+	if cfg.ParityPercent > 0 {
+		if n, err := parity.Create(zipPath, cfg.ParityPercent); err != nil {
+			log.Warn(i18n.Tf("log.warn.parity", entry.File, err))
+		} else {
+			log.Info(i18n.Tf("log.msg.parity_created", entry.File, n))
+		}
+	}
+	return entry
+}
+
+// zipFileName returns the name of the backup ZIP of db for date (YYYYMMDD) and host (see hostnameForFile).
+func zipFileName(date, host, db string) string {
+	return fmt.Sprintf("mysql_backup_%s_%s_%s.zip", date, host, db)
+}
+
+// Completed reports whether backup_dir already holds a complete backup ZIP of db for today (--resume): the
+// ZIP has its checksum sidecar, which is written only after the ZIP was finished, the checksum matches and no
+// .sav of an interrupted rewrite is left. It returns the catalog entry of the ZIP.
+func Completed(cfg *config.Config, db string) (catalog.Entry, bool) {
+	dateStr := cfg.Now().Format("20060102")
+	name := zipFileName(dateStr, hostnameForFile(cfg.HostnameForBackup()), db)
+	path := filepath.Join(filepath.FromSlash(cfg.BackupDir), name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return catalog.Entry{}, false
+	}
+	if _, err := os.Stat(strings.TrimSuffix(path, ".zip") + ".sav"); err == nil {
+		return catalog.Entry{}, false
+	}
+	want, err := catalog.ReadSidecar(path)
+	if err != nil {
+		return catalog.Entry{}, false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return catalog.Entry{}, false
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil || !strings.EqualFold(hex.EncodeToString(h.Sum(nil)), want) {
+		return catalog.Entry{}, false
+	}
+	return catalog.Entry{File: name, Database: db, Date: dateStr, Size: info.Size(), SHA256: want, Created: info.ModTime()}, true
+}
+
+// manifestName is the ZIP entry with the metadata of a backup (shown by --inspect).
+const manifestName = "manifest.json"
+
+// Manifest is the content of manifest.json, written after the dump of every database. It records the rows of each
+// table as dumped (--diff), the events with the global event_scheduler setting (a restore recreates them
+// disabled, see restore.Options.Events) and, for backups dumped from a Galera cluster (galera_nodes), the state
+// of the donor node at the start of the dump.
+type Manifest struct {
+	Database       string               `json:"database"`
+	Created        time.Time            `json:"created"`
+	Rows           map[string]int64     `json:"rows"`                      // INSERT-Tupel je Tabelle, gezählt beim Schreiben des Dumps
+	EventScheduler string               `json:"event_scheduler,omitempty"` // @@GLOBAL.event_scheduler: ON, OFF, DISABLED
+	Events         []mysql.Event        `json:"events,omitempty"`
+	Cluster        *mysql.ClusterStatus `json:"cluster,omitempty"`
+}
+
+// buildManifest returns the manifest for the dump of db before it starts: its events with scheduler
+// (event_scheduler) and, with galera, the current cluster state of conn. What cannot be read is logged and left
+// out, the dump itself does not depend on it. The row counts are added by writeDatabaseZIP.
+func buildManifest(conn *mysql.Conn, db, scheduler string, galera bool, log interface {
+	Warn(string, ...interface{})
+}) Manifest {
+	m := Manifest{Database: db, Created: time.Now()}
+	events, err := conn.Events(db)
+	if err != nil {
+		log.Warn(i18n.Tf("log.warn.events_manifest", db, err))
+	}
+	if len(events) > 0 {
+		m.Events, m.EventScheduler = events, scheduler
+	}
+	if galera {
+		if status, err := conn.ClusterStatus(); err != nil {
+			log.Warn(i18n.Tf("log.warn.galera_manifest", db, err))
+		} else {
+			m.Cluster = &status
+		}
+	}
+	return m
+}
+
+// writeDatabaseZIP dumps db into the ZIP zipPath (entry <db>.sql), appends its users block and then writes
+// manifest with the row counts of the dump as manifest.json. On failure the ZIP is removed and an older one
+// restored (cancel). Returns the SHA-256 of the ZIP.
+func writeDatabaseZIP(ctx context.Context, conn *mysql.Conn, db string, isMariaDB bool, excludeTables []string, userBlock string, manifest Manifest, zipPath, workDir string, log interface {
+	Info(string, ...interface{})
+	Warn(string, ...interface{})
+	Error(string, ...interface{})
+}) (string, error) {
+	digest := sha256.New()
+	entryWriter, finish, cancel, err := safeWriteZIPStreaming(zipPath, workDir, db+".sql", digest, log)
+	if err != nil {
+		return "", fmt.Errorf(i18n.Tf("err.zip_db", db), err)
+	}
+	rows := newRowCounter()
+	if err := conn.DumpDatabase(ctx, db, isMariaDB, excludeTables, io.MultiWriter(entryWriter, rows)); err != nil {
+		cancel()
+		return "", fmt.Errorf(i18n.Tf("err.dump_db", db), err)
+	}
+	log.Info(i18n.Tf("log.msg.dumped_db", db))
+	if userBlock != "" {
+		for _, s := range []string{"\n\n", userBlock, "\n\nFLUSH PRIVILEGES;\n"} {
+			if _, err := io.WriteString(entryWriter, s); err != nil {
+				cancel()
+				return "", fmt.Errorf(i18n.Tf("err.zip_user_block", db), err)
+			}
+		}
+	}
+	manifest.Rows = rows.rows
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		cancel()
+		return "", fmt.Errorf(i18n.Tf("err.zip_db", db), err)
+	}
+	// Nur im Erfolgsfall: ZIP schließen und .sav löschen
+	if err := finish(append(data, '\n')); err != nil {
+		cancel()
+		return "", fmt.Errorf(i18n.Tf("err.zip_db", db), err)
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
 }
 
 // recoverSavFiles runs at backup start: for each leftover *.sav in backupDir, if the
@@ -139,12 +448,43 @@ func recoverSavFiles(backupDir string, log interface {
 }
 
 // safeWriteZIPStreaming prepares a zip for streaming: renames existing to .sav, creates zip and entry.
-// Returns entry writer, finish (close zip and file, remove .sav), cancel (remove zip, restore .sav).
-// Caller streams dump to entryWriter, appends user block, then calls finish() or cancel() on error.
-func safeWriteZIPStreaming(zipPath, entryName string, log interface {
+// Returns entry writer, finish (write manifest.json if manifest is not nil, close zip and file, remove .sav),
+// cancel (remove zip, restore .sav).
+// Caller streams dump to entryWriter, appends user block, then calls finish(manifest) or cancel() on error.
+// All bytes of the ZIP file are also written to digest (e.g. SHA-256 for the catalog).
+// With workDir (work_dir) the ZIP is written there as <name>.zip.part and only moved to zipPath by finish,
+// so an existing ZIP stays untouched until the new one is complete.
+func safeWriteZIPStreaming(zipPath, workDir, entryName string, digest io.Writer, log interface {
+	Info(string, ...interface{})
+	Warn(string, ...interface{})
+}) (entryWriter io.Writer, finish func(manifest []byte) error, cancel func(), err error) {
+	w, closeZIP, cancel, err := safeWriteZIP(zipPath, workDir, digest, log)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	wr, err := w.Create(entryName)
+	if err != nil {
+		cancel()
+		return nil, nil, nil, err
+	}
+	finish = func(manifest []byte) error {
+		if err := writeManifest(w, manifest); err != nil {
+			return err
+		}
+		return closeZIP()
+	}
+	return wr, finish, cancel, nil
+}
+
+// safeWriteZIP is safeWriteZIPStreaming without entries: the caller creates them in the returned ZIP writer
+// (file_backups).
+func safeWriteZIP(zipPath, workDir string, digest io.Writer, log interface {
 	Info(string, ...interface{})
 	Warn(string, ...interface{})
-}) (entryWriter io.Writer, finish func() error, cancel func(), err error) {
+}) (w *zip.Writer, finish func() error, cancel func(), err error) {
+	if workDir != "" {
+		return workZIP(zipPath, filepath.Join(workDir, filepath.Base(zipPath)+partExt), digest)
+	}
 	savPath := strings.TrimSuffix(zipPath, ".zip") + ".sav"
 	if _, statErr := os.Stat(zipPath); statErr == nil {
 		if renameErr := os.Rename(zipPath, savPath); renameErr != nil {
@@ -158,17 +498,7 @@ func safeWriteZIPStreaming(zipPath, entryName string, log interface {
 		}
 		return nil, nil, nil, err
 	}
-	w := zip.NewWriter(f)
-	wr, err := w.Create(entryName)
-	if err != nil {
-		_ = w.Close()
-		_ = f.Close()
-		_ = os.Remove(zipPath)
-		if _, e := os.Stat(savPath); e == nil {
-			_ = os.Rename(savPath, zipPath)
-		}
-		return nil, nil, nil, err
-	}
+	w = zip.NewWriter(io.MultiWriter(f, digest))
 	finish = func() error {
 		if err := w.Close(); err != nil {
 			return err
@@ -192,5 +522,61 @@ func safeWriteZIPStreaming(zipPath, entryName string, log interface {
 			}
 		}
 	}
-	return wr, finish, cancel, nil
+	return w, finish, cancel, nil
+}
+
+// writeManifest writes manifest (if not nil) as the entry manifest.json of w.
+func writeManifest(w *zip.Writer, manifest []byte) error {
+	if manifest == nil {
+		return nil
+	}
+	mw, err := w.Create(manifestName)
+	if err != nil {
+		return err
+	}
+	_, err = mw.Write(manifest)
+	return err
+}
+
+// partExt is the suffix of ZIPs being written in work_dir.
+const partExt = ".part"
+
+// workZIP is safeWriteZIP with work_dir: the ZIP is written to partPath; finish moves it to zipPath (replacing
+// an existing ZIP), cancel removes it.
+func workZIP(zipPath, partPath string, digest io.Writer) (w *zip.Writer, finish func() error, cancel func(), err error) {
+	f, err := os.Create(partPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	w = zip.NewWriter(io.MultiWriter(f, digest))
+	finish = func() error {
+		if err := w.Close(); err != nil {
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		return retention.MoveFile(partPath, zipPath)
+	}
+	cancel = func() {
+		_ = w.Close()
+		_ = f.Close()
+		_ = os.Remove(partPath)
+	}
+	return w, finish, cancel, nil
+}
+
+// removePartFiles deletes ZIPs left incomplete in work_dir by an aborted run.
+func removePartFiles(workDir string, log interface {
+	Info(string, ...interface{})
+	Warn(string, ...interface{})
+}) {
+	parts, _ := filepath.Glob(filepath.Join(workDir, "*.zip"+partExt))
+	for _, p := range parts {
+		if err := os.Remove(p); err != nil {
+			log.Warn(i18n.Tf("log.warn.remove_part", filepath.Base(p), err))
+		} else {
+			log.Info(i18n.Tf("log.msg.removed_part", filepath.Base(p)))
+		}
+	}
 }
@@ -0,0 +1,148 @@
+package backup
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/janmz/mysqlbackup/internal/catalog"
+	"github.com/janmz/mysqlbackup/internal/config"
+)
+
+func TestPartialError(t *testing.T) {
+	err := error(&PartialError{Total: 3, Failed: []*DatabaseError{
+		{DB: "shop", Err: errors.New("dump failed")},
+		{DB: "crm", Err: errors.New("pre_hook failed")},
+	}})
+	if msg := err.Error(); !strings.Contains(msg, "shop, crm") {
+		t.Errorf("Error() = %q, want both databases", msg)
+	}
+	var dbErr *DatabaseError
+	if !errors.As(err, &dbErr) || dbErr.DB != "shop" {
+		t.Errorf("errors.As DatabaseError = %v", dbErr)
+	}
+}
+
+func TestCompleted(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.BackupDir = t.TempDir()
+	name := zipFileName(cfg.Now().Format("20060102"), hostnameForFile(cfg.HostnameForBackup()), "shop")
+	path := filepath.Join(cfg.BackupDir, name)
+	data := []byte("zip content")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Completed(cfg, "shop"); ok {
+		t.Error("ZIP without sidecar counted as complete")
+	}
+	sum := sha256.Sum256(data)
+	if err := catalog.WriteSidecar(path, hex.EncodeToString(sum[:])); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := Completed(cfg, "shop"); !ok || e.File != name || e.Size != int64(len(data)) {
+		t.Errorf("Completed = %+v, %v", e, ok)
+	}
+	if _, ok := Completed(cfg, "crm"); ok {
+		t.Error("missing ZIP counted as complete")
+	}
+	if err := os.WriteFile(strings.TrimSuffix(path, ".zip")+".sav", data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Completed(cfg, "shop"); ok {
+		t.Error("ZIP with leftover .sav counted as complete")
+	}
+}
+
+func TestManifestEntry(t *testing.T) {
+	for _, workDir := range []string{"", t.TempDir()} {
+		zipPath := filepath.Join(t.TempDir(), "mysql_backup_20261016_db1_shop.zip")
+		manifest := []byte(`{"database": "shop"}` + "\n")
+		w, finish, _, err := safeWriteZIPStreaming(zipPath, workDir, "shop.sql", io.Discard, nopLog{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, "CREATE DATABASE `shop`;\n"); err != nil {
+			t.Fatal(err)
+		}
+		if err := finish(manifest); err != nil {
+			t.Fatal(err)
+		}
+		zr, err := zip.OpenReader(zipPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+		zr.Close()
+		if strings.Join(names, ",") != "shop.sql,manifest.json" {
+			t.Errorf("work_dir %q: entries %v", workDir, names)
+		}
+	}
+}
+
+func TestRowCounter(t *testing.T) {
+	dump := "-- Dumping data for table `orders`\n" +
+		"INSERT INTO `orders` VALUES (1,'a),(b'),(2,'it\\'s (x)'),(3,NULL);\n" +
+		"INSERT INTO `orders` VALUES (4,'');\n" +
+		"INSERT INTO `log``s` (`id`, `msg`) VALUES (1,'x'),(2,'y');\n" +
+		"/*!40000 ALTER TABLE `orders` ENABLE KEYS */;\n" +
+		"CREATE TABLE `t` (`a` int);\n"
+	c := newRowCounter()
+	// in kleinen Stücken schreiben: Zustand über Write-Aufrufe hinweg
+	for i := 0; i < len(dump); i += 7 {
+		if _, err := io.WriteString(c, dump[i:min(i+7, len(dump))]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(c.rows) != 2 || c.rows["orders"] != 4 || c.rows["log`s"] != 2 {
+		t.Errorf("rows = %v", c.rows)
+	}
+}
+
+func TestFilesZIP(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{"index.php", "app.log", "cache/page.html", "wp-content/cache/x", "wp-content/uploads/a.jpg", "backups/old.zip"} {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(f), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fb := config.FileBackup{Path: root, Exclude: []string{"*.log", "/cache", "wp-content/cache/"}}
+	zipPath := filepath.Join(t.TempDir(), fileZIPName("20261016", "db1", fb.BackupName()))
+	sum, err := writeFilesZIP(context.Background(), fb, zipPath, "", []string{filepath.Join(root, "backups")}, nopLog{})
+	if err != nil || sum == "" {
+		t.Fatalf("writeFilesZIP: %q %v", sum, err)
+	}
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if got := strings.Join(names, ","); got != "index.php,wp-content/,wp-content/uploads/,wp-content/uploads/a.jpg" {
+		t.Errorf("entries %s", got)
+	}
+	if _, err := writeFilesZIP(context.Background(), config.FileBackup{Path: filepath.Join(root, "index.php")}, zipPath, "", nil, nopLog{}); err == nil {
+		t.Error("file as root accepted")
+	}
+}
+
+type nopLog struct{}
+
+func (nopLog) Info(string, ...interface{}) {}
+func (nopLog) Warn(string, ...interface{}) {}
@@ -0,0 +1,83 @@
+package backup
+
+import (
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/catalog"
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// estimate predicts the run time of the dumps from the typical durations of earlier runs (catalog).
+type estimate struct {
+	cfg     *config.Config
+	typical []time.Duration // pro Datenbank in Dump-Reihenfolge, 0 = ohne Historie
+	started time.Time
+}
+
+// newEstimate logs the estimated duration of dumping dbs (without the skipped ones); nil if none of them has
+// a timed backup in the catalog.
+func newEstimate(cfg *config.Config, dbs []string, log interface{ Info(string, ...interface{}) }) *estimate {
+	cat, err := catalog.Load(cfg.BackupDir)
+	if err != nil {
+		return nil
+	}
+	e := &estimate{cfg: cfg, started: time.Now()}
+	var total time.Duration
+	known := 0
+	for _, db := range dbs {
+		if dc := cfg.Database(db); dc != nil && dc.Skip {
+			continue
+		}
+		d, _ := cat.TypicalDuration(db)
+		e.typical = append(e.typical, d)
+		if d > 0 {
+			total += d
+			known++
+		}
+	}
+	if known == 0 {
+		return nil
+	}
+	log.Info(i18n.Tf("log.msg.estimate", formatDuration(total), known, len(e.typical), e.clock(total)))
+	return e
+}
+
+// progress logs the remaining time and the ETA after done databases: the typical durations of the remaining
+// ones, scaled by how fast the run has been so far compared to the estimate (only if all finished databases
+// have a history, otherwise the elapsed time contains dumps without estimate).
+func (e *estimate) progress(done int, log interface{ Info(string, ...interface{}) }) {
+	if e == nil || done <= 0 || done >= len(e.typical) {
+		return
+	}
+	var past, remaining time.Duration
+	scale := true
+	for i, d := range e.typical {
+		if i < done {
+			past += d
+			scale = scale && d > 0
+		} else {
+			remaining += d
+		}
+	}
+	if remaining == 0 {
+		return
+	}
+	if scale {
+		remaining = time.Duration(float64(remaining) * float64(time.Since(e.started)) / float64(past))
+	}
+	log.Info(i18n.Tf("log.msg.eta", done, len(e.typical), formatDuration(remaining), e.clock(remaining)))
+}
+
+// clock returns the time of day d from now (in timezone).
+func (e *estimate) clock(d time.Duration) string {
+	return e.cfg.Now().Add(d).Format("15:04")
+}
+
+// formatDuration rounds d for the log: to seconds below a minute, to minutes above.
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+	return d.Round(time.Minute).String()
+}
@@ -0,0 +1,158 @@
+package backup
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/retention"
+)
+
+// fileZIPName returns the name of the ZIP of file backup name (file_backups) for date and host; the
+// extension keeps it apart from the database ZIPs (see retention.IsFileBackup).
+func fileZIPName(date, host, name string) string {
+	return fmt.Sprintf("mysql_backup_%s_%s_%s%s", date, host, name, retention.FileBackupExt)
+}
+
+// writeFilesZIP archives the directory tree of fb into zipPath: paths relative to fb.Path, directories with a
+// trailing "/", symbolic links as links; other special files, the entries matching fb.Exclude and the
+// directories in skip (backup_dir, work_dir inside the tree) are left out. Files that disappear or cannot be
+// opened during the walk are logged and skipped. On other errors the ZIP is removed and an older one restored
+// (cancel). Returns the SHA-256 of the ZIP.
+func writeFilesZIP(ctx context.Context, fb config.FileBackup, zipPath, workDir string, skip []string, log interface {
+	Info(string, ...interface{})
+	Warn(string, ...interface{})
+}) (string, error) {
+	root, err := filepath.Abs(filepath.FromSlash(fb.Path))
+	if err != nil {
+		return "", fmt.Errorf(i18n.T("err.files_zip"), fb.Label(), err)
+	}
+	info, err := os.Stat(root)
+	if err == nil && !info.IsDir() {
+		err = fmt.Errorf(i18n.T("err.files_not_dir"), root)
+	}
+	if err != nil {
+		return "", fmt.Errorf(i18n.T("err.files_zip"), fb.Label(), err)
+	}
+	digest := sha256.New()
+	w, finish, cancel, err := safeWriteZIP(zipPath, workDir, digest, log)
+	if err != nil {
+		return "", fmt.Errorf(i18n.T("err.files_zip"), fb.Label(), err)
+	}
+	files, skipped := 0, 0
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			if p == root {
+				return err
+			}
+			// Verschwunden oder nicht lesbar: überspringen, der Rest des Baums wird trotzdem gesichert
+			log.Warn(i18n.Tf("log.warn.files_skipped", p, err))
+			skipped++
+			return nil
+		}
+		if p == root {
+			return nil
+		}
+		for _, s := range skip {
+			if p == s {
+				log.Info(i18n.Tf("log.msg.files_skip_dir", p))
+				return fs.SkipDir
+			}
+		}
+		rel := filepath.ToSlash(strings.TrimPrefix(p, root+string(filepath.Separator)))
+		if excluded(fb.Exclude, rel) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			log.Warn(i18n.Tf("log.warn.files_skipped", p, err))
+			skipped++
+			return nil
+		}
+		h, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		h.Name = rel
+		switch {
+		case d.IsDir():
+			h.Name += "/"
+			_, err = w.CreateHeader(h)
+			return err
+		case info.Mode()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				log.Warn(i18n.Tf("log.warn.files_skipped", p, err))
+				skipped++
+				return nil
+			}
+			ew, err := w.CreateHeader(h)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(ew, target)
+			files++
+			return err
+		case info.Mode().IsRegular():
+			f, err := os.Open(p)
+			if err != nil {
+				log.Warn(i18n.Tf("log.warn.files_skipped", p, err))
+				skipped++
+				return nil
+			}
+			defer f.Close()
+			h.Method = zip.Deflate
+			ew, err := w.CreateHeader(h)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(ew, f)
+			files++
+			return err
+		}
+		return nil
+	})
+	if err == nil {
+		err = finish()
+	}
+	if err != nil {
+		cancel()
+		return "", fmt.Errorf(i18n.T("err.files_zip"), fb.Label(), err)
+	}
+	log.Info(i18n.Tf("log.msg.files_archived", fb.Label(), files, skipped))
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// excluded reports whether rel (slash path relative to the root of a file backup) matches one of patterns:
+// patterns without "/" match the name of any file or directory, the others the whole relative path ("/" at the
+// start anchors a name at the root, at the end is ignored).
+func excluded(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		anchored := strings.HasPrefix(p, "/")
+		p = strings.Trim(p, "/")
+		target := path.Base(rel)
+		if anchored || strings.Contains(p, "/") {
+			target = rel
+		}
+		if ok, _ := path.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// runHook runs a pre_hook/post_hook command line of database db via the shell (cmd /C on Windows, sh -c otherwise)
+// with MYSQLBACKUP_DB and, if set, MYSQLBACKUP_FILE (path of the created ZIP) in the environment. Output is logged.
+func runHook(name, command, db, file string, log interface {
+	Info(string, ...interface{})
+}) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), "MYSQLBACKUP_DB="+db)
+	if file != "" {
+		cmd.Env = append(cmd.Env, "MYSQLBACKUP_FILE="+file)
+	}
+	out, err := cmd.CombinedOutput()
+	if s := strings.TrimSpace(string(out)); s != "" {
+		log.Info(i18n.Tf("log.msg.hook", name, db, s))
+	}
+	if err != nil {
+		return fmt.Errorf(i18n.T("err.hook"), name, db, err)
+	}
+	return nil
+}
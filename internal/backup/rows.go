@@ -0,0 +1,98 @@
+package backup
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// insertRe matches the beginning of an INSERT statement of mysqldump up to the VALUES keyword.
+var insertRe = regexp.MustCompile("^INSERT (?:IGNORE )?INTO `((?:[^`]|``)+)` (?:\\(.*\\) )?VALUES $")
+
+// maxInsertHead limits the part of an INSERT line buffered before VALUES (column list of --complete-insert).
+const maxInsertHead = 64 * 1024
+
+// rowCounter counts the rows of the INSERT statements in a mysqldump stream per table while it is written
+// (manifest.json, used by --diff). The values are followed with their quotes and backslash escapes, so "),("
+// inside a string does not count as a row.
+type rowCounter struct {
+	rows   map[string]int64
+	head   []byte // Zeilenanfang bis VALUES
+	table  string // Tabelle der laufenden INSERT-Anweisung, "" außerhalb
+	skip   bool   // Rest der Zeile ist kein INSERT
+	quote  bool   // in einem '...'-String
+	escape bool   // nach einem Backslash im String
+	depth  int    // Klammertiefe der Werte
+}
+
+func newRowCounter() *rowCounter {
+	return &rowCounter{rows: make(map[string]int64)}
+}
+
+// Write implements io.Writer; it never fails.
+func (c *rowCounter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		switch {
+		case c.table != "":
+			c.value(b)
+		case b == '\n':
+			c.head, c.skip = c.head[:0], false
+		case c.skip:
+		default:
+			c.head = append(c.head, b)
+			c.startLine()
+		}
+	}
+	return len(p), nil
+}
+
+// startLine checks the buffered beginning of a line: an INSERT of a table starts its values, anything else is
+// skipped up to the end of the line.
+func (c *rowCounter) startLine() {
+	const prefix = "INSERT "
+	if len(c.head) <= len(prefix) {
+		c.skip = !strings.HasPrefix(prefix, string(c.head))
+		return
+	}
+	if len(c.head) > maxInsertHead {
+		c.skip = true
+		return
+	}
+	if !bytes.HasSuffix(c.head, []byte(" VALUES ")) {
+		return
+	}
+	if m := insertRe.FindSubmatch(c.head); m != nil {
+		c.table = strings.ReplaceAll(string(m[1]), "``", "`")
+		c.quote, c.escape, c.depth = false, false, 0
+	}
+	c.head, c.skip = c.head[:0], true
+}
+
+// value follows one byte of the values of an INSERT statement; a tuple starts with "(" at depth 0.
+func (c *rowCounter) value(b byte) {
+	switch {
+	case c.quote:
+		switch {
+		case c.escape:
+			c.escape = false
+		case b == '\\':
+			c.escape = true
+		case b == '\'':
+			c.quote = false
+		}
+	case b == '\'':
+		c.quote = true
+	case b == '(':
+		if c.depth == 0 {
+			c.rows[c.table]++
+		}
+		c.depth++
+	case b == ')':
+		c.depth--
+	case b == ';' && c.depth == 0, b == '\n':
+		c.table = ""
+		if b == '\n' {
+			c.head, c.skip = c.head[:0], false
+		}
+	}
+}
@@ -0,0 +1,387 @@
+// Package catalog keeps the bookkeeping file catalog.json in backup_dir: one entry per backup ZIP
+// (size, checksum, duration, remote status), pinned backups and the retention history for the report.
+package catalog
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileName is the catalog file name inside backup_dir.
+const FileName = "catalog.json"
+
+// SidecarExt is appended to a backup ZIP name for its checksum file ("<sha256>  <name>", sha256sum format).
+const SidecarExt = ".sha256"
+
+// SignatureExt is appended to a backup ZIP name for its signature (signing_key_file, see package signing).
+const SignatureExt = ".sig"
+
+// Sidecars are the extensions of the small files next to a backup ZIP that are copied, moved and deleted with it.
+var Sidecars = []string{SidecarExt, SignatureExt}
+
+var backupName = regexp.MustCompile(`^mysql_backup_(\d{8})_.+\.zip$`)
+
+// Entry describes one backup ZIP in backup_dir. Database, SHA256 and DurationMS are empty for
+// backups found on disk that were not created by this version (see Reconcile).
+type Entry struct {
+	File       string    `json:"file"`
+	Database   string    `json:"database,omitempty"`
+	Date       string    `json:"date"` // YYYYMMDD aus dem Dateinamen
+	Size       int64     `json:"size"`
+	SHA256     string    `json:"sha256,omitempty"`
+	DurationMS int64     `json:"duration_ms,omitempty"`
+	Created    time.Time `json:"created"`
+	RemoteAt   time.Time `json:"remote_at"`         // letzter erfolgreicher Upload (Nullwert = nicht remote)
+	Encrypted  bool      `json:"encrypted"`         // remote AES-256-verschlüsselt
+	Undated    bool      `json:"undated,omitempty"` // Name ohne Datum, Date aus der Änderungszeit
+
+	CheckedAt  time.Time `json:"checked_at"`            // letzte Prüfung mit --verify (Prüfsumme, Parität, Signatur)
+	Checked    string    `json:"checked,omitempty"`     // deren Ergebnis: ok, repaired, failed
+	TestedAt   time.Time `json:"tested_at"`             // letzter Test-Restore (--verify-restore, verify_after_backup)
+	TestResult string    `json:"test_result,omitempty"` // dessen Ergebnis: ok, failed
+}
+
+// Pin marks one backup ZIP (by base name) as held: retention and remote deletion skip it until it is unpinned.
+type Pin struct {
+	File     string    `json:"file"`
+	PinnedAt time.Time `json:"pinned_at"`
+}
+
+// Pruned records one backup removed or archived by retention (for the storage report).
+type Pruned struct {
+	File     string    `json:"file"`
+	Size     int64     `json:"size"`
+	Archived bool      `json:"archived,omitempty"`
+	At       time.Time `json:"at"`
+
+	RemoteRemoved bool `json:"remote_removed,omitempty"` // Remote-Gegenstück (ZIP + Sidecar) entfernt/archiviert
+}
+
+// Catalog is the content of catalog.json.
+type Catalog struct {
+	Backups []Entry `json:"backups"`
+	Pins    []Pin   `json:"pins"`
+
+	Pruned     []Pruned  `json:"pruned,omitempty"`
+	LastReport time.Time `json:"last_report"`
+
+	Usage          []Usage   `json:"usage,omitempty"` // Platz nach jedem Lauf (Prognose, siehe Forecast)
+	ForecastWarned time.Time `json:"forecast_warned"` // letzte Warnung der Platz-Prognose
+
+	// IncludeUndated makes Reconcile also track *.zip files without date in the name (retain_undated_by_mtime).
+	IncludeUndated bool `json:"-"`
+
+	path string
+}
+
+// Load reads the catalog from dir. A missing file yields an empty catalog.
+func Load(dir string) (*Catalog, error) {
+	c := &Catalog{path: filepath.Join(filepath.FromSlash(dir), FileName)}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Save writes the catalog atomically (temp file + rename).
+func (c *Catalog) Save() error {
+	data, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	// eigene temporäre Datei je Aufruf, damit sich zwei Schreiber nicht gegenseitig die Datei ersetzen
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Record adds or replaces the entry for e.File (same file name = backup of the same day rewritten).
+func (c *Catalog) Record(e Entry) {
+	for i := range c.Backups {
+		if c.Backups[i].File == e.File {
+			c.Backups[i] = e
+			return
+		}
+	}
+	c.Backups = append(c.Backups, e)
+	sort.Slice(c.Backups, func(i, j int) bool {
+		if c.Backups[i].Date != c.Backups[j].Date {
+			return c.Backups[i].Date < c.Backups[j].Date
+		}
+		return c.Backups[i].File < c.Backups[j].File
+	})
+}
+
+// LastDaySize returns the total size of the backups of the newest backup day (expected size of the next run).
+func (c *Catalog) LastDaySize() int64 {
+	var last string
+	var size int64
+	for _, e := range c.Backups {
+		switch {
+		case e.Undated:
+			continue
+		case e.Date > last:
+			last, size = e.Date, e.Size
+		case e.Date == last:
+			size += e.Size
+		}
+	}
+	return size
+}
+
+// Remove drops the entry for name (after retention deleted or archived the file).
+func (c *Catalog) Remove(name string) {
+	for i := range c.Backups {
+		if c.Backups[i].File == name {
+			c.Backups = append(c.Backups[:i], c.Backups[i+1:]...)
+			return
+		}
+	}
+}
+
+// Entry returns the entry for name.
+func (c *Catalog) Entry(name string) (Entry, bool) {
+	for _, e := range c.Backups {
+		if e.File == name {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// MarkRemote records a successful upload of name.
+func (c *Catalog) MarkRemote(name string, encrypted bool) {
+	for i := range c.Backups {
+		if c.Backups[i].File == name {
+			c.Backups[i].RemoteAt = time.Now()
+			c.Backups[i].Encrypted = encrypted
+			return
+		}
+	}
+}
+
+// Reconcile aligns the entries with the backup ZIPs in the catalog's directory using one directory
+// listing: entries whose file is gone are dropped, ZIPs without entry (older versions, copied in by
+// hand) are added from their file info. Known files are not stat-ed again. Returns whether anything changed.
+func (c *Catalog) Reconcile() (bool, error) {
+	dir := filepath.Dir(c.path)
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			changed := len(c.Backups) > 0
+			c.Backups = nil
+			return changed, nil
+		}
+		return false, err
+	}
+	onDisk := make(map[string]os.DirEntry)
+	for _, d := range dirEntries {
+		if d.IsDir() || filepath.Ext(d.Name()) != ".zip" {
+			continue
+		}
+		if backupName.MatchString(d.Name()) || (c.IncludeUndated && HasDump(filepath.Join(dir, d.Name()))) {
+			onDisk[d.Name()] = d
+		}
+	}
+	changed := false
+	kept := c.Backups[:0]
+	known := make(map[string]bool)
+	for _, e := range c.Backups {
+		if _, ok := onDisk[e.File]; ok {
+			kept = append(kept, e)
+			known[e.File] = true
+		} else {
+			changed = true
+		}
+	}
+	c.Backups = kept
+	for name, d := range onDisk {
+		if known[name] {
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			continue
+		}
+		e := Entry{
+			File:    name,
+			Size:    info.Size(),
+			Created: info.ModTime(),
+		}
+		if m := backupName.FindStringSubmatch(name); m != nil {
+			e.Date = m[1]
+		} else {
+			e.Date = info.ModTime().Format("20060102")
+			e.Undated = true
+		}
+		c.Record(e)
+		changed = true
+	}
+	return changed, nil
+}
+
+// HasDump reports whether the ZIP at path contains an SQL dump (<db>.sql), i.e. is a backup and not some other
+// ZIP in backup_dir; only such ZIPs without date in the name are dated by mtime (retain_undated_by_mtime).
+func HasDump(path string) bool {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return false
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if strings.EqualFold(filepath.Ext(f.Name), ".sql") {
+			return true
+		}
+	}
+	return false
+}
+
+// Dir returns the directory the catalog belongs to (backup_dir).
+func (c *Catalog) Dir() string {
+	return filepath.Dir(c.path)
+}
+
+// Pin adds name to the pins. Returns false if it was already pinned.
+func (c *Catalog) Pin(name string) bool {
+	if c.IsPinned(name) {
+		return false
+	}
+	c.Pins = append(c.Pins, Pin{File: name, PinnedAt: time.Now()})
+	sort.Slice(c.Pins, func(i, j int) bool { return c.Pins[i].File < c.Pins[j].File })
+	return true
+}
+
+// Unpin removes name from the pins. Returns false if it was not pinned.
+func (c *Catalog) Unpin(name string) bool {
+	for i, p := range c.Pins {
+		if p.File == name {
+			c.Pins = append(c.Pins[:i], c.Pins[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// IsPinned reports whether the backup with base name name is pinned.
+func (c *Catalog) IsPinned(name string) bool {
+	for _, p := range c.Pins {
+		if p.File == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AddPruned records a backup removed (or archived) by retention.
+func (c *Catalog) AddPruned(name string, size int64, archived bool) {
+	c.Pruned = append(c.Pruned, Pruned{File: name, Size: size, Archived: archived, At: time.Now()})
+}
+
+// MarkRemoteRemoved records that the remote counterpart of a pruned backup was removed.
+func (c *Catalog) MarkRemoteRemoved(name string) {
+	if c == nil {
+		return
+	}
+	for i := len(c.Pruned) - 1; i >= 0; i-- {
+		if c.Pruned[i].File == name {
+			c.Pruned[i].RemoteRemoved = true
+			return
+		}
+	}
+}
+
+// WriteSidecar writes the checksum file for zipPath.
+func WriteSidecar(zipPath, sha256Hex string) error {
+	return os.WriteFile(zipPath+SidecarExt, []byte(sha256Hex+"  "+filepath.Base(zipPath)+"\n"), 0644)
+}
+
+// ReadSidecar returns the checksum stored in the sidecar of zipPath.
+func ReadSidecar(zipPath string) (string, error) {
+	data, err := os.ReadFile(zipPath + SidecarExt)
+	if err != nil {
+		return "", err
+	}
+	sum, _, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+	return sum, nil
+}
+
+// CheckSidecar reports whether the SHA-256 of zipPath matches its sidecar; the error of a missing sidecar
+// satisfies os.IsNotExist.
+func CheckSidecar(zipPath string) (bool, error) {
+	want, err := ReadSidecar(zipPath)
+	if err != nil {
+		return false, err
+	}
+	f, err := os.Open(zipPath)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return false, err
+	}
+	return strings.EqualFold(hex.EncodeToString(h.Sum(nil)), want), nil
+}
+
+// PrunedSince returns the pruned records at or after t.
+func (c *Catalog) PrunedSince(t time.Time) []Pruned {
+	var list []Pruned
+	for _, p := range c.Pruned {
+		if !p.At.Before(t) {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// TrimPruned drops pruned records older than t, so the catalog does not grow without bound.
+func (c *Catalog) TrimPruned(t time.Time) {
+	c.Pruned = c.PrunedSince(t)
+}
+
+// PinnedSet returns the pinned base names as a set (nil-safe for a nil catalog).
+func (c *Catalog) PinnedSet() map[string]bool {
+	set := make(map[string]bool)
+	if c == nil {
+		return set
+	}
+	for _, p := range c.Pins {
+		set[p.File] = true
+	}
+	return set
+}
@@ -0,0 +1,94 @@
+package catalog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReconcileAndSaveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	onDisk := "mysql_backup_20261015_host_shop.zip"
+	if err := os.WriteFile(filepath.Join(dir, onDisk), []byte("zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Record(Entry{File: "mysql_backup_20261001_host_gone.zip", Date: "20261001", SHA256: "abc"})
+	c.Pin(onDisk)
+	changed, err := c.Reconcile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || len(c.Backups) != 1 || c.Backups[0].File != onDisk || c.Backups[0].Size != 3 || c.Backups[0].Date != "20261015" {
+		t.Fatalf("Reconcile: changed=%v backups=%+v", changed, c.Backups)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	c2, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c2.Backups) != 1 || !c2.IsPinned(onDisk) {
+		t.Errorf("after reload: backups=%+v pins=%+v", c2.Backups, c2.Pins)
+	}
+	if changed, _ := c2.Reconcile(); changed {
+		t.Error("second Reconcile reported a change")
+	}
+}
+
+func TestForecast(t *testing.T) {
+	const gb = 1 << 30
+	start := time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC)
+	c := &Catalog{}
+	for d := 0; d < 4; d++ {
+		c.RecordUsage(start.AddDate(0, 0, d), uint64(100-2*d)*gb)
+	}
+	if _, _, ok := c.Forecast(10 * gb); ok {
+		t.Error("forecast from fewer than 5 records")
+	}
+	for d := 4; d < 10; d++ {
+		c.RecordUsage(start.AddDate(0, 0, d), uint64(100-2*d)*gb)
+	}
+	// 82 GB frei, 2 GB weniger pro Tag: 10 GB Bedarf in 36 Tagen erreicht
+	full, perDay, ok := c.Forecast(10 * gb)
+	if !ok || perDay != 2*gb || !full.Equal(start.AddDate(0, 0, 9+36)) {
+		t.Errorf("Forecast = %v, %v/day, %v", full, perDay, ok)
+	}
+	c.RecordUsage(start.AddDate(0, 0, 10), 200*gb)
+	c.RecordUsage(start.AddDate(0, 0, 11), 210*gb)
+	c.RecordUsage(start.AddDate(0, 0, 12), 220*gb)
+	if _, _, ok := c.Forecast(10 * gb); ok {
+		t.Error("forecast although free space grows")
+	}
+}
+
+func TestTypicalDuration(t *testing.T) {
+	c := &Catalog{}
+	for i, ms := range []int64{900, 60000, 1000, 0, 1200, 800, 1100} {
+		date := fmt.Sprintf("202610%02d", i+1)
+		c.Record(Entry{File: "mysql_backup_" + date + "_host_shop.zip", Database: "shop", Date: date, DurationMS: ms})
+	}
+	c.Record(Entry{File: "mysql_backup_20261007_host_blog.zip", Database: "blog", Date: "20261007", DurationMS: 3000})
+	// neueste fünf mit Dauer: 1100, 800, 1200, 1000, 60000 → Median 1100
+	if d, ok := c.TypicalDuration("shop"); !ok || d != 1100*time.Millisecond {
+		t.Errorf("TypicalDuration(shop) = %v, %v", d, ok)
+	}
+	if _, ok := c.TypicalDuration("wiki"); ok {
+		t.Error("TypicalDuration of a database without history")
+	}
+	if total, unknown := c.Estimate([]string{"shop", "blog", "wiki"}); total != 4100*time.Millisecond || unknown != 1 {
+		t.Errorf("Estimate = %v, %d unknown", total, unknown)
+	}
+	if dbs := c.LastDayDatabases(); len(dbs) != 2 || dbs[0] != "blog" || dbs[1] != "shop" {
+		t.Errorf("LastDayDatabases = %v", dbs)
+	}
+}
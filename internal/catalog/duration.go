@@ -0,0 +1,59 @@
+package catalog
+
+import (
+	"sort"
+	"time"
+)
+
+// durationSamples is the number of newest dumps per database the typical duration is taken from.
+const durationSamples = 5
+
+// TypicalDuration returns the median dump duration of the newest backups of db that have one; ok is false if
+// db has no timed backup yet. The median keeps a single slow night (lock wait, busy disk) from skewing it.
+func (c *Catalog) TypicalDuration(db string) (d time.Duration, ok bool) {
+	var ms []int64
+	for i := len(c.Backups) - 1; i >= 0 && len(ms) < durationSamples; i-- {
+		if e := c.Backups[i]; e.Database == db && e.DurationMS > 0 {
+			ms = append(ms, e.DurationMS)
+		}
+	}
+	if len(ms) == 0 {
+		return 0, false
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i] < ms[j] })
+	mid := ms[len(ms)/2]
+	if len(ms)%2 == 0 {
+		mid = (ms[len(ms)/2-1] + mid) / 2
+	}
+	return time.Duration(mid) * time.Millisecond, true
+}
+
+// Estimate returns the sum of the typical durations of dbs and the number of databases without history, which
+// are not included in total.
+func (c *Catalog) Estimate(dbs []string) (total time.Duration, unknown int) {
+	for _, db := range dbs {
+		if d, ok := c.TypicalDuration(db); ok {
+			total += d
+		} else {
+			unknown++
+		}
+	}
+	return total, unknown
+}
+
+// LastDayDatabases returns the databases backed up on the newest backup day (the databases of the next run).
+func (c *Catalog) LastDayDatabases() []string {
+	var last string
+	var dbs []string
+	for _, e := range c.Backups {
+		switch {
+		case e.Undated || e.Database == "":
+			continue
+		case e.Date > last:
+			last, dbs = e.Date, []string{e.Database}
+		case e.Date == last:
+			dbs = append(dbs, e.Database)
+		}
+	}
+	return dbs
+}
@@ -0,0 +1,54 @@
+package catalog
+
+import (
+	"sort"
+	"time"
+)
+
+// Results of the checks recorded per backup (Entry.Checked, Entry.TestResult).
+const (
+	ResultOK       = "ok"
+	ResultRepaired = "repaired" // --verify hat die ZIP aus den Paritätsdateien repariert
+	ResultFailed   = "failed"
+)
+
+// MarkChecked records the result of --verify for name (ResultOK, ResultRepaired or ResultFailed).
+func (c *Catalog) MarkChecked(name, result string, at time.Time) {
+	for i := range c.Backups {
+		if c.Backups[i].File == name {
+			c.Backups[i].CheckedAt, c.Backups[i].Checked = at, result
+			return
+		}
+	}
+}
+
+// MarkTested records the result of a test restore of name.
+func (c *Catalog) MarkTested(name string, ok bool, at time.Time) {
+	for i := range c.Backups {
+		if c.Backups[i].File == name {
+			c.Backups[i].TestedAt, c.Backups[i].TestResult = at, ResultFailed
+			if ok {
+				c.Backups[i].TestResult = ResultOK
+			}
+			return
+		}
+	}
+}
+
+// History returns the entries of database db (all databases if db is empty) ordered by database and date, the
+// per-database backup history of --history.
+func (c *Catalog) History(db string) []Entry {
+	var h []Entry
+	for _, e := range c.Backups {
+		if db == "" || e.Database == db {
+			h = append(h, e)
+		}
+	}
+	sort.SliceStable(h, func(i, j int) bool {
+		if h[i].Database != h[j].Database {
+			return h[i].Database < h[j].Database
+		}
+		return h[i].Date < h[j].Date
+	})
+	return h
+}
@@ -0,0 +1,69 @@
+package catalog
+
+import "time"
+
+// Usage is the space situation of backup_dir after one run (for the forecast, see Forecast).
+type Usage struct {
+	At   time.Time `json:"at"`
+	Used int64     `json:"used"` // Summe der Backup-ZIPs im Katalog
+	Free uint64    `json:"free"` // freier Speicher des Volumes
+}
+
+// maxUsage is the number of usage records kept (about three months of nightly runs).
+const maxUsage = 90
+
+// forecastWindow is the period of the usage records the trend is computed from.
+const forecastWindow = 30 * 24 * time.Hour
+
+// RecordUsage adds the usage after a run: the backups in the catalog and free, the free space of the volume.
+// Only the newest maxUsage records are kept.
+func (c *Catalog) RecordUsage(at time.Time, free uint64) {
+	var used int64
+	for _, e := range c.Backups {
+		used += e.Size
+	}
+	c.Usage = append(c.Usage, Usage{At: at, Used: used, Free: free})
+	if len(c.Usage) > maxUsage {
+		c.Usage = c.Usage[len(c.Usage)-maxUsage:]
+	}
+}
+
+// Forecast projects when the free space of the volume falls below need (the space a run requires), from the
+// linear trend of the usage records of the last 30 days: growth of the backups, retention and other data on
+// the volume all show in the free space. ok is false without a usable trend (fewer than 5 records, less than
+// a week, free space not shrinking or lasting more than ten years); perDay is the decrease per day.
+func (c *Catalog) Forecast(need uint64) (full time.Time, perDay float64, ok bool) {
+	if len(c.Usage) == 0 {
+		return time.Time{}, 0, false
+	}
+	last := c.Usage[len(c.Usage)-1]
+	var pts []Usage
+	for _, u := range c.Usage {
+		if last.At.Sub(u.At) <= forecastWindow {
+			pts = append(pts, u)
+		}
+	}
+	if len(pts) < 5 || last.At.Sub(pts[0].At) < 7*24*time.Hour {
+		return time.Time{}, 0, false
+	}
+	// Kleinste Quadrate: freier Speicher über der Zeit in Tagen
+	var sx, sy, sxx, sxy float64
+	for _, p := range pts {
+		x := p.At.Sub(pts[0].At).Hours() / 24
+		y := float64(p.Free)
+		sx, sy, sxx, sxy = sx+x, sy+y, sxx+x*x, sxy+x*y
+	}
+	n := float64(len(pts))
+	slope := (n*sxy - sx*sy) / (n*sxx - sx*sx)
+	if slope >= 0 {
+		return time.Time{}, 0, false
+	}
+	if last.Free <= need {
+		return last.At, -slope, true
+	}
+	days := float64(last.Free-need) / -slope
+	if days > 3650 {
+		return time.Time{}, 0, false
+	}
+	return last.At.Add(time.Duration(days * 24 * float64(time.Hour))), -slope, true
+}
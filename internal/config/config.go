@@ -2,18 +2,38 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"net"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/janmz/mysqlbackup/internal/cron"
 	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/kms"
+	"github.com/janmz/mysqlbackup/internal/retry"
 	"github.com/janmz/sconfig"
+	"golang.org/x/crypto/ssh"
 )
 
 // Config holds all settings for MySQL backup (JSON with sconfig secure password pairs).
 type Config struct {
 	Version int `json:"version"`
+	// Optional: weitere Config-Dateien (z. B. zentral gepflegte SMTP-Einstellungen, lokale Overrides), in dieser
+	// Reihenfolge über diese Datei gelegt; spätere überschreiben frühere. Relative Pfade gelten ab dieser Datei.
+	Include []string `json:"include"`
+	// Optional: mehrere MySQL-Server von einem Verwaltungshost aus. Je Server eine Config-Datei, wie include über
+	// diese Datei gelegt (Name = Dateiname ohne Endung); ohne eigene Angabe liegen seine Backups in
+	// <backup_dir>/<name>, <archive_dir>/<name>, <remote_backup_dir>/<name> und <remote_archive_dir>/<name>.
+	Servers []string `json:"servers"`
 
 	MySQLHost      string `json:"mysql_host"`
 	MySQLHostname  string `json:"mysql_hostname"` // optional: für Benennung (Backup-Dateien), wenn mysql_host = localhost
@@ -21,6 +41,19 @@ type Config struct {
 	MySQLBin       string `json:"mysql_bin"`        // optional: Verzeichnis mit mysql, mysqldump, mysqlpump (z. B. D:\xampp\mysql\bin)
 	MySQLDataDir   string `json:"mysql_data_dir"`   // Pfad zum data-Verzeichnis der Instanz (für -restorefull)
 	MySQLBackupDir string `json:"mysql_backup_dir"` // optional: Pfad zum backup-Verzeichnis der Instanz (für -restorefull), leer = Nachbar von mysql_data_dir
+	// Optional: Ziel von -physical-backup (Kopie von mysql_data_dir als mysql_data_JJJJMMTT_HHMMSS, unter Windows aus
+	// einem VSS-Snapshot bei laufendem Server); physical_backup_keep = Anzahl aufbewahrter Kopien (0 = alle).
+	PhysicalBackupDir  string `json:"physical_backup_dir"`
+	PhysicalBackupKeep int    `json:"physical_backup_keep"`
+	// Optional: Zeichensatz/Collation beim Restore erzwingen (z. B. latin1-Dumps auf reine utf8mb4-Server);
+	// -charset/-collation überschreiben sie. Leer = wie im Dump.
+	RestoreCharset   string `json:"restore_charset"`
+	RestoreCollation string `json:"restore_collation"`
+	// Optional: Galera-Cluster. Aus galera_nodes ("host" oder "host:port", sonst mysql_port) wird der erste Knoten im
+	// Zustand Synced als Donor der Dumps gewählt; galera_desync nimmt ihn währenddessen per wsrep_desync aus der
+	// Flusskontrolle. Die Backups heißen weiter nach mysql_host/mysql_hostname.
+	GaleraNodes  []string `json:"galera_nodes"`
+	GaleraDesync bool     `json:"galera_desync"`
 
 	// MySQL-Lifecycle (z. B. XAMPP): bei Backup prüfen, ob MySQL läuft; wenn nicht, starten, nach Backup wieder stoppen.
 	MySQLAutoStartStop bool   `json:"mysql_auto_start_stop"`
@@ -29,14 +62,61 @@ type Config struct {
 
 	RootPassword       string `json:"root_password"`
 	RootSecurePassword string `json:"root_secure_password"`
+	RootPasswordFile   string `json:"root_password_file"` // optional: Passwort aus Datei (Docker-/Kubernetes-Secret), hat Vorrang
 
 	RetainDaily   int `json:"retain_daily"`
 	RetainWeekly  int `json:"retain_weekly"`
 	RetainMonthly int `json:"retain_monthly"`
 	RetainYearly  int `json:"retain_yearly"`
 
+	// Stichtage der Aufbewahrung: Wochentag für wöchentliche Backups (z. B. "sunday", "saturday")
+	// und Datum TT.MM für jährliche Backups (z. B. "30.06" für ein Geschäftsjahr bis 30.06.).
+	RetainWeeklyDay  string `json:"retain_weekly_day"`
+	RetainYearlyDate string `json:"retain_yearly_date"`
+	// Optional: Obergrenze für die Summe der Backup-ZIPs in backup_dir (z. B. "500M", "50G"); leer = keine Grenze.
+	MaxBackupDirSize string `json:"max_backup_dir_size"`
+	// Optional: ZIPs ohne Datum im Namen (umbenannt/importiert) nach Änderungszeit einordnen statt ignorieren.
+	RetainUndatedByMtime bool `json:"retain_undated_by_mtime"`
+
+	// Optional: Archiv-Stufe. Abgelaufene Backups werden nach archive_dir verschoben statt gelöscht;
+	// auf dem Remote-Host werden sie nach remote_archive_dir verschoben. archive_retain_days = Aufbewahrung im Archiv (0 = unbegrenzt).
+	ArchiveDir        string `json:"archive_dir"`
+	RemoteArchiveDir  string `json:"remote_archive_dir"`
+	ArchiveRetainDays int    `json:"archive_retain_days"`
+	// Optional: PAR2-Paritätsdateien je Backup-ZIP mit parity_percent % Redundanz (0 = keine), lokal und auf dem
+	// Remote-Host abgelegt; --verify repariert damit beschädigte ZIPs.
+	ParityPercent int `json:"parity_percent"`
+	// Optional: Ed25519-Signatur je Backup-ZIP (<zip>.sig, SSH-Signaturformat, prüfbar mit ssh-keygen -Y verify).
+	// signing_key_file = privater Schlüssel (OpenSSH, ohne Passphrase), signing_public_keys = weitere vertrauenswürdige
+	// öffentliche Schlüssel ("ssh-ed25519 AAAA…", z. B. auf Hosts, die nur zurückspielen, oder nach Schlüsselwechsel).
+	// Ist einer gesetzt, prüfen --verify, --getfile und Restore die Signatur; fehlt sie oder passt sie nicht, wird abgebrochen.
+	SigningKeyFile    string   `json:"signing_key_file"`
+	SigningPublicKeys []string `json:"signing_public_keys"`
+
+	// backup_dir, log_filename und remote_backup_dir dürfen Platzhalter enthalten: {hostname}, {date}, {env}, {env:NAME} (siehe Expand).
 	BackupDir   string `json:"backup_dir"`
 	LogFilename string `json:"log_filename"`
+	// Optional: lokales Arbeitsverzeichnis für entstehende ZIPs, die temporäre Defaults-Datei mit dem MySQL-Passwort und
+	// Downloads von --getfile (z. B. lokale SSD, wenn backup_dir eine langsame Netzwerkfreigabe ist); leer = ZIPs direkt in
+	// backup_dir, Defaults-Datei im Temp-Verzeichnis des Systems, Downloads direkt ins Zielverzeichnis.
+	WorkDir string `json:"work_dir"`
+	// Optional: eigene Logdatei je Backup-Lauf (name_JJJJMMTT_HHMMSS.log neben log_filename), nach log_retain_days Tagen gelöscht (0 = nie).
+	LogPerRun     bool   `json:"log_per_run"`
+	LogRetainDays int    `json:"log_retain_days"`
+	LogFormat     string `json:"log_format"` // "text" (Standard) oder "json" (ein JSON-Objekt pro Zeile, z. B. für Loki/ELK)
+	// Linux: als systemd-Dienst ins Journal loggen (strukturierte Felder) statt auf stdout; die Logdatei bleibt.
+	LogJournald bool `json:"log_journald"`
+	// Level je Ausgabe: "debug", "info", "warn", "error", "off". Leer = info; Konsole unter systemd aus (Journal),
+	// syslog nur unter systemd (Journal) bzw. wenn gesetzt an den lokalen syslog-Dienst.
+	LogLevelConsole string `json:"log_level_console"`
+	LogLevelFile    string `json:"log_level_file"`
+	LogLevelSyslog  string `json:"log_level_syslog"`
+	// Optional: Verzeichnis mit eigenen Übersetzungen (<sprache>.json, z. B. de.json mit geänderten Texten oder sv.json
+	// für eine weitere Sprache), die beim Start über die eingebauten gelegt werden. Relative Pfade gelten ab dieser Datei.
+	TranslationsDir string `json:"translations_dir"`
+	// Optional: Audit-Datei (JSON-Zeilen, nur angehängt, getrennt vom Log) mit allen löschenden und administrativen
+	// Aktionen: Aufbewahrung, Remote-Löschung, Restore, Zeitplan, Config-Migration, Anheften, Controller-Richtlinie.
+	AuditFile string `json:"audit_file"`
 
 	AdminEmail              string `json:"admin_email"`
 	AdminSMTPServer         string `json:"admin_smtp_server"`
@@ -45,6 +125,55 @@ type Config struct {
 	AdminSMTPTLS            string `json:"admin_smtp_tls"`  // "tls" (implizit, Port 465), "starttls" (Port 587), "" = Auto
 	AdminSMTPPassword       string `json:"admin_smtp_password"`
 	AdminSMTPSecurePassword string `json:"admin_smtp_secure_password"`
+	AdminSMTPPasswordFile   string `json:"admin_smtp_password_file"`
+	// Optional: Absender (leer = admin_email), Empfänger (leer = admin_email), Kopie und Antwortadresse der E-Mails.
+	MailFrom    string   `json:"mail_from"`
+	MailTo      []string `json:"mail_to"`
+	MailCC      []string `json:"mail_cc"`
+	MailReplyTo string   `json:"mail_reply_to"`
+	// Optional: monatlicher Speicherbericht an admin_email (erster Backup-Lauf eines Monats).
+	MonthlyReport bool `json:"monthly_report"`
+	// Optional: Zusammenfassung nach jedem erfolgreichen Lauf an admin_email (Datenbanken, Größen, Dauer, Aufbewahrung, Remote).
+	SuccessEmail bool `json:"success_email"`
+	// Letzte N KB des Logs als Anhang der Fehler-E-Mails (0 = kein Anhang).
+	MailLogKB int `json:"mail_log_kb"`
+	// Gleiche Fehler in Folge: nach N Benachrichtigungen nur noch eine pro Tag (0 = jedes Mal benachrichtigen).
+	NotifyRepeat int `json:"notify_repeat"`
+	// Welche Läufe gemeldet werden (E-Mail, Telegram, Webhook): "errors" (Standard), "warnings" (auch Läufe mit Warnungen), "all".
+	NotifyLevel string `json:"notify_level"`
+	// Optional: Telegram-Bot (Token wie Passwörter verschlüsselt) und Chat-ID für Fehlermeldungen, mit telegram_success auch nach Erfolg.
+	TelegramBotPassword       string `json:"telegram_bot_password"`
+	TelegramBotSecurePassword string `json:"telegram_bot_secure_password"`
+	TelegramChatID            string `json:"telegram_chat_id"`
+	TelegramSuccess           bool   `json:"telegram_success"`
+	// Optional: HTTP-Webhook nach Läufen gemäß notify_level; Header als "Name: Wert", Body als Go-Template (leer = JSON).
+	WebhookURL     string   `json:"webhook_url"`
+	WebhookMethod  string   `json:"webhook_method"`
+	WebhookHeaders []string `json:"webhook_headers"`
+	WebhookBody    string   `json:"webhook_body"`
+	// Optional: Ping-URL (healthchecks.io o. Ä.): <url>/start beim Start, <url> bei Erfolg, <url>/fail bei Fehler.
+	HealthcheckURL string `json:"healthcheck_url"`
+	// Optional: Prometheus-Metriken nach jedem Lauf als Datei für den node_exporter-Textfile-Collector und/oder an ein Pushgateway.
+	MetricsFile        string `json:"metrics_file"`
+	MetricsPushgateway string `json:"metrics_pushgateway"`
+	// Optional: StatsD-Empfänger (host:port, UDP), z. B. Telegraf mit statsd-Input; Tags im InfluxDB-Format.
+	MetricsStatsD string `json:"metrics_statsd"`
+	// Optional (--daemon): JSON-API für Orchestrierung (Backup auslösen, Status, Katalog, Laufberichte, --getfile) auf
+	// dieser Adresse (z. B. "127.0.0.1:8080"), nur mit api_password als Bearer-Token; mit Zertifikat und Schlüssel per HTTPS.
+	APIListen         string `json:"api_listen"`
+	APIPassword       string `json:"api_password"`
+	APISecurePassword string `json:"api_secure_password"`
+	APITLSCert        string `json:"api_tls_cert"`
+	APITLSKey         string `json:"api_tls_key"`
+	// Optional: Flotte. Agent: controller_url (API eines mysqlbackup im Controller-Modus) meldet jeden Lauf und holt
+	// die Richtlinie (Aufbewahrung, Zeitplan, ...) nach controller_policy.json neben dieser Datei, die wie include
+	// darübergelegt wird. Controller (--daemon mit api_listen): controller_dir hält Richtlinien und Agent-Berichte.
+	// controller_password ist auf beiden Seiten das Token der Agents; agent_name ist ohne Angabe der Hostname.
+	ControllerURL            string `json:"controller_url"`
+	ControllerPassword       string `json:"controller_password"`
+	ControllerSecurePassword string `json:"controller_secure_password"`
+	AgentName                string `json:"agent_name"`
+	ControllerDir            string `json:"controller_dir"`
 
 	RemoteBackupDir         string `json:"remote_backup_dir"`
 	RemoteSSHHost           string `json:"remote_ssh_host"`
@@ -52,27 +181,182 @@ type Config struct {
 	RemoteSSHUser           string `json:"remote_ssh_user"`
 	RemoteSSHPassword       string `json:"remote_ssh_password"`
 	RemoteSSHSecurePassword string `json:"remote_ssh_secure_password"`
+	RemoteSSHPasswordFile   string `json:"remote_ssh_password_file"`
 	RemoteSSHKeyFile        string `json:"remote_ssh_key_file"`
 
 	// Optional: Remote-Dateien vor Upload mit AES-256 verschlüsseln. Schlüssel aus remote_aes_password abgeleitet.
 	// Wenn entschlüsselter Wert "" ist, erfolgt keine Verschlüsselung.
 	RemoteAESPassword       string `json:"remote_aes_password"`
 	RemoteAESSecurePassword string `json:"remote_aes_secure_password"`
+	RemoteAESPasswordFile   string `json:"remote_aes_password_file"`
+	// Optional: Envelope-Verschlüsselung statt remote_aes_password: je Datei ein Datenschlüssel, vom KMS verschlüsselt
+	// im Datei-Header (aws-kms://<Key-ID|ARN|alias/…>, azure-kv://<vault>.vault.azure.net/keys/<name>, gcp-kms://projects/…).
+	RemoteKMSKey string `json:"remote_kms_key"`
 
 	StartTime string `json:"start_time"`
+	// Optional: Cron-Ausdruck (z. B. "0 3 * * 1-5" = werktags 03:00); ersetzt start_time für Zeitplan und Daemon.
+	Schedule string `json:"schedule"`
+	// Optional: zufällige Startverzögerung in Minuten (Windows RandomDelay, systemd RandomizedDelaySec, Cron: feste Verschiebung pro Host).
+	StartJitterMinutes int `json:"start_jitter_minutes"`
+	// Minuten, die --backup auf einen noch laufenden Backup-Lauf wartet (0 = sofort mit Exit-Code 3 beenden).
+	LockWaitMinutes int `json:"lock_wait_minutes"`
+	// Optional: Höchstdauer eines Backup-Laufs (z. B. "4h", "90m"); danach werden laufende Dumps und Uploads
+	// abgebrochen und eine Timeout-Meldung verschickt. Leer = unbegrenzt.
+	MaxRunDuration string `json:"max_run_duration"`
+	// Optional: Ende des Backup-Fensters (HH:MM, timezone), z. B. "06:00" vor Geschäftsbeginn; danach wird der
+	// laufende Dump abgebrochen (fertige ZIPs bleiben) und Uploads pausieren bis zum nächsten Lauf. Leer = kein Fenster.
+	BackupWindowEnd string `json:"backup_window_end"`
+	// Schlägt eine Datenbank fehl (pre_hook, Dump, ZIP), mit den übrigen weitermachen; Aufbewahrung und Remote-Sync
+	// laufen für die gesicherten, der Lauf endet als Teilfehler mit einer Meldung über alle fehlgeschlagenen.
+	DumpContinueOnError bool `json:"dump_continue_on_error"`
+	// Freier Speicher vor dem Lauf: Größe des letzten Laufs mal diesem Faktor, mindestens 100 MB (0 = nur 100 MB).
+	DiskSpaceFactor float64 `json:"disk_space_factor"`
+	// Warnen (Log, E-Mail, Telegram; höchstens wöchentlich), wenn der Trend des freien Speichers im backup_dir
+	// in weniger als so vielen Tagen unter den Bedarf eines Laufs fällt. 0 = keine Prognose.
+	DiskForecastDays int `json:"disk_forecast_days"`
+	// Wiederholungen bei vorübergehenden Fehlern, getrennt für den Dump je Datenbank, den Remote-Sync und die
+	// Benachrichtigungen: Anzahl weiterer Versuche und Pause vor dem ersten (Go-Dauer, verdoppelt sich je Versuch).
+	DumpRetries        int    `json:"dump_retries"`
+	DumpRetryBackoff   string `json:"dump_retry_backoff"`
+	RemoteRetries      int    `json:"remote_retries"`
+	RemoteRetryBackoff string `json:"remote_retry_backoff"`
+	NotifyRetries      int    `json:"notify_retries"`
+	NotifyRetryBackoff string `json:"notify_retry_backoff"`
+	// Optional: Befehle um den ganzen Lauf (z. B. verschlüsseltes Volume ein-/aushängen): pre_run_cmd vor dem Lauf
+	// (Fehler bricht ab), danach post_run_success_cmd bzw. post_run_failure_cmd und zuletzt post_run_cmd.
+	PreRunCmd         string `json:"pre_run_cmd"`
+	PostRunCmd        string `json:"post_run_cmd"`
+	PostRunSuccessCmd string `json:"post_run_success_cmd"`
+	PostRunFailureCmd string `json:"post_run_failure_cmd"`
+	// Zeitplan bei --backup/--status automatisch prüfen und einrichten (Standard true); false z. B. bei Verwaltung per Ansible.
+	AutoSchedule bool `json:"auto_schedule"`
+	// Verpasste Läufe nachholen (Standard true): Windows StartWhenAvailable, systemd Persistent=,
+	// Cron: stündliche Prüfung per --catchup (Lauf nur, wenn ein geplanter Lauf ausgefallen ist).
+	CatchUp bool `json:"catch_up"`
+	// Name des geplanten Jobs, wenn mehrere Configs auf einem Host laufen (Task "MySQLBackup-<name>",
+	// Unit "mysqlbackup-<name>"); "auto" = aus dem Config-Pfad abgeleitet, leer = bisherige Namen.
+	JobName string `json:"job_name"`
+	// Linux: "user" (Standard, systemd-User-Timer bzw. Cron) oder "system" (/etc/systemd/system, benötigt root);
+	// BSD zusätzlich "periodic" (Skript in /usr/local/etc/periodic/daily).
+	// schedule_user = Konto für System-Units (leer = Aufrufer von sudo, sonst root).
+	ScheduleScope string `json:"schedule_scope"`
+	ScheduleUser  string `json:"schedule_user"`
+	// Windows: Konto der geplanten Aufgabe (leer = aufrufender Benutzer, "SYSTEM", Dienstkonto "DOMAIN\svc" oder gMSA
+	// "DOMAIN\gmsa$") und Anmeldetyp ("" = automatisch, "password", "s4u", "serviceaccount", "interactive").
+	// Passwort nur für Dienstkonten mit Anmeldetyp password (nicht für SYSTEM/gMSA).
+	WindowsTaskUser           string `json:"windows_task_user"`
+	WindowsTaskLogonType      string `json:"windows_task_logon_type"`
+	WindowsTaskPassword       string `json:"windows_task_password"`
+	WindowsTaskSecurePassword string `json:"windows_task_secure_password"`
+	// Windows: Start, Erfolg und Fehler jedes Laufs ins Anwendungs-Ereignisprotokoll schreiben (Quelle "MySqlBackup").
+	WindowsEventLog bool `json:"windows_event_log"`
+	// Optional: IANA-Zeitzone (z. B. "Europe/Berlin") für das Datum im Dateinamen und die Einordnung der Aufbewahrung;
+	// leer = Zeitzone des Systems. start_time bleibt in Systemzeit (Scheduler).
+	Timezone string `json:"timezone"`
+
+	// Optional: Test-Restore (--verify-restore, mit verify_after_backup nach jedem Lauf): jüngstes Backup jeder Datenbank in
+	// eine Wegwerf-Instanz einspielen und mit Zeilenzahl/CHECKSUM TABLE prüfen. verify_docker_image (z. B. "mysql:8.0")
+	// startet sie per docker run auf verify_mysql_port; leer = bereits laufende Sandbox-Instanz unter
+	// verify_mysql_host:verify_mysql_port (User root). Nie die produktive Instanz eintragen.
+	VerifyAfterBackup         bool   `json:"verify_after_backup"`
+	VerifyDockerImage         string `json:"verify_docker_image"`
+	VerifyMySQLHost           string `json:"verify_mysql_host"`
+	VerifyMySQLPort           int    `json:"verify_mysql_port"`
+	VerifyMySQLPassword       string `json:"verify_mysql_password"`
+	VerifyMySQLSecurePassword string `json:"verify_mysql_secure_password"`
+
+	// Optional: Einstellungen je Datenbank (Liste statt Objekt, weil sconfig keine Maps unterstützt).
+	Databases []DatabaseConfig `json:"databases"`
+	// Optional: Verzeichnisse (z. B. Web-Root), die nach den Dumps in eigene ZIPs gesichert werden; diese laufen
+	// durch dieselbe Aufbewahrung, Verschlüsselung und Remote-Synchronisation wie die Datenbank-ZIPs.
+	FileBackups []FileBackup `json:"file_backups"`
+
+	migrated []string       // changes of the config migrations on load (see Migrations)
+	paths    *pathTemplates // backup_dir, log_filename, remote_backup_dir with placeholders (see ExpandPaths)
+	includes []string       // resolved paths of include, controller policy and servers (see Includes)
+	servers  []*Config      // configs of servers (see ServerConfigs)
+	server   string         // name of this server config, "" = main config
+	policy   string         // path of the controller policy file, "" without controller_url (see PolicyPath)
+}
+
+// DatabaseConfig holds the settings of one database (databases[]); empty fields use the global settings.
+type DatabaseConfig struct {
+	Name string `json:"name"`
+	// Datenbank nicht sichern.
+	Skip bool `json:"skip"`
+	// Tabellen, die mysqldump auslässt (--ignore-table), ohne Datenbank-Präfix.
+	ExcludeTables []string `json:"exclude_tables"`
+	// Aufbewahrung dieser Datenbank; fehlend/null = globale retain_*.
+	RetainDaily   *int `json:"retain_daily"`
+	RetainWeekly  *int `json:"retain_weekly"`
+	RetainMonthly *int `json:"retain_monthly"`
+	RetainYearly  *int `json:"retain_yearly"`
+	// Befehle vor dem Dump (Fehler bricht den Lauf ab) und nach dem ZIP (Fehler = Warnung);
+	// Umgebung: MYSQLBACKUP_DB, nach dem ZIP zusätzlich MYSQLBACKUP_FILE. Platzhalter wie in backup_dir, zusätzlich {db}.
+	PreHook  string `json:"pre_hook"`
+	PostHook string `json:"post_hook"`
+	// Zusätzliche Empfänger der Fehler-E-Mails, die diese Datenbank betreffen.
+	MailTo []string `json:"mail_to"`
+}
+
+// FileBackup is one directory of file_backups.
+type FileBackup struct {
+	// Teil des ZIP-Namens (Buchstaben, Ziffern, "-", "_"); leer = letzter Teil von path.
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Auszulassende Dateien und Verzeichnisse: Muster ohne "/" gelten für jeden Namen (z. B. "*.log", "cache"),
+	// Muster mit "/" für den Pfad relativ zu path (z. B. "wp-content/cache").
+	Exclude []string `json:"exclude"`
+}
+
+var fileBackupNameInvalid = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// BackupName returns name, or the last element of path with other characters than letters, digits, "-" and
+// "_" replaced by "_".
+func (f FileBackup) BackupName() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	return fileBackupNameInvalid.ReplaceAllString(filepath.Base(filepath.Clean(f.Path)), "_")
+}
+
+// Label names the file backup in the catalog, logs and errors ("files:<name>"; ":" keeps it apart from the
+// database names).
+func (f FileBackup) Label() string {
+	return "files:" + f.BackupName()
 }
 
 // DefaultConfig returns config with default values.
 func DefaultConfig() *Config {
 	return &Config{
-		MySQLPort:     3306,
-		RetainDaily:   14,
-		RetainWeekly:  3,
-		RetainMonthly: 3,
-		RetainYearly:  3,
-		AdminSMTPPort: 587,
-		RemoteSSHPort: 22,
-		StartTime:     "22:00",
+		MySQLPort:          3306,
+		RetainDaily:        14,
+		RetainWeekly:       3,
+		RetainMonthly:      3,
+		RetainYearly:       3,
+		RetainWeeklyDay:    "sunday",
+		RetainYearlyDate:   "31.12",
+		PhysicalBackupKeep: 3,
+		AdminSMTPPort:      587,
+		MailLogKB:          64,
+		LogRetainDays:      30,
+		NotifyRepeat:       3,
+		DiskSpaceFactor:    1.5,
+		DiskForecastDays:   21,
+		DumpRetries:        1,
+		DumpRetryBackoff:   "1m",
+		RemoteRetries:      2,
+		RemoteRetryBackoff: "1m",
+		NotifyRetries:      2,
+		NotifyRetryBackoff: "10s",
+		RemoteSSHPort:      22,
+		VerifyMySQLHost:    "127.0.0.1",
+		VerifyMySQLPort:    3307,
+		StartTime:          "22:00",
+		CatchUp:            true,
+		AutoSchedule:       true,
+		WindowsEventLog:    true,
+		LogJournald:        true,
 	}
 }
 
@@ -90,18 +374,597 @@ func Load(path string, cleanConfig bool) (*Config, error) {
 		fmt.Println(i18n.Tf("log.debug.hardware_id", id))
 	}
 
+	migrated, err := migrate(path)
+	if err != nil {
+		return nil, err
+	}
 	cfg := DefaultConfig()
-	if err := sconfig.LoadConfig(cfg, cfg.Version, path, cleanConfig, debugSconfig); err != nil {
+	if err := sconfig.LoadConfig(cfg, SchemaVersion, path, cleanConfig, debugSconfig); err != nil {
 		return nil, fmt.Errorf(i18n.T("err.sconfig_load"), err)
 	}
+	cfg.migrated = migrated
+	if err := cfg.loadIncludes(path, cleanConfig, debugSconfig); err != nil {
+		return nil, err
+	}
+	if err := cfg.loadPolicy(path, cleanConfig, debugSconfig); err != nil {
+		return nil, err
+	}
+	if err := cfg.loadServers(path, cleanConfig, debugSconfig); err != nil {
+		return nil, err
+	}
+	if err := cfg.resolveSecrets(); err != nil {
+		return nil, err
+	}
 	cfg.normalizePaths()
+	if err := cfg.loadTranslations(path); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	for _, s := range cfg.servers {
+		if err := s.resolveSecrets(); err != nil {
+			return nil, fmt.Errorf(i18n.T("err.config_server"), s.server, err)
+		}
+		s.normalizePaths()
+		if err := s.Validate(); err != nil {
+			return nil, fmt.Errorf(i18n.T("err.config_server"), s.server, err)
+		}
+	}
 	return cfg, nil
 }
 
+// loadTranslations puts the files of translations_dir (relative to the directory of the config file base) on top
+// of the embedded messages, or removes a previously loaded overlay when translations_dir is empty (config reload).
+func (c *Config) loadTranslations(base string) error {
+	dir := filepath.FromSlash(strings.TrimSpace(c.TranslationsDir))
+	if dir != "" && !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(base), dir)
+	}
+	if err := i18n.LoadOverlay(dir); err != nil {
+		return fmt.Errorf(i18n.T("err.config_translations"), dir, err)
+	}
+	return nil
+}
+
+// Validate checks settings that cannot be corrected silently (e.g. retention anchors).
+func (c *Config) Validate() error {
+	if _, err := c.WeeklyAnchor(); err != nil {
+		return err
+	}
+	if _, _, err := c.YearlyAnchor(); err != nil {
+		return err
+	}
+	if _, err := c.RunTimeout(); err != nil {
+		return err
+	}
+	if _, _, err := c.WindowEnd(time.Now()); err != nil {
+		return err
+	}
+	for _, step := range []string{"dump", "remote", "notify"} {
+		if _, err := c.RetryPolicy(step); err != nil {
+			return err
+		}
+	}
+	if _, err := c.MaxBackupDirBytes(); err != nil {
+		return err
+	}
+	for _, p := range []struct{ key, value string }{
+		{"backup_dir", c.BackupDir}, {"log_filename", c.LogFilename}, {"remote_backup_dir", c.RemoteBackupDir},
+	} {
+		if strings.Contains(p.value, "{db}") {
+			return fmt.Errorf(i18n.T("err.config_placeholder_db"), p.key)
+		}
+	}
+	if c.ArchiveRetainDays < 0 {
+		return fmt.Errorf(i18n.T("err.config_negative"), "archive_retain_days", c.ArchiveRetainDays)
+	}
+	if c.PhysicalBackupKeep < 0 {
+		return fmt.Errorf(i18n.T("err.config_negative"), "physical_backup_keep", c.PhysicalBackupKeep)
+	}
+	if _, err := c.GaleraAddrs(); err != nil {
+		return err
+	}
+	if c.GaleraDesync && len(c.GaleraNodes) == 0 {
+		return fmt.Errorf(i18n.T("err.config_galera_desync"))
+	}
+	if c.ParityPercent < 0 || c.ParityPercent > 100 {
+		return fmt.Errorf(i18n.T("err.config_parity_percent"), c.ParityPercent)
+	}
+	for _, k := range c.SigningPublicKeys {
+		if key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(k)); err != nil || key.Type() != ssh.KeyAlgoED25519 {
+			return fmt.Errorf(i18n.T("err.config_signing_public_key"), k)
+		}
+	}
+	if _, err := c.Location(); err != nil {
+		return err
+	}
+	if _, err := c.ScheduleSpec(); err != nil {
+		return err
+	}
+	if _, _, err := c.StartClock(); err != nil {
+		return err // auch bei gesetztem schedule: Tippfehler nicht stillschweigend übergehen
+	}
+	for _, r := range []struct {
+		key   string
+		value int
+	}{{"retain_daily", c.RetainDaily}, {"retain_weekly", c.RetainWeekly}, {"retain_monthly", c.RetainMonthly}, {"retain_yearly", c.RetainYearly}} {
+		if r.value < 0 {
+			return fmt.Errorf(i18n.T("err.config_retain"), r.key, r.value)
+		}
+	}
+	if s := strings.ToLower(strings.TrimSpace(c.ScheduleScope)); s != "" && s != "user" && s != "system" && s != "periodic" {
+		return fmt.Errorf(i18n.T("err.config_schedule_scope"), c.ScheduleScope)
+	}
+	switch strings.ToLower(strings.TrimSpace(c.WindowsTaskLogonType)) {
+	case "", "password", "s4u", "serviceaccount", "interactive":
+	default:
+		return fmt.Errorf(i18n.T("err.config_logon_type"), c.WindowsTaskLogonType)
+	}
+	for _, h := range c.WebhookHeaders {
+		if name, _, ok := strings.Cut(h, ":"); !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf(i18n.T("err.config_webhook_header"), h)
+		}
+	}
+	for _, l := range []struct{ key, value string }{
+		{"log_level_console", c.LogLevelConsole}, {"log_level_file", c.LogLevelFile}, {"log_level_syslog", c.LogLevelSyslog},
+	} {
+		switch strings.ToLower(strings.TrimSpace(l.value)) {
+		case "", "debug", "info", "warn", "warning", "error", "off":
+		default:
+			return fmt.Errorf(i18n.T("err.config_log_level"), l.key, l.value)
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(c.LogFormat)) {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf(i18n.T("err.config_log_format"), c.LogFormat)
+	}
+	switch strings.ToLower(strings.TrimSpace(c.NotifyLevel)) {
+	case "", "errors", "warnings", "warnings+errors", "all":
+	default:
+		return fmt.Errorf(i18n.T("err.config_notify_level"), c.NotifyLevel)
+	}
+	if c.NotifyRepeat < 0 {
+		return fmt.Errorf(i18n.T("err.config_negative"), "notify_repeat", c.NotifyRepeat)
+	}
+	if c.LogRetainDays < 0 {
+		return fmt.Errorf(i18n.T("err.config_negative"), "log_retain_days", c.LogRetainDays)
+	}
+	if c.MailLogKB < 0 {
+		return fmt.Errorf(i18n.T("err.config_negative"), "mail_log_kb", c.MailLogKB)
+	}
+	if c.DiskSpaceFactor != 0 && c.DiskSpaceFactor < 1 {
+		return fmt.Errorf(i18n.T("err.config_disk_factor"), c.DiskSpaceFactor)
+	}
+	if c.DiskForecastDays < 0 {
+		return fmt.Errorf(i18n.T("err.config_negative"), "disk_forecast_days", c.DiskForecastDays)
+	}
+	if c.LockWaitMinutes < 0 {
+		return fmt.Errorf(i18n.T("err.config_negative"), "lock_wait_minutes", c.LockWaitMinutes)
+	}
+	if c.StartJitterMinutes < 0 {
+		return fmt.Errorf(i18n.T("err.config_negative"), "start_jitter_minutes", c.StartJitterMinutes)
+	}
+	if c.RemoteKMSKey != "" {
+		if _, _, err := kms.ParseKey(c.RemoteKMSKey); err != nil {
+			return err
+		}
+	}
+	if c.MetricsStatsD != "" {
+		if _, _, err := net.SplitHostPort(c.MetricsStatsD); err != nil {
+			return fmt.Errorf(i18n.T("err.config_metrics_statsd"), c.MetricsStatsD, err)
+		}
+	}
+	if c.APIListen != "" {
+		if _, _, err := net.SplitHostPort(c.APIListen); err != nil {
+			return fmt.Errorf(i18n.T("err.config_api_listen"), c.APIListen, err)
+		}
+		if strings.TrimSpace(c.APIPassword) == "" {
+			return fmt.Errorf(i18n.T("err.config_api_password"))
+		}
+		if (c.APITLSCert == "") != (c.APITLSKey == "") {
+			return fmt.Errorf(i18n.T("err.config_api_tls"))
+		}
+	}
+	if c.ControllerURL != "" {
+		u, err := url.Parse(c.ControllerURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf(i18n.T("err.config_controller_url"), c.ControllerURL)
+		}
+	}
+	if c.AgentName != "" && !agentNameRe.MatchString(c.AgentName) {
+		return fmt.Errorf(i18n.T("err.config_agent_name"), c.AgentName)
+	}
+	if c.ControllerDir != "" && c.APIListen == "" {
+		return fmt.Errorf(i18n.T("err.config_controller_dir"))
+	}
+	if (c.ControllerURL != "" || c.ControllerDir != "") && strings.TrimSpace(c.ControllerPassword) == "" {
+		return fmt.Errorf(i18n.T("err.config_controller_password"))
+	}
+	seen := make(map[string]bool)
+	for _, d := range c.Databases {
+		if strings.TrimSpace(d.Name) == "" || seen[d.Name] {
+			return fmt.Errorf(i18n.T("err.config_database_name"), d.Name)
+		}
+		seen[d.Name] = true
+		for _, r := range []struct {
+			key   string
+			value *int
+		}{{"retain_daily", d.RetainDaily}, {"retain_weekly", d.RetainWeekly}, {"retain_monthly", d.RetainMonthly}, {"retain_yearly", d.RetainYearly}} {
+			if r.value != nil && *r.value < 0 {
+				return fmt.Errorf(i18n.T("err.config_retain"), "databases["+d.Name+"]."+r.key, *r.value)
+			}
+		}
+	}
+	names := make(map[string]bool)
+	for _, f := range c.FileBackups {
+		name := f.BackupName()
+		if strings.TrimSpace(f.Path) == "" || fileBackupNameInvalid.MatchString(name) || name == "" || names[name] {
+			return fmt.Errorf(i18n.T("err.config_file_backup"), name, f.Path)
+		}
+		names[name] = true
+		for _, p := range f.Exclude {
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf(i18n.T("err.config_file_backup_exclude"), p, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Migrations returns the changes made by the config migrations when the file was loaded (older version).
+func (c *Config) Migrations() []string {
+	return c.migrated
+}
+
+// Database returns the settings of database name from databases, or nil.
+func (c *Config) Database(name string) *DatabaseConfig {
+	for i := range c.Databases {
+		if c.Databases[i].Name == name {
+			return &c.Databases[i]
+		}
+	}
+	return nil
+}
+
+// Recipients returns the email recipients: mail_to, otherwise admin_email (empty = no emails).
+func (c *Config) Recipients() []string {
+	var to []string
+	for _, a := range c.MailTo {
+		if a = strings.TrimSpace(a); a != "" {
+			to = append(to, a)
+		}
+	}
+	if len(to) == 0 && strings.TrimSpace(c.AdminEmail) != "" {
+		to = []string{strings.TrimSpace(c.AdminEmail)}
+	}
+	return to
+}
+
+// Secrets returns the configured passwords, keys and tokens (masked in log output), also those of the servers.
+func (c *Config) Secrets() []string {
+	var s []string
+	for _, f := range c.secretFields() {
+		s = append(s, *f.value)
+	}
+	for _, srv := range c.servers {
+		s = append(s, srv.Secrets()...)
+	}
+	return s
+}
+
+// Masked returns a copy for display (--print-config): all password fields, plain and encrypted, and the values
+// of webhook_headers are replaced by "***".
+func (c *Config) Masked() *Config {
+	m := *c
+	v := reflect.ValueOf(&m).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		key, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if f.Kind() == reflect.String && strings.HasSuffix(key, "_password") && f.String() != "" {
+			f.SetString("***")
+		}
+	}
+	m.WebhookHeaders = nil
+	for _, h := range c.WebhookHeaders {
+		name, _, _ := strings.Cut(h, ":")
+		m.WebhookHeaders = append(m.WebhookHeaders, name+": ***")
+	}
+	return &m
+}
+
+// Diff returns the settings that differ between c and n as "key: old -> new" (JSON values) for the config reload
+// of --daemon; those of a server as "name: key: old -> new". The unmasked values are compared, so a rotated
+// password counts as a change, but password fields (plain and encrypted) and webhook header values are reported as
+// "key: changed" without their values.
+func (c *Config) Diff(n *Config) []string {
+	var changes []string
+	a, b := reflect.ValueOf(c).Elem(), reflect.ValueOf(n).Elem()
+	ma, mb := reflect.ValueOf(c.Masked()).Elem(), reflect.ValueOf(n.Masked()).Elem()
+	for i := 0; i < a.NumField(); i++ {
+		key, _, _ := strings.Cut(a.Type().Field(i).Tag.Get("json"), ",")
+		if key == "" || key == "-" || !a.Type().Field(i).IsExported() {
+			continue
+		}
+		old, _ := json.Marshal(a.Field(i).Interface())
+		cur, _ := json.Marshal(b.Field(i).Interface())
+		if string(old) == string(cur) {
+			continue
+		}
+		old, _ = json.Marshal(ma.Field(i).Interface())
+		cur, _ = json.Marshal(mb.Field(i).Interface())
+		if strings.HasSuffix(key, "_password") || string(old) == string(cur) {
+			changes = append(changes, key+": changed")
+			continue
+		}
+		changes = append(changes, key+": "+string(old)+" -> "+string(cur))
+	}
+	for _, s := range n.servers {
+		if o, err := c.Server(s.server); err == nil {
+			for _, ch := range o.Diff(s) {
+				changes = append(changes, s.server+": "+ch)
+			}
+		}
+	}
+	return changes
+}
+
+// JSONLog reports whether log_format is "json".
+func (c *Config) JSONLog() bool {
+	return strings.EqualFold(strings.TrimSpace(c.LogFormat), "json")
+}
+
+// NotifyWarnings reports whether notify_level includes successful runs with warnings ("warnings" or "all").
+func (c *Config) NotifyWarnings() bool {
+	switch strings.ToLower(strings.TrimSpace(c.NotifyLevel)) {
+	case "warnings", "warnings+errors", "all":
+		return true
+	}
+	return false
+}
+
+// NotifyAll reports whether notify_level is "all" (every run is reported on all channels).
+func (c *Config) NotifyAll() bool {
+	return strings.EqualFold(strings.TrimSpace(c.NotifyLevel), "all")
+}
+
+// TelegramEnabled reports whether bot token and chat ID for Telegram notifications are set.
+func (c *Config) TelegramEnabled() bool {
+	return strings.TrimSpace(c.TelegramBotPassword) != "" && strings.TrimSpace(c.TelegramChatID) != ""
+}
+
+// SystemScope reports whether schedule_scope is "system".
+func (c *Config) SystemScope() bool {
+	return strings.EqualFold(strings.TrimSpace(c.ScheduleScope), "system")
+}
+
+// PeriodicScope reports whether schedule_scope is "periodic" (BSD periodic(8) daily script).
+func (c *Config) PeriodicScope() bool {
+	return strings.EqualFold(strings.TrimSpace(c.ScheduleScope), "periodic")
+}
+
+// ScheduleSpec returns the run schedule: the cron expression from schedule, otherwise daily at
+// start_time (see StartClock).
+func (c *Config) ScheduleSpec() (*cron.Spec, error) {
+	if strings.TrimSpace(c.Schedule) != "" {
+		return cron.Parse(c.Schedule)
+	}
+	hour, min, err := c.StartClock()
+	if err != nil {
+		return nil, err
+	}
+	return cron.Daily(hour, min), nil
+}
+
+// StartClock returns hour and minute of start_time (HH:MM on the 24-hour clock, empty = 22:00).
+func (c *Config) StartClock() (hour, min int, err error) {
+	s := strings.TrimSpace(c.StartTime)
+	if s == "" {
+		return 22, 0, nil
+	}
+	hour, min, ok := parseClock(s)
+	if !ok {
+		return 22, 0, fmt.Errorf(i18n.T("err.config_start_time"), c.StartTime)
+	}
+	return hour, min, nil
+}
+
+// parseClock parses HH:MM on the 24-hour clock.
+func parseClock(s string) (hour, min int, ok bool) {
+	h, m, ok := strings.Cut(s, ":")
+	hour, errH := strconv.Atoi(h)
+	min, errM := strconv.Atoi(m)
+	if !ok || errH != nil || errM != nil || len(m) != 2 || hour < 0 || hour > 23 || min < 0 || min > 59 {
+		return 0, 0, false
+	}
+	return hour, min, true
+}
+
+// WindowEnd returns the end of the backup window of a run started at start. The window opens at a scheduled
+// run (start_time or schedule) and closes at the next backup_window_end (HH:MM in timezone): a run starting at
+// 22:00 with "06:00" may run until the next morning. A run that starts after backup_window_end and before the
+// next scheduled run (manual or catch-up run at 07:00) is outside the window: open is false and end is the
+// time the window closed. Zero end without backup_window_end.
+func (c *Config) WindowEnd(start time.Time) (end time.Time, open bool, err error) {
+	s := strings.TrimSpace(c.BackupWindowEnd)
+	if s == "" {
+		return time.Time{}, true, nil
+	}
+	hour, min, ok := parseClock(s)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf(i18n.T("err.config_window_end"), c.BackupWindowEnd)
+	}
+	if loc, err := c.Location(); err == nil {
+		start = start.In(loc)
+	}
+	end = time.Date(start.Year(), start.Month(), start.Day(), hour, min, 0, 0, start.Location())
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	closed := end.AddDate(0, 0, -1)
+	if spec, err := c.ScheduleSpec(); err == nil {
+		// kein geplanter Lauf seit dem letzten Fensterende: das Fenster ist noch zu
+		if next := spec.Next(closed); next.IsZero() || next.After(start) {
+			return closed, false, nil
+		}
+	}
+	return end, true, nil
+}
+
+// Location returns the configured timezone; "" or "local" is the system timezone.
+func (c *Config) Location() (*time.Location, error) {
+	tz := strings.TrimSpace(c.Timezone)
+	if tz == "" || strings.EqualFold(tz, "local") {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("err.config_timezone"), c.Timezone, err)
+	}
+	return loc, nil
+}
+
+// Now returns the current time in the configured timezone (system timezone if invalid).
+func (c *Config) Now() time.Time {
+	loc, err := c.Location()
+	if err != nil {
+		return time.Now()
+	}
+	return time.Now().In(loc)
+}
+
+// RunTimeout returns max_run_duration (0 = unlimited), a Go duration such as "4h" or "1h30m".
+func (c *Config) RunTimeout() (time.Duration, error) {
+	s := strings.TrimSpace(c.MaxRunDuration)
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf(i18n.T("err.config_duration"), "max_run_duration", c.MaxRunDuration)
+	}
+	return d, nil
+}
+
+// RetryPolicy returns the retry setting of step "dump", "remote" or "notify" (<step>_retries and
+// <step>_retry_backoff).
+func (c *Config) RetryPolicy(step string) (retry.Policy, error) {
+	var retries int
+	var backoff string
+	switch step {
+	case "dump":
+		retries, backoff = c.DumpRetries, c.DumpRetryBackoff
+	case "remote":
+		retries, backoff = c.RemoteRetries, c.RemoteRetryBackoff
+	case "notify":
+		retries, backoff = c.NotifyRetries, c.NotifyRetryBackoff
+	}
+	if retries < 0 {
+		return retry.Policy{}, fmt.Errorf(i18n.T("err.config_negative"), step+"_retries", retries)
+	}
+	p := retry.Policy{Retries: retries}
+	if s := strings.TrimSpace(backoff); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return retry.Policy{}, fmt.Errorf(i18n.T("err.config_duration"), step+"_retry_backoff", backoff)
+		}
+		p.Backoff = d
+	}
+	return p, nil
+}
+
+// MaxBackupDirBytes returns max_backup_dir_size in bytes (0 = no cap). Suffixes K, M, G, T (base 1024) are accepted.
+func (c *Config) MaxBackupDirBytes() (int64, error) {
+	return ParseSize("max_backup_dir_size", c.MaxBackupDirSize)
+}
+
+// ParseSize parses sizes like "1024", "500K", "20M", "1.5G" or "2T" (base 1024). Empty string is 0.
+// key names the option in the error; negative, infinite, NaN and sizes beyond int64 are rejected.
+func ParseSize(key, value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+	if s == "" {
+		return 0, nil
+	}
+	mult := int64(1)
+	switch s[len(s)-1] {
+	case 'K':
+		mult = 1 << 10
+	case 'M':
+		mult = 1 << 20
+	case 'G':
+		mult = 1 << 30
+	case 'T':
+		mult = 1 << 40
+	}
+	if mult > 1 {
+		s = strings.TrimSpace(s[:len(s)-1])
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	// v >= MaxInt64/mult: Produkt liefe über, int64(v*mult) wäre undefiniert
+	if err != nil || v < 0 || math.IsNaN(v) || math.IsInf(v, 0) || v >= float64(math.MaxInt64/mult) {
+		return 0, fmt.Errorf(i18n.T("err.config_size"), key, value)
+	}
+	return int64(v * float64(mult)), nil
+}
+
+// weekdayNames maps English (full and abbreviated) and German weekday names to time.Weekday.
+var weekdayNames = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday, "sonntag": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday, "montag": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "dienstag": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday, "mittwoch": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "donnerstag": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday, "freitag": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday, "samstag": time.Saturday, "sonnabend": time.Saturday,
+}
+
+// WeeklyAnchor returns the weekday on which weekly backups are kept (retain_weekly_day, default Sunday).
+func (c *Config) WeeklyAnchor() (time.Weekday, error) {
+	s := strings.ToLower(strings.TrimSpace(c.RetainWeeklyDay))
+	if s == "" {
+		return time.Sunday, nil
+	}
+	if d, ok := weekdayNames[s]; ok {
+		return d, nil
+	}
+	return time.Sunday, fmt.Errorf(i18n.T("err.config_weekly_day"), c.RetainWeeklyDay)
+}
+
+// YearlyAnchor returns month and day of the yearly cut-over (retain_yearly_date as TT.MM, default 31.12).
+func (c *Config) YearlyAnchor() (time.Month, int, error) {
+	s := strings.TrimSpace(c.RetainYearlyDate)
+	if s == "" {
+		return time.December, 31, nil
+	}
+	t, err := time.Parse("02.01", s)
+	if err != nil {
+		t, err = time.Parse("2.1", s)
+	}
+	if err != nil || (t.Month() == time.February && t.Day() == 29) {
+		return time.December, 31, fmt.Errorf(i18n.T("err.config_yearly_date"), c.RetainYearlyDate)
+	}
+	return t.Month(), t.Day(), nil
+}
+
 func (c *Config) normalizePaths() {
-	c.BackupDir = filepath.FromSlash(filepath.Clean(c.BackupDir))
-	c.LogFilename = filepath.FromSlash(filepath.Clean(c.LogFilename))
-	c.RemoteBackupDir = filepath.FromSlash(filepath.Clean(c.RemoteBackupDir))
+	c.ExpandPaths(c.Now())
+	if c.WorkDir != "" {
+		c.WorkDir = filepath.FromSlash(filepath.Clean(c.WorkDir))
+	}
+	if c.ArchiveDir != "" {
+		c.ArchiveDir = filepath.FromSlash(filepath.Clean(c.ArchiveDir))
+	}
+	if c.RemoteArchiveDir != "" {
+		c.RemoteArchiveDir = filepath.FromSlash(filepath.Clean(c.RemoteArchiveDir))
+	}
+	if c.MetricsFile != "" {
+		c.MetricsFile = filepath.FromSlash(filepath.Clean(c.MetricsFile))
+	}
+	if c.AuditFile != "" {
+		c.AuditFile = filepath.FromSlash(filepath.Clean(c.AuditFile))
+	}
 	if c.MySQLBin != "" {
 		c.MySQLBin = filepath.FromSlash(filepath.Clean(c.MySQLBin))
 	}
@@ -111,19 +974,73 @@ func (c *Config) normalizePaths() {
 	if c.MySQLBackupDir != "" {
 		c.MySQLBackupDir = filepath.FromSlash(filepath.Clean(c.MySQLBackupDir))
 	}
+	if c.PhysicalBackupDir != "" {
+		c.PhysicalBackupDir = filepath.FromSlash(filepath.Clean(c.PhysicalBackupDir))
+	}
 	if c.RemoteSSHKeyFile != "" {
 		c.RemoteSSHKeyFile = filepath.FromSlash(filepath.Clean(c.RemoteSSHKeyFile))
 	}
+	if c.SigningKeyFile != "" {
+		c.SigningKeyFile = filepath.FromSlash(filepath.Clean(c.SigningKeyFile))
+	}
+	if c.APITLSCert != "" {
+		c.APITLSCert = filepath.FromSlash(filepath.Clean(c.APITLSCert))
+	}
+	if c.APITLSKey != "" {
+		c.APITLSKey = filepath.FromSlash(filepath.Clean(c.APITLSKey))
+	}
+	if c.ControllerDir != "" {
+		c.ControllerDir = filepath.FromSlash(filepath.Clean(c.ControllerDir))
+	}
 }
 
-// LoadClean reads config and writes it back with plaintext passwords (for migration/inspection).
+// LoadClean reads config and writes it back with plaintext passwords (for migration/inspection), also the include files.
 // If debug is true, sconfig may print debug output (e.g. when -verbose is used).
 func LoadClean(path string, debug bool) error {
+	if _, err := migrate(path); err != nil {
+		return err
+	}
 	cfg := DefaultConfig()
-	if err := sconfig.LoadConfig(cfg, cfg.Version, path, true, debug); err != nil {
+	if err := sconfig.LoadConfig(cfg, SchemaVersion, path, true, debug); err != nil {
 		return fmt.Errorf(i18n.T("err.sconfig_clean"), err)
 	}
-	return nil
+	if err := cfg.loadIncludes(path, true, debug); err != nil {
+		return err
+	}
+	if err := cfg.loadPolicy(path, true, debug); err != nil {
+		return err
+	}
+	return cfg.loadServers(path, true, debug)
+}
+
+// Node is the address of one MySQL server (galera_nodes).
+type Node struct {
+	Host string
+	Port int
+}
+
+func (n Node) String() string {
+	return net.JoinHostPort(n.Host, strconv.Itoa(n.Port))
+}
+
+// GaleraAddrs returns the nodes of galera_nodes in their order; entries without port use mysql_port.
+func (c *Config) GaleraAddrs() ([]Node, error) {
+	var nodes []Node
+	for _, s := range c.GaleraNodes {
+		n := Node{Host: strings.TrimSpace(s), Port: c.MySQLPort}
+		if h, p, err := net.SplitHostPort(n.Host); err == nil {
+			port, err := strconv.Atoi(p)
+			if err != nil || port <= 0 || port > 65535 {
+				return nil, fmt.Errorf(i18n.T("err.config_galera_node"), s)
+			}
+			n.Host, n.Port = h, port
+		}
+		if n.Host == "" || strings.ContainsAny(n.Host, " /") {
+			return nil, fmt.Errorf(i18n.T("err.config_galera_node"), s)
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
 }
 
 // HostnameForBackup returns the hostname used for Backup-Dateinamen. Bei localhost/127.0.0.1 und gesetztem mysql_hostname wird dieser verwendet.
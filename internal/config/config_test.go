@@ -0,0 +1,196 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMasked(t *testing.T) {
+	c := DefaultConfig()
+	c.RootPassword = "geheim"
+	c.RootSecurePassword = "enc"
+	c.WebhookHeaders = []string{"Authorization: Bearer tok"}
+	m := c.Masked()
+	if m.RootPassword != "***" || m.RootSecurePassword != "***" || m.AdminSMTPPassword != "" {
+		t.Errorf("passwords = %q %q %q", m.RootPassword, m.RootSecurePassword, m.AdminSMTPPassword)
+	}
+	if len(m.WebhookHeaders) != 1 || m.WebhookHeaders[0] != "Authorization: ***" {
+		t.Errorf("headers = %q", m.WebhookHeaders)
+	}
+	if c.RootPassword != "geheim" || c.WebhookHeaders[0] != "Authorization: Bearer tok" {
+		t.Error("original modified")
+	}
+}
+
+func TestExampleFile(t *testing.T) {
+	want, err := ExampleJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join("..", "..", "config.example.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("config.example.json differs from ExampleJSON (regenerate with mysqlbackup --example-config):\n%s", want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a, b := DefaultConfig(), DefaultConfig()
+	b.RetainDaily = 30
+	b.RootPassword = "neu"
+	b.MailTo = []string{"ops@example.com"}
+	b.WebhookHeaders = []string{"Authorization: Bearer neu"}
+	got := a.Diff(b)
+	want := []string{"root_password: changed", "retain_daily: 14 -> 30", `mail_to: null -> ["ops@example.com"]`, `webhook_headers: null -> ["Authorization: ***"]`}
+	if len(got) != len(want) {
+		t.Fatalf("Diff = %q", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Diff[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+	// nur Secrets geändert: Neuladen nötig, Werte erscheinen nicht
+	c := *b
+	c.RootPassword, c.WebhookHeaders = "rotiert", []string{"Authorization: Bearer rotiert"}
+	got = b.Diff(&c)
+	if strings.Join(got, "|") != "root_password: changed|webhook_headers: changed" {
+		t.Errorf("secret-only Diff = %q", got)
+	}
+}
+
+func TestValidateStartTimeAndRetention(t *testing.T) {
+	for _, c := range []struct {
+		start string
+		daily int
+		ok    bool
+	}{
+		{"", 14, true}, {"03:30", 14, true}, {"9:05", 0, true},
+		{"24:00", 14, false}, {"22.00", 14, false}, {"22:5", 14, false}, {"abends", 14, false},
+		{"22:00", -1, false},
+	} {
+		cfg := DefaultConfig()
+		cfg.StartTime, cfg.RetainDaily = c.start, c.daily
+		if err := cfg.Validate(); (err == nil) != c.ok {
+			t.Errorf("start_time %q, retain_daily %d: err = %v", c.start, c.daily, err)
+		}
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	for _, c := range []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, true}, {"4h", 4 * time.Hour, true}, {" 1h30m ", 90 * time.Minute, true},
+		{"4", 0, false}, {"-1h", 0, false}, {"vier Stunden", 0, false},
+	} {
+		cfg := DefaultConfig()
+		cfg.MaxRunDuration = c.value
+		d, err := cfg.RunTimeout()
+		if (err == nil) != c.ok || d != c.want {
+			t.Errorf("max_run_duration %q: %v, %v", c.value, d, err)
+		}
+		if (cfg.Validate() == nil) != c.ok {
+			t.Errorf("max_run_duration %q: Validate = %v", c.value, cfg.Validate())
+		}
+	}
+}
+
+func TestWindowEnd(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Timezone = "UTC"
+	cfg.StartTime = "22:00"
+	day := func(d, h, m int) time.Time { return time.Date(2026, 10, d, h, m, 0, 0, time.UTC) }
+	for _, c := range []struct {
+		value string
+		start time.Time
+		want  time.Time
+		open  bool
+		ok    bool
+	}{
+		{"", day(16, 22, 0), time.Time{}, true, true},
+		{"06:00", day(16, 22, 0), day(17, 6, 0), true, true},
+		{"06:00", day(16, 23, 30), day(17, 6, 0), true, true}, // verspäteter Lauf der Nacht
+		{"06:00", day(17, 7, 0), day(17, 6, 0), false, true},  // Nachholen/manuell nach Fensterende
+		{"23:30", day(16, 22, 0), day(16, 23, 30), true, true},
+		{"6", day(16, 22, 0), time.Time{}, false, false}, {"24:00", day(16, 22, 0), time.Time{}, false, false},
+	} {
+		cfg.BackupWindowEnd = c.value
+		end, open, err := cfg.WindowEnd(c.start)
+		if (err == nil) != c.ok || !end.Equal(c.want) || open != c.open {
+			t.Errorf("backup_window_end %q at %v: %v, %v, %v", c.value, c.start, end, open, err)
+		}
+		if (cfg.Validate() == nil) != c.ok {
+			t.Errorf("backup_window_end %q: Validate = %v", c.value, cfg.Validate())
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	cfg := DefaultConfig()
+	if p, err := cfg.RetryPolicy("remote"); err != nil || p.Retries != 2 || p.Backoff != time.Minute {
+		t.Errorf("default remote: %+v, %v", p, err)
+	}
+	cfg.NotifyRetries, cfg.NotifyRetryBackoff = 0, ""
+	if p, err := cfg.RetryPolicy("notify"); err != nil || p.Retries != 0 || p.Backoff != 0 {
+		t.Errorf("notify off: %+v, %v", p, err)
+	}
+	cfg.DumpRetries = -1
+	if _, err := cfg.RetryPolicy("dump"); err == nil || cfg.Validate() == nil {
+		t.Error("dump_retries -1 accepted")
+	}
+	cfg.DumpRetries, cfg.DumpRetryBackoff = 1, "eine Minute"
+	if _, err := cfg.RetryPolicy("dump"); err == nil || cfg.Validate() == nil {
+		t.Error("dump_retry_backoff \"eine Minute\" accepted")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for s, want := range map[string]int64{"": 0, "1024": 1024, "500K": 500 << 10, "1.5G": 3 << 29, "2TiB": 2 << 40, "8388606T": 8388606 << 40} {
+		if n, err := ParseSize("max_backup_dir_size", s); err != nil || n != want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", s, n, err, want)
+		}
+	}
+	for _, s := range []string{"inf", "-inf", "nan", "1e400", "-1G", "9223372036854775807", "8388607T", "viel"} {
+		if _, err := ParseSize("max_backup_dir_size", s); err == nil {
+			t.Errorf("ParseSize(%q) accepted", s)
+		}
+	}
+	cfg := DefaultConfig()
+	cfg.MaxBackupDirSize = "inf"
+	if cfg.Validate() == nil {
+		t.Error("max_backup_dir_size \"inf\" accepted")
+	}
+}
+
+func TestGaleraAddrs(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GaleraNodes = []string{"db1", "db2:3307", "[fd00::3]:3308", "fd00::4"}
+	nodes, err := cfg.GaleraAddrs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"db1:3306", "db2:3307", "[fd00::3]:3308", "[fd00::4]:3306"}
+	for i, n := range nodes {
+		if n.String() != want[i] {
+			t.Errorf("galera_nodes[%d] = %s, want %s", i, n, want[i])
+		}
+	}
+	for _, bad := range []string{"", "db1:0", "db1:port", "db 1"} {
+		cfg.GaleraNodes = []string{bad}
+		if cfg.Validate() == nil {
+			t.Errorf("galera_nodes %q accepted", bad)
+		}
+	}
+	cfg.GaleraNodes, cfg.GaleraDesync = nil, true
+	if cfg.Validate() == nil {
+		t.Error("galera_desync without galera_nodes accepted")
+	}
+}
@@ -0,0 +1,38 @@
+package config
+
+import "encoding/json"
+
+// Example returns the template of --example-config and config.example.json: the defaults plus placeholders
+// for host, directories and mail, every key present (lists empty instead of null).
+func Example() *Config {
+	c := DefaultConfig()
+	c.Version = SchemaVersion
+	c.Include = []string{}
+	c.Servers = []string{}
+	c.MySQLHost = "localhost"
+	c.BackupDir = "./backups"
+	c.LogFilename = "./backups/mysqlbackup.log"
+	c.LogFormat = "text"
+	c.AdminEmail = "admin@example.com"
+	c.AdminSMTPServer = "smtp.example.com"
+	c.AdminSMTPTLS = "starttls"
+	c.MailTo, c.MailCC = []string{}, []string{}
+	c.NotifyLevel = "errors"
+	c.WebhookMethod = "POST"
+	c.WebhookHeaders = []string{}
+	c.GaleraNodes = []string{}
+	c.SigningPublicKeys = []string{}
+	c.ScheduleScope = "user"
+	c.Databases = []DatabaseConfig{}
+	c.FileBackups = []FileBackup{}
+	return c
+}
+
+// ExampleJSON returns Example as indented JSON (JSON has no comments; the keys are described in README.md).
+func ExampleJSON() ([]byte, error) {
+	b, err := json.MarshalIndent(Example(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
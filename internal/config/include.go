@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/janmz/sconfig"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// loadIncludes applies the files of include in order on top of c (later files override earlier ones); relative
+// paths are relative to the directory of the main config file base. Only the keys present in a fragment are
+// applied. Fragments go through sconfig like the main file: plaintext passwords are encrypted and written back
+// (only the keys of the fragment), clean writes them back in plaintext. include and servers inside a fragment are
+// ignored.
+func (c *Config) loadIncludes(base string, clean, debug bool) error {
+	c.includes = nil
+	for _, inc := range c.Include {
+		p := fragmentPath(base, inc)
+		if p == "" {
+			continue
+		}
+		if err := c.applyFragment(p, clean, debug); err != nil {
+			return fmt.Errorf(i18n.T("err.config_include"), p, err)
+		}
+		c.includes = append(c.includes, p)
+	}
+	return nil
+}
+
+// fragmentPath resolves a path of include or servers relative to the directory of the main config file base.
+func fragmentPath(base, p string) string {
+	p = filepath.FromSlash(strings.TrimSpace(p))
+	if p != "" && !filepath.IsAbs(p) {
+		p = filepath.Join(filepath.Dir(base), p)
+	}
+	return p
+}
+
+// applyFragment loads the fragment at path into a struct that has only the fields of its keys (plus the
+// encrypted counterpart of password fields), so sconfig neither adds the other keys nor the version to the
+// fragment, and copies those fields into c.
+func (c *Config) applyFragment(path string, clean, debug bool) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	migrated, err := migrate(path)
+	if err != nil {
+		return err
+	}
+	c.migrated = append(c.migrated, migrated...)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	t := reflect.TypeOf(*c)
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || key == "" || key == "include" || key == "servers" || key == "version" {
+			continue
+		}
+		_, ok := keys[key]
+		if pair := passwordPair(key); pair != "" {
+			_, okPair := keys[pair]
+			ok = ok || okPair
+		}
+		if ok {
+			fields = append(fields, f)
+		}
+	}
+	frag := reflect.New(reflect.StructOf(fields))
+	if err := sconfig.LoadConfig(frag.Interface(), SchemaVersion, path, clean, debug); err != nil {
+		return err
+	}
+	out, err := json.Marshal(frag.Interface())
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(out, c)
+}
+
+// passwordPair returns the key of the other field of a sconfig password pair (x_password <-> x_secure_password),
+// "" for other keys.
+func passwordPair(key string) string {
+	switch {
+	case strings.HasSuffix(key, "_secure_password"):
+		return strings.TrimSuffix(key, "_secure_password") + "_password"
+	case strings.HasSuffix(key, "_password"):
+		return strings.TrimSuffix(key, "_password") + "_secure_password"
+	}
+	return ""
+}
+
+// Includes returns the paths of the included config files (include, controller policy and servers), e.g. to watch
+// them for changes.
+func (c *Config) Includes() []string {
+	return c.includes
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInclude(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	write("smtp.json", `{"admin_smtp_server": "smtp.example.com", "admin_smtp_password": "geheim", "mysql_port": 3307}`)
+	write("local.json", `{"mysql_port": 3308}`)
+	path := write("config.json", `{"mysql_host": "db1", "include": ["smtp.json", "local.json"]}`)
+	cfg, err := Load(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MySQLHost != "db1" || cfg.AdminSMTPServer != "smtp.example.com" || cfg.AdminSMTPPassword != "geheim" || cfg.MySQLPort != 3308 {
+		t.Errorf("cfg = host %q, smtp %q/%q, port %d", cfg.MySQLHost, cfg.AdminSMTPServer, cfg.AdminSMTPPassword, cfg.MySQLPort)
+	}
+	if len(cfg.Includes()) != 2 {
+		t.Errorf("includes = %q", cfg.Includes())
+	}
+	// Das Fragment behält nur seine Schlüssel, das Passwort ist verschlüsselt.
+	data, _ := os.ReadFile(filepath.Join(dir, "smtp.json"))
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil || len(keys) != 4 || keys["admin_smtp_secure_password"] == nil {
+		t.Errorf("fragment written back:\n%s", data)
+	}
+	cfg, err = Load(path, false)
+	if err != nil || cfg.AdminSMTPPassword != "geheim" {
+		t.Errorf("reload: password %q, %v", cfg.AdminSMTPPassword, err)
+	}
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// SchemaVersion is the version of the config format (field version); sconfig writes it into the file.
+// Files with an older version are upgraded by the migrations on load.
+const SchemaVersion = 1
+
+// migration upgrades the raw JSON object of a config file to version and returns the changes made.
+type migration struct {
+	version int
+	apply   func(m map[string]json.RawMessage) ([]string, error)
+}
+
+// migrations in ascending order; renamed or restructured keys get a new entry with the next version.
+var migrations = []migration{
+	{1, migrateV1},
+}
+
+// migrate applies all migrations newer than the version of the file at path. If anything changed, the
+// original is kept as <path>.v<version>.bak and the upgraded JSON is written back (sconfig then stores it in
+// field order with the new version). Returns the changes for the log; a missing file is no error.
+func migrate(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, nil // sconfig reports the syntax error
+	}
+	var version int
+	if raw, ok := m["version"]; ok {
+		_ = json.Unmarshal(raw, &version)
+	}
+	var changes []string
+	for _, mg := range migrations {
+		if mg.version <= version {
+			continue
+		}
+		c, err := mg.apply(m)
+		if err != nil {
+			return nil, fmt.Errorf(i18n.T("err.config_migrate"), mg.version, err)
+		}
+		changes = append(changes, c...)
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	out, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(fmt.Sprintf("%s.v%d.bak", path, version), data, mode); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, out, mode); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// migrateV1 converts the loose forms of hand-written files of version 0 that the typed config cannot read:
+// databases as object {"name": {...}} becomes a list with "name", a single string in mail_to/mail_cc a list,
+// webhook_headers as object {"Name": "Value"} a list of "Name: Value".
+func migrateV1(m map[string]json.RawMessage) ([]string, error) {
+	var changes []string
+	if raw, ok := m["databases"]; ok {
+		var byName map[string]map[string]json.RawMessage
+		if json.Unmarshal(raw, &byName) == nil {
+			names := make([]string, 0, len(byName))
+			for name := range byName {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			list := make([]map[string]json.RawMessage, 0, len(names))
+			for _, name := range names {
+				d := byName[name]
+				if d == nil {
+					d = make(map[string]json.RawMessage)
+				}
+				d["name"], _ = json.Marshal(name)
+				list = append(list, d)
+			}
+			if err := setJSON(m, "databases", list); err != nil {
+				return nil, err
+			}
+			changes = append(changes, "databases: object -> list")
+		}
+	}
+	for _, key := range []string{"mail_to", "mail_cc"} {
+		var s string
+		if raw, ok := m[key]; ok && json.Unmarshal(raw, &s) == nil {
+			list := []string{}
+			if s != "" {
+				list = append(list, s)
+			}
+			if err := setJSON(m, key, list); err != nil {
+				return nil, err
+			}
+			changes = append(changes, key+": string -> list")
+		}
+	}
+	if raw, ok := m["webhook_headers"]; ok {
+		var headers map[string]string
+		if json.Unmarshal(raw, &headers) == nil {
+			list := []string{}
+			for name, value := range headers {
+				list = append(list, name+": "+value)
+			}
+			sort.Strings(list)
+			if err := setJSON(m, "webhook_headers", list); err != nil {
+				return nil, err
+			}
+			changes = append(changes, "webhook_headers: object -> list")
+		}
+	}
+	return changes, nil
+}
+
+func setJSON(m map[string]json.RawMessage, key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	m[key] = raw
+	return nil
+}
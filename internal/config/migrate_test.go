@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateV1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	old := `{"version": 0, "mail_to": "ops@example.com", "databases": {"shop": {"retain_daily": 30}},
+		"webhook_headers": {"Authorization": "Bearer x"}}`
+	if err := os.WriteFile(path, []byte(old), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := migrate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 {
+		t.Errorf("changes = %q", changes)
+	}
+	if bak, err := os.ReadFile(path + ".v0.bak"); err != nil || string(bak) != old {
+		t.Errorf("backup = %q, %v", bak, err)
+	}
+	cfg, err := Load(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != SchemaVersion || len(cfg.MailTo) != 1 || cfg.WebhookHeaders[0] != "Authorization: Bearer x" {
+		t.Errorf("cfg = version %d, mail_to %q, headers %q", cfg.Version, cfg.MailTo, cfg.WebhookHeaders)
+	}
+	if d := cfg.Database("shop"); d == nil || d.RetainDaily == nil || *d.RetainDaily != 30 {
+		t.Errorf("databases = %+v", cfg.Databases)
+	}
+	if changes, err := migrate(path); err != nil || len(changes) != 0 {
+		t.Errorf("second migrate = %q, %v", changes, err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"version": 1`) {
+		t.Errorf("version not written:\n%s", data)
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// PolicyFile is the file next to the config file that holds the policy an agent received from its controller.
+const PolicyFile = "controller_policy.json"
+
+// agentNameRe matches valid agent names (agent_name, part of the controller URLs and file names).
+var agentNameRe = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// loadPolicy applies the controller policy file (see PolicyFile) on top of c when controller_url is set, after
+// include and before servers, so the servers inherit the policy. The path is watched even while the file does
+// not exist yet, so the daemon picks up the first policy.
+func (c *Config) loadPolicy(base string, clean, debug bool) error {
+	c.policy = ""
+	if c.ControllerURL == "" {
+		return nil
+	}
+	p := filepath.Join(filepath.Dir(base), PolicyFile)
+	c.policy = p
+	c.includes = append(c.includes, p)
+	if _, err := os.Stat(p); os.IsNotExist(err) {
+		return nil
+	}
+	if err := c.applyFragment(p, clean, debug); err != nil {
+		return fmt.Errorf(i18n.T("err.config_controller_policy"), p, err)
+	}
+	return nil
+}
+
+// PolicyPath returns the path of the controller policy file; "" without controller_url.
+func (c *Config) PolicyPath() string {
+	return c.policy
+}
+
+// PolicyKey reports whether a controller may set key in the policy of an agent. Not allowed are keys that run
+// commands or select programs and images (the controller must not be able to execute code on the agent), secrets,
+// the signing keys (which backup signatures an agent trusts), remote_kms_key (who can decrypt the uploads), the
+// access to the databases (also galera_*), local paths and the OS scheduler, and the keys of include, servers, API
+// and controller themselves.
+func PolicyKey(key string) bool {
+	switch key {
+	case "version", "include", "servers", "databases", "agent_name", "verify_docker_image", "translations_dir",
+		"backup_dir", "archive_dir", "work_dir", "log_filename", "metrics_file", "audit_file", "physical_backup_dir",
+		"schedule_scope", "schedule_user", "remote_kms_key", "file_backups":
+		return false
+	}
+	for _, p := range []string{"controller_", "api_", "mysql_", "root_", "windows_task_", "signing_", "galera_"} {
+		if strings.HasPrefix(key, p) {
+			return false
+		}
+	}
+	for _, s := range []string{"_cmd", "_command", "_password", "_password_file", "_key_file"} {
+		if strings.HasSuffix(key, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// AgentID returns the name this config reports under to the controller: agent_name, without it the hostname
+// (without domain); server configs append "-<server>".
+func (c *Config) AgentID() string {
+	name := c.AgentName
+	if name == "" {
+		h, _ := os.Hostname()
+		h, _, _ = strings.Cut(h, ".")
+		name = strings.Map(func(r rune) rune {
+			if agentNameRe.MatchString(string(r)) {
+				return r
+			}
+			return '-'
+		}, h)
+		if name == "" {
+			name = "agent"
+		}
+	}
+	if c.server != "" {
+		name += "-" + c.server
+	}
+	return name
+}
+
+// ValidAgentName reports whether name is a valid agent name (letters, digits, ".", "_" and "-").
+func ValidAgentName(name string) bool {
+	return agentNameRe.MatchString(name) && name != "." && name != ".."
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/keyring"
+)
+
+// secretField is a password field (JSON key and pointer) that may hold a secret reference,
+// with its optional *_password_file field.
+type secretField struct {
+	key   string
+	value *string
+	file  *string
+}
+
+func (c *Config) secretFields() []secretField {
+	return []secretField{
+		{"root_password", &c.RootPassword, &c.RootPasswordFile},
+		{"admin_smtp_password", &c.AdminSMTPPassword, &c.AdminSMTPPasswordFile},
+		{"remote_ssh_password", &c.RemoteSSHPassword, &c.RemoteSSHPasswordFile},
+		{"remote_aes_password", &c.RemoteAESPassword, &c.RemoteAESPasswordFile},
+		{"windows_task_password", &c.WindowsTaskPassword, nil},
+		{"telegram_bot_password", &c.TelegramBotPassword, nil},
+		{"api_password", &c.APIPassword, nil},
+		{"controller_password", &c.ControllerPassword, nil},
+		{"verify_mysql_password", &c.VerifyMySQLPassword, nil},
+	}
+}
+
+// resolveSecrets replaces secret references in the password fields by the secret (in memory only;
+// sconfig stores the reference itself encrypted like a password). A set *_password_file (Docker/Kubernetes
+// secret mount) takes precedence and is read like file://. References:
+//
+//	file:///run/secrets/mysql_root   content of the file (relative paths: config directory), trailing newline removed
+//	env://MYSQL_ROOT_PASSWORD        environment variable
+//	vault://secret/data/mysql#root   field of a HashiCorp Vault secret (KV v1/v2) via VAULT_ADDR and VAULT_TOKEN
+//	keyring://remote_aes             secret stored with --keyring-set in the OS credential store (see keyring)
+func (c *Config) resolveSecrets() error {
+	for _, f := range c.secretFields() {
+		ref := *f.value
+		if f.file != nil && strings.TrimSpace(*f.file) != "" {
+			ref = "file://" + strings.TrimSpace(*f.file)
+		}
+		v, err := resolveSecret(ref)
+		if err != nil {
+			return fmt.Errorf(i18n.T("err.config_secret"), f.key, err)
+		}
+		*f.value = v
+	}
+	return nil
+}
+
+// resolveSecret returns the secret for a reference, or v unchanged if it is none.
+func resolveSecret(v string) (string, error) {
+	switch {
+	case strings.HasPrefix(v, "file://"):
+		b, err := os.ReadFile(filepath.FromSlash(strings.TrimPrefix(v, "file://")))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	case strings.HasPrefix(v, "env://"):
+		name := strings.TrimPrefix(v, "env://")
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf(i18n.T("err.secret_env"), name)
+		}
+		return s, nil
+	case strings.HasPrefix(v, "vault://"):
+		return vaultSecret(strings.TrimPrefix(v, "vault://"))
+	case strings.HasPrefix(v, keyring.Prefix):
+		return keyring.Get(strings.TrimPrefix(v, keyring.Prefix))
+	}
+	return v, nil
+}
+
+// vaultSecret reads field of the secret at path ("path#field") from Vault. KV v2 nests the fields under data.data,
+// KV v1 directly under data. VAULT_NAMESPACE is sent if set.
+func vaultSecret(ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || field == "" {
+		return "", fmt.Errorf(i18n.T("err.secret_vault_ref"), ref)
+	}
+	addr, token := strings.TrimRight(os.Getenv("VAULT_ADDR"), "/"), os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		return "", errors.New(i18n.T("err.secret_vault_env"))
+	}
+	req, err := http.NewRequest(http.MethodGet, addr+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf(i18n.T("err.secret_vault_status"), path, resp.Status)
+	}
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	data := body.Data
+	if raw, ok := data["data"]; ok {
+		var v2 map[string]json.RawMessage
+		if json.Unmarshal(raw, &v2) == nil {
+			if _, isMeta := data["metadata"]; isMeta {
+				data = v2
+			}
+		}
+	}
+	var s string
+	if raw, ok := data[field]; !ok || json.Unmarshal(raw, &s) != nil {
+		return "", fmt.Errorf(i18n.T("err.secret_vault_field"), field, path)
+	}
+	return s, nil
+}
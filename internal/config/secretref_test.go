@@ -0,0 +1,85 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveSecret(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_SECRET", "from-env")
+	for in, want := range map[string]string{
+		"plain":             "plain",
+		"":                  "",
+		"file://" + file:    "from-file",
+		"env://TEST_SECRET": "from-env",
+	} {
+		got, err := resolveSecret(in)
+		if err != nil || got != want {
+			t.Errorf("resolveSecret(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := resolveSecret("env://TEST_SECRET_MISSING"); err == nil {
+		t.Error("missing environment variable: no error")
+	}
+	if _, err := resolveSecret("keyring://"); err == nil {
+		t.Error("keyring reference without name: no error")
+	}
+}
+
+func TestPasswordFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "mysql_root")
+	if err := os.WriteFile(file, []byte("mounted\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := DefaultConfig()
+	c.RootPassword = "from-json"
+	c.RootPasswordFile = file
+	c.AdminSMTPPassword = "smtp"
+	if err := c.resolveSecrets(); err != nil {
+		t.Fatal(err)
+	}
+	if c.RootPassword != "mounted" || c.AdminSMTPPassword != "smtp" {
+		t.Errorf("passwords = %q, %q", c.RootPassword, c.AdminSMTPPassword)
+	}
+	c.RemoteAESPasswordFile = file + ".missing"
+	if err := c.resolveSecrets(); err == nil {
+		t.Error("missing password file: no error")
+	}
+}
+
+func TestVaultSecret(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "tok" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/mysql": // KV v2
+			_, _ = w.Write([]byte(`{"data":{"data":{"root":"v2pw"},"metadata":{"version":3}}}`))
+		case "/v1/kv/mysql": // KV v1
+			_, _ = w.Write([]byte(`{"data":{"root":"v1pw"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "tok")
+	for in, want := range map[string]string{"vault://secret/data/mysql#root": "v2pw", "vault://kv/mysql#root": "v1pw"} {
+		if got, err := resolveSecret(in); err != nil || got != want {
+			t.Errorf("resolveSecret(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"vault://secret/data/mysql#other", "vault://missing#root", "vault://secret/data/mysql"} {
+		if _, err := resolveSecret(in); err == nil {
+			t.Errorf("resolveSecret(%q): no error", in)
+		}
+	}
+}
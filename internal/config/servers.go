@@ -0,0 +1,88 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// loadServers builds one config per file of servers: a copy of c (with its include files) with the fragment
+// applied on top, named after the file without extension. backup_dir, archive_dir, remote_backup_dir and
+// remote_archive_dir the fragment does not set get the name as subdirectory, so the servers never share a
+// backup_dir (run lock, state, catalog).
+func (c *Config) loadServers(base string, clean, debug bool) error {
+	c.servers = nil
+	seen := make(map[string]bool)
+	for _, entry := range c.Servers {
+		p := fragmentPath(base, entry)
+		if p == "" {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))
+		if seen[name] {
+			return fmt.Errorf(i18n.T("err.config_server_duplicate"), name)
+		}
+		seen[name] = true
+		s, err := c.clone()
+		if err != nil {
+			return err
+		}
+		s.Include, s.Servers, s.server = nil, nil, name
+		if err := s.applyFragment(p, clean, debug); err != nil {
+			return fmt.Errorf(i18n.T("err.config_server"), name, err)
+		}
+		subdir := func(v *string, parent string, join func(...string) string) {
+			if *v == parent && parent != "" {
+				*v = join(parent, name)
+			}
+		}
+		subdir(&s.BackupDir, c.BackupDir, filepath.Join)
+		subdir(&s.ArchiveDir, c.ArchiveDir, filepath.Join)
+		subdir(&s.RemoteBackupDir, c.RemoteBackupDir, path.Join) // SFTP: immer "/"
+		subdir(&s.RemoteArchiveDir, c.RemoteArchiveDir, path.Join)
+		c.migrated = append(c.migrated, s.migrated...)
+		s.migrated = nil
+		c.servers = append(c.servers, s)
+		c.includes = append(c.includes, p)
+	}
+	return nil
+}
+
+// clone returns a deep copy of the settings of c (JSON round trip, so lists are not shared).
+func (c *Config) clone() (*Config, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	n := &Config{}
+	if err := json.Unmarshal(data, n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// ServerConfigs returns the configs of servers in the order of the list; empty without servers.
+func (c *Config) ServerConfigs() []*Config {
+	return c.servers
+}
+
+// Server returns the config of the server name (see servers).
+func (c *Config) Server(name string) (*Config, error) {
+	names := make([]string, 0, len(c.servers))
+	for _, s := range c.servers {
+		if s.server == name {
+			return s, nil
+		}
+		names = append(names, s.server)
+	}
+	return nil, fmt.Errorf(i18n.T("err.config_server_unknown"), name, strings.Join(names, ", "))
+}
+
+// ServerName returns the name of this server config, "" for the main config.
+func (c *Config) ServerName() string {
+	return c.server
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServers(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	backups := filepath.Join(dir, "backups")
+	write("db1.json", `{"mysql_host": "db1.example.com", "root_password": "eins", "mail_to": ["team1@example.com"]}`)
+	write("db2.json", `{"mysql_host": "db2.example.com", "backup_dir": "`+filepath.ToSlash(filepath.Join(dir, "db2"))+`", "schedule": "0 3 * * *"}`)
+	path := write("config.json", `{"backup_dir": "`+filepath.ToSlash(backups)+`", "remote_backup_dir": "/srv/backup",
+		"mail_to": ["ops@example.com"], "servers": ["db1.json", "servers/../db2.json"]}`)
+	cfg, err := Load(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	servers := cfg.ServerConfigs()
+	if len(servers) != 2 || len(cfg.Includes()) != 2 {
+		t.Fatalf("servers = %d, includes = %q", len(servers), cfg.Includes())
+	}
+	db1, db2 := servers[0], servers[1]
+	if db1.ServerName() != "db1" || db1.MySQLHost != "db1.example.com" || db1.RootPassword != "eins" ||
+		db1.BackupDir != filepath.Join(backups, "db1") || db1.RemoteBackupDir != "/srv/backup/db1" ||
+		len(db1.MailTo) != 1 || db1.MailTo[0] != "team1@example.com" {
+		t.Errorf("db1 = %q %q %q %q %q", db1.ServerName(), db1.MySQLHost, db1.BackupDir, db1.RemoteBackupDir, db1.MailTo)
+	}
+	if db2.BackupDir != filepath.Join(dir, "db2") || db2.Schedule != "0 3 * * *" || db2.MailTo[0] != "ops@example.com" {
+		t.Errorf("db2 = %q %q %q", db2.BackupDir, db2.Schedule, db2.MailTo)
+	}
+	if cfg.MailTo[0] != "ops@example.com" || cfg.BackupDir != backups || cfg.ServerName() != "" {
+		t.Errorf("main config changed: %q %q", cfg.MailTo, cfg.BackupDir)
+	}
+	if s, err := cfg.Server("db2"); err != nil || s != db2 {
+		t.Errorf("Server(db2) = %v, %v", s, err)
+	}
+	if _, err := cfg.Server("db3"); err == nil {
+		t.Error("Server(db3): no error")
+	}
+	write("config.json", `{"backup_dir": "`+filepath.ToSlash(backups)+`", "servers": ["db1.json", "db1.json"]}`)
+	if _, err := Load(path, false); err == nil {
+		t.Error("duplicate server name: no error")
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// placeholderRe matches the placeholders of Expand: {hostname}, {date}, {db}, {env} and {env:NAME}.
+var placeholderRe = regexp.MustCompile(`\{(hostname|date|db|env(?::[A-Za-z_][A-Za-z0-9_]*)?)\}`)
+
+// pathTemplates holds backup_dir, log_filename and remote_backup_dir as written in the config (before Expand),
+// so ExpandPaths can determine {date} again for each run (--daemon).
+type pathTemplates struct {
+	backupDir, logFilename, remoteBackupDir string
+}
+
+// Expand replaces the placeholders in s: {hostname} (name of this machine, without domain), {date} (date of now as
+// YYYY-MM-DD), {db} (database name; kept if db is empty), {env:NAME} (environment variable NAME) and {env}
+// (MYSQLBACKUP_ENV, e.g. "prod"). Unknown placeholders are kept unchanged.
+func Expand(s string, now time.Time, db string) string {
+	if !strings.Contains(s, "{") {
+		return s
+	}
+	return placeholderRe.ReplaceAllStringFunc(s, func(m string) string {
+		name := m[1 : len(m)-1]
+		switch {
+		case name == "hostname":
+			h, _ := os.Hostname()
+			h, _, _ = strings.Cut(h, ".")
+			return h
+		case name == "date":
+			return now.Format("2006-01-02")
+		case name == "db":
+			if db == "" {
+				return m
+			}
+			return db
+		case name == "env":
+			return os.Getenv("MYSQLBACKUP_ENV")
+		default:
+			return os.Getenv(strings.TrimPrefix(name, "env:"))
+		}
+	})
+}
+
+// ExpandPaths sets backup_dir, log_filename and remote_backup_dir from their config values with the placeholders
+// expanded for now (see Expand). Load calls it with the load time; --daemon calls it again before each run.
+func (c *Config) ExpandPaths(now time.Time) {
+	if c.paths == nil {
+		c.paths = &pathTemplates{c.BackupDir, c.LogFilename, c.RemoteBackupDir}
+	}
+	c.BackupDir = filepath.FromSlash(filepath.Clean(Expand(c.paths.backupDir, now, "")))
+	c.LogFilename = filepath.FromSlash(filepath.Clean(Expand(c.paths.logFilename, now, "")))
+	c.RemoteBackupDir = filepath.FromSlash(filepath.Clean(Expand(c.paths.remoteBackupDir, now, "")))
+}
+
+// PathTemplates returns backup_dir and log_filename as written in the config, with their placeholders.
+func (c *Config) PathTemplates() (backupDir, logFilename string) {
+	if c.paths == nil {
+		return c.BackupDir, c.LogFilename
+	}
+	return c.paths.backupDir, c.paths.logFilename
+}
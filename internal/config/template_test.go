@@ -0,0 +1,26 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpand(t *testing.T) {
+	t.Setenv("MYSQLBACKUP_ENV", "prod")
+	t.Setenv("SITE", "fra1")
+	host, _ := os.Hostname()
+	host, _, _ = strings.Cut(host, ".")
+	now := time.Date(2025, 6, 12, 22, 0, 0, 0, time.UTC)
+	for _, c := range []struct{ in, db, want string }{
+		{"/backups/{hostname}", "", "/backups/" + host},
+		{"/b/{env}/{env:SITE}/{date}", "", "/b/prod/fra1/2025-06-12"},
+		{"/b/{db}", "", "/b/{db}"},
+		{"dump {db} {unknown}", "shop", "dump shop {unknown}"},
+	} {
+		if got := Expand(c.in, now, c.db); got != c.want {
+			t.Errorf("Expand(%q, %q) = %q, want %q", c.in, c.db, got, c.want)
+		}
+	}
+}
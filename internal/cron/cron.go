@@ -0,0 +1,305 @@
+// Package cron parses standard 5-field cron expressions (minute hour day-of-month month day-of-week)
+// and translates them for the schedulers (cron, systemd OnCalendar, Windows triggers) and the daemon.
+package cron
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// Spec is a parsed cron expression. Each field holds the allowed values (sorted).
+type Spec struct {
+	Expr string // normalized expression (5 fields, single spaces; macros expanded)
+
+	Minute []int // 0-59
+	Hour   []int // 0-23
+	Dom    []int // 1-31
+	Month  []int // 1-12
+	Dow    []int // 0-6, 0 = Sunday
+
+	domStar, dowStar bool
+}
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+var dowNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Parse parses a cron expression: lists (1,15), ranges (1-5), steps (*/15, 0-30/10), names for
+// months (jan-dec) and weekdays (sun-sat, 7 = Sunday) and the macros @daily, @weekly, @monthly, @yearly, @hourly.
+func Parse(expr string) (*Spec, error) {
+	e := strings.ToLower(strings.TrimSpace(expr))
+	if m, ok := macros[e]; ok {
+		e = m
+	}
+	fields := strings.Fields(e)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf(i18n.T("err.cron_fields"), expr, len(fields))
+	}
+	s := &Spec{Expr: strings.Join(fields, " ")}
+	var err error
+	if s.Minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf(i18n.T("err.cron_field"), expr, "minute", err)
+	}
+	if s.Hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf(i18n.T("err.cron_field"), expr, "hour", err)
+	}
+	if s.Dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf(i18n.T("err.cron_field"), expr, "day of month", err)
+	}
+	if s.Month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf(i18n.T("err.cron_field"), expr, "month", err)
+	}
+	if s.Dow, err = parseField(fields[4], 0, 7, dowNames); err != nil {
+		return nil, fmt.Errorf(i18n.T("err.cron_field"), expr, "weekday", err)
+	}
+	// 7 = Sonntag wie 0
+	if n := len(s.Dow); n > 0 && s.Dow[n-1] == 7 {
+		s.Dow = uniqueSorted(append([]int{0}, s.Dow[:n-1]...))
+	}
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+	return s, nil
+}
+
+// Daily returns the spec for a daily run at hour:minute (start_time).
+func Daily(hour, minute int) *Spec {
+	s, _ := Parse(fmt.Sprintf("%d %d * * *", minute, hour))
+	return s
+}
+
+func parseField(f string, min, max int, names []string) ([]int, error) {
+	set := make(map[int]bool)
+	for _, part := range strings.Split(f, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf(i18n.T("err.cron_step"), part)
+			}
+			step = n
+			part = part[:i]
+		}
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			a, b, _ := strings.Cut(part, "-")
+			var err error
+			if lo, err = value(a, min, max, names); err != nil {
+				return nil, err
+			}
+			if hi, err = value(b, min, max, names); err != nil {
+				return nil, err
+			}
+			if lo > hi {
+				return nil, fmt.Errorf(i18n.T("err.cron_range"), part)
+			}
+		default:
+			v, err := value(part, min, max, names)
+			if err != nil {
+				return nil, err
+			}
+			lo = v
+			if step == 1 {
+				hi = v
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	var list []int
+	for v := range set {
+		list = append(list, v)
+	}
+	return uniqueSorted(list), nil
+}
+
+func value(s string, min, max int, names []string) (int, error) {
+	for i, n := range names {
+		if s == n {
+			return i + min, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf(i18n.T("err.cron_value"), s, min, max)
+	}
+	return v, nil
+}
+
+func uniqueSorted(list []int) []int {
+	sort.Ints(list)
+	out := list[:0]
+	for i, v := range list {
+		if i == 0 || v != list[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func contains(list []int, v int) bool {
+	i := sort.SearchInts(list, v)
+	return i < len(list) && list[i] == v
+}
+
+// matchDay applies the cron rule: if both day fields are restricted, either may match.
+func (s *Spec) matchDay(t time.Time) bool {
+	dom := contains(s.Dom, t.Day())
+	dow := contains(s.Dow, int(t.Weekday()))
+	if !s.domStar && !s.dowStar {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// Next returns the first time after t (at minute precision, in t's location) that matches the spec.
+// Returns the zero time if there is none within five years (e.g. 31 February).
+func (s *Spec) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !contains(s.Month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !contains(s.Hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !contains(s.Minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// IsDaily reports whether the spec runs once a day at a fixed time (start_time style).
+func (s *Spec) IsDaily() bool {
+	return len(s.Minute) == 1 && len(s.Hour) == 1 && s.domStar && s.dowStar && len(s.Month) == 12
+}
+
+// OnCalendar returns systemd OnCalendar= values. Since systemd combines weekday and day of month
+// with AND while cron uses OR, two values are returned when both are restricted.
+func (s *Spec) OnCalendar() []string {
+	dowSD := []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+	join := func(list []int, all int, width int) string {
+		if len(list) == all {
+			return "*"
+		}
+		parts := make([]string, len(list))
+		for i, v := range list {
+			parts[i] = fmt.Sprintf("%0*d", width, v)
+		}
+		return strings.Join(parts, ",")
+	}
+	month := join(s.Month, 12, 2)
+	clock := join(s.Hour, 24, 2) + ":" + join(s.Minute, 60, 2) + ":00"
+	var days []string
+	for _, d := range s.Dow {
+		days = append(days, dowSD[d])
+	}
+	weekdays := strings.Join(days, ",") + " "
+	switch {
+	case s.domStar && s.dowStar:
+		return []string{"*-" + month + "-* " + clock}
+	case s.domStar:
+		return []string{weekdays + "*-" + month + "-* " + clock}
+	case s.dowStar:
+		return []string{"*-" + month + "-" + join(s.Dom, 31, 2) + " " + clock}
+	default:
+		return []string{
+			weekdays + "*-" + month + "-* " + clock,
+			"*-" + month + "-" + join(s.Dom, 31, 2) + " " + clock,
+		}
+	}
+}
+
+// WindowsTriggers returns PowerShell New-ScheduledTaskTrigger expressions (one per run time of day).
+// Day-of-month and month restrictions cannot be expressed this way and return an error, as do
+// more than maxTriggers run times per day.
+func (s *Spec) WindowsTriggers(maxTriggers int) ([]string, error) {
+	if !s.domStar || len(s.Month) != 12 {
+		return nil, fmt.Errorf(i18n.T("err.cron_windows_days"), s.Expr)
+	}
+	if n := len(s.Hour) * len(s.Minute); n > maxTriggers {
+		return nil, fmt.Errorf(i18n.T("err.cron_windows_count"), s.Expr, n, maxTriggers)
+	}
+	winDays := []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+	var days []string
+	for _, d := range s.Dow {
+		days = append(days, winDays[d])
+	}
+	var triggers []string
+	for _, h := range s.Hour {
+		for _, m := range s.Minute {
+			at := fmt.Sprintf("%02d:%02d", h, m)
+			if s.dowStar {
+				triggers = append(triggers, "New-ScheduledTaskTrigger -Daily -At '"+at+"'")
+			} else {
+				triggers = append(triggers, "New-ScheduledTaskTrigger -Weekly -DaysOfWeek "+strings.Join(days, ",")+" -At '"+at+"'")
+			}
+		}
+	}
+	return triggers, nil
+}
+
+// CalendarIntervals returns launchd StartCalendarInterval entries (keys Minute, Hour, Day, Month,
+// Weekday; a missing key matches every value). Weekday and day of month are OR-ed as in cron by
+// emitting separate entries. More than maxEntries entries return an error.
+func (s *Spec) CalendarIntervals(maxEntries int) ([]map[string]int, error) {
+	entries := []map[string]int{{}}
+	expand := func(in []map[string]int, key string, list []int, all int) []map[string]int {
+		if len(list) == all {
+			return in
+		}
+		var out []map[string]int
+		for _, e := range in {
+			for _, v := range list {
+				n := map[string]int{key: v}
+				for k, x := range e {
+					n[k] = x
+				}
+				out = append(out, n)
+			}
+		}
+		return out
+	}
+	entries = expand(entries, "Minute", s.Minute, 60)
+	entries = expand(entries, "Hour", s.Hour, 24)
+	entries = expand(entries, "Month", s.Month, 12)
+	switch {
+	case s.domStar && s.dowStar:
+	case s.domStar:
+		entries = expand(entries, "Weekday", s.Dow, 0)
+	case s.dowStar:
+		entries = expand(entries, "Day", s.Dom, 0)
+	default:
+		entries = append(expand(entries, "Weekday", s.Dow, 0), expand(entries, "Day", s.Dom, 0)...)
+	}
+	if len(entries) > maxEntries {
+		return nil, fmt.Errorf(i18n.T("err.cron_launchd_count"), s.Expr, len(entries), maxEntries)
+	}
+	return entries, nil
+}
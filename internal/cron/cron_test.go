@@ -0,0 +1,80 @@
+package cron
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseFields(t *testing.T) {
+	s, err := Parse("0,30 3 * * mon-fri")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s.Minute, []int{0, 30}) || !reflect.DeepEqual(s.Hour, []int{3}) || !reflect.DeepEqual(s.Dow, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("Parse: minute=%v hour=%v dow=%v", s.Minute, s.Hour, s.Dow)
+	}
+	if s, _ := Parse("*/20 0 1 1 7"); !reflect.DeepEqual(s.Minute, []int{0, 20, 40}) || !reflect.DeepEqual(s.Dow, []int{0}) {
+		t.Errorf("steps/sunday: minute=%v dow=%v", s.Minute, s.Dow)
+	}
+	for _, bad := range []string{"", "0 3 * *", "60 3 * * *", "0 3 * * 1-9", "0 3 5-1 * *", "0 3 * * */0"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q): expected error", bad)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	loc := time.UTC
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		// Friday 2026-10-16 04:00 -> next weekday 03:00 is Monday
+		{"0 3 * * 1-5", time.Date(2026, 10, 16, 4, 0, 0, 0, loc), time.Date(2026, 10, 19, 3, 0, 0, 0, loc)},
+		{"@daily", time.Date(2026, 10, 16, 23, 59, 30, 0, loc), time.Date(2026, 10, 17, 0, 0, 0, 0, loc)},
+		{"30 22 * * *", time.Date(2026, 10, 16, 22, 30, 0, 0, loc), time.Date(2026, 10, 17, 22, 30, 0, 0, loc)},
+		// both day fields restricted: the 1st OR a Sunday
+		{"0 0 1 * 0", time.Date(2026, 10, 16, 0, 0, 0, 0, loc), time.Date(2026, 10, 18, 0, 0, 0, 0, loc)},
+		{"0 0 31 2 *", time.Date(2026, 10, 16, 0, 0, 0, 0, loc), time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q, %v) = %v, want %v", tt.expr, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestTranslations(t *testing.T) {
+	s, _ := Parse("0 3 * * 1-5")
+	if got := s.OnCalendar(); !reflect.DeepEqual(got, []string{"Mon,Tue,Wed,Thu,Fri *-*-* 03:00:00"}) {
+		t.Errorf("OnCalendar = %v", got)
+	}
+	tr, err := s.WindowsTriggers(48)
+	if err != nil || len(tr) != 1 || tr[0] != "New-ScheduledTaskTrigger -Weekly -DaysOfWeek Monday,Tuesday,Wednesday,Thursday,Friday -At '03:00'" {
+		t.Errorf("WindowsTriggers = %v, %v", tr, err)
+	}
+	if _, err := Daily(22, 0).WindowsTriggers(48); err != nil {
+		t.Errorf("daily: %v", err)
+	}
+	ci, err := s.CalendarIntervals(64)
+	if err != nil || len(ci) != 5 || !reflect.DeepEqual(ci[0], map[string]int{"Minute": 0, "Hour": 3, "Weekday": 1}) {
+		t.Errorf("CalendarIntervals = %v, %v", ci, err)
+	}
+	s, _ = Parse("0 0 1 * *")
+	if _, err := s.WindowsTriggers(48); err == nil {
+		t.Error("monthly schedule: expected error for Windows")
+	}
+	if ci, _ := s.CalendarIntervals(64); len(ci) != 1 || !reflect.DeepEqual(ci[0], map[string]int{"Minute": 0, "Hour": 0, "Day": 1}) {
+		t.Errorf("monthly CalendarIntervals = %v", ci)
+	}
+	s, _ = Parse("*/5 * * * *")
+	if _, err := s.CalendarIntervals(64); err != nil {
+		t.Errorf("every 5 minutes: %v", err)
+	}
+}
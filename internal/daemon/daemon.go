@@ -0,0 +1,175 @@
+// Package daemon runs backups in the foreground on the configured schedule (--daemon, e.g. as a systemd
+// service or container entrypoint) and reloads the config file when it changes. With servers every server
+// runs on its own schedule, one run at a time.
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/logger"
+)
+
+// DefaultPoll is the interval of the config file check.
+const DefaultPoll = 10 * time.Second
+
+// Options configures Serve.
+type Options struct {
+	Path   string                         // config file, watched for changes (with its include files)
+	Load   func() (*config.Config, error) // loads and validates the config file
+	Backup func(cfg *config.Config)       // one backup run (logs its result); cfg is a server config with servers
+	Reload func(cfg *config.Config)       // optional: applies a reloaded config (e.g. log levels)
+	Run    <-chan string                  // optional: each value starts a run of this server ("" = all) at once (API)
+	Poll   time.Duration                  // interval of the change check (0 = DefaultPoll)
+}
+
+// job is the next scheduled run of one server (the config itself without servers).
+type job struct {
+	cfg  *config.Config
+	next time.Time // zero = no schedule
+}
+
+// Serve runs opt.Backup at every time of the schedule of cfg (with servers: of each server) and for every value of opt.Run until stop
+// is closed. When the config file
+// or one of its include files changes it is reloaded: an invalid file is logged and ignored (the previous config stays active), otherwise
+// the changed settings (without passwords) are logged and the next run is planned with the new schedule.
+func Serve(cfg *config.Config, log *logger.Logger, opt Options, stop <-chan struct{}) {
+	poll := opt.Poll
+	if poll <= 0 {
+		poll = DefaultPoll
+	}
+	stamp := fileStamp(opt.Path, cfg.Includes()...)
+	jobs := plan(cfg, log, time.Now())
+	next := earliest(jobs)
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	timer := time.NewTimer(until(next))
+	defer timer.Stop()
+	for {
+		select {
+		case <-stop:
+			log.Info(i18n.T("log.msg.daemon_stop"))
+			return
+		case <-ticker.C:
+			if fileStamp(opt.Path, cfg.Includes()...) == stamp {
+				continue
+			}
+			n, err := opt.Load()
+			if err != nil {
+				stamp = fileStamp(opt.Path, cfg.Includes()...)
+				log.Warn(i18n.Tf("log.warn.config_reload", err))
+				continue
+			}
+			stamp = fileStamp(opt.Path, n.Includes()...) // Load may write the files back (encrypted passwords)
+			changes := cfg.Diff(n)
+			for _, c := range changes {
+				log.Info(i18n.Tf("log.msg.config_changed", c))
+			}
+			if len(changes) == 0 {
+				continue
+			}
+			cfg = n
+			if opt.Reload != nil {
+				opt.Reload(cfg)
+			}
+			jobs = plan(cfg, log, time.Now())
+			if p := earliest(jobs); !p.Equal(next) {
+				next = p
+				resetTimer(timer, until(next))
+			}
+		case <-timer.C:
+			now := time.Now()
+			for _, j := range jobs {
+				if !j.next.IsZero() && !j.next.After(now) {
+					opt.Backup(j.cfg)
+				}
+			}
+			jobs = plan(cfg, log, time.Now())
+			next = earliest(jobs)
+			timer.Reset(until(next))
+		case name := <-opt.Run:
+			for _, j := range jobs {
+				if name == "" || j.cfg.ServerName() == name {
+					opt.Backup(j.cfg)
+				}
+			}
+			jobs = plan(cfg, log, time.Now())
+			next = earliest(jobs)
+			resetTimer(timer, until(next))
+		}
+	}
+}
+
+// plan returns the next scheduled run after now of cfg, with servers of each server, and logs them; the zero
+// time for a schedule without any.
+func plan(cfg *config.Config, log *logger.Logger, now time.Time) []job {
+	configs := cfg.ServerConfigs()
+	if len(configs) == 0 {
+		configs = []*config.Config{cfg}
+	}
+	jobs := make([]job, 0, len(configs))
+	for _, c := range configs {
+		j := job{cfg: c}
+		if spec, err := c.ScheduleSpec(); err == nil {
+			j.next = spec.Next(now)
+		}
+		switch {
+		case j.next.IsZero():
+		case c.ServerName() != "":
+			log.Info(i18n.Tf("log.msg.daemon_next_server", c.ServerName(), j.next.Format("2006-01-02 15:04")))
+		default:
+			log.Info(i18n.Tf("log.msg.daemon_next", j.next.Format("2006-01-02 15:04")))
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs
+}
+
+// earliest returns the first next run of jobs, the zero time if none has a schedule.
+func earliest(jobs []job) time.Time {
+	var t time.Time
+	for _, j := range jobs {
+		if !j.next.IsZero() && (t.IsZero() || j.next.Before(t)) {
+			t = j.next
+		}
+	}
+	return t
+}
+
+// until returns the wait time until next; practically forever for the zero time.
+func until(next time.Time) time.Duration {
+	if next.IsZero() {
+		return 100 * 365 * 24 * time.Hour
+	}
+	if d := time.Until(next); d > 0 {
+		return d
+	}
+	return 0
+}
+
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
+
+// fileStamp identifies a version of the config files (modification time and size of each).
+func fileStamp(path string, includes ...string) string {
+	var b strings.Builder
+	for _, p := range append([]string{path}, includes...) {
+		if info, err := os.Stat(p); err == nil {
+			fmt.Fprintf(&b, "%d/%d;", info.ModTime().UnixNano(), info.Size())
+		} else {
+			b.WriteString("-;")
+		}
+	}
+	return b.String()
+}
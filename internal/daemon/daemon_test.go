@@ -0,0 +1,87 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/logger"
+)
+
+func TestServeReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	log, err := logger.New(filepath.Join(dir, "test.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	log.SetLevel(logger.SinkConsole, logger.LevelOff)
+
+	reloaded := make(chan *config.Config, 1)
+	next := config.DefaultConfig()
+	next.RetainDaily = 30
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		Serve(config.DefaultConfig(), log, Options{
+			Path:   path,
+			Load:   func() (*config.Config, error) { return next, nil },
+			Backup: func(*config.Config) { t.Error("unexpected backup run") },
+			Reload: func(c *config.Config) { reloaded <- c },
+			Poll:   10 * time.Millisecond,
+		}, stop)
+		close(done)
+	}()
+	time.Sleep(30 * time.Millisecond)
+	if err := os.WriteFile(path, []byte(`{"retain_daily": 30}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case c := <-reloaded:
+		if c.RetainDaily != 30 {
+			t.Errorf("reloaded retain_daily = %d", c.RetainDaily)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("config change not detected")
+	}
+	close(stop)
+	<-done
+}
+
+func TestPlanServers(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	write("db1.json", `{"mysql_host": "db1"}`)
+	write("db2.json", `{"mysql_host": "db2", "schedule": "0 3 * * *"}`)
+	cfg, err := config.Load(write("config.json", `{"backup_dir": "`+filepath.ToSlash(dir)+`", "start_time": "22:00", "servers": ["db1.json", "db2.json"]}`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log, err := logger.New(filepath.Join(dir, "test.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	log.SetLevel(logger.SinkConsole, logger.LevelOff)
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+	jobs := plan(cfg, log, now)
+	if len(jobs) != 2 || jobs[0].cfg.ServerName() != "db1" || jobs[0].next.Hour() != 22 || jobs[1].next.Hour() != 3 {
+		t.Fatalf("jobs = %+v", jobs)
+	}
+	if e := earliest(jobs); !e.Equal(jobs[0].next) {
+		t.Errorf("earliest = %v, want %v", e, jobs[0].next)
+	}
+}
@@ -13,10 +13,66 @@ var ErrInsufficientSpace = errors.New("insufficient disk space")
 // MinFreeBytes is a reasonable minimum (e.g. 100 MB) to require before starting backup.
 const MinFreeBytes = 100 * 1024 * 1024
 
+// MinFreeInodes is the minimum of free inodes required before starting backup: a run creates a few files per
+// database (ZIP, checksum, temporary dump files), so a volume below this fails with "no space left" although
+// bytes are free.
+const MinFreeInodes = 1000
+
+// ErrNoInodes is returned by Inodes on systems without inode counts (Windows, NetBSD).
+var ErrNoInodes = errors.New("inode count not supported on this system")
+
+// Required returns the free space needed before a backup run: the size of the previous run times factor
+// (safety margin for growing databases), at least MinFreeBytes. factor 0 or no previous run yields MinFreeBytes.
+func Required(previous int64, factor float64) uint64 {
+	need := uint64(float64(previous) * factor)
+	if previous <= 0 || factor <= 0 || need < MinFreeBytes {
+		return MinFreeBytes
+	}
+	return need
+}
+
 // Available returns the number of bytes available for writing in the given path's volume.
 // Uses syscall.Statfs on Unix and GetDiskFreeSpaceEx on Windows.
-// available() is defined in disk_unix.go (build !windows) and disk_windows.go (build windows).
+// available() is defined in disk_unix.go, disk_openbsd.go, disk_netbsd.go and disk_windows.go.
 func Available(path string) (uint64, error) {
+	return available(volumePath(path))
+}
+
+// Inodes returns the free and total number of inodes of the given path's volume (Unix: statfs). Filesystems
+// that allocate inodes dynamically (btrfs, ZFS) may report total 0: no limit to check.
+// inodes() is defined next to available(); Windows and NetBSD return ErrNoInodes.
+func Inodes(path string) (free, total uint64, err error) {
+	return inodes(volumePath(path))
+}
+
+// Stats describes the volume of a path (--status): bytes and, where the system reports them, inodes.
+type Stats struct {
+	Total      uint64 // bytes
+	Free       uint64 // bytes available for writing (see Available)
+	Inodes     uint64 // 0 = no inode counts (Windows, NetBSD, btrfs/ZFS)
+	InodesFree uint64
+}
+
+// Stat returns size, free space and inodes of the given path's volume.
+// total() is defined next to available().
+func Stat(path string) (Stats, error) {
+	path = volumePath(path)
+	var s Stats
+	var err error
+	if s.Free, err = available(path); err != nil {
+		return s, err
+	}
+	if s.Total, err = total(path); err != nil {
+		return s, err
+	}
+	if s.InodesFree, s.Inodes, err = inodes(path); err != nil && !errors.Is(err, ErrNoInodes) {
+		return s, err
+	}
+	return s, nil
+}
+
+// volumePath returns the absolute directory of path for the statfs calls.
+func volumePath(path string) string {
 	path = filepath.FromSlash(path)
 	abs, err := filepath.Abs(path)
 	if err != nil {
@@ -26,5 +82,5 @@ func Available(path string) (uint64, error) {
 	if info, err := os.Stat(abs); err == nil && !info.IsDir() {
 		abs = filepath.Dir(abs)
 	}
-	return available(abs)
+	return abs
 }
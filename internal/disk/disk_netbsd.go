@@ -0,0 +1,20 @@
+//go:build netbsd
+
+package disk
+
+import (
+	"errors"
+)
+
+// NetBSD has no statfs in package syscall (statvfs only); the caller logs the error and continues.
+func available(path string) (uint64, error) {
+	return 0, errors.New("free disk space check not supported on netbsd")
+}
+
+func inodes(path string) (free, total uint64, err error) {
+	return 0, 0, ErrNoInodes
+}
+
+func total(path string) (uint64, error) {
+	return 0, errors.New("disk size not supported on netbsd")
+}
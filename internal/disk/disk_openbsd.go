@@ -0,0 +1,31 @@
+//go:build openbsd
+
+package disk
+
+import (
+	"syscall"
+)
+
+func available(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.F_bavail) * uint64(stat.F_bsize), nil
+}
+
+func inodes(path string) (free, total uint64, err error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	return uint64(stat.F_ffree), uint64(stat.F_files), nil
+}
+
+func total(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.F_blocks) * uint64(stat.F_bsize), nil
+}
@@ -0,0 +1,48 @@
+package disk
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRequired(t *testing.T) {
+	const gb = 1 << 30
+	tests := []struct {
+		previous int64
+		factor   float64
+		want     uint64
+	}{
+		{0, 1.5, MinFreeBytes},
+		{10 * 1024 * 1024, 1.5, MinFreeBytes},
+		{2 * gb, 1.5, 3 * gb},
+		{2 * gb, 0, MinFreeBytes},
+	}
+	for _, tt := range tests {
+		if got := Required(tt.previous, tt.factor); got != tt.want {
+			t.Errorf("Required(%d, %v) = %d, want %d", tt.previous, tt.factor, got, tt.want)
+		}
+	}
+}
+
+func TestInodes(t *testing.T) {
+	free, total, err := Inodes(t.TempDir())
+	if errors.Is(err, ErrNoInodes) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if free > total {
+		t.Errorf("Inodes = %d free of %d", free, total)
+	}
+}
+
+func TestStat(t *testing.T) {
+	s, err := Stat(t.TempDir())
+	if err != nil {
+		t.Skip(err) // z. B. NetBSD
+	}
+	if s.Total == 0 || s.Free > s.Total || s.InodesFree > s.Inodes {
+		t.Errorf("Stat = %+v", s)
+	}
+}
@@ -1,4 +1,4 @@
-//go:build !windows
+//go:build !windows && !openbsd && !netbsd
 
 package disk
 
@@ -11,5 +11,22 @@ func available(path string) (uint64, error) {
 	if err := syscall.Statfs(path, &stat); err != nil {
 		return 0, err
 	}
-	return stat.Bavail * uint64(stat.Bsize), nil
+	// field types differ between Linux, macOS and FreeBSD
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
+
+func inodes(path string) (free, total uint64, err error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	return uint64(stat.Ffree), uint64(stat.Files), nil
+}
+
+func total(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Blocks) * uint64(stat.Bsize), nil
 }
@@ -29,3 +29,26 @@ func available(path string) (uint64, error) {
 	}
 	return freeBytesAvailable, nil
 }
+
+// NTFS has no fixed inode table.
+func inodes(path string) (free, total uint64, err error) {
+	return 0, 0, ErrNoInodes
+}
+
+func total(path string) (uint64, error) {
+	ptr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var totalBytes uint64
+	r, _, err := getDiskFreeSpaceEx.Call(
+		uintptr(unsafe.Pointer(ptr)),
+		0,
+		uintptr(unsafe.Pointer(&totalBytes)),
+		0,
+	)
+	if r == 0 {
+		return 0, err
+	}
+	return totalBytes, nil
+}
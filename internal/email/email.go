@@ -1,10 +1,11 @@
-// Package email sends error notifications via SMTP.
+// Package email sends error notifications and reports via SMTP (plain text or multipart with HTML).
 package email
 
 import (
 	"crypto/tls"
 	"fmt"
 	"net"
+	"net/mail"
 	"net/smtp"
 	"strings"
 
@@ -15,9 +16,23 @@ import (
 // Send sends an email to admin_email with the given subject and body (plain text).
 // admin_smtp_tls: "tls" = implizites TLS (Port 465), "starttls" = STARTTLS (Port 587), "" = Auto (465→tls, 587→starttls).
 func Send(cfg *config.Config, subject, body string) error {
-	if cfg.AdminEmail == "" || cfg.AdminSMTPServer == "" {
+	return SendHTML(cfg, subject, body, "")
+}
+
+// SendHTML sends a multipart/alternative email with the plain text and an HTML version (see FormatHTML);
+// htmlBody "" sends plain text only.
+// Sender is mail_from (else admin_email), recipients are mail_to (else admin_email) plus mail_cc.
+// attachments are added as text files (e.g. log excerpt).
+func SendHTML(cfg *config.Config, subject, body, htmlBody string, attachments ...Attachment) error {
+	to := cfg.Recipients()
+	if len(to) == 0 || cfg.AdminSMTPServer == "" {
 		return nil
 	}
+	h := header{From: cfg.MailFrom, To: to, CC: nonEmpty(cfg.MailCC), ReplyTo: strings.TrimSpace(cfg.MailReplyTo)}
+	if strings.TrimSpace(h.From) == "" {
+		h.From = cfg.AdminEmail
+	}
+	h.From = strings.TrimSpace(h.From)
 	port := cfg.AdminSMTPPort
 	if port <= 0 {
 		port = 587
@@ -29,10 +44,10 @@ func Send(cfg *config.Config, subject, body string) error {
 	}
 	// Manche Server (z. B. kasserver) erwarten Identity = Username (beides E-Mail/Login).
 	auth := smtp.PlainAuth(authUser, authUser, cfg.AdminSMTPPassword, cfg.AdminSMTPServer)
-	msg := []byte("To: " + cfg.AdminEmail + "\r\n" +
-		"Subject: " + subject + "\r\n" +
-		"Content-Type: text/plain; charset=UTF-8\r\n" +
-		"\r\n" + body + "\r\n")
+	msg, err := buildMessage(h, subject, body, htmlBody, attachments)
+	if err != nil {
+		return err
+	}
 
 	tlsMode := strings.ToLower(strings.TrimSpace(cfg.AdminSMTPTLS))
 	if tlsMode == "" {
@@ -44,18 +59,31 @@ func Send(cfg *config.Config, subject, body string) error {
 		}
 	}
 
+	from, err := mail.ParseAddress(h.From)
+	if err != nil {
+		return fmt.Errorf(i18n.T("err.mail_address"), h.From, err)
+	}
+	var rcpts []string
+	for _, a := range append(append([]string{}, h.To...), h.CC...) {
+		addr, err := mail.ParseAddress(a)
+		if err != nil {
+			return fmt.Errorf(i18n.T("err.mail_address"), a, err)
+		}
+		rcpts = append(rcpts, addr.Address)
+	}
+
 	switch tlsMode {
 	case "tls":
-		return sendTLS(cfg, addr, auth, msg)
+		return sendTLS(cfg, addr, auth, from.Address, rcpts, msg)
 	case "starttls":
-		return sendSTARTTLS(cfg, addr, auth, msg)
+		return sendSTARTTLS(cfg, addr, auth, from.Address, rcpts, msg)
 	default:
-		return smtp.SendMail(addr, auth, cfg.AdminEmail, []string{cfg.AdminEmail}, msg)
+		return smtp.SendMail(addr, auth, from.Address, rcpts, msg)
 	}
 }
 
 // sendTLS: implizites TLS (Port 465).
-func sendTLS(cfg *config.Config, addr string, auth smtp.Auth, msg []byte) error {
+func sendTLS(cfg *config.Config, addr string, auth smtp.Auth, from string, rcpts []string, msg []byte) error {
 	tlsConfig := &tls.Config{ServerName: cfg.AdminSMTPServer}
 	conn, err := tls.Dial("tcp", addr, tlsConfig)
 	if err != nil {
@@ -70,11 +98,13 @@ func sendTLS(cfg *config.Config, addr string, auth smtp.Auth, msg []byte) error
 	if err := client.Auth(auth); err != nil {
 		return err
 	}
-	if err := client.Mail(cfg.AdminEmail); err != nil {
+	if err := client.Mail(from); err != nil {
 		return err
 	}
-	if err := client.Rcpt(cfg.AdminEmail); err != nil {
-		return err
+	for _, rcpt := range rcpts {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
 	}
 	w, err := client.Data()
 	if err != nil {
@@ -90,7 +120,7 @@ func sendTLS(cfg *config.Config, addr string, auth smtp.Auth, msg []byte) error
 }
 
 // sendSTARTTLS: Verbindung, dann STARTTLS (typisch Port 587).
-func sendSTARTTLS(cfg *config.Config, addr string, auth smtp.Auth, msg []byte) error {
+func sendSTARTTLS(cfg *config.Config, addr string, auth smtp.Auth, from string, rcpts []string, msg []byte) error {
 	conn, err := net.Dial("tcp", addr)
 	if err != nil {
 		return fmt.Errorf(i18n.T("err.dial"), err)
@@ -110,11 +140,13 @@ func sendSTARTTLS(cfg *config.Config, addr string, auth smtp.Auth, msg []byte) e
 	if err := client.Auth(auth); err != nil {
 		return err
 	}
-	if err := client.Mail(cfg.AdminEmail); err != nil {
+	if err := client.Mail(from); err != nil {
 		return err
 	}
-	if err := client.Rcpt(cfg.AdminEmail); err != nil {
-		return err
+	for _, rcpt := range rcpts {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
 	}
 	w, err := client.Data()
 	if err != nil {
@@ -129,17 +161,29 @@ func sendSTARTTLS(cfg *config.Config, addr string, auth smtp.Auth, msg []byte) e
 	return client.Quit()
 }
 
-// FormatErrorBody builds a plain-text body for error notification (subject + log excerpt).
-func FormatErrorBody(subject, errDetail, logExcerpt string) string {
+// nonEmpty returns the trimmed, non-empty entries of list.
+func nonEmpty(list []string) []string {
+	var out []string
+	for _, s := range list {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// FormatErrorBody builds a plain-text body for error notification (subject, error details, optional note
+// such as a hint to the attached log).
+func FormatErrorBody(subject, errDetail, note string) string {
 	var b strings.Builder
 	b.WriteString(subject)
 	b.WriteString("\n\n")
 	b.WriteString("Fehlerdetails / Error details:\n")
 	b.WriteString(errDetail)
 	b.WriteString("\n\n")
-	if logExcerpt != "" {
-		b.WriteString("Log-Auszug / Log excerpt:\n")
-		b.WriteString(logExcerpt)
+	if note != "" {
+		b.WriteString(note)
+		b.WriteString("\n")
 	}
 	return b.String()
 }
@@ -0,0 +1,178 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// StepStatus is the outcome of one run step in the HTML table.
+type StepStatus int
+
+const (
+	StepOK StepStatus = iota
+	StepFailed
+	StepSkipped
+)
+
+// Step is one row of the HTML run table (e.g. disk check, dump, remote sync).
+type Step struct {
+	Name   string
+	Status StepStatus
+	Detail string
+}
+
+// FormatHTML builds the HTML body: title, table of run steps (failing step highlighted) and the
+// details (error text, summary or log excerpt) as preformatted text.
+func FormatHTML(title string, steps []Step, details ...string) string {
+	var b strings.Builder
+	b.WriteString(`<!DOCTYPE html><html><head><meta charset="utf-8">` +
+		`<meta name="viewport" content="width=device-width, initial-scale=1"></head>` +
+		`<body style="font-family:sans-serif;font-size:14px">`)
+	fmt.Fprintf(&b, "<h2 style=\"font-size:18px\">%s</h2>\n", html.EscapeString(title))
+	if len(steps) > 0 {
+		b.WriteString(`<table style="border-collapse:collapse">` + "\n")
+		for _, s := range steps {
+			label, style := i18n.T("email.status.ok"), "color:#1a7f37"
+			switch s.Status {
+			case StepFailed:
+				label, style = i18n.T("email.status.failed"), "color:#fff;background:#cf222e;font-weight:bold"
+			case StepSkipped:
+				label, style = i18n.T("email.status.skipped"), "color:#6e7781"
+			}
+			fmt.Fprintf(&b, "<tr><td style=\"padding:4px 8px;border:1px solid #d0d7de\">%s</td>"+
+				"<td style=\"padding:4px 8px;border:1px solid #d0d7de;%s\">%s</td>"+
+				"<td style=\"padding:4px 8px;border:1px solid #d0d7de\">%s</td></tr>\n",
+				html.EscapeString(s.Name), style, html.EscapeString(label), html.EscapeString(s.Detail))
+		}
+		b.WriteString("</table>\n")
+	}
+	for _, d := range details {
+		if d == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "<pre style=\"white-space:pre-wrap;font-size:12px\">%s</pre>\n", html.EscapeString(d))
+	}
+	b.WriteString("</body></html>\n")
+	return b.String()
+}
+
+// header holds the address fields of a message (addresses as in the config, e.g. "Backup <b@example.com>").
+type header struct {
+	From    string
+	To, CC  []string
+	ReplyTo string
+}
+
+// Attachment is a text file attached to a message (e.g. log excerpt, mysqldump error output).
+type Attachment struct {
+	Name string
+	Data []byte
+}
+
+// buildMessage returns the RFC 5322 message: text/plain only, or multipart/alternative with the
+// plain text first and the HTML part as preferred alternative. With attachments the body is wrapped
+// in multipart/mixed and each attachment follows as base64-encoded text/plain part.
+func buildMessage(h header, subject, text, htmlBody string, attachments []Attachment) ([]byte, error) {
+	var msg bytes.Buffer
+	msg.WriteString("From: " + h.From + "\r\n")
+	msg.WriteString("To: " + strings.Join(h.To, ", ") + "\r\n")
+	if len(h.CC) > 0 {
+		msg.WriteString("Cc: " + strings.Join(h.CC, ", ") + "\r\n")
+	}
+	if h.ReplyTo != "" {
+		msg.WriteString("Reply-To: " + h.ReplyTo + "\r\n")
+	}
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	contentType, body, err := messageBody(text, htmlBody)
+	if err != nil {
+		return nil, err
+	}
+	if len(attachments) == 0 {
+		msg.WriteString("Content-Type: " + contentType + "\r\n\r\n")
+		msg.Write(body)
+		return msg.Bytes(), nil
+	}
+	mixed := multipart.NewWriter(&msg)
+	msg.WriteString("Content-Type: multipart/mixed; boundary=" + mixed.Boundary() + "\r\n\r\n")
+	w, err := mixed.CreatePart(textproto.MIMEHeader{"Content-Type": {contentType}})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	for _, a := range attachments {
+		w, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType("text/plain", map[string]string{"charset": "UTF-8", "name": a.Name})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Name})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(w, a.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+// messageBody returns content type and body: text/plain only or multipart/alternative with plain
+// text and HTML (quoted-printable).
+func messageBody(text, htmlBody string) (contentType string, body []byte, err error) {
+	if htmlBody == "" {
+		return "text/plain; charset=UTF-8", []byte(text + "\r\n"), nil
+	}
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", htmlBody},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return "", nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return "", nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return "", nil, err
+	}
+	return "multipart/alternative; boundary=" + mw.Boundary(), buf.Bytes(), nil
+}
+
+// writeBase64 writes data base64-encoded in lines of 76 characters (RFC 2045).
+func writeBase64(w io.Writer, data []byte) error {
+	enc := base64.StdEncoding.EncodeToString(data)
+	for len(enc) > 76 {
+		if _, err := io.WriteString(w, enc[:76]+"\r\n"); err != nil {
+			return err
+		}
+		enc = enc[76:]
+	}
+	_, err := io.WriteString(w, enc+"\r\n")
+	return err
+}
@@ -0,0 +1,102 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestBuildMessageMultipart(t *testing.T) {
+	htmlBody := FormatHTML("Backup <failed>", []Step{{Name: "Dump", Status: StepFailed, Detail: "a & b"}})
+	if !strings.Contains(htmlBody, "Backup &lt;failed&gt;") || !strings.Contains(htmlBody, "a &amp; b") {
+		t.Fatalf("HTML not escaped:\n%s", htmlBody)
+	}
+	h := header{From: "Backup <backup@example.com>", To: []string{"admin@example.com", "ops@example.com"}, CC: []string{"cc@example.com"}}
+	raw, err := buildMessage(h, "Sicherung fehlgeschlagen: Prüfung", "plain text", htmlBody, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if to, err := msg.Header.AddressList("To"); err != nil || len(to) != 2 {
+		t.Errorf("To = %v, %v", to, err)
+	}
+	if from, err := mail.ParseAddress(msg.Header.Get("From")); err != nil || from.Address != "backup@example.com" {
+		t.Errorf("From = %v, %v", from, err)
+	}
+	if msg.Header.Get("Cc") != "cc@example.com" {
+		t.Errorf("Cc = %q", msg.Header.Get("Cc"))
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); subject != "Sicherung fehlgeschlagen: Prüfung" {
+		t.Errorf("subject = %q", subject)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content type %q, %v", mediaType, err)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	var types []string
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(p) // quoted-printable is decoded by the reader
+		types = append(types, strings.SplitN(p.Header.Get("Content-Type"), ";", 2)[0])
+		if len(types) == 1 && string(body) != "plain text" {
+			t.Errorf("plain part = %q", body)
+		}
+	}
+	if strings.Join(types, ",") != "text/plain,text/html" {
+		t.Errorf("parts = %v", types)
+	}
+}
+
+func TestBuildMessageAttachments(t *testing.T) {
+	h := header{From: "backup@example.com", To: []string{"admin@example.com"}}
+	log := []byte("2026-10-16 22:00:01 ERROR dump failed\n")
+	raw, err := buildMessage(h, "Backup failed", "plain text", "", []Attachment{{Name: "mysqlbackup-log.txt", Data: log}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("content type %q, %v", mediaType, err)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	body, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := body.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("body part type = %q", ct)
+	}
+	att, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if att.FileName() != "mysqlbackup-log.txt" {
+		t.Errorf("file name = %q", att.FileName())
+	}
+	data, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, att))
+	if !bytes.Equal(data, log) {
+		t.Errorf("attachment = %q", data)
+	}
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("expected end of parts, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+// Package errcode attaches stable, language-independent codes (MB-xxxx) to errors. The error text stays
+// localized; logs, last_run.json, the webhook payload and notification subjects carry the code, so monitoring
+// and support can match it instead of the translated text.
+package errcode
+
+import "errors"
+
+// Code is a stable error code "MB-xxxx": 01xx run start, 02xx disk, 03xx MySQL and dump, 04xx remote,
+// 05xx verification, 09xx run aborted. Codes are never reused for another meaning.
+type Code string
+
+// Codes of the backup run (table in the README).
+const (
+	Locked        Code = "MB-0102" // another run holds the lock (lock_wait_minutes elapsed)
+	PreRun        Code = "MB-0111" // pre_run_cmd failed
+	SigningKey    Code = "MB-0112" // signing_key_file cannot be read
+	DiskSpace     Code = "MB-0201" // not enough free space in backup_dir
+	DiskInodes    Code = "MB-0202" // not enough free inodes in backup_dir
+	MySQLStart    Code = "MB-0301" // mysql_start_cmd failed
+	MySQLTimeout  Code = "MB-0302" // MySQL not reachable after mysql_start_cmd
+	MySQLServer   Code = "MB-0303" // MySQL server not reachable or query failed
+	ListDatabases Code = "MB-0304" // listing the databases failed
+	GaleraDonor   Code = "MB-0305" // no node of galera_nodes is synced
+	Dump          Code = "MB-0311" // backup of a database failed (mysqldump, hook, ZIP)
+	DumpPartial   Code = "MB-0312" // some databases failed (dump_continue_on_error)
+	Remote        Code = "MB-0400" // remote sync failed (other)
+	RemoteConnect Code = "MB-0401" // SSH connection to the remote target failed
+	RemoteSFTP    Code = "MB-0402" // SFTP session could not be started
+	RemoteList    Code = "MB-0411" // listing local or remote backups failed
+	RemoteUpload  Code = "MB-0421" // upload of a backup failed
+	Verify        Code = "MB-0501" // restore verification failed
+	Timeout       Code = "MB-0901" // max_run_duration exceeded
+	Interrupted   Code = "MB-0902" // run interrupted (SIGINT/SIGTERM)
+	Window        Code = "MB-0903" // backup_window_end reached before the run was complete (or run started after it)
+)
+
+// Error is an error with a code; Error() is the text of the wrapped error.
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Wrap attaches code to err. An error that already carries a code keeps it (the more specific one from deeper
+// in the call chain); nil stays nil.
+func Wrap(code Code, err error) error {
+	if err == nil || Of(err) != "" {
+		return err
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Of returns the code of err or one of the errors it wraps, "" if none.
+func Of(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+// Tag puts the code in front of s ("[MB-0421] s"); without code s is returned unchanged.
+func Tag(code Code, s string) string {
+	if code == "" {
+		return s
+	}
+	return "[" + string(code) + "] " + s
+}
@@ -0,0 +1,30 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	if Wrap(Remote, nil) != nil {
+		t.Error("Wrap(nil) != nil")
+	}
+	upload := Wrap(RemoteUpload, errors.New("connection lost"))
+	err := Wrap(Remote, fmt.Errorf("remote sync: %w", upload))
+	if got := Of(err); got != RemoteUpload {
+		t.Errorf("Of = %q, want the inner code %q", got, RemoteUpload)
+	}
+	if err.Error() != "remote sync: connection lost" {
+		t.Errorf("Error() = %q, want the text unchanged", err.Error())
+	}
+	if got := Of(errors.New("plain")); got != "" {
+		t.Errorf("Of(plain) = %q", got)
+	}
+	if got := Tag(RemoteUpload, "upload failed"); got != "[MB-0421] upload failed" {
+		t.Errorf("Tag = %q", got)
+	}
+	if got := Tag("", "upload failed"); got != "upload failed" {
+		t.Errorf("Tag without code = %q", got)
+	}
+}
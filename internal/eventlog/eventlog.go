@@ -0,0 +1,28 @@
+// Package eventlog writes run events (start, success, failure) to the Windows Application event log.
+// On other systems all functions do nothing.
+package eventlog
+
+// Source is the event source name in the Application log.
+const Source = "MySqlBackup"
+
+// Event IDs of the run events.
+const (
+	EventStart   = 1
+	EventSuccess = 2
+	EventFailure = 3
+)
+
+// Start records the start of a run (information).
+func Start(msg string) error { return report(typeInfo, EventStart, msg) }
+
+// Success records a successful run (information).
+func Success(msg string) error { return report(typeInfo, EventSuccess, msg) }
+
+// Failure records a failed run (error).
+func Failure(msg string) error { return report(typeError, EventFailure, msg) }
+
+// Event types as used by ReportEvent.
+const (
+	typeError = 0x0001
+	typeInfo  = 0x0004
+)
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package eventlog
+
+func report(eventType uint16, eventID uint32, msg string) error { return nil }
+
+// Register does nothing outside Windows.
+func Register() error { return nil }
@@ -0,0 +1,45 @@
+//go:build windows
+
+package eventlog
+
+import (
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	registerEventSource       = advapi32.NewProc("RegisterEventSourceW")
+	reportEvent               = advapi32.NewProc("ReportEventW")
+	deregisterEventSourceProc = advapi32.NewProc("DeregisterEventSource")
+)
+
+func report(eventType uint16, eventID uint32, msg string) error {
+	src, err := syscall.UTF16PtrFromString(Source)
+	if err != nil {
+		return err
+	}
+	h, _, err := registerEventSource.Call(0, uintptr(unsafe.Pointer(src)))
+	if h == 0 {
+		return err
+	}
+	defer deregisterEventSourceProc.Call(h)
+	text, err := syscall.UTF16PtrFromString(msg)
+	if err != nil {
+		return err
+	}
+	strs := []*uint16{text}
+	r, _, err := reportEvent.Call(h, uintptr(eventType), 0, uintptr(eventID), 0, 1, 0, uintptr(unsafe.Pointer(&strs[0])), 0)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+// Register registers Source in the Application log (New-EventLog, needs administrator rights), so the
+// Event Viewer shows the messages without "description not found". Already registered is not an error.
+func Register() error {
+	script := "if (-not [System.Diagnostics.EventLog]::SourceExists('" + Source + "')) { New-EventLog -LogName Application -Source '" + Source + "' }"
+	return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script).Run()
+}
@@ -0,0 +1,110 @@
+package fleet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/runreport"
+)
+
+// Sync fetches the policy of cfg from the controller and writes the allowed keys (see config.PolicyKey) to the
+// policy file of cfg. It returns whether the file changed and the keys it ignored.
+func Sync(cfg *config.Config) (changed bool, ignored []string, err error) {
+	var policy map[string]json.RawMessage
+	if err := call(cfg, http.MethodGet, "/policy", nil, &policy); err != nil {
+		return false, nil, err
+	}
+	allowed := make(map[string]json.RawMessage)
+	for k, v := range policy {
+		if config.PolicyKey(k) {
+			allowed[k] = v
+		} else {
+			ignored = append(ignored, k)
+		}
+	}
+	sort.Strings(ignored)
+	path := cfg.PolicyPath()
+	if old, err := os.ReadFile(path); err == nil {
+		if same, _ := equalJSON(old, allowed); same {
+			return false, ignored, nil
+		}
+	} else if len(allowed) == 0 {
+		return false, ignored, nil
+	}
+	data, err := json.MarshalIndent(allowed, "", "  ")
+	if err != nil {
+		return false, ignored, err
+	}
+	if err := writeFile(path, data); err != nil {
+		return false, ignored, fmt.Errorf(i18n.T("err.fleet_policy_write"), path, err)
+	}
+	return true, ignored, nil
+}
+
+// Report sends the run report rep of cfg to the controller.
+func Report(cfg *config.Config, rep *runreport.Report) error {
+	data, err := json.Marshal(rep)
+	if err != nil {
+		return err
+	}
+	return call(cfg, http.MethodPost, "/report", data, nil)
+}
+
+// call sends a request to <controller_url>/api/v1/agents/<agent>/<suffix> with controller_password as bearer
+// token and decodes the JSON response into out (if not nil).
+func call(cfg *config.Config, method, suffix string, body []byte, out interface{}) error {
+	u := strings.TrimRight(cfg.ControllerURL, "/") + "/api/v1/agents/" + url.PathEscape(cfg.AgentID()) + suffix
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf(i18n.T("err.fleet_request"), err)
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.ControllerPassword)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf(i18n.T("err.fleet_request"), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf(i18n.T("err.fleet_status"), resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out); err != nil {
+		return fmt.Errorf(i18n.T("err.fleet_request"), err)
+	}
+	return nil
+}
+
+// equalJSON reports whether the JSON document a has the same content as v (ignoring formatting and key order).
+func equalJSON(a []byte, v interface{}) (bool, error) {
+	var x, y interface{}
+	if err := json.Unmarshal(a, &x); err != nil {
+		return false, err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, &y); err != nil {
+		return false, err
+	}
+	xa, _ := json.Marshal(x)
+	ya, _ := json.Marshal(y)
+	return bytes.Equal(xa, ya), nil
+}
@@ -0,0 +1,100 @@
+package fleet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/runreport"
+)
+
+// PolicyFile and AgentsDir are the policy of all agents and the directory of the agents in controller_dir.
+const (
+	PolicyFile = "policy.json"
+	AgentsDir  = "agents"
+)
+
+var agentMu sync.Mutex // schützt agents/<name>.json (Anfragen mehrerer Agents gleichzeitig)
+
+// Policy returns the policy of the agent name: policy.json of dir with agents/<name>.policy.json on top (both
+// optional), as JSON object.
+func Policy(dir, name string) (map[string]json.RawMessage, error) {
+	policy := make(map[string]json.RawMessage)
+	for _, p := range []string{filepath.Join(dir, PolicyFile), filepath.Join(dir, AgentsDir, name+".policy.json")} {
+		data, err := os.ReadFile(p)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var keys map[string]json.RawMessage
+		if err := json.Unmarshal(data, &keys); err != nil {
+			return nil, fmt.Errorf(i18n.T("err.fleet_policy_read"), p, err)
+		}
+		for k, v := range keys {
+			policy[k] = v
+		}
+	}
+	return policy, nil
+}
+
+// Seen records a contact of the agent name from addr in dir/agents/<name>.json, with rep as its last run if not
+// nil.
+func Seen(dir, name, addr string, rep *runreport.Report) error {
+	agentMu.Lock()
+	defer agentMu.Unlock()
+	path := filepath.Join(dir, AgentsDir, name+".json")
+	a := &Agent{}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, a) // kaputte Datei: neu anlegen
+	}
+	a.Name, a.Address, a.LastSeen = name, addr, time.Now().UTC()
+	if rep != nil {
+		a.LastRun = rep
+	}
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
+
+// Agents returns the agents known in dir, sorted by name.
+func Agents(dir string) ([]Agent, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, AgentsDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []Agent
+	for _, e := range entries {
+		n := e.Name()
+		if e.IsDir() || !strings.HasSuffix(n, ".json") || strings.HasSuffix(n, ".policy.json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, AgentsDir, n))
+		if err != nil {
+			continue
+		}
+		var a Agent
+		if json.Unmarshal(data, &a) != nil || a.Name == "" {
+			continue
+		}
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
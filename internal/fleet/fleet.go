@@ -0,0 +1,42 @@
+// Package fleet connects agents to a controller (another mysqlbackup with controller_dir and api_listen), so a
+// fleet of servers is administered from one place: agents fetch their policy (config keys such as retention and
+// schedule) from the controller and report every run; the controller keeps the policies and the last report of
+// each agent in controller_dir.
+//
+// Layout of controller_dir:
+//
+//	policy.json                policy of all agents
+//	agents/<name>.policy.json  policy of one agent, overrides policy.json key by key
+//	agents/<name>.json         last contact and last run report of the agent (written by the controller)
+package fleet
+
+import (
+	"os"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/runreport"
+)
+
+// SyncInterval is how often the daemon of an agent fetches its policy.
+const SyncInterval = 5 * time.Minute
+
+// Agent is what the controller knows about an agent (agents/<name>.json).
+type Agent struct {
+	Name     string            `json:"name"`
+	Address  string            `json:"address"` // remote address of the last request
+	LastSeen time.Time         `json:"last_seen"`
+	LastRun  *runreport.Report `json:"last_run,omitempty"`
+}
+
+// writeFile writes data to path via a temp file and rename.
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
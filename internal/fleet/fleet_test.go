@@ -0,0 +1,99 @@
+package fleet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/runreport"
+)
+
+func TestAgentController(t *testing.T) {
+	ctrl := t.TempDir()
+	write := func(p, content string) {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(ctrl, PolicyFile), `{"retain_daily": 14, "schedule": "0 2 * * *", "post_run_cmd": "rm -rf /"}`)
+	write(filepath.Join(ctrl, AgentsDir, "club1.policy.json"), `{"retain_daily": 30, "backup_dir": "/tmp"}`)
+
+	// Controller-Seite wie die API, ohne Token-Prüfung
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/agents/"), "/")[0]
+		if r.Header.Get("Authorization") != "Bearer agent-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/policy") {
+			p, err := Policy(ctrl, name)
+			if err != nil {
+				t.Error(err)
+			}
+			_ = json.NewEncoder(w).Encode(p)
+			return
+		}
+		var rep runreport.Report
+		if err := json.NewDecoder(r.Body).Decode(&rep); err != nil {
+			t.Error(err)
+		}
+		if err := Seen(ctrl, name, r.RemoteAddr, &rep); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	write(path, `{"backup_dir": "`+filepath.ToSlash(filepath.Join(dir, "b"))+`", "controller_url": "`+srv.URL+`/",
+		"controller_password": "agent-token", "agent_name": "club1"}`)
+	cfg, err := config.Load(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.PolicyPath() != filepath.Join(dir, config.PolicyFile) || cfg.RetainDaily == 30 {
+		t.Fatalf("policy path %q, retain_daily %d", cfg.PolicyPath(), cfg.RetainDaily)
+	}
+	changed, ignored, err := Sync(cfg)
+	if err != nil || !changed || strings.Join(ignored, ",") != "backup_dir,post_run_cmd" {
+		t.Fatalf("Sync = %v, %q, %v", changed, ignored, err)
+	}
+	cfg, err = config.Load(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed, _, err := Sync(cfg); err != nil || changed {
+		t.Errorf("second Sync = %v, %v, want unchanged", changed, err)
+	}
+	if cfg.RetainDaily != 30 || cfg.Schedule != "0 2 * * *" || cfg.PostRunCmd != "" || cfg.BackupDir != filepath.Join(dir, "b") {
+		t.Errorf("policy not applied: retain_daily %d, schedule %q, post_run_cmd %q, backup_dir %q",
+			cfg.RetainDaily, cfg.Schedule, cfg.PostRunCmd, cfg.BackupDir)
+	}
+
+	started := time.Date(2026, 10, 16, 2, 0, 0, 0, time.UTC)
+	if err := Report(cfg, &runreport.Report{Started: started, Status: runreport.StatusSuccess}); err != nil {
+		t.Fatal(err)
+	}
+	agents, err := Agents(ctrl)
+	if err != nil || len(agents) != 1 {
+		t.Fatalf("Agents = %+v, %v", agents, err)
+	}
+	if a := agents[0]; a.Name != "club1" || a.LastSeen.IsZero() || a.LastRun == nil || !a.LastRun.Started.Equal(started) {
+		t.Errorf("agent = %+v", a)
+	}
+
+	cfg.ControllerPassword = "wrong"
+	if err := Report(cfg, &runreport.Report{}); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Report with wrong token: %v", err)
+	}
+}
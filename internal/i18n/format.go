@@ -0,0 +1,219 @@
+package i18n
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Arg is a named argument for Tf. ICU-style messages refer to it as {name}; fmt verbs and {n} see its value at
+// its position like any other argument.
+type Arg struct {
+	Name  string
+	Value interface{}
+}
+
+// Named returns the named argument name=value for Tf.
+func Named(name string, value interface{}) Arg {
+	return Arg{Name: name, Value: value}
+}
+
+// format renders msg with the Tf arguments. Messages with ICU-like placeholders are expanded by expand:
+//
+//	{2}              second argument (1-based, like %[2]v)
+//	{count}          named argument (Named("count", n))
+//	{1, plural, =0 {no files} one {# file} other {# files}}
+//
+// In a plural block "#" is the number; branches are "=n" (exact value) or the CLDR category of the language
+// (one, few, many, other), "other" is the fallback. In such messages "%" is a plain character.
+// All other messages are formatted with fmt.Sprintf as before; a "{" that is not a placeholder stays as it is.
+func format(l, msg string, args []interface{}) string {
+	vals := make([]interface{}, len(args))
+	names := make(map[string]interface{})
+	for i, a := range args {
+		if n, ok := a.(Arg); ok {
+			vals[i], names[n.Name] = n.Value, n.Value
+		} else {
+			vals[i] = a
+		}
+	}
+	if strings.Contains(msg, "{") {
+		f := formatter{lang: l, vals: vals, names: names}
+		if out := f.expand(msg, nil); f.used {
+			return out
+		}
+	}
+	return fmt.Sprintf(msg, vals...)
+}
+
+// unwrap returns the argument values without the names (for fmt and Source).
+func unwrap(args []interface{}) []interface{} {
+	if args == nil {
+		return nil
+	}
+	vals := make([]interface{}, len(args))
+	for i, a := range args {
+		if n, ok := a.(Arg); ok {
+			vals[i] = n.Value
+		} else {
+			vals[i] = a
+		}
+	}
+	return vals
+}
+
+type formatter struct {
+	lang  string
+	vals  []interface{}
+	names map[string]interface{}
+	used  bool // at least one placeholder was expanded
+}
+
+// expand replaces the placeholders in s; num is the value for "#" inside a plural branch (nil outside).
+func (f *formatter) expand(s string, num interface{}) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '#' && num != nil:
+			b.WriteString(fmt.Sprint(num))
+		case s[i] == '{':
+			if out, n, ok := f.placeholder(s[i:]); ok {
+				b.WriteString(out)
+				i += n - 1
+				f.used = true
+				continue
+			}
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// placeholder expands the placeholder at the start of s ("{...}") and returns the text and its length.
+// ok is false for anything that is not a known argument or not a well-formed plural block.
+func (f *formatter) placeholder(s string) (string, int, bool) {
+	end := strings.IndexAny(s, ",}")
+	if end < 0 {
+		return "", 0, false
+	}
+	v, ok := f.arg(strings.TrimSpace(s[1:end]))
+	if !ok {
+		return "", 0, false
+	}
+	if s[end] == '}' {
+		return fmt.Sprint(v), end + 1, true
+	}
+	rest := s[end+1:]
+	kw := strings.IndexByte(rest, ',')
+	if kw < 0 || strings.TrimSpace(rest[:kw]) != "plural" {
+		return "", 0, false
+	}
+	pos := end + 1 + kw + 1
+	branches := make(map[string]string)
+	for {
+		for pos < len(s) && s[pos] == ' ' {
+			pos++
+		}
+		if pos >= len(s) {
+			return "", 0, false
+		}
+		if s[pos] == '}' {
+			pos++
+			break
+		}
+		open := strings.IndexByte(s[pos:], '{')
+		if open < 0 {
+			return "", 0, false
+		}
+		sel := strings.TrimSpace(s[pos : pos+open])
+		text, n, ok := braced(s[pos+open:])
+		if sel == "" || !ok {
+			return "", 0, false
+		}
+		branches[sel] = text
+		pos += open + n
+	}
+	n, isNum := number(v)
+	text, ok := branches["="+strconv.FormatInt(n, 10)]
+	if !ok || !isNum {
+		if text, ok = branches[pluralCategory(f.lang, n)]; !ok || !isNum {
+			text = branches["other"]
+		}
+	}
+	return f.expand(text, v), pos, true
+}
+
+// arg resolves a placeholder name: 1-based position or name of a Named argument.
+func (f *formatter) arg(ref string) (interface{}, bool) {
+	if i, err := strconv.Atoi(ref); err == nil {
+		if i < 1 || i > len(f.vals) {
+			return nil, false
+		}
+		return f.vals[i-1], true
+	}
+	v, ok := f.names[ref]
+	return v, ok
+}
+
+// braced returns the content of the balanced "{...}" at the start of s and the length including the braces.
+func braced(s string) (string, int, bool) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return s[1:i], i + 1, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// number returns v as integer for the plural selection; ok is false for non-numeric values.
+func number(v interface{}) (int64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return int64(rv.Float()), true
+	}
+	return 0, false
+}
+
+// pluralCategory returns the CLDR plural category of the integer n (simplified to integers):
+// fr/pt: 0 and 1 are "one"; pl: one, few (2-4, 22-24, ... but not 12-14), many; otherwise only 1 is "one".
+// Unknown languages (translations_dir) use the English rule.
+func pluralCategory(l string, n int64) string {
+	if n < 0 {
+		n = -n
+	}
+	switch l {
+	case LangFR, LangPT:
+		if n <= 1 {
+			return "one"
+		}
+	case LangPL:
+		switch {
+		case n == 1:
+			return "one"
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	default:
+		if n == 1 {
+			return "one"
+		}
+	}
+	return "other"
+}
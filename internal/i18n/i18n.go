@@ -1,30 +1,38 @@
-// Package i18n provides embedded translations (de, en, fr, nl). Locale from LANG/LC_ALL/LANGUAGE; fallback en (British English).
+// Package i18n provides embedded translations (de, en, es, fr, it, nl, pl, pt). Locale from LANG/LC_ALL/LANGUAGE; fallback en (British English).
 package i18n
 
 import (
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 )
 
-//go:embed translations/de.json translations/en.json translations/fr.json translations/nl.json
+//go:embed translations/de.json translations/en.json translations/es.json translations/fr.json translations/it.json
+//go:embed translations/nl.json translations/pl.json translations/pt.json
 var embedFS embed.FS
 
 var (
 	mu       sync.RWMutex
 	messages map[string]string
 	lang     string
+	overlay  string // translations_dir loaded by LoadOverlay, "" = embedded messages only
 )
 
-// Supported languages: de, en (British English), fr, nl.
+// Supported languages: de, en (British English), es, fr, it, nl, pl, pt (Brazilian Portuguese).
 const (
 	LangDE = "de"
 	LangEN = "en"
+	LangES = "es"
 	LangFR = "fr"
+	LangIT = "it"
 	LangNL = "nl"
+	LangPL = "pl"
+	LangPT = "pt"
 )
 
 func init() {
@@ -32,27 +40,81 @@ func init() {
 	loadLang(lang)
 }
 
-// detectLang reads LC_ALL, LANG, LANGUAGE (first part) and maps to de/en/fr/nl; unknown → en (British English).
+// detectLang reads LC_ALL, LANG, LANGUAGE (first part) and maps to de/en/es/fr/it/nl/pl/pt; unknown → en (British English).
 func detectLang() string {
-	for _, env := range []string{"LC_ALL", "LANG", "LANGUAGE"} {
-		v := os.Getenv(env)
-		if v == "" {
-			continue
+	for _, l := range localeLangs() {
+		switch l {
+		case "de", "en", "es", "fr", "it", "nl", "pl", "pt":
+			return l
 		}
+	}
+	return LangEN
+}
+
+// localeLangs returns the language part of LC_ALL, LANG and LANGUAGE in this order, also unsupported ones.
+func localeLangs() []string {
+	var langs []string
+	for _, env := range []string{"LC_ALL", "LANG", "LANGUAGE"} {
 		// "de_DE.UTF-8" -> "de", "en_GB" -> "en"
-		part := v
+		part := os.Getenv(env)
 		if i := strings.IndexAny(part, "._@"); i >= 0 {
 			part = part[:i]
 		}
-		part = strings.ToLower(strings.TrimSpace(part))
-		switch part {
-		case "de", "en", "fr", "nl":
-			return part
-		case "en_gb", "en_us", "en_au":
-			return LangEN
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			langs = append(langs, part)
 		}
 	}
-	return LangEN
+	return langs
+}
+
+// LoadOverlay puts <lang>.json from dir (translations_dir) on top of the embedded messages: its keys replace or
+// extend the embedded texts. The file is looked up for each language of the locale in order, so a language that is
+// not embedded (e.g. sv) can be supplied as a whole, then for the embedded language in use; English fills in
+// missing keys of an unsupported language. No matching file is not an error. dir "" removes a loaded overlay.
+func LoadOverlay(dir string) error {
+	mu.RLock()
+	active := overlay
+	mu.RUnlock()
+	if dir == "" && active == "" {
+		return nil
+	}
+	base := detectLang()
+	loadLang(base)
+	mu.Lock()
+	overlay = dir
+	mu.Unlock()
+	if dir == "" {
+		return nil
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return err
+	}
+	for _, l := range append(localeLangs(), base) {
+		file := filepath.Join(dir, l+".json")
+		data, err := os.ReadFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var extra map[string]string
+		if err := json.Unmarshal(data, &extra); err != nil {
+			return fmt.Errorf(T("err.file_failed"), file, err)
+		}
+		mu.Lock()
+		merged := make(map[string]string, len(messages)+len(extra))
+		for k, v := range messages {
+			merged[k] = v
+		}
+		for k, v := range extra {
+			merged[k] = v
+		}
+		messages, lang = merged, l
+		mu.Unlock()
+		return nil
+	}
+	return nil
 }
 
 func loadLang(l string) {
@@ -77,7 +139,7 @@ func loadLang(l string) {
 	}
 }
 
-// Lang returns the current language code (de, en, fr, nl).
+// Lang returns the current language code (de, en, es, fr, it, nl, pl, pt).
 func Lang() string {
 	mu.RLock()
 	defer mu.RUnlock()
@@ -86,6 +148,21 @@ func Lang() string {
 
 // T returns the translation for key; if missing, returns key. No formatting.
 func T(key string) string {
+	s := translate(key)
+	record(s, key, nil)
+	return s
+}
+
+// Tf returns the translation for key with fmt-style formatting (e.g. %s, %d), or with ICU-like placeholders
+// and plural forms if the message uses them ({1}, {name}, {1, plural, one {# file} other {# files}}, see format).
+// Named arguments are passed as Named(name, value).
+func Tf(key string, a ...interface{}) string {
+	s := format(Lang(), translate(key), a)
+	record(s, key, unwrap(a))
+	return s
+}
+
+func translate(key string) string {
 	mu.RLock()
 	m := messages
 	mu.RUnlock()
@@ -98,7 +175,55 @@ func T(key string) string {
 	return key
 }
 
-// Tf returns the translation for key with fmt-style formatting (e.g. %s, %d).
-func Tf(key string, a ...interface{}) string {
-	return fmt.Sprintf(T(key), a...)
+// Message is the origin of a translated text: key and format arguments (see Source).
+type Message struct {
+	Key    string
+	Params []interface{}
+}
+
+// maxRecent is the number of recently translated texts remembered for Source.
+const maxRecent = 64
+
+var (
+	recentMu sync.Mutex
+	recentOn bool
+	recent   []recentText // ring buffer, newest last
+)
+
+type recentText struct {
+	text string
+	msg  Message
+}
+
+// RecordMessages switches on remembering key and arguments of the last translated texts, so a structured
+// logger can report them (log_format "json"). Off by default.
+func RecordMessages(on bool) {
+	recentMu.Lock()
+	defer recentMu.Unlock()
+	recentOn = on
+	recent = nil
+}
+
+func record(text, key string, params []interface{}) {
+	recentMu.Lock()
+	defer recentMu.Unlock()
+	if !recentOn {
+		return
+	}
+	if len(recent) >= maxRecent {
+		recent = recent[1:]
+	}
+	recent = append(recent, recentText{text: text, msg: Message{Key: key, Params: params}})
+}
+
+// Source returns key and arguments of the most recent T/Tf call that produced text (RecordMessages must be on).
+func Source(text string) (Message, bool) {
+	recentMu.Lock()
+	defer recentMu.Unlock()
+	for i := len(recent) - 1; i >= 0; i-- {
+		if recent[i].text == text {
+			return recent[i].msg, true
+		}
+	}
+	return Message{}, false
 }
@@ -0,0 +1,81 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOverlay(t *testing.T) {
+	t.Cleanup(func() { _ = LoadOverlay("") }) // runs after the environment is restored
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LANGUAGE", "")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sv.json"), []byte(`{"msg.jobs_removed": "Jobb borttagna."}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "de.json"), []byte(`{"msg.jobs_removed": "Aufträge entfernt."}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("LANG", "sv_SE.UTF-8")
+	if err := LoadOverlay(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := T("msg.jobs_removed"); got != "Jobb borttagna." || Lang() != "sv" {
+		t.Errorf("sv overlay: %q, lang %s", got, Lang())
+	}
+	if got := T("msg.no_backups"); got != "No backup files found." {
+		t.Errorf("sv missing key: %q, want the English text", got)
+	}
+
+	t.Setenv("LANG", "de_DE.UTF-8")
+	if err := LoadOverlay(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := T("msg.jobs_removed"); got != "Aufträge entfernt." || Lang() != LangDE {
+		t.Errorf("de overlay: %q, lang %s", got, Lang())
+	}
+	if err := LoadOverlay(""); err != nil {
+		t.Fatal(err)
+	}
+	if got := T("msg.jobs_removed"); got != "Jobs wurden entfernt." {
+		t.Errorf("without overlay: %q", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "de.json"), []byte(`{"msg.jobs_removed": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadOverlay(dir); err == nil {
+		t.Error("invalid overlay file accepted")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	files := "{1, plural, =0 {no files} one {# file} other {# files}}"
+	pl := "{1, plural, one {# plik} few {# pliki} many {# plików} other {# pliku}}"
+	tests := []struct {
+		lang, msg string
+		args      []interface{}
+		want      string
+	}{
+		{LangEN, files, []interface{}{0}, "no files"},
+		{LangEN, files, []interface{}{1}, "1 file"},
+		{LangEN, files, []interface{}{int64(7)}, "7 files"},
+		{LangDE, "{1, plural, one {# Datei} other {# Dateien}} in {2}", []interface{}{1, "/backup"}, "1 Datei in /backup"},
+		{LangFR, "{1, plural, one {# fichier} other {# fichiers}}", []interface{}{0}, "0 fichier"},
+		{LangPL, pl, []interface{}{1}, "1 plik"},
+		{LangPL, pl, []interface{}{3}, "3 pliki"},
+		{LangPL, pl, []interface{}{12}, "12 plików"},
+		{LangPL, pl, []interface{}{22}, "22 pliki"},
+		{LangEN, "{done, plural, one {# database} other {# databases}} done, {todo} to go", []interface{}{Named("done", 2), Named("todo", 1)}, "2 databases done, 1 to go"},
+		{LangEN, "100% of {1}", []interface{}{"x"}, "100% of x"},
+		{LangEN, "%s: the placeholder {db} is only available in hooks", []interface{}{"dump_cmd"}, "dump_cmd: the placeholder {db} is only available in hooks"},
+		{LangEN, "%d of %s", []interface{}{Named("n", 3), "x"}, "3 of x"},
+	}
+	for _, tt := range tests {
+		if got := format(tt.lang, tt.msg, tt.args); got != tt.want {
+			t.Errorf("format(%s, %q) = %q, want %q", tt.lang, tt.msg, got, tt.want)
+		}
+	}
+}
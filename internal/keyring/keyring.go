@@ -0,0 +1,47 @@
+// Package keyring stores secrets in the credential store of the operating system instead of the config file:
+// Windows Credential Manager (DPAPI-protected per user), macOS keychain and on Linux/BSD the Secret Service
+// (GNOME Keyring, KWallet) via secret-tool. Config password fields refer to them as keyring://<name>.
+package keyring
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// Service is the service name the secrets are stored under (Windows: target "mysqlbackup:<name>").
+const Service = "mysqlbackup"
+
+// Prefix marks a secret reference to the keyring in a password field.
+const Prefix = "keyring://"
+
+// Get returns the secret stored under name.
+func Get(name string) (string, error) {
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	s, err := get(name)
+	if err != nil {
+		return "", fmt.Errorf(i18n.T("err.keyring_get"), name, err)
+	}
+	return s, nil
+}
+
+// Set stores secret under name, replacing an existing one.
+func Set(name, secret string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if err := set(name, secret); err != nil {
+		return fmt.Errorf(i18n.T("err.keyring_set"), name, err)
+	}
+	return nil
+}
+
+func checkName(name string) error {
+	if strings.TrimSpace(name) == "" || strings.ContainsAny(name, "\r\n") {
+		return fmt.Errorf(i18n.T("err.keyring_name"), name)
+	}
+	return nil
+}
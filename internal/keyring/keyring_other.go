@@ -0,0 +1,57 @@
+//go:build !windows
+
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// get reads the secret with security (macOS keychain) or secret-tool (Secret Service, needs an unlocked keyring
+// of the user running the backup).
+func get(name string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "find-generic-password", "-s", Service, "-a", name, "-w")
+	} else {
+		cmd = exec.Command("secret-tool", "lookup", "service", Service, "account", name)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", toolError(err, stderr.String())
+	}
+	s := strings.TrimRight(string(out), "\r\n")
+	if s == "" {
+		// secret-tool lookup endet auch ohne Treffer teilweise mit 0
+		return "", errors.New("not found")
+	}
+	return s, nil
+}
+
+// set stores the secret; the secret is passed on stdin (secret-tool) or as argument of security, which has no
+// other non-interactive way.
+func set(name, secret string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", Service, "-a", name, "-w", secret)
+	} else {
+		cmd = exec.Command("secret-tool", "store", "--label", Service+" "+name, "service", Service, "account", name)
+		cmd.Stdin = strings.NewReader(secret)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return toolError(err, string(out))
+	}
+	return nil
+}
+
+func toolError(err error, output string) error {
+	if output = strings.TrimSpace(output); output != "" {
+		return errors.New(err.Error() + ": " + output)
+	}
+	return err
+}
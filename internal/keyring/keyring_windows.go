@@ -0,0 +1,76 @@
+//go:build windows
+
+package keyring
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+)
+
+var (
+	advapi32  = syscall.NewLazyDLL("advapi32.dll")
+	credRead  = advapi32.NewProc("CredReadW")
+	credWrite = advapi32.NewProc("CredWriteW")
+	credFree  = advapi32.NewProc("CredFree")
+)
+
+// credential is CREDENTIALW.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// get reads the generic credential "mysqlbackup:<name>" of the current user from the Credential Manager.
+func get(name string) (string, error) {
+	target, err := syscall.UTF16PtrFromString(Service + ":" + name)
+	if err != nil {
+		return "", err
+	}
+	var c *credential
+	if r, _, err := credRead.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&c))); r == 0 {
+		return "", err // ERROR_NOT_FOUND: "Element not found."
+	}
+	defer credFree.Call(uintptr(unsafe.Pointer(c)))
+	if c.CredentialBlobSize == 0 {
+		return "", nil
+	}
+	return string(unsafe.Slice(c.CredentialBlob, c.CredentialBlobSize)), nil
+}
+
+// set stores the secret as generic credential "mysqlbackup:<name>" (persisted for the user on this machine,
+// protected by DPAPI).
+func set(name, secret string) error {
+	target, err := syscall.UTF16PtrFromString(Service + ":" + name)
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	blob := []byte(secret)
+	c := credential{Type: credTypeGeneric, TargetName: target, UserName: user, Persist: credPersistLocalMachine,
+		CredentialBlobSize: uint32(len(blob))}
+	if len(blob) > 0 {
+		c.CredentialBlob = &blob[0]
+	}
+	if r, _, err := credWrite.Call(uintptr(unsafe.Pointer(&c)), 0); r == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+// Package kms implements envelope encryption of the remote uploads with a cloud key management service: every
+// file gets a random AES-256 data key, which the KMS wraps with a key that never leaves it (AWS KMS, Azure Key
+// Vault, Google Cloud KMS); the wrapped key is stored in the header of the file. Restoring needs decrypt
+// permission on the KMS key instead of a password in the config.
+package kms
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// Magic starts every envelope-encrypted file; the AES password format starts with a random salt instead.
+const Magic = "MBKMSv1\n"
+
+// Cipher is the content encryption of the files (as with remote_aes_password, so remote restores can read at any
+// offset).
+const Cipher = "AES-256-CTR"
+
+// NonceLen is the length of the CTR nonce after the header.
+const NonceLen = 16
+
+// MinOverhead is the smallest number of bytes an envelope adds to a file (magic, length, header, nonce).
+const MinOverhead = len(Magic) + 4 + 64 + NonceLen
+
+// maxHeader limits the header length read from a file.
+const maxHeader = 64 << 10
+
+// Providers are the URI schemes of remote_kms_key.
+var Providers = []string{"aws-kms://", "azure-kv://", "gcp-kms://"}
+
+// Header is the JSON header of an envelope-encrypted file.
+type Header struct {
+	Key        string `json:"key"`              // remote_kms_key beim Upload
+	KeyID      string `json:"key_id,omitempty"` // vom KMS gemeldete Schlüssel-ID inkl. Version (AWS-ARN, Azure-kid)
+	WrappedKey []byte `json:"wrapped_key"`      // Datenschlüssel, vom KMS verschlüsselt (Base64)
+	Cipher     string `json:"cipher"`
+}
+
+// ParseKey splits a key URI into provider scheme and key: aws-kms://<key id, ARN or alias>,
+// azure-kv://<vault>.vault.azure.net/keys/<name>[/<version>], gcp-kms://projects/<p>/locations/<l>/keyRings/<r>/cryptoKeys/<k>.
+func ParseKey(uri string) (provider, key string, err error) {
+	for _, p := range Providers {
+		if k, ok := strings.CutPrefix(uri, p); ok && strings.TrimSpace(k) != "" {
+			switch {
+			case p == "azure-kv://" && !strings.Contains(k, "/keys/"):
+			case p == "gcp-kms://" && !strings.HasPrefix(k, "projects/"):
+			default:
+				return p, k, nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf(i18n.T("err.kms_key"), uri, strings.Join(Providers, ", "))
+}
+
+// NewDataKey generates a data key for one file and wraps it with the KMS key uri.
+func NewDataKey(uri string) (plain []byte, h Header, err error) {
+	provider, key, err := ParseKey(uri)
+	if err != nil {
+		return nil, Header{}, err
+	}
+	h = Header{Key: uri, Cipher: Cipher}
+	if provider == "aws-kms://" {
+		plain, h.WrappedKey, h.KeyID, err = awsGenerate(key)
+	} else {
+		plain = make([]byte, 32)
+		if _, err = rand.Read(plain); err == nil {
+			if provider == "azure-kv://" {
+				h.WrappedKey, h.KeyID, err = azureWrap(key, plain)
+			} else {
+				h.WrappedKey, err = gcpEncrypt(key, plain)
+			}
+		}
+	}
+	if err != nil {
+		return nil, Header{}, fmt.Errorf(i18n.T("err.kms_wrap"), uri, err)
+	}
+	return plain, h, nil
+}
+
+// DataKey unwraps the data key of h with the configured KMS key uri (remote_kms_key). The header is read from
+// the remote file and not trusted: a header naming another key is rejected, and its key ID is only used if it
+// names the configured key (AWS key ARN) or a version of it on the same vault (Azure kid), so a tampered file
+// cannot send the credentials of the KMS call elsewhere.
+func (h Header) DataKey(uri string) ([]byte, error) {
+	if uri == "" || h.Key != uri {
+		return nil, fmt.Errorf(i18n.T("err.kms_key_mismatch"), h.Key, uri)
+	}
+	provider, key, err := ParseKey(uri)
+	if err != nil {
+		return nil, err
+	}
+	var plain []byte
+	switch provider {
+	case "aws-kms://":
+		id, ok := awsKeyID(key, h.KeyID)
+		if !ok {
+			return nil, fmt.Errorf(i18n.T("err.kms_key_id"), h.KeyID, uri)
+		}
+		plain, err = awsDecrypt(id, h.WrappedKey)
+	case "azure-kv://":
+		keyURL, ok := azureKeyURL(key, h.KeyID)
+		if !ok {
+			return nil, fmt.Errorf(i18n.T("err.kms_key_id"), h.KeyID, uri)
+		}
+		plain, err = azureUnwrap(keyURL, h.WrappedKey)
+	default:
+		plain, err = gcpDecrypt(key, h.WrappedKey)
+	}
+	if err == nil && len(plain) != 32 {
+		err = fmt.Errorf("data key of %d bytes", len(plain))
+	}
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("err.kms_unwrap"), h.Key, err)
+	}
+	return plain, nil
+}
+
+// IsEnvelope reports whether a file starting with prefix is envelope-encrypted.
+func IsEnvelope(prefix []byte) bool {
+	return bytes.HasPrefix(prefix, []byte(Magic))
+}
+
+// WriteHeader writes magic, header and nonce to w; the ciphertext follows.
+func WriteHeader(w io.Writer, h Header, nonce []byte) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 0, len(Magic)+4+len(data)+len(nonce))
+	buf = append(buf, Magic...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(data)))
+	buf = append(append(buf, data...), nonce...)
+	_, err = w.Write(buf)
+	return err
+}
+
+// ReadHeader reads magic, header and nonce from r (exactly, nothing beyond) and returns the number of bytes read,
+// i.e. the offset of the ciphertext.
+func ReadHeader(r io.Reader) (h Header, nonce []byte, n int64, err error) {
+	fixed := make([]byte, len(Magic)+4)
+	if _, err := io.ReadFull(r, fixed); err != nil || !IsEnvelope(fixed) {
+		return Header{}, nil, 0, errors.New(i18n.T("err.kms_header"))
+	}
+	size := binary.BigEndian.Uint32(fixed[len(Magic):])
+	if size == 0 || size > maxHeader {
+		return Header{}, nil, 0, errors.New(i18n.T("err.kms_header"))
+	}
+	rest := make([]byte, int(size)+NonceLen)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return Header{}, nil, 0, errors.New(i18n.T("err.kms_header"))
+	}
+	if err := json.Unmarshal(rest[:size], &h); err != nil || h.Cipher != Cipher || len(h.WrappedKey) == 0 {
+		return Header{}, nil, 0, errors.New(i18n.T("err.kms_header"))
+	}
+	return h, rest[size:], int64(len(fixed) + len(rest)), nil
+}
+
+// run executes a cloud CLI with stdin and returns its stdout; replaced in tests.
+var run = func(stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return out, nil
+}
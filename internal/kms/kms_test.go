@@ -0,0 +1,134 @@
+package kms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	for uri, ok := range map[string]bool{
+		"aws-kms://alias/backup": true,
+		"aws-kms://arn:aws:kms:eu-central-1:123456789012:key/1234abcd":  true,
+		"azure-kv://myvault.vault.azure.net/keys/backup":                true,
+		"azure-kv://myvault.vault.azure.net":                            false,
+		"gcp-kms://projects/p/locations/europe/keyRings/r/cryptoKeys/k": true,
+		"gcp-kms://p/r/k": false,
+		"aws-kms://":      false,
+		"vault://x":       false,
+	} {
+		if _, _, err := ParseKey(uri); (err == nil) != ok {
+			t.Errorf("ParseKey(%q): %v", uri, err)
+		}
+	}
+}
+
+func TestHeader(t *testing.T) {
+	h := Header{Key: "gcp-kms://projects/p/locations/l/keyRings/r/cryptoKeys/k", WrappedKey: []byte("wrapped"), Cipher: Cipher}
+	nonce := bytes.Repeat([]byte{7}, NonceLen)
+	var buf bytes.Buffer
+	if err := WriteHeader(&buf, h, nonce); err != nil {
+		t.Fatal(err)
+	}
+	size := buf.Len()
+	buf.WriteString("ciphertext")
+	if !IsEnvelope(buf.Bytes()) {
+		t.Fatal("IsEnvelope = false")
+	}
+	got, gotNonce, n, err := ReadHeader(&buf)
+	if err != nil || got.Key != h.Key || string(got.WrappedKey) != "wrapped" || !bytes.Equal(gotNonce, nonce) || n != int64(size) {
+		t.Fatalf("ReadHeader = %+v %x %d %v", got, gotNonce, n, err)
+	}
+	if buf.String() != "ciphertext" {
+		t.Errorf("ReadHeader read beyond the header: %q left", buf.String())
+	}
+	if _, _, _, err := ReadHeader(strings.NewReader("PK\x03\x04 not an envelope")); err == nil {
+		t.Error("ReadHeader accepted a ZIP")
+	}
+}
+
+func TestAWS(t *testing.T) {
+	plain := bytes.Repeat([]byte{1}, 32)
+	var calls [][]string
+	orig := run
+	defer func() { run = orig }()
+	run = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		calls = append(calls, append([]string{name}, args...))
+		switch args[1] {
+		case "generate-data-key":
+			return []byte(fmt.Sprintf(`{"CiphertextBlob": %q, "Plaintext": %q, "KeyId": "arn:aws:kms:eu-west-1:1:key/k"}`,
+				base64.StdEncoding.EncodeToString([]byte("blob")), base64.StdEncoding.EncodeToString(plain))), nil
+		case "decrypt":
+			return []byte(fmt.Sprintf(`{"Plaintext": %q}`, base64.StdEncoding.EncodeToString(plain))), nil
+		}
+		return nil, fmt.Errorf("unexpected %v", args)
+	}
+	key, h, err := NewDataKey("aws-kms://alias/backup")
+	if err != nil || !bytes.Equal(key, plain) || string(h.WrappedKey) != "blob" {
+		t.Fatalf("NewDataKey = %x %+v %v", key, h, err)
+	}
+	key, err = h.DataKey("aws-kms://alias/backup")
+	if err != nil || !bytes.Equal(key, plain) {
+		t.Fatalf("DataKey = %x %v", key, err)
+	}
+	// Alias: AWS prüft das Chiffrat gegen den konfigurierten Schlüssel, die Key-ARN des Headers zählt nicht
+	if last := calls[len(calls)-1]; !slices.Contains(last, "alias/backup") || slices.Contains(last, h.KeyID) {
+		t.Errorf("decrypt call %v", last)
+	}
+	// Key-ID konfiguriert: die gemeldete ARN desselben Schlüssels wählt die Region
+	h = Header{Key: "aws-kms://k", KeyID: "arn:aws:kms:eu-west-1:1:key/k", WrappedKey: []byte("blob"), Cipher: Cipher}
+	if _, err := h.DataKey("aws-kms://k"); err != nil {
+		t.Fatal(err)
+	}
+	if last := calls[len(calls)-1]; !slices.Contains(last, "eu-west-1") || !slices.Contains(last, h.KeyID) {
+		t.Errorf("decrypt call %v", last)
+	}
+	n := len(calls)
+	h.KeyID = "arn:aws:kms:eu-west-1:666:key/other"
+	if _, err := h.DataKey("aws-kms://k"); err == nil || len(calls) != n {
+		t.Errorf("foreign key ARN accepted: %v", err)
+	}
+}
+
+func TestDataKeyUntrustedHeader(t *testing.T) {
+	orig := run
+	defer func() { run = orig }()
+	run = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		t.Fatalf("KMS called: %s %v", name, args)
+		return nil, nil
+	}
+	const azure = "azure-kv://myvault.vault.azure.net/keys/backup"
+	for _, h := range []Header{
+		{Key: "azure-kv://attacker.example/keys/backup"},
+		{Key: azure, KeyID: "https://attacker.example/keys/backup/1"},
+		{Key: azure, KeyID: "https://myvault.vault.azure.net/keys/other/1"},
+		{Key: azure, KeyID: "http://myvault.vault.azure.net/keys/backup/1"},
+	} {
+		h.WrappedKey, h.Cipher = []byte("w"), Cipher
+		if _, err := h.DataKey(azure); err == nil {
+			t.Errorf("DataKey accepted %+v", h)
+		}
+	}
+	if _, err := (Header{Key: azure, WrappedKey: []byte("w"), Cipher: Cipher}).DataKey(""); err == nil {
+		t.Error("DataKey without remote_kms_key")
+	}
+}
+
+func TestAzureKeyURL(t *testing.T) {
+	for _, c := range []struct{ key, kid, want string }{
+		{"v.vault.azure.net/keys/backup", "", "https://v.vault.azure.net/keys/backup"},
+		{"v.vault.azure.net/keys/backup", "https://v.vault.azure.net/keys/backup/abc", "https://v.vault.azure.net/keys/backup/abc"},
+		{"v.vault.azure.net/keys/backup/abc", "https://v.vault.azure.net/keys/backup/abc", "https://v.vault.azure.net/keys/backup/abc"},
+		{"v.vault.azure.net/keys/backup/abc", "https://v.vault.azure.net/keys/backup/def", ""},
+		{"v.vault.azure.net/keys/backup", "https://v.vault.azure.net.attacker.example/keys/backup/abc", ""},
+		{"v.vault.azure.net/keys/backup", "https://v.vault.azure.net/keys/backup/abc?x=1", ""},
+		{"v.vault.azure.net/keys/backup", "https://user@v.vault.azure.net/keys/backup/abc", ""},
+	} {
+		if got, ok := azureKeyURL(c.key, c.kid); got != c.want || ok != (c.want != "") {
+			t.Errorf("azureKeyURL(%q, %q) = %q, %v", c.key, c.kid, got, ok)
+		}
+	}
+}
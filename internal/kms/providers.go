@@ -0,0 +1,170 @@
+package kms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AWS KMS: the aws CLI generates the data key (GenerateDataKey) and decrypts it again; a key ARN selects its
+// region, otherwise the region of the CLI configuration applies.
+
+func awsArgs(key string, args ...string) []string {
+	if parts := strings.Split(key, ":"); len(parts) > 4 && parts[0] == "arn" && parts[2] == "kms" {
+		args = append(args, "--region", parts[3])
+	}
+	return append(args, "--output", "json")
+}
+
+func awsGenerate(key string) (plain, wrapped []byte, keyID string, err error) {
+	out, err := run(nil, "aws", awsArgs(key, "kms", "generate-data-key", "--key-id", key, "--key-spec", "AES_256")...)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	var resp struct {
+		CiphertextBlob, Plaintext, KeyId string
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, nil, "", err
+	}
+	if plain, err = base64.StdEncoding.DecodeString(resp.Plaintext); err != nil {
+		return nil, nil, "", err
+	}
+	if wrapped, err = base64.StdEncoding.DecodeString(resp.CiphertextBlob); err != nil {
+		return nil, nil, "", err
+	}
+	return plain, wrapped, resp.KeyId, nil
+}
+
+// awsKeyID returns the key of the decrypt call for the configured key and the key ID of a header: the reported key
+// ARN if it is the configured key (ARN or key ID), which selects its region, the configured key for an alias (AWS
+// checks the ciphertext against it). false if the key ID names another key.
+func awsKeyID(key, keyID string) (string, bool) {
+	switch {
+	case keyID == "" || keyID == key:
+		return key, true
+	case strings.HasPrefix(key, "alias/") || strings.Contains(key, ":alias/"):
+		return key, true
+	case !strings.HasPrefix(key, "arn:") && strings.HasPrefix(keyID, "arn:aws") && strings.HasSuffix(keyID, ":key/"+key):
+		return keyID, true
+	}
+	return "", false
+}
+
+func awsDecrypt(key string, wrapped []byte) ([]byte, error) {
+	// Der CLI liest Binärdaten nur aus Dateien (fileb://)
+	f, err := os.CreateTemp("", "mysqlbackup-kms-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(wrapped)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	out, err := run(nil, "aws", awsArgs(key, "kms", "decrypt", "--key-id", key, "--ciphertext-blob", "fileb://"+filepath.ToSlash(f.Name()))...)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct{ Plaintext string }
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp.Plaintext)
+}
+
+// Azure Key Vault: wrapkey/unwrapkey (RSA-OAEP-256) over the REST API, so the data key never appears on a command
+// line; the access token comes from AZURE_KEYVAULT_TOKEN or the az CLI (az login, also --identity).
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+func azureToken() (string, error) {
+	if t := os.Getenv("AZURE_KEYVAULT_TOKEN"); t != "" {
+		return t, nil
+	}
+	out, err := run(nil, "az", "account", "get-access-token", "--resource", "https://vault.azure.net", "--query", "accessToken", "--output", "tsv")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// azureCall posts value to <keyURL>/<op> and returns the key ID and value of the answer.
+func azureCall(keyURL, op string, value []byte) (kid string, result []byte, err error) {
+	token, err := azureToken()
+	if err != nil {
+		return "", nil, err
+	}
+	body, _ := json.Marshal(map[string]string{"alg": "RSA-OAEP-256", "value": base64.RawURLEncoding.EncodeToString(value)})
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(keyURL, "/")+"/"+op+"?api-version=7.4", bytes.NewReader(body))
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("%s: %s", op, resp.Status)
+	}
+	var answer struct{ Kid, Value string }
+	if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil {
+		return "", nil, err
+	}
+	result, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(answer.Value, "="))
+	return answer.Kid, result, err
+}
+
+func azureWrap(key string, plain []byte) (wrapped []byte, kid string, err error) {
+	kid, wrapped, err = azureCall("https://"+key, "wrapkey", plain)
+	return wrapped, kid, err
+}
+
+// azureKeyURL returns the key URL to unwrap with for the configured key (<vault host>/keys/<name>[/<version>]) and
+// the kid of a header: the kid rebuilt on the configured vault if it is a version of the configured key, so after a
+// key rotation the old version still unwraps. false if the kid names another host, key or version.
+func azureKeyURL(key, kid string) (string, bool) {
+	if kid == "" {
+		return "https://" + key, true
+	}
+	host, path, _ := strings.Cut(key, "/")
+	want := strings.Split(strings.Trim(path, "/"), "/") // keys, <name>[, <version>]
+	u, err := url.Parse(kid)
+	if err != nil || u.Scheme != "https" || u.User != nil || u.RawQuery != "" || u.Fragment != "" || !strings.EqualFold(u.Host, host) {
+		return "", false
+	}
+	got := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(want) < 2 || len(got) != 3 || got[0] != "keys" || !strings.EqualFold(got[1], want[1]) || got[2] == "" ||
+		(len(want) > 2 && got[2] != want[2]) {
+		return "", false
+	}
+	return "https://" + host + "/keys/" + got[1] + "/" + got[2], true
+}
+
+func azureUnwrap(keyURL string, wrapped []byte) ([]byte, error) {
+	_, plain, err := azureCall(keyURL, "unwrapkey", wrapped)
+	return plain, err
+}
+
+// Google Cloud KMS: gcloud kms encrypt/decrypt over stdin/stdout; the ciphertext names the key version itself.
+
+func gcpEncrypt(key string, plain []byte) ([]byte, error) {
+	return run(plain, "gcloud", "kms", "encrypt", "--key", key, "--plaintext-file", "-", "--ciphertext-file", "-")
+}
+
+func gcpDecrypt(key string, wrapped []byte) ([]byte, error) {
+	return run(wrapped, "gcloud", "kms", "decrypt", "--key", key, "--ciphertext-file", "-", "--plaintext-file", "-")
+}
@@ -0,0 +1,68 @@
+// Package lock prevents overlapping backup runs with an OS file lock on backup_dir/mysqlbackup.lock.
+// The lock is released by the OS when the process ends, so a crashed run never leaves a stale lock.
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/errcode"
+)
+
+// FileName is the lock file in backup_dir.
+const FileName = "mysqlbackup.lock"
+
+// ErrLocked is returned (wrapped, with the holder) when another run holds the lock.
+var ErrLocked = errors.New("another backup run is active")
+
+// pollInterval between attempts while waiting for the lock.
+const pollInterval = time.Second
+
+// Lock is a held run lock.
+type Lock struct {
+	f *os.File
+}
+
+// Acquire takes the lock in dir, waiting up to wait (0 = do not wait). The file records PID and start
+// time of the holder for the error message of a second run.
+func Acquire(dir string, wait time.Duration) (*Lock, error) {
+	path := filepath.Join(filepath.FromSlash(dir), FileName)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(wait)
+	for {
+		err := tryLock(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errWouldBlock) {
+			f.Close()
+			return nil, err
+		}
+		if !time.Now().Before(deadline) {
+			holder, _ := os.ReadFile(path)
+			f.Close()
+			return nil, errcode.Wrap(errcode.Locked, fmt.Errorf("%w (%s)", ErrLocked, strings.TrimSpace(string(holder))))
+		}
+		time.Sleep(pollInterval)
+	}
+	_ = f.Truncate(0)
+	_, _ = f.WriteAt([]byte(fmt.Sprintf("pid %d, started %s\n", os.Getpid(), time.Now().Format("2006-01-02 15:04:05"))), 0)
+	return &Lock{f: f}, nil
+}
+
+// Release unlocks and closes the lock file. The file itself stays (removing it would race a waiting run).
+func (l *Lock) Release() {
+	if l == nil || l.f == nil {
+		return
+	}
+	_ = unlock(l.f)
+	_ = l.f.Close()
+	l.f = nil
+}
@@ -0,0 +1,23 @@
+package lock
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAcquireTwice(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Acquire(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Acquire(dir, 0); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second Acquire: got %v, want ErrLocked", err)
+	}
+	l.Release()
+	l2, err := Acquire(dir, 0)
+	if err != nil {
+		t.Fatalf("after Release: %v", err)
+	}
+	l2.Release()
+}
@@ -0,0 +1,54 @@
+package logger
+
+import "os"
+
+// ANSI escape sequences for colored console output.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+)
+
+// levelColors colors WARN and ERROR lines on the console.
+var levelColors = [...]string{LevelWarn: ansiYellow, LevelError: ansiRed}
+
+// Interactive reports whether stdout is a terminal (not a pipe, file or the scheduler's null device).
+func Interactive() bool {
+	return isTerminal(os.Stdout)
+}
+
+// ColorSupported reports whether stdout accepts ANSI colors: a terminal, NO_COLOR not set and, on Windows,
+// virtual terminal processing enabled.
+func ColorSupported() bool {
+	return os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout) && enableColor(os.Stdout)
+}
+
+// VirtualTerminal reports whether stdout is a terminal that interprets ANSI escape sequences (cursor movement
+// of --tui; on Windows virtual terminal processing is switched on), regardless of NO_COLOR.
+func VirtualTerminal() bool {
+	return isTerminal(os.Stdout) && enableColor(os.Stdout)
+}
+
+// SetColor enables colored WARN/ERROR lines on the console (see ColorSupported).
+func (l *Logger) SetColor(on bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.color = on
+}
+
+// Heading returns s in bold when on (section headers of --status).
+func Heading(s string, on bool) string {
+	if !on {
+		return s
+	}
+	return ansiBold + s + ansiReset
+}
+
+// colorize wraps line (without the trailing newline) in the color of level.
+func colorize(level Level, line string) string {
+	if int(level) >= len(levelColors) || levelColors[level] == "" {
+		return line
+	}
+	return levelColors[level] + line + ansiReset
+}
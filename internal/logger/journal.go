@@ -0,0 +1,26 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+)
+
+// journalPriority maps log levels to syslog priorities (journald field PRIORITY).
+var journalPriority = [...]string{LevelDebug: "7", LevelInfo: "6", LevelWarn: "4", LevelError: "3"}
+
+// journalEntry encodes fields in the journald native protocol: KEY=value lines; values containing a
+// newline use the binary form KEY\n<64-bit little-endian length><value>\n.
+func journalEntry(fields [][2]string) []byte {
+	var b bytes.Buffer
+	for _, f := range fields {
+		if !strings.Contains(f[1], "\n") {
+			b.WriteString(f[0] + "=" + f[1] + "\n")
+			continue
+		}
+		b.WriteString(f[0] + "\n")
+		_ = binary.Write(&b, binary.LittleEndian, uint64(len(f[1])))
+		b.WriteString(f[1] + "\n")
+	}
+	return b.Bytes()
+}
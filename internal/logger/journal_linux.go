@@ -0,0 +1,36 @@
+//go:build linux
+
+package logger
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+const journalSocket = "/run/systemd/journal/socket"
+
+type journal struct {
+	conn *net.UnixConn
+}
+
+// openJournal connects to journald if the process runs under systemd with its output going to the journal
+// (JOURNAL_STREAM is set by systemd for services).
+func openJournal() (systemLog, error) {
+	if os.Getenv("JOURNAL_STREAM") == "" {
+		return nil, errors.New("not started by systemd")
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &journal{conn: conn}, nil
+}
+
+func (j *journal) write(level Level, text string, fields [][2]string) error {
+	all := append([][2]string{{"MESSAGE", text}, {"PRIORITY", journalPriority[level]}, {"SYSLOG_IDENTIFIER", "mysqlbackup"}}, fields...)
+	_, err := j.conn.Write(journalEntry(all))
+	return err
+}
+
+func (j *journal) close() error { return j.conn.Close() }
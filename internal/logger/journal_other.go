@@ -0,0 +1,7 @@
+//go:build !linux
+
+package logger
+
+import "errors"
+
+func openJournal() (systemLog, error) { return nil, errors.New("journald is only available on Linux") }
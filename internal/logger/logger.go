@@ -1,20 +1,86 @@
-// Package logger provides file-based append logging for mysqlbackup.
+// Package logger provides leveled logging for mysqlbackup to a log file, the console and the system log.
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/janmz/mysqlbackup/internal/errcode"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// Level is the minimum severity a sink writes.
+type Level int
+
+// Levels in increasing severity; LevelOff disables a sink.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelOff
+)
+
+var levelNames = [...]string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// ParseLevel parses "debug", "info", "warn"/"warning", "error" or "off" (case-insensitive).
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	case "off":
+		return LevelOff, nil
+	}
+	return LevelOff, fmt.Errorf(i18n.T("err.log_level"), s)
+}
+
+// Sink is one output of a Logger; each has its own level (see SetLevel).
+type Sink int
+
+// Sinks: console (stdout), log file, system log (systemd journal or local syslog daemon).
+const (
+	SinkConsole Sink = iota
+	SinkFile
+	SinkSyslog
+	numSinks
 )
 
-// Logger writes lines to a file with optional stdout echo.
+// Logger writes lines to a log file, stdout and optionally the system log, each sink filtered by its level.
+// Defaults: console and file info, system log off (until UseJournal/UseSyslog).
 type Logger struct {
-	f       *os.File
-	mu      sync.Mutex
-	echo    bool
-	Verbose bool // when true, Debug() writes [DEBUG] lines
+	f      *os.File
+	path   string
+	mu     sync.Mutex
+	levels [numSinks]Level
+	JSON   bool // when true, the file gets one JSON object per line (log_format "json"); stdout stays text
+
+	runID string    // set per backup run (JSON field run_id)
+	db    string    // database currently processed (JSON field db)
+	sys   systemLog // journal or syslog (see UseJournal, UseSyslog)
+
+	warnings []string // messages of all Warn calls (for notify_level "warnings")
+	secrets  []string // masked in all output (see SetSecrets)
+	color    bool     // colored WARN/ERROR lines on the console (see SetColor)
+
+	recent    []byte // last lines for error notifications (see Recent)
+	recentMax int
+}
+
+// systemLog is the system log sink: the systemd journal (structured fields) or the local syslog daemon.
+type systemLog interface {
+	write(level Level, text string, fields [][2]string) error
+	close() error
 }
 
 // New opens or creates the log file for appending. Creates parent dirs if needed.
@@ -27,33 +93,225 @@ func New(path string) (*Logger, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Logger{f: f, echo: true}, nil
+	return &Logger{f: f, path: path, levels: [numSinks]Level{LevelInfo, LevelInfo, LevelOff}}, nil
+}
+
+// SetLevel sets the minimum level of a sink (LevelOff disables it).
+func (l *Logger) SetLevel(s Sink, lvl Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.levels[s] = lvl
+}
+
+// Level returns the minimum level of a sink.
+func (l *Logger) Level(s Sink) Level {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.levels[s]
+}
+
+// DebugEnabled reports whether any sink writes debug lines (e.g. to skip expensive debug output).
+func (l *Logger) DebugEnabled() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.debugEnabled()
+}
+
+func (l *Logger) debugEnabled() bool {
+	for s, lvl := range l.levels {
+		if lvl == LevelDebug && (Sink(s) != SinkSyslog || l.sys != nil) {
+			return true
+		}
+	}
+	return false
 }
 
-func (l *Logger) write(level, format string, a ...interface{}) {
+// write logs one line; code (errcode, may be "") is put in front of the text and into the JSON/system log field.
+func (l *Logger) write(level Level, code errcode.Code, format string, a ...interface{}) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	line := fmt.Sprintf("%s [%s] %s\n", time.Now().Format(time.RFC3339), level, fmt.Sprintf(format, a...))
-	_, _ = l.f.WriteString(line)
-	if l.echo {
-		fmt.Print(line)
+	now := time.Now()
+	raw := fmt.Sprintf(format, a...) // unmasked, only for the key lookup (i18n.Source)
+	text := errcode.Tag(code, l.redact(raw))
+	line := fmt.Sprintf("%s [%s] %s\n", now.Format(time.RFC3339), levelNames[level], text)
+	if level >= LevelInfo || l.debugEnabled() {
+		l.addRecent(line)
+	}
+	if level >= l.levels[SinkFile] && l.f != nil {
+		if l.JSON {
+			_, _ = l.f.Write(l.jsonLine(now, level, code, raw))
+		} else {
+			_, _ = l.f.WriteString(line)
+		}
+	}
+	if level >= l.levels[SinkConsole] {
+		if l.color {
+			fmt.Print(colorize(level, strings.TrimSuffix(line, "\n")) + "\n")
+		} else {
+			fmt.Print(line)
+		}
 	}
+	if l.sys != nil && level >= l.levels[SinkSyslog] {
+		l.writeSystem(level, code, raw)
+	}
+}
+
+// UseJournal makes the systemd journal the system log sink, if the process runs as a systemd service.
+// Returns false if the journal is not available.
+func (l *Logger) UseJournal() bool {
+	return l.useSystem(openJournal())
+}
+
+// UseSyslog makes the local syslog daemon the system log sink (not on Windows). Returns false if it is not available.
+func (l *Logger) UseSyslog() bool {
+	return l.useSystem(openSyslog())
+}
+
+func (l *Logger) useSystem(sys systemLog, err error) bool {
+	if err != nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sys != nil {
+		_ = l.sys.close()
+	}
+	l.sys = sys
+	return true
+}
+
+// writeSystem sends one entry with the fields of the JSON log (MYSQLBACKUP_KEY, _DB, _RUN_ID) to the system log.
+// raw is the unmasked message (for the key lookup); secrets are masked before sending.
+func (l *Logger) writeSystem(level Level, code errcode.Code, raw string) {
+	var fields [][2]string
+	if msg, ok := i18n.Source(raw); ok {
+		fields = append(fields, [2]string{"MYSQLBACKUP_KEY", msg.Key})
+	}
+	if code != "" {
+		fields = append(fields, [2]string{"MYSQLBACKUP_CODE", string(code)})
+	}
+	text := errcode.Tag(code, l.redact(raw))
+	if l.db != "" {
+		fields = append(fields, [2]string{"MYSQLBACKUP_DB", l.db})
+	}
+	if l.runID != "" {
+		fields = append(fields, [2]string{"MYSQLBACKUP_RUN_ID", l.runID})
+	}
+	if err := l.sys.write(level, text, fields); err != nil {
+		// System-Log nicht mehr erreichbar: abschalten, Konsole übernimmt wieder
+		_ = l.sys.close()
+		l.sys = nil
+		if l.levels[SinkConsole] == LevelOff {
+			l.levels[SinkConsole] = l.levels[SinkSyslog]
+			fmt.Printf("%s [%s] %s\n", time.Now().Format(time.RFC3339), levelNames[level], text)
+		}
+	}
+}
+
+// jsonEntry is one line of the JSON log (log_format "json").
+type jsonEntry struct {
+	Timestamp string        `json:"timestamp"`
+	Level     string        `json:"level"`
+	Key       string        `json:"key,omitempty"`  // translation key of the message (language-independent)
+	Code      string        `json:"code,omitempty"` // stable error code (MB-xxxx, see errcode)
+	Message   string        `json:"message"`
+	Params    []interface{} `json:"params,omitempty"`
+	DB        string        `json:"db,omitempty"`
+	RunID     string        `json:"run_id,omitempty"`
+}
+
+// jsonLine builds the JSON entry for the unmasked message raw; message and string parameters are masked.
+func (l *Logger) jsonLine(now time.Time, level Level, code errcode.Code, raw string) []byte {
+	text := l.redact(raw)
+	e := jsonEntry{Timestamp: now.Format(time.RFC3339Nano), Level: strings.ToLower(levelNames[level]), Code: string(code), Message: text, DB: l.db, RunID: l.runID}
+	if msg, ok := i18n.Source(raw); ok {
+		e.Key = msg.Key
+		for _, p := range msg.Params {
+			v := jsonParam(p)
+			if s, ok := v.(string); ok {
+				v = l.redact(s)
+			}
+			e.Params = append(e.Params, v)
+		}
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		b, _ = json.Marshal(jsonEntry{Timestamp: e.Timestamp, Level: e.Level, Code: e.Code, Message: text, DB: l.db, RunID: l.runID})
+	}
+	return append(b, '\n')
+}
+
+// jsonParam keeps numbers, strings and booleans; errors, durations and other values become their text.
+func jsonParam(p interface{}) interface{} {
+	switch v := p.(type) {
+	case nil, string, bool, int, int32, int64, uint, uint32, uint64, float32, float64:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// SetRunID sets the run_id written with each JSON line ("" = none).
+func (l *Logger) SetRunID(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.runID = id
+}
+
+// SetDB sets the database written with each JSON line while it is processed ("" = none).
+func (l *Logger) SetDB(db string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.db = db
 }
 
 // Info logs an info message.
-func (l *Logger) Info(format string, a ...interface{}) { l.write("INFO", format, a...) }
+func (l *Logger) Info(format string, a ...interface{}) { l.write(LevelInfo, "", format, a...) }
 
-// Warn logs a warning.
-func (l *Logger) Warn(format string, a ...interface{}) { l.write("WARN", format, a...) }
+// Warn logs a warning and remembers it (see Warnings).
+func (l *Logger) Warn(format string, a ...interface{}) {
+	l.write(LevelWarn, "", format, a...)
+	l.mu.Lock()
+	l.warnings = append(l.warnings, l.redact(fmt.Sprintf(format, a...)))
+	l.mu.Unlock()
+}
 
-// Error logs an error.
-func (l *Logger) Error(format string, a ...interface{}) { l.write("ERROR", format, a...) }
+// WarnCount returns the number of warnings logged so far (remembered at the start of a run, see Warnings).
+func (l *Logger) WarnCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.warnings)
+}
 
-// Debug logs a debug message only when Verbose is true (prefix [DEBUG]).
-func (l *Logger) Debug(format string, a ...interface{}) {
-	if l.Verbose {
-		l.write("DEBUG", format, a...)
+// Warnings returns the warnings logged after the first from ones (from = WarnCount at the start of a run).
+func (l *Logger) Warnings(from int) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if from < 0 || from >= len(l.warnings) {
+		return nil
 	}
+	return append([]string(nil), l.warnings[from:]...)
+}
+
+// Error logs an error.
+func (l *Logger) Error(format string, a ...interface{}) { l.write(LevelError, "", format, a...) }
+
+// ErrorCode logs an error with its code (errcode.Of of the error; "" = like Error): "[MB-0421] " in front of the
+// text, field "code" in the JSON log and MYSQLBACKUP_CODE in the system log.
+func (l *Logger) ErrorCode(code errcode.Code, format string, a ...interface{}) {
+	l.write(LevelError, code, format, a...)
+}
+
+// Debug logs a debug message (prefix [DEBUG]); written only by sinks with LevelDebug.
+func (l *Logger) Debug(format string, a ...interface{}) { l.write(LevelDebug, "", format, a...) }
+
+// Path returns the log file path (e.g. to attach an excerpt to error emails).
+func (l *Logger) Path() string {
+	return l.path
 }
 
 // Close closes the log file.
@@ -63,6 +321,10 @@ func (l *Logger) Close() error {
 	if l.f == nil {
 		return nil
 	}
+	if l.sys != nil {
+		_ = l.sys.close()
+		l.sys = nil
+	}
 	err := l.f.Close()
 	l.f = nil
 	return err
@@ -0,0 +1,148 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+func TestJSONLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	l, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetLevel(SinkConsole, LevelOff)
+	l.JSON = true
+	i18n.RecordMessages(true)
+	defer i18n.RecordMessages(false)
+	l.SetRunID("run1")
+	l.SetDB("shop")
+	l.Warn(i18n.Tf("log.warn.email", errors.New("smtp down")))
+	l.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e jsonEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		t.Fatalf("%v: %s", err, data)
+	}
+	if e.Level != "warn" || e.Key != "log.warn.email" || e.DB != "shop" || e.RunID != "run1" {
+		t.Errorf("entry = %+v", e)
+	}
+	if len(e.Params) != 1 || e.Params[0] != "smtp down" || !strings.Contains(e.Message, "smtp down") {
+		t.Errorf("params = %v, message = %q", e.Params, e.Message)
+	}
+	if got := l.Warnings(0); len(got) != 1 {
+		t.Errorf("Warnings = %v", got)
+	}
+}
+
+func TestJournalEntry(t *testing.T) {
+	got := journalEntry([][2]string{{"PRIORITY", "4"}, {"MESSAGE", "a\nb"}})
+	want := "PRIORITY=4\nMESSAGE\n\x03\x00\x00\x00\x00\x00\x00\x00a\nb\n"
+	if string(got) != want {
+		t.Errorf("journalEntry = %q, want %q", got, want)
+	}
+}
+
+func TestSinkLevels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	l, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetLevel(SinkConsole, LevelOff)
+	if l.DebugEnabled() {
+		t.Error("debug enabled by default")
+	}
+	l.Debug("hidden")
+	l.SetLevel(SinkFile, LevelDebug)
+	l.Debug("shown")
+	l.SetLevel(SinkFile, LevelWarn)
+	l.Info("filtered")
+	l.Error("failed")
+	l.Close()
+	data, _ := os.ReadFile(path)
+	got := string(data)
+	if strings.Contains(got, "hidden") || strings.Contains(got, "filtered") || !strings.Contains(got, "[DEBUG] shown") || !strings.Contains(got, "[ERROR] failed") {
+		t.Errorf("log file:\n%s", got)
+	}
+	if lvl, err := ParseLevel("Warning"); err != nil || lvl != LevelWarn {
+		t.Errorf("ParseLevel(Warning) = %v, %v", lvl, err)
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel(verbose): expected error")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	l, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetLevel(SinkConsole, LevelOff)
+	l.SetSecrets("", "geheim", "geheim123")
+	l.Info("exec mysqldump -uroot -pgeheim123 shop")
+	l.Warn("exec mysql --password=xyz MYSQL_PWD=abc ok")
+	l.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	for _, leak := range []string{"geheim", "123", "xyz", "abc"} {
+		if strings.Contains(out, leak) {
+			t.Errorf("%q not masked:\n%s", leak, out)
+		}
+	}
+	if !strings.Contains(out, "-p***") || !strings.Contains(out, "--password=*** MYSQL_PWD=*** ok") {
+		t.Errorf("log = %s", out)
+	}
+	if w := l.Warnings(0); len(w) != 1 || strings.Contains(w[0], "xyz") {
+		t.Errorf("warnings = %q", w)
+	}
+}
+
+func TestColorize(t *testing.T) {
+	if got := colorize(LevelError, "x"); got != ansiRed+"x"+ansiReset {
+		t.Errorf("error = %q", got)
+	}
+	if got := colorize(LevelInfo, "x"); got != "x" {
+		t.Errorf("info = %q", got)
+	}
+	if Heading("h", false) != "h" {
+		t.Error("heading without color")
+	}
+}
+
+func TestRecent(t *testing.T) {
+	l, err := New(filepath.Join(t.TempDir(), "test.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.SetLevel(SinkConsole, LevelOff)
+	l.SetLevel(SinkFile, LevelOff)
+	l.SetRecent(100)
+	l.Debug("hidden")
+	for i := 0; i < 20; i++ {
+		l.Info("line %02d", i)
+	}
+	got := string(l.Recent())
+	if len(got) > 100 || strings.Contains(got, "hidden") || !strings.HasSuffix(got, "line 19\n") {
+		t.Errorf("recent = %q", got)
+	}
+	if !strings.Contains(strings.SplitN(got, "\n", 2)[0], "[INFO] line") {
+		t.Errorf("recent does not start at a line boundary: %q", got)
+	}
+}
@@ -0,0 +1,48 @@
+package logger
+
+import "bytes"
+
+// SetRecent sets the size of the in-memory buffer with the last lines of this process (see Recent);
+// 0 disables it. Error notifications attach it, independent of log file, format and per-run logs.
+func (l *Logger) SetRecent(maxBytes int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.recentMax = maxBytes
+	l.trimRecent()
+}
+
+// Recent returns the last lines written by this process (at most the size set by SetRecent,
+// starting at a line boundary), as plain text with masked secrets.
+func (l *Logger) Recent() []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.recent
+	if len(b) > l.recentMax {
+		b = b[len(b)-l.recentMax:]
+		if i := bytes.IndexByte(b, '\n'); i >= 0 {
+			b = b[i+1:]
+		}
+	}
+	return append([]byte(nil), b...)
+}
+
+// addRecent appends line to the buffer; it holds up to twice the size so trimming is rare.
+func (l *Logger) addRecent(line string) {
+	if l.recentMax <= 0 {
+		return
+	}
+	l.recent = append(l.recent, line...)
+	if len(l.recent) > 2*l.recentMax {
+		l.trimRecent()
+	}
+}
+
+func (l *Logger) trimRecent() {
+	if l.recentMax <= 0 {
+		l.recent = nil
+		return
+	}
+	if len(l.recent) > l.recentMax {
+		l.recent = append(l.recent[:0], l.recent[len(l.recent)-l.recentMax:]...)
+	}
+}
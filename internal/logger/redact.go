@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// secretMask replaces secrets in every written line.
+const secretMask = "***"
+
+// passwordArgRe matches password arguments and variables of command lines (value masked, option kept).
+var passwordArgRe = regexp.MustCompile(`(?i)(--password=|MYSQL_PWD=|-NewPassword |-Password )("[^"]*"|'[^']*'|\S+)`)
+
+// SetSecrets sets the values masked in all log output (file, console, system log, Warnings), e.g. the
+// configured passwords. Empty values are ignored; longer secrets are replaced first.
+func (l *Logger) SetSecrets(secrets ...string) {
+	var list []string
+	for _, s := range secrets {
+		if s != "" {
+			list = append(list, s)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return len(list[i]) > len(list[j]) })
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.secrets = list
+}
+
+// redact masks the configured secrets and password arguments in s.
+func (l *Logger) redact(s string) string {
+	for _, secret := range l.secrets {
+		s = strings.ReplaceAll(s, secret, secretMask)
+	}
+	return passwordArgRe.ReplaceAllString(s, "${1}"+secretMask)
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// runLogRe matches the timestamp part of per-run log file names (name_YYYYMMDD_HHMMSS.log).
+var runLogRe = regexp.MustCompile(`^_(\d{8}_\d{6})\.log$`)
+
+// RunLogPath returns the per-run log file for mainPath (log_filename) and start time t:
+// "mysqlbackup.log" becomes "mysqlbackup_20250612_220001.log" in the same directory.
+func RunLogPath(mainPath string, t time.Time) string {
+	ext := filepath.Ext(mainPath)
+	return strings.TrimSuffix(mainPath, ext) + "_" + t.Format("20060102_150405") + ".log"
+}
+
+// PruneRunLogs deletes per-run log files of mainPath started before cutoff and returns their paths.
+func PruneRunLogs(mainPath string, cutoff time.Time) ([]string, error) {
+	dir := filepath.Dir(mainPath)
+	base := strings.TrimSuffix(filepath.Base(mainPath), filepath.Ext(mainPath))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, base) {
+			continue
+		}
+		m := runLogRe.FindStringSubmatch(strings.TrimPrefix(name, base))
+		if m == nil {
+			continue
+		}
+		t, err := time.ParseInLocation("20060102_150405", m[1], time.Local)
+		if err != nil || !t.Before(cutoff) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
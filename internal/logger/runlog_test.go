@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunLogs(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "mysqlbackup.log")
+	start := time.Date(2025, 6, 12, 22, 0, 1, 0, time.Local)
+	if got := filepath.Base(RunLogPath(main, start)); got != "mysqlbackup_20250612_220001.log" {
+		t.Fatalf("RunLogPath = %s", got)
+	}
+	for _, name := range []string{"mysqlbackup.log", "mysqlbackup_20250501_220000.log", "mysqlbackup_20250612_220001.log", "mysqlbackup_notes.log", "other_20250101_000000.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	removed, err := PruneRunLogs(main, start.AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || filepath.Base(removed[0]) != "mysqlbackup_20250501_220000.log" {
+		t.Errorf("removed = %v", removed)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !windows && !plan9
+
+package logger
+
+import "log/syslog"
+
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+func openSyslog() (systemLog, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, "mysqlbackup")
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{w: w}, nil
+}
+
+// write sends text with the priority of level; the structured fields are not supported by syslog.
+func (s *syslogSink) write(level Level, text string, fields [][2]string) error {
+	switch level {
+	case LevelDebug:
+		return s.w.Debug(text)
+	case LevelWarn:
+		return s.w.Warning(text)
+	case LevelError:
+		return s.w.Err(text)
+	}
+	return s.w.Info(text)
+}
+
+func (s *syslogSink) close() error { return s.w.Close() }
@@ -0,0 +1,8 @@
+//go:build windows
+
+package logger
+
+import "errors"
+
+// openSyslog: Windows has no syslog daemon (see windows_event_log for the event log).
+func openSyslog() (systemLog, error) { return nil, errors.New("syslog is not available on Windows") }
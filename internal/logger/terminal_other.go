@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly && !windows
+
+package logger
+
+import "os"
+
+func isTerminal(*os.File) bool { return false }
+
+func enableColor(*os.File) bool { return false }
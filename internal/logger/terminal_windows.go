@@ -0,0 +1,36 @@
+//go:build windows
+
+package logger
+
+import (
+	"os"
+	"syscall"
+)
+
+// enableVirtualTerminalProcessing lets the console interpret ANSI escape sequences (Windows 10 and later).
+const enableVirtualTerminalProcessing = 0x0004
+
+var (
+	kernel32       = syscall.NewLazyDLL("kernel32.dll")
+	setConsoleMode = kernel32.NewProc("SetConsoleMode")
+)
+
+// isTerminal reports whether f is a console (not redirected, not started by the Task Scheduler).
+func isTerminal(f *os.File) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(f.Fd()), &mode) == nil
+}
+
+// enableColor switches on virtual terminal processing for f; false on older consoles.
+func enableColor(f *os.File) bool {
+	var mode uint32
+	h := syscall.Handle(f.Fd())
+	if syscall.GetConsoleMode(h, &mode) != nil {
+		return false
+	}
+	if mode&enableVirtualTerminalProcessing != 0 {
+		return true
+	}
+	r, _, _ := setConsoleMode.Call(uintptr(h), uintptr(mode|enableVirtualTerminalProcessing))
+	return r != 0
+}
@@ -0,0 +1,162 @@
+// Package metrics writes Prometheus metrics of the last run for the node_exporter textfile collector,
+// optionally pushes them to a Pushgateway and formats them for the /metrics endpoint of --daemon.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/catalog"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// Job is the Pushgateway job name.
+const Job = "mysqlbackup"
+
+// Run holds the values exported after a run.
+type Run struct {
+	Started     time.Time // zero = no run yet (no run metrics)
+	Finished    time.Time
+	Success     bool
+	LastSuccess time.Time // zero = never succeeded
+	Failures    int       // failed runs in a row since the last success
+	// Remote sync: RemoteConfigured false = no metric; RemoteOK is the result of this run's sync.
+	RemoteConfigured bool
+	RemoteOK         bool
+	Backups          []catalog.Entry // catalog entries; the newest per database is exported
+	Daemon           *Daemon         // nil outside the /metrics endpoint
+}
+
+// Daemon holds the values only the running daemon knows.
+type Daemon struct {
+	Running bool
+	NextRun time.Time // zero = no schedule
+}
+
+// Format returns the metrics in the Prometheus text exposition format.
+func Format(r Run) []byte {
+	var b bytes.Buffer
+	gauge := func(name, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+	if d := r.Daemon; d != nil {
+		gauge("mysqlbackup_running", "1 while a backup run is in progress.")
+		fmt.Fprintf(&b, "mysqlbackup_running %d\n", boolValue(d.Running))
+		if !d.NextRun.IsZero() {
+			gauge("mysqlbackup_next_run_timestamp_seconds", "Time of the next scheduled backup run.")
+			fmt.Fprintf(&b, "mysqlbackup_next_run_timestamp_seconds %d\n", d.NextRun.Unix())
+		}
+	}
+	if !r.Started.IsZero() {
+		gauge("mysqlbackup_last_run_timestamp_seconds", "Start time of the last backup run.")
+		fmt.Fprintf(&b, "mysqlbackup_last_run_timestamp_seconds %d\n", r.Started.Unix())
+		gauge("mysqlbackup_last_run_duration_seconds", "Duration of the last backup run.")
+		fmt.Fprintf(&b, "mysqlbackup_last_run_duration_seconds %g\n", r.Finished.Sub(r.Started).Seconds())
+		gauge("mysqlbackup_last_run_success", "1 if the last backup run succeeded, 0 otherwise.")
+		fmt.Fprintf(&b, "mysqlbackup_last_run_success %d\n", boolValue(r.Success))
+	}
+	gauge("mysqlbackup_consecutive_failures", "Failed backup runs in a row since the last successful one.")
+	fmt.Fprintf(&b, "mysqlbackup_consecutive_failures %d\n", r.Failures)
+	if !r.LastSuccess.IsZero() {
+		gauge("mysqlbackup_last_success_timestamp_seconds", "End time of the last successful backup run.")
+		fmt.Fprintf(&b, "mysqlbackup_last_success_timestamp_seconds %d\n", r.LastSuccess.Unix())
+	}
+	if r.RemoteConfigured {
+		if !r.Started.IsZero() {
+			gauge("mysqlbackup_remote_sync_success", "1 if the remote sync of the last run succeeded, 0 otherwise.")
+			fmt.Fprintf(&b, "mysqlbackup_remote_sync_success %d\n", boolValue(r.RemoteOK))
+		}
+		pending := 0
+		for _, e := range r.Backups {
+			if e.RemoteAt.IsZero() {
+				pending++
+			}
+		}
+		gauge("mysqlbackup_remote_pending_uploads", "Backup ZIPs in backup_dir not yet uploaded to the remote target.")
+		fmt.Fprintf(&b, "mysqlbackup_remote_pending_uploads %d\n", pending)
+	}
+	newest, dbs := newestBackups(r.Backups)
+	if len(dbs) == 0 {
+		return b.Bytes()
+	}
+	gauge("mysqlbackup_backup_size_bytes", "Size of the newest backup ZIP per database.")
+	for _, db := range dbs {
+		fmt.Fprintf(&b, "mysqlbackup_backup_size_bytes{database=%q} %d\n", db, newest[db].Size)
+	}
+	gauge("mysqlbackup_backup_timestamp_seconds", "Creation time of the newest backup per database.")
+	for _, db := range dbs {
+		fmt.Fprintf(&b, "mysqlbackup_backup_timestamp_seconds{database=%q} %d\n", db, newest[db].Created.Unix())
+	}
+	gauge("mysqlbackup_backup_duration_seconds", "Dump duration of the newest backup per database.")
+	for _, db := range dbs {
+		fmt.Fprintf(&b, "mysqlbackup_backup_duration_seconds{database=%q} %g\n", db, float64(newest[db].DurationMS)/1000)
+	}
+	return b.Bytes()
+}
+
+// newestBackups returns the newest backup per database and the databases in sorted order.
+func newestBackups(backups []catalog.Entry) (map[string]catalog.Entry, []string) {
+	newest := make(map[string]catalog.Entry)
+	for _, e := range backups {
+		if e.Database == "" {
+			continue
+		}
+		if n, ok := newest[e.Database]; !ok || e.Created.After(n.Created) {
+			newest[e.Database] = e
+		}
+	}
+	dbs := make([]string, 0, len(newest))
+	for db := range newest {
+		dbs = append(dbs, db)
+	}
+	sort.Strings(dbs)
+	return newest, dbs
+}
+
+func boolValue(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// WriteFile writes data atomically to path (temporary file + rename), so the collector never reads a partial file.
+func WriteFile(path string, data []byte) error {
+	path = filepath.FromSlash(path)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf(i18n.T("err.metrics_write"), err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf(i18n.T("err.metrics_write"), err)
+	}
+	return nil
+}
+
+// Push replaces the metrics of job/instance on the Pushgateway at gatewayURL.
+func Push(gatewayURL, instance string, data []byte) error {
+	u := strings.TrimRight(strings.TrimSpace(gatewayURL), "/") + "/metrics/job/" + Job + "/instance/" + url.PathEscape(instance)
+	req, err := http.NewRequest(http.MethodPut, u, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf(i18n.T("err.metrics_push"), err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf(i18n.T("err.metrics_push"), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf(i18n.T("err.metrics_push_status"), resp.Status)
+	}
+	return nil
+}
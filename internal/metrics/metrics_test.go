@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/catalog"
+)
+
+func TestFormat(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	r := Run{
+		Started:          start,
+		Finished:         start.Add(90 * time.Second),
+		Success:          true,
+		LastSuccess:      start.Add(90 * time.Second),
+		RemoteConfigured: true,
+		Backups: []catalog.Entry{
+			{Database: "shop", Size: 100, Created: start.Add(-24 * time.Hour)},
+			{Database: "shop", Size: 200, Created: start, DurationMS: 1500, RemoteAt: start.Add(time.Minute)},
+			{Database: "blog", Size: 50, Created: start},
+		},
+	}
+	out := string(Format(r))
+	for _, want := range []string{
+		"mysqlbackup_last_run_timestamp_seconds 1700000000\n",
+		"mysqlbackup_last_run_duration_seconds 90\n",
+		"mysqlbackup_last_run_success 1\n",
+		"mysqlbackup_remote_sync_success 0\n",
+		"mysqlbackup_remote_pending_uploads 2\n",
+		"mysqlbackup_consecutive_failures 0\n",
+		"mysqlbackup_backup_duration_seconds{database=\"shop\"} 1.5\n",
+		"mysqlbackup_backup_size_bytes{database=\"blog\"} 50\nmysqlbackup_backup_size_bytes{database=\"shop\"} 200\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if out := string(Format(Run{Started: start, Finished: start})); strings.Contains(out, "remote_sync") || strings.Contains(out, "last_success") {
+		t.Errorf("unexpected remote or last success metric:\n%s", out)
+	}
+	out = string(Format(Run{Failures: 2, Daemon: &Daemon{Running: true, NextRun: start}}))
+	if strings.Contains(out, "last_run") || !strings.Contains(out, "mysqlbackup_running 1\n") ||
+		!strings.Contains(out, "mysqlbackup_next_run_timestamp_seconds 1700000000\n") || !strings.Contains(out, "mysqlbackup_consecutive_failures 2\n") {
+		t.Errorf("daemon metrics without a run:\n%s", out)
+	}
+}
+
+func TestStatsD(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	r := Run{
+		Started:  start,
+		Finished: start.Add(90 * time.Second),
+		Failures: 2,
+		Backups: []catalog.Entry{
+			{Database: "shop", Size: 100, Created: start.Add(-24 * time.Hour)},
+			{Database: "shop", Size: 200, Created: start, DurationMS: 1500},
+		},
+	}
+	lines := StatsD(r, "db 1")
+	want := []string{
+		"mysqlbackup.run.duration,host=db_1:90000|ms",
+		"mysqlbackup.run.failure,host=db_1:1|c",
+		"mysqlbackup.run.consecutive_failures,host=db_1:2|g",
+		"mysqlbackup.backup.size_bytes,host=db_1,database=shop:200|g",
+		"mysqlbackup.backup.duration,host=db_1,database=shop:1500|ms",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("StatsD =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer pc.Close()
+	if err := SendStatsD(pc.LocalAddr().String(), lines); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 2048)
+	_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil || string(buf[:n]) != strings.Join(want, "\n") {
+		t.Errorf("datagram = %q, %v", buf[:n], err)
+	}
+}
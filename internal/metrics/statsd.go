@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// statsdPacketSize keeps a StatsD datagram below the usual MTU, so it is not fragmented.
+const statsdPacketSize = 1400
+
+// StatsD returns the metrics of the run as StatsD lines with tags in the InfluxDB format (name,tag=value),
+// as the statsd input of Telegraf reads them: run duration (timer), success/failure counters, consecutive
+// failures and per database size and dump duration of the newest backup.
+func StatsD(r Run, host string) []string {
+	tags := ",host=" + statsdTag(host)
+	var lines []string
+	add := func(name, tags, value, typ string) {
+		lines = append(lines, Job+"."+name+tags+":"+value+"|"+typ)
+	}
+	if !r.Started.IsZero() {
+		add("run.duration", tags, fmt.Sprint(r.Finished.Sub(r.Started).Milliseconds()), "ms")
+		if r.Success {
+			add("run.success", tags, "1", "c")
+		} else {
+			add("run.failure", tags, "1", "c")
+		}
+		if r.RemoteConfigured && !r.RemoteOK {
+			add("remote.failure", tags, "1", "c")
+		}
+	}
+	add("run.consecutive_failures", tags, fmt.Sprint(r.Failures), "g")
+	newest, dbs := newestBackups(r.Backups)
+	for _, db := range dbs {
+		dbTags := tags + ",database=" + statsdTag(db)
+		add("backup.size_bytes", dbTags, fmt.Sprint(newest[db].Size), "g")
+		if newest[db].DurationMS > 0 {
+			add("backup.duration", dbTags, fmt.Sprint(newest[db].DurationMS), "ms")
+		}
+	}
+	return lines
+}
+
+// statsdTag escapes a tag value: characters with a meaning in the line (",", "=", ":", "|", space) become "_".
+func statsdTag(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ',', '=', ':', '|', ' ', '\n':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// SendStatsD sends lines over UDP to addr (host:port), several per datagram separated by newlines.
+func SendStatsD(addr string, lines []string) error {
+	conn, err := net.DialTimeout("udp", addr, 10*time.Second)
+	if err != nil {
+		return fmt.Errorf(i18n.T("err.metrics_statsd"), addr, err)
+	}
+	defer conn.Close()
+	var packet strings.Builder
+	flush := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := conn.Write([]byte(packet.String()))
+		packet.Reset()
+		return err
+	}
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > statsdPacketSize {
+			if err := flush(); err != nil {
+				return fmt.Errorf(i18n.T("err.metrics_statsd"), addr, err)
+			}
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if err := flush(); err != nil {
+		return fmt.Errorf(i18n.T("err.metrics_statsd"), addr, err)
+	}
+	return nil
+}
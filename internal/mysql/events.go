@@ -0,0 +1,55 @@
+package mysql
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// Event is one scheduled event of a database (information_schema.EVENTS), recorded in the manifest of its backup.
+type Event struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // ENABLED, DISABLED, SLAVESIDE_DISABLED
+}
+
+// EventScheduler returns the global event_scheduler setting of the server: ON, OFF or DISABLED.
+func (c *Conn) EventScheduler() (string, error) {
+	out, err := c.query("SELECT @@GLOBAL.event_scheduler")
+	if err != nil {
+		return "", fmt.Errorf(i18n.T("err.mysql_event_scheduler"), err)
+	}
+	return strings.ToUpper(strings.TrimSpace(string(out))), nil
+}
+
+// Events returns the events of database db sorted by name.
+func (c *Conn) Events(db string) ([]Event, error) {
+	name := strings.ReplaceAll(strings.ReplaceAll(db, "\\", "\\\\"), "'", "''")
+	out, err := c.query("SELECT EVENT_NAME, STATUS FROM information_schema.EVENTS WHERE EVENT_SCHEMA = '" + name + "' ORDER BY EVENT_NAME")
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("err.mysql_events"), db, err)
+	}
+	var events []Event
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		if n, status, ok := strings.Cut(strings.TrimRight(sc.Text(), "\r"), "\t"); ok {
+			events = append(events, Event{Name: n, Status: status})
+		}
+	}
+	return events, nil
+}
+
+// query runs q with mysql -N and returns its output; errors include the output of stderr.
+func (c *Conn) query(q string) ([]byte, error) {
+	cmd := exec.Command(c.binPath("mysql"), append(c.baseArgs(), "-N", "-e", q)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
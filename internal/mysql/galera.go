@@ -0,0 +1,99 @@
+package mysql
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// ClusterStatus is the Galera state of one node (SHOW GLOBAL STATUS LIKE 'wsrep_%'), recorded in the manifest of
+// backups dumped from a cluster.
+type ClusterStatus struct {
+	Node          string `json:"node"`               // Host:Port, über den der Knoten erreicht wurde
+	NodeName      string `json:"node_name"`          // wsrep_node_name
+	ClusterName   string `json:"cluster_name"`       // wsrep_cluster_name
+	ClusterUUID   string `json:"cluster_state_uuid"` // wsrep_cluster_state_uuid
+	ClusterStatus string `json:"cluster_status"`     // wsrep_cluster_status: Primary, Non-Primary, Disconnected
+	ClusterSize   int    `json:"cluster_size"`
+	LocalState    string `json:"local_state"` // wsrep_local_state_comment: Synced, Donor/Desynced, Joining, …
+	Ready         bool   `json:"ready"`
+	LastCommitted int64  `json:"last_committed"` // Seqno des letzten angewendeten Write-Sets (GTID-Position im Cluster)
+	Desync        bool   `json:"desync"`         // wsrep_desync
+}
+
+// Synced reports whether the node is a ready member of the primary component with state Synced, i.e. a
+// suitable donor for a dump.
+func (s ClusterStatus) Synced() bool {
+	return s.Ready && s.ClusterStatus == "Primary" && s.LocalState == "Synced"
+}
+
+// ClusterStatus returns the Galera state of the server; a server without wsrep fails with err.galera_status.
+func (c *Conn) ClusterStatus() (ClusterStatus, error) {
+	q := "SHOW GLOBAL STATUS LIKE 'wsrep_%'; SHOW GLOBAL VARIABLES WHERE Variable_name IN ('wsrep_node_name', 'wsrep_cluster_name', 'wsrep_desync')"
+	cmd := exec.Command(c.binPath("mysql"), append(c.baseArgs(), "-N", "-e", q)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return ClusterStatus{}, fmt.Errorf(i18n.T("err.galera_status"), c.Host, c.Port, err, stderr.String())
+	}
+	v := map[string]string{}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		if name, value, ok := strings.Cut(strings.TrimRight(sc.Text(), "\r"), "\t"); ok {
+			v[strings.ToLower(name)] = value
+		}
+	}
+	if _, ok := v["wsrep_cluster_status"]; !ok {
+		return ClusterStatus{}, fmt.Errorf(i18n.T("err.galera_no_wsrep"), c.Host, c.Port)
+	}
+	s := ClusterStatus{
+		Node:          net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		NodeName:      v["wsrep_node_name"],
+		ClusterName:   v["wsrep_cluster_name"],
+		ClusterUUID:   v["wsrep_cluster_state_uuid"],
+		ClusterStatus: v["wsrep_cluster_status"],
+		LocalState:    v["wsrep_local_state_comment"],
+		Ready:         strings.EqualFold(v["wsrep_ready"], "ON"),
+		Desync:        strings.EqualFold(v["wsrep_desync"], "ON"),
+	}
+	s.ClusterSize, _ = strconv.Atoi(v["wsrep_cluster_size"])
+	s.LastCommitted, _ = strconv.ParseInt(v["wsrep_last_committed"], 10, 64)
+	return s, nil
+}
+
+// SetDesync switches wsrep_desync of the node: while on, the node may fall behind the cluster without sending
+// flow control, so a long dump does not slow down writes on the other nodes.
+func (c *Conn) SetDesync(on bool) error {
+	value := "OFF"
+	if on {
+		value = "ON"
+	}
+	cmd := exec.Command(c.binPath("mysql"), append(c.baseArgs(), "-e", "SET GLOBAL wsrep_desync = "+value)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf(i18n.T("err.galera_desync"), value, c.Host, c.Port, err, string(out))
+	}
+	return nil
+}
+
+// WaitSynced polls the node until it is Synced again (after wsrep_desync = OFF) or timeout has passed.
+func (c *Conn) WaitSynced(timeout, interval time.Duration) (ClusterStatus, bool) {
+	deadline := time.Now().Add(timeout)
+	for {
+		s, err := c.ClusterStatus()
+		if err == nil && s.Synced() {
+			return s, true
+		}
+		if time.Now().After(deadline) {
+			return s, false
+		}
+		time.Sleep(interval)
+	}
+}
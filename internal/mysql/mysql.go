@@ -4,11 +4,16 @@ package mysql
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/janmz/mysqlbackup/internal/i18n"
@@ -21,6 +26,11 @@ type Conn struct {
 	User     string
 	Password string
 	BinDir   string // optional: Verzeichnis mit mysql, mysqldump, mysqlpump (leer = aus PATH)
+	TempDir  string // optional: Verzeichnis der temporären Defaults-Datei (work_dir; leer = Temp-Verzeichnis des Systems)
+	// optional: Zeichensatz der Verbindung beim Import (--default-character-set); SET NAMES im Dump hat Vorrang
+	DefaultCharset string
+
+	defaultsFile string // temporäre Defaults-Datei mit dem Passwort (siehe Close)
 }
 
 // binPath returns the path to the given executable (mysql, mysqldump, mysqlpump). Wenn BinDir leer, nur Name (aus PATH); sonst voller Pfad.
@@ -34,19 +44,63 @@ func (c *Conn) binPath(name string) string {
 	return filepath.Join(c.BinDir, name)
 }
 
-// baseArgs returns common args for mysql/mysqldump (host, port, user, password).
+// baseArgs returns common args for mysql/mysqldump (host, port, user, password). The password is passed in a
+// temporary defaults file (--defaults-extra-file, must be the first option), so it does not show up in the process
+// list; only if that file cannot be written, it is passed as -p argument as before.
 func (c *Conn) baseArgs() []string {
-	args := []string{
+	var args []string
+	if c.Password != "" && c.writeDefaultsFile() == nil {
+		args = append(args, "--defaults-extra-file="+c.defaultsFile)
+	}
+	args = append(args,
 		"-h", c.Host,
 		"-P", fmt.Sprintf("%d", c.Port),
 		"-u", c.User,
-	}
-	if c.Password != "" {
+	)
+	if c.Password != "" && c.defaultsFile == "" {
 		args = append(args, "-p"+c.Password)
 	}
 	return args
 }
 
+// writeDefaultsFile creates the temporary defaults file ([client] password=...) on first use; CreateTemp
+// creates it with mode 0600.
+func (c *Conn) writeDefaultsFile() error {
+	if c.defaultsFile != "" {
+		return nil
+	}
+	if c.TempDir != "" {
+		if err := os.MkdirAll(c.TempDir, 0755); err != nil {
+			return err
+		}
+	}
+	f, err := os.CreateTemp(c.TempDir, "mysqlbackup-*.cnf")
+	if err != nil {
+		return err
+	}
+	pw := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(c.Password)
+	_, err = fmt.Fprintf(f, "[client]\npassword=\"%s\"\n", pw)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	c.defaultsFile = f.Name()
+	return nil
+}
+
+// Close removes the temporary defaults file (if any). The Conn stays usable; a later call creates a new file.
+func (c *Conn) Close() error {
+	if c.defaultsFile == "" {
+		return nil
+	}
+	err := os.Remove(c.defaultsFile)
+	c.defaultsFile = ""
+	return err
+}
+
 // Reachable returns nil if the server accepts connections (e.g. for lifecycle check before start).
 func (c *Conn) Reachable() error {
 	args := append(c.baseArgs(), "-e", "SELECT 1")
@@ -71,6 +125,44 @@ func (c *Conn) IsMariaDB() (bool, error) {
 	return strings.Contains(strings.ToLower(string(out)), "mariadb"), nil
 }
 
+// ServerInfo describes the server for the checks before a restore.
+type ServerInfo struct {
+	Version string // @@version, e.g. "8.0.36" or "10.11.6-MariaDB-1:10.11.6+maria~ubu2204"
+	Charset string // @@character_set_server
+	DataDir string // @@datadir
+}
+
+// Info returns version, default character set and data directory of the server.
+func (c *Conn) Info() (ServerInfo, error) {
+	args := append(c.baseArgs(), "-N", "-e", "SELECT @@version, @@character_set_server, @@datadir")
+	cmd := exec.Command(c.binPath("mysql"), args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return ServerInfo{}, fmt.Errorf(i18n.T("err.mysql_version"), err, stderr.String())
+	}
+	f := strings.Split(strings.TrimRight(string(out), "\r\n"), "\t")
+	for len(f) < 3 {
+		f = append(f, "")
+	}
+	return ServerInfo{Version: f[0], Charset: f[1], DataDir: f[2]}, nil
+}
+
+// CharsetSupported reports whether the server knows the character set cs.
+func (c *Conn) CharsetSupported(cs string) (bool, error) {
+	name := strings.ReplaceAll(strings.ReplaceAll(cs, "\\", "\\\\"), "'", "''")
+	args := append(c.baseArgs(), "-N", "-e", "SELECT COUNT(*) FROM information_schema.character_sets WHERE character_set_name = '"+name+"'")
+	cmd := exec.Command(c.binPath("mysql"), args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf(i18n.T("err.mysql_charset"), err, stderr.String())
+	}
+	return strings.TrimSpace(string(out)) != "0", nil
+}
+
 // ListDatabases returns database names excluding system schemas: information_schema, performance_schema, mysql, sys.
 func (c *Conn) ListDatabases() ([]string, error) {
 	args := append(c.baseArgs(), "-e", "SHOW DATABASES")
@@ -91,6 +183,85 @@ func (c *Conn) ListDatabases() ([]string, error) {
 	return dbs, sc.Err()
 }
 
+// TableCount returns the number of tables and views of database db (0 if it does not exist).
+func (c *Conn) TableCount(db string) (int, error) {
+	name := strings.ReplaceAll(strings.ReplaceAll(db, "\\", "\\\\"), "'", "''")
+	args := append(c.baseArgs(), "-N", "-e", "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = '"+name+"'")
+	cmd := exec.Command(c.binPath("mysql"), args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf(i18n.T("err.mysql_table_count"), db, err, string(out))
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return 0, fmt.Errorf(i18n.T("err.mysql_table_count"), db, err, string(out))
+	}
+	return n, nil
+}
+
+// DropDatabase drops database db (DROP DATABASE IF EXISTS), e.g. before a restore with --force.
+func (c *Conn) DropDatabase(db string) error {
+	args := append(c.baseArgs(), "-e", "DROP DATABASE IF EXISTS `"+strings.ReplaceAll(db, "`", "``")+"`")
+	cmd := exec.Command(c.binPath("mysql"), args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf(i18n.T("err.mysql_drop_database"), db, err, string(out))
+	}
+	return nil
+}
+
+// TableStat is the result of the probes of one restored table (see TableStats).
+type TableStat struct {
+	Name     string
+	Rows     int64
+	Checksum string // CHECKSUM TABLE; "NULL" if the table could not be read
+}
+
+// TableStats returns the base tables of database db with their row count (COUNT(*)) and CHECKSUM TABLE, e.g.
+// to probe a test restore. Both read every row, so damaged tables fail here.
+func (c *Conn) TableStats(db string) ([]TableStat, error) {
+	name := strings.ReplaceAll(strings.ReplaceAll(db, "\\", "\\\\"), "'", "''")
+	args := append(c.baseArgs(), "-N", "-e", "SELECT table_name FROM information_schema.tables WHERE table_schema = '"+name+"' AND table_type = 'BASE TABLE' ORDER BY table_name")
+	out, err := exec.Command(c.binPath("mysql"), args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("err.mysql_table_stats"), db, err, string(out))
+	}
+	var stats []TableStat
+	var counts, tables []string
+	quotedDB := "`" + strings.ReplaceAll(db, "`", "``") + "`"
+	for _, t := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if t = strings.TrimRight(t, "\r"); t == "" {
+			continue
+		}
+		stats = append(stats, TableStat{Name: t})
+		quoted := quotedDB + ".`" + strings.ReplaceAll(t, "`", "``") + "`"
+		counts = append(counts, "SELECT COUNT(*) FROM "+quoted)
+		tables = append(tables, quoted)
+	}
+	if len(stats) == 0 {
+		return nil, nil
+	}
+	// eine Zeile je Tabelle: erst die Zeilenzahlen, dann "db.tabelle<TAB>checksum"
+	q := strings.Join(counts, " UNION ALL ") + "; CHECKSUM TABLE " + strings.Join(tables, ", ")
+	cmd := exec.Command(c.binPath("mysql"), append(c.baseArgs(), "-N", "-e", q)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err = cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("err.mysql_table_stats"), db, err, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 2*len(stats) {
+		return nil, fmt.Errorf(i18n.T("err.mysql_table_stats"), db, errors.New("unexpected output"), string(out))
+	}
+	for i := range stats {
+		stats[i].Rows, _ = strconv.ParseInt(strings.TrimSpace(lines[i]), 10, 64)
+		if _, sum, ok := strings.Cut(strings.TrimSpace(lines[len(stats)+i]), "\t"); ok {
+			stats[i].Checksum = sum
+		}
+	}
+	return stats, nil
+}
+
 // ExportUsers runs mysqldump --system=users (MariaDB, wo unterstützt) oder mysqlpump --users (MySQL), returns SQL.
 // MariaDB: Wenn --system=users nicht unterstützt wird (z. B. vor 10.2.37), Fallback per mysql.user + SHOW GRANTS.
 func (c *Conn) ExportUsers(isMariaDB bool) ([]byte, error) {
@@ -191,9 +362,23 @@ func (c *Conn) exportUsersMariaDBFallback() ([]byte, error) {
 	return []byte(buf.String()), nil
 }
 
+// CommandError is returned when a MySQL client program fails; Stderr holds its complete error output
+// (e.g. for an email attachment).
+type CommandError struct {
+	msg    string
+	Err    error
+	Stderr string
+}
+
+func (e *CommandError) Error() string { return e.msg }
+
+func (e *CommandError) Unwrap() error { return e.Err }
+
 // DumpDatabase streams mysqldump output for one database into dest. Kein vollständiger Dump im Speicher.
+// excludeTables (ohne Datenbank-Präfix) werden per --ignore-table ausgelassen.
 // isMariaDB: bei true wird --set-gtid-purged=OFF weggelassen (nur MySQL, nicht MariaDB).
-func (c *Conn) DumpDatabase(db string, isMariaDB bool, dest io.Writer) error {
+// Endet ctx (max_run_duration), wird mysqldump beendet.
+func (c *Conn) DumpDatabase(ctx context.Context, db string, isMariaDB bool, excludeTables []string, dest io.Writer) error {
 	args := append(c.baseArgs(),
 		"--single-transaction",
 		"--routines", "--triggers", "--events",
@@ -201,20 +386,32 @@ func (c *Conn) DumpDatabase(db string, isMariaDB bool, dest io.Writer) error {
 	if !isMariaDB {
 		args = append(args, "--set-gtid-purged=OFF")
 	}
+	for _, t := range excludeTables {
+		args = append(args, "--ignore-table="+db+"."+t)
+	}
 	args = append(args, "--databases", db)
-	cmd := exec.Command(c.binPath("mysqldump"), args...)
+	cmd := exec.CommandContext(ctx, c.binPath("mysqldump"), args...)
 	cmd.Stdout = dest
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf(i18n.Tf("err.mysqldump_db", db), err, stderr.String())
+		return &CommandError{msg: fmt.Errorf(i18n.Tf("err.mysqldump_db", db), err, stderr.String()).Error(), Err: err, Stderr: stderr.String()}
 	}
 	return nil
 }
 
+// importArgs returns the arguments of mysql for an import (baseArgs plus DefaultCharset).
+func (c *Conn) importArgs() []string {
+	args := c.baseArgs()
+	if c.DefaultCharset != "" {
+		args = append(args, "--default-character-set="+c.DefaultCharset)
+	}
+	return args
+}
+
 // ImportSQL streams SQL input into mysql via stdin.
 func (c *Conn) ImportSQL(src io.Reader) error {
-	args := c.baseArgs()
+	args := c.importArgs()
 	cmd := exec.Command(c.binPath("mysql"), args...)
 	cmd.Stdin = src
 	var stderr bytes.Buffer
@@ -225,6 +422,43 @@ func (c *Conn) ImportSQL(src io.Reader) error {
 	return nil
 }
 
+// statementErrorRe matches an error of mysql --force: "ERROR 1062 (23000) at line 45: Duplicate entry ...".
+var statementErrorRe = regexp.MustCompile(`^(ERROR \d+(?: \([0-9A-Z]+\))?) at line (\d+)[^:]*: (.*)$`)
+
+// StatementError is a statement that failed during ImportSQLForce; Line is its line in the SQL input.
+type StatementError struct {
+	Line    int
+	Message string
+}
+
+// ImportSQLForce streams SQL input into mysql --force: failing statements are skipped and returned with their
+// line number. err is only set if the import itself failed (e.g. no connection).
+func (c *Conn) ImportSQLForce(src io.Reader) ([]StatementError, error) {
+	args := append(c.importArgs(), "--force")
+	cmd := exec.Command(c.binPath("mysql"), args...)
+	cmd.Stdin = src
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var failed []StatementError
+	var other []string
+	sc := bufio.NewScanner(&stderr)
+	sc.Buffer(nil, 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if m := statementErrorRe.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			failed = append(failed, StatementError{Line: n, Message: m[1] + ": " + m[3]})
+		} else if line != "" {
+			other = append(other, line)
+		}
+	}
+	if err != nil && len(failed) == 0 {
+		return nil, fmt.Errorf(i18n.T("err.mysql_import"), err, strings.Join(other, "\n"))
+	}
+	return failed, nil
+}
+
 // MysqldumpPath returns the name of mysqldump (or full path on Windows if in PATH).
 func MysqldumpPath() string {
 	if runtime.GOOS == "windows" {
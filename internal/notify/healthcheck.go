@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// Healthcheck signals for healthcheck_url (healthchecks.io ping API): start of a run, success and failure.
+const (
+	PingStart   = "/start"
+	PingSuccess = ""
+	PingFail    = "/fail"
+)
+
+// healthcheckMaxBody is the largest request body healthchecks.io stores (larger logs are cut at the front).
+const healthcheckMaxBody = 100 * 1024
+
+// Healthcheck pings healthcheck_url + signal with body (e.g. the run log) as POST. Does nothing if healthcheck_url is empty.
+func Healthcheck(cfg *config.Config, signal string, body []byte) error {
+	base := strings.TrimRight(strings.TrimSpace(cfg.HealthcheckURL), "/")
+	if base == "" {
+		return nil
+	}
+	if len(body) > healthcheckMaxBody {
+		body = body[len(body)-healthcheckMaxBody:]
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(base+signal, "text/plain; charset=utf-8", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf(i18n.T("err.healthcheck"), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf(i18n.T("err.healthcheck_status"), resp.Status)
+	}
+	return nil
+}
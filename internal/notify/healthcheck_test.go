@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+)
+
+func TestHealthcheck(t *testing.T) {
+	var paths []string
+	var lastBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		paths = append(paths, r.URL.Path)
+		lastBody = string(b)
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	if err := Healthcheck(cfg, PingStart, nil); err != nil || len(paths) != 0 {
+		t.Fatalf("unconfigured: err=%v paths=%v", err, paths)
+	}
+	cfg.HealthcheckURL = srv.URL + "/uuid/"
+	for _, signal := range []string{PingStart, PingFail} {
+		if err := Healthcheck(cfg, signal, []byte(strings.Repeat("x", healthcheckMaxBody)+"end")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if strings.Join(paths, ",") != "/uuid/start,/uuid/fail" {
+		t.Errorf("paths = %v", paths)
+	}
+	if len(lastBody) != healthcheckMaxBody || !strings.HasSuffix(lastBody, "end") {
+		t.Errorf("body length %d, want the last %d bytes", len(lastBody), healthcheckMaxBody)
+	}
+}
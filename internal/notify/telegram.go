@@ -0,0 +1,54 @@
+// Package notify pushes short failure and success messages to a chat (Telegram bot API).
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// telegramAPI is the bot API base URL (replaced in tests).
+var telegramAPI = "https://api.telegram.org"
+
+// telegramMaxLen is the maximum length of a Telegram message in characters.
+const telegramMaxLen = 4096
+
+// Telegram sends text to telegram_chat_id with the bot telegram_bot_password. Does nothing if Telegram is not configured.
+func Telegram(cfg *config.Config, text string) error {
+	if !cfg.TelegramEnabled() {
+		return nil
+	}
+	token := strings.TrimSpace(cfg.TelegramBotPassword)
+	if r := []rune(text); len(r) > telegramMaxLen {
+		text = string(r[:telegramMaxLen-1]) + "…"
+	}
+	form := url.Values{
+		"chat_id":                  {strings.TrimSpace(cfg.TelegramChatID)},
+		"text":                     {text},
+		"disable_web_page_preview": {"true"},
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.PostForm(telegramAPI+"/bot"+token+"/sendMessage", form)
+	if err != nil {
+		// Die URL enthält den Bot-Token: nicht im Log/in E-Mails ausgeben
+		return fmt.Errorf(i18n.T("err.telegram"), strings.ReplaceAll(err.Error(), token, "***"))
+	}
+	defer resp.Body.Close()
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || !result.OK {
+		if result.Description == "" {
+			result.Description = resp.Status
+		}
+		return fmt.Errorf(i18n.T("err.telegram_api"), resp.StatusCode, result.Description)
+	}
+	return nil
+}
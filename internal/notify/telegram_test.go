@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+)
+
+func TestTelegram(t *testing.T) {
+	var gotPath, gotChat, gotText string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotChat = r.FormValue("chat_id")
+		gotText = r.FormValue("text")
+		if gotChat != "42" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"ok":false,"description":"Bad Request: chat not found"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+	old := telegramAPI
+	telegramAPI = srv.URL
+	defer func() { telegramAPI = old }()
+
+	cfg := config.DefaultConfig()
+	if err := Telegram(cfg, "not configured"); err != nil || gotPath != "" {
+		t.Fatalf("unconfigured: err=%v path=%q", err, gotPath)
+	}
+	cfg.TelegramBotPassword = "123:abc"
+	cfg.TelegramChatID = "42"
+	if err := Telegram(cfg, strings.Repeat("x", 5000)); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/bot123:abc/sendMessage" || len([]rune(gotText)) != telegramMaxLen {
+		t.Errorf("path=%q len=%d", gotPath, len([]rune(gotText)))
+	}
+	cfg.TelegramChatID = "7"
+	if err := Telegram(cfg, "hi"); err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Errorf("expected API error, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// Event describes a finished run; it is the data of the webhook_body template and the default JSON payload.
+type Event struct {
+	Status    string    `json:"status"` // "success", "warning" (succeeded with warnings) or "failure"
+	Host      string    `json:"host"`
+	Databases []string  `json:"databases"`
+	Failed    []string  `json:"failed_databases,omitempty"` // databases that failed with dump_continue_on_error
+	TotalSize int64     `json:"total_size"`                 // bytes of the backups created in this run
+	Duration  float64   `json:"duration"`                   // seconds
+	Error     string    `json:"error,omitempty"`
+	Code      string    `json:"code,omitempty"` // stable error code of Error (MB-xxxx)
+	Warnings  []string  `json:"warnings,omitempty"`
+	Started   time.Time `json:"started"`
+	Finished  time.Time `json:"finished"`
+}
+
+// templateFuncs are available in webhook_body: json encodes a value (e.g. {{json .Error}} for a quoted string).
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// Webhook sends ev to webhook_url (webhook_method, default POST) with webhook_headers ("Name: Value").
+// The body is webhook_body rendered as Go template with ev, or ev as JSON if webhook_body is empty.
+// Does nothing if webhook_url is empty.
+func Webhook(cfg *config.Config, ev Event) error {
+	if strings.TrimSpace(cfg.WebhookURL) == "" {
+		return nil
+	}
+	var body bytes.Buffer
+	if strings.TrimSpace(cfg.WebhookBody) == "" {
+		if err := json.NewEncoder(&body).Encode(ev); err != nil {
+			return err
+		}
+	} else {
+		tmpl, err := template.New("webhook_body").Funcs(templateFuncs).Parse(cfg.WebhookBody)
+		if err != nil {
+			return fmt.Errorf(i18n.T("err.webhook_template"), err)
+		}
+		if err := tmpl.Execute(&body, ev); err != nil {
+			return fmt.Errorf(i18n.T("err.webhook_template"), err)
+		}
+	}
+	method := strings.ToUpper(strings.TrimSpace(cfg.WebhookMethod))
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequest(method, strings.TrimSpace(cfg.WebhookURL), &body)
+	if err != nil {
+		return fmt.Errorf(i18n.T("err.webhook"), err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for _, h := range cfg.WebhookHeaders {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return fmt.Errorf(i18n.T("err.config_webhook_header"), h)
+		}
+		req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf(i18n.T("err.webhook"), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf(i18n.T("err.webhook_status"), resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+)
+
+func TestWebhook(t *testing.T) {
+	var gotMethod, gotAuth, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotMethod, gotAuth, gotBody = r.Method, r.Header.Get("Authorization"), string(b)
+	}))
+	defer srv.Close()
+
+	ev := Event{Status: "failure", Host: "db1", Databases: []string{"shop"}, Error: `dump "shop" failed`, Started: time.Unix(0, 0), Finished: time.Unix(90, 0)}
+	cfg := config.DefaultConfig()
+	cfg.WebhookURL = srv.URL
+	cfg.WebhookMethod = "put"
+	cfg.WebhookHeaders = []string{"Authorization: Bearer secret"}
+	cfg.WebhookBody = `{"text": {{json (printf "%s on %s: %s" .Status .Host .Error)}}}`
+	if err := Webhook(cfg, ev); err != nil {
+		t.Fatal(err)
+	}
+	if gotMethod != http.MethodPut || gotAuth != "Bearer secret" {
+		t.Errorf("method=%q auth=%q", gotMethod, gotAuth)
+	}
+	if want := `{"text": "failure on db1: dump \"shop\" failed"}`; gotBody != want {
+		t.Errorf("body = %s, want %s", gotBody, want)
+	}
+
+	cfg.WebhookBody = ""
+	cfg.WebhookMethod = ""
+	if err := Webhook(cfg, ev); err != nil {
+		t.Fatal(err)
+	}
+	if gotMethod != http.MethodPost || gotBody == "" || gotBody[0] != '{' {
+		t.Errorf("default payload: method=%q body=%s", gotMethod, gotBody)
+	}
+
+	cfg.WebhookURL = srv.URL + "/x"
+	srv.Config.Handler = http.NotFoundHandler()
+	if err := Webhook(cfg, ev); err == nil {
+		t.Error("expected error for HTTP 404")
+	}
+}
@@ -0,0 +1,249 @@
+package parity
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// ErrNoParity is returned by Check and Repair when the ZIP has no (readable) parity files.
+var ErrNoParity = errors.New("no parity files")
+
+// Result is the state of a backup ZIP according to its parity files.
+type Result struct {
+	Slices        int   // Slices der ZIP
+	Damaged       []int // beschädigte oder fehlende Slices
+	SizeMismatch  bool  // ZIP ist länger oder kürzer als beim Anlegen der Paritätsdaten
+	Recovery      int   // intakte Recovery-Slices
+	ParityDamaged bool  // mindestens ein Paket der Paritätsdateien ist beschädigt
+}
+
+// OK reports whether the ZIP is unchanged since the parity files were written.
+func (r Result) OK() bool {
+	return len(r.Damaged) == 0 && !r.SizeMismatch
+}
+
+// Repairable reports whether enough recovery slices are intact to repair the ZIP.
+func (r Result) Repairable() bool {
+	return len(r.Damaged) <= r.Recovery
+}
+
+// load reads the parity files of zipPath and returns the set of the ZIP and its intact recovery slices.
+func load(zipPath string) (*set, []recovery, bool, error) {
+	files := Files(zipPath)
+	if len(files) == 0 {
+		return nil, nil, false, ErrNoParity
+	}
+	f := &found{main: map[[16]byte][]byte{}, fileDesc: map[[16]byte][]byte{}, ifsc: map[[16]byte][]byte{},
+		recovery: map[[16]byte][]recovery{}}
+	for _, p := range files {
+		if err := f.scan(p); err != nil {
+			return nil, nil, false, err
+		}
+	}
+	name := filepath.Base(zipPath)
+	for id := range f.main {
+		s, ok := f.parseSet(id)
+		if !ok || s.name != name {
+			continue
+		}
+		// je Exponent nur ein Slice (Kopien in mehreren Dateien)
+		seen := map[uint32]bool{}
+		var recs []recovery
+		for _, r := range f.recovery[id] {
+			if !seen[r.exponent] {
+				seen[r.exponent] = true
+				recs = append(recs, r)
+			}
+		}
+		sort.Slice(recs, func(i, j int) bool { return recs[i].exponent < recs[j].exponent })
+		return s, recs, f.damaged, nil
+	}
+	return nil, nil, f.damaged, ErrNoParity
+}
+
+// Check compares the slices of zipPath with the checksums of its parity files.
+func Check(zipPath string) (Result, error) {
+	s, recs, damaged, err := load(zipPath)
+	if err != nil {
+		return Result{}, err
+	}
+	return check(zipPath, s, recs, damaged)
+}
+
+func check(zipPath string, s *set, recs []recovery, parityDamaged bool) (Result, error) {
+	res := Result{Slices: s.count(), Recovery: len(recs), ParityDamaged: parityDamaged}
+	f, err := os.Open(zipPath)
+	if err != nil {
+		return res, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return res, err
+	}
+	res.SizeMismatch = info.Size() != s.length
+	buf := make([]byte, s.sliceSize)
+	for i, want := range s.slices {
+		off := int64(i) * s.sliceSize
+		if err := readSlice(f, off, buf); err != nil {
+			return res, err
+		}
+		// letzte Slice: hinter der ursprünglichen Länge zählen nur Nullen (eine längere ZIP wird beim Reparieren gekürzt)
+		if end := s.length - off; end < s.sliceSize {
+			clear(buf[end:])
+		}
+		if hashSlice(buf) != want {
+			res.Damaged = append(res.Damaged, i)
+		}
+	}
+	return res, nil
+}
+
+// Repair restores the damaged slices of zipPath from its recovery slices and replaces the ZIP when the result
+// matches the MD5 of the original. It returns the state before the repair.
+func Repair(zipPath string) (Result, error) {
+	s, recs, parityDamaged, err := load(zipPath)
+	if err != nil {
+		return Result{}, err
+	}
+	res, err := check(zipPath, s, recs, parityDamaged)
+	if err != nil || res.OK() {
+		return res, err
+	}
+	if !res.Repairable() {
+		return res, fmt.Errorf(i18n.T("err.parity_not_enough"), len(res.Damaged), res.Recovery)
+	}
+	return res, repair(zipPath, s, recs[:len(res.Damaged)], res.Damaged)
+}
+
+// repair computes the missing slices from the recovery slices recs (as many as missing): each recovery slice
+// minus the share of the intact slices is a combination of the missing ones, the inverted matrix solves for them.
+func repair(zipPath string, s *set, recs []recovery, missing []int) error {
+	logs := inputLogs(s.count())
+	k := len(missing)
+	m := make([][]uint16, k)
+	for r, rec := range recs {
+		m[r] = make([]uint16, k)
+		for j, i := range missing {
+			m[r][j] = gfPow(logs[i], rec.exponent)
+		}
+	}
+	if !invert(m) {
+		return fmt.Errorf(i18n.T("err.parity_singular"))
+	}
+	src, err := os.Open(zipPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	tmp := zipPath + ".repair"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	ok := false
+	defer func() {
+		if !ok {
+			out.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+	if _, err := io.Copy(out, io.NewSectionReader(src, 0, min(info.Size(), s.length))); err != nil {
+		return err
+	}
+	if err := out.Truncate(s.length); err != nil {
+		return err
+	}
+	isMissing := make(map[int]bool, k)
+	for _, i := range missing {
+		isMissing[i] = true
+	}
+	recFiles := map[string]*os.File{}
+	defer func() {
+		for _, f := range recFiles {
+			f.Close()
+		}
+	}()
+	for _, rec := range recs {
+		if recFiles[rec.path] == nil {
+			f, err := os.Open(rec.path)
+			if err != nil {
+				return err
+			}
+			recFiles[rec.path] = f
+		}
+	}
+	chunk := columnChunk(s.sliceSize, 2*k)
+	acc := make([][]byte, k)
+	res := make([][]byte, k)
+	for r := range acc {
+		acc[r] = make([]byte, chunk)
+		res[r] = make([]byte, chunk)
+	}
+	in := make([]byte, chunk)
+	for col := int64(0); col < s.sliceSize; col += chunk {
+		n := min(chunk, s.sliceSize-col)
+		for r, rec := range recs {
+			if _, err := recFiles[rec.path].ReadAt(acc[r][:n], rec.offset+col); err != nil {
+				return err
+			}
+		}
+		for i := range logs {
+			off := int64(i)*s.sliceSize + col
+			if isMissing[i] || off >= s.length {
+				continue
+			}
+			if err := readSlice(src, off, in[:n]); err != nil {
+				return err
+			}
+			if end := s.length - off; end < n {
+				clear(in[end:n])
+			}
+			for r, rec := range recs {
+				mulAdd(acc[r][:n], in[:n], gfPow(logs[i], rec.exponent))
+			}
+		}
+		for j, i := range missing {
+			clear(res[j][:n])
+			for r := range recs {
+				mulAdd(res[j][:n], acc[r][:n], m[j][r])
+			}
+			off := int64(i)*s.sliceSize + col
+			if off >= s.length {
+				continue
+			}
+			if _, err := out.WriteAt(res[j][:min(n, s.length-off)], off); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	d := md5.New()
+	if _, err := io.Copy(d, out); err != nil {
+		return err
+	}
+	if sum16(d) != s.hash {
+		return fmt.Errorf(i18n.T("err.parity_repair_failed"))
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	ok = true
+	src.Close()
+	// Änderungszeit behalten: sonst lädt der Remote-Abgleich die reparierte ZIP erneut hoch
+	_ = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	return os.Rename(tmp, zipPath)
+}
@@ -0,0 +1,114 @@
+package parity
+
+// Arithmetik in GF(2^16) mit dem Polynom von PAR2 (x^16 + x^12 + x^3 + x + 1); Addition ist XOR.
+const (
+	gfPoly  = 0x1100B
+	gfLimit = 65535 // Ordnung der multiplikativen Gruppe
+)
+
+var (
+	gfLog [1 << 16]uint16
+	gfExp [2 * gfLimit]uint16 // doppelt lang, damit log a + log b ohne Modulo passt
+)
+
+func init() {
+	x := 1
+	for i := 0; i < gfLimit; i++ {
+		gfExp[i] = uint16(x)
+		gfExp[i+gfLimit] = uint16(x)
+		gfLog[x] = uint16(i)
+		x <<= 1
+		if x&0x10000 != 0 {
+			x ^= gfPoly
+		}
+	}
+}
+
+func gfMul(a, b uint16) uint16 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+// gfInv returns 1/a (a != 0).
+func gfInv(a uint16) uint16 {
+	return gfExp[gfLimit-int(gfLog[a])]
+}
+
+// gfPow returns (2^n)^e.
+func gfPow(n, e uint32) uint16 {
+	return gfExp[uint64(n)*uint64(e)%gfLimit]
+}
+
+// inputLogs returns the exponents n of the constants 2^n of count input slices: PAR2 takes the n in ascending
+// order that are not divisible by 3, 5, 17 or 257 (the prime factors of 65535).
+func inputLogs(count int) []uint32 {
+	logs := make([]uint32, 0, count)
+	for n := uint32(1); len(logs) < count; n++ {
+		if n%3 != 0 && n%5 != 0 && n%17 != 0 && n%257 != 0 {
+			logs = append(logs, n)
+		}
+	}
+	return logs
+}
+
+// mulAdd adds f*src to dst, both read as 16-bit little-endian words. The product of a word is looked up in two
+// tables for its low and high byte, which is much faster than a logarithm per word.
+func mulAdd(dst, src []byte, f uint16) {
+	if f == 0 {
+		return
+	}
+	var lo, hi [256]uint16
+	lf := int(gfLog[f])
+	for i := 1; i < 256; i++ {
+		lo[i] = gfExp[int(gfLog[i])+lf]
+		hi[i] = gfExp[int(gfLog[i<<8])+lf]
+	}
+	for i := 0; i+1 < len(src); i += 2 {
+		p := lo[src[i]] ^ hi[src[i+1]]
+		dst[i] ^= byte(p)
+		dst[i+1] ^= byte(p >> 8)
+	}
+}
+
+// invert inverts the square matrix m in place (Gauss-Jordan); false if it is singular.
+func invert(m [][]uint16) bool {
+	n := len(m)
+	inv := make([][]uint16, n)
+	for i := range inv {
+		inv[i] = make([]uint16, n)
+		inv[i][i] = 1
+	}
+	for col := 0; col < n; col++ {
+		pivot := -1
+		for r := col; r < n; r++ {
+			if m[r][col] != 0 {
+				pivot = r
+				break
+			}
+		}
+		if pivot < 0 {
+			return false
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		inv[col], inv[pivot] = inv[pivot], inv[col]
+		f := gfInv(m[col][col])
+		for c := 0; c < n; c++ {
+			m[col][c] = gfMul(m[col][c], f)
+			inv[col][c] = gfMul(inv[col][c], f)
+		}
+		for r := 0; r < n; r++ {
+			if r == col || m[r][col] == 0 {
+				continue
+			}
+			f := m[r][col]
+			for c := 0; c < n; c++ {
+				m[r][c] ^= gfMul(m[col][c], f)
+				inv[r][c] ^= gfMul(inv[col][c], f)
+			}
+		}
+	}
+	copy(m, inv)
+	return true
+}
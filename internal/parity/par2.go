@@ -0,0 +1,258 @@
+package parity
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// Packet format of PAR 2.0: header (magic, length, MD5 from the set ID to the end, set ID, type), then the body;
+// lengths are multiples of 4.
+const headerSize = 64
+
+var packetMagic = []byte("PAR2\x00PKT")
+
+const (
+	typeMain     = "PAR 2.0\x00Main\x00\x00\x00\x00"
+	typeFileDesc = "PAR 2.0\x00FileDesc"
+	typeIFSC     = "PAR 2.0\x00IFSC\x00\x00\x00\x00"
+	typeRecovery = "PAR 2.0\x00RecvSlic"
+	typeCreator  = "PAR 2.0\x00Creator\x00"
+)
+
+const creator = "Created by mysqlbackup"
+
+// set is the recovery set of one backup ZIP (PAR2 allows several files, mysqlbackup writes one per set).
+type set struct {
+	id        [16]byte
+	sliceSize int64
+	fileID    [16]byte
+	hash      [16]byte // MD5 der ganzen Datei
+	hash16k   [16]byte // MD5 der ersten 16 KiB
+	length    int64
+	name      string
+	slices    []sliceHash
+}
+
+// sliceHash is the checksum of one input slice (IFSC packet); the last slice is padded with zeros.
+type sliceHash struct {
+	md5 [16]byte
+	crc uint32
+}
+
+// recovery is a recovery slice found in a parity file: its exponent and where its data starts.
+type recovery struct {
+	exponent uint32
+	path     string
+	offset   int64
+}
+
+func (s *set) count() int {
+	return int((s.length + s.sliceSize - 1) / s.sliceSize)
+}
+
+func (s *set) mainBody() []byte {
+	b := binary.LittleEndian.AppendUint64(nil, uint64(s.sliceSize))
+	b = binary.LittleEndian.AppendUint32(b, 1)
+	return append(b, s.fileID[:]...)
+}
+
+func (s *set) fileDescBody() []byte {
+	b := append(append(append([]byte{}, s.fileID[:]...), s.hash[:]...), s.hash16k[:]...)
+	b = binary.LittleEndian.AppendUint64(b, uint64(s.length))
+	return pad4(append(b, s.name...))
+}
+
+func (s *set) ifscBody() []byte {
+	b := append([]byte{}, s.fileID[:]...)
+	for _, h := range s.slices {
+		b = append(b, h.md5[:]...)
+		b = binary.LittleEndian.AppendUint32(b, h.crc)
+	}
+	return b
+}
+
+// critical returns the packets needed to verify the file: main, file description, slice checksums, creator.
+func (s *set) critical() []byte {
+	var b []byte
+	b = append(b, packet(s.id, typeMain, s.mainBody())...)
+	b = append(b, packet(s.id, typeFileDesc, s.fileDescBody())...)
+	b = append(b, packet(s.id, typeIFSC, s.ifscBody())...)
+	return append(b, packet(s.id, typeCreator, pad4([]byte(creator)))...)
+}
+
+// fileID is the PAR2 ID of a file: MD5 of the MD5 of its first 16 KiB, its length and its name.
+func fileID(hash16k [16]byte, length int64, name string) [16]byte {
+	b := binary.LittleEndian.AppendUint64(append([]byte{}, hash16k[:]...), uint64(length))
+	return md5.Sum(append(b, name...))
+}
+
+func pad4(b []byte) []byte {
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// header returns the packet header for a body whose MD5 (together with set ID and type) is sum.
+func header(id [16]byte, typ string, bodyLen int, sum [16]byte) []byte {
+	h := append([]byte{}, packetMagic...)
+	h = binary.LittleEndian.AppendUint64(h, uint64(headerSize+bodyLen))
+	h = append(h, sum[:]...)
+	h = append(h, id[:]...)
+	return append(h, typ...)
+}
+
+func packet(id [16]byte, typ string, body []byte) []byte {
+	d := md5.New()
+	d.Write(id[:])
+	d.Write([]byte(typ))
+	d.Write(body)
+	var sum [16]byte
+	copy(sum[:], d.Sum(nil))
+	return append(header(id, typ, len(body), sum), body...)
+}
+
+// hashSlice returns the checksum of a slice (padded to the slice size).
+func hashSlice(b []byte) sliceHash {
+	return sliceHash{md5: md5.Sum(b), crc: crc32.ChecksumIEEE(b)}
+}
+
+// readSlice reads the slice at off of a file of length bytes into buf; beyond the end it is zero.
+func readSlice(r io.ReaderAt, off int64, buf []byte) error {
+	n, err := r.ReadAt(buf, off)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	clear(buf[n:])
+	return nil
+}
+
+// found collects the intact packets of the parity files; damaged is set when a packet had to be skipped.
+type found struct {
+	main, fileDesc, ifsc map[[16]byte][]byte // Set-ID -> Body
+	recovery             map[[16]byte][]recovery
+	damaged              bool
+}
+
+// scan reads the packets of the parity file path. A packet whose checksum does not match is skipped and the
+// next one searched by its magic, so a damaged parity file still yields its intact packets.
+func (f *found) scan(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	var h [headerSize]byte
+	for pos := int64(0); pos+headerSize <= size; {
+		if _, err := file.ReadAt(h[:], pos); err != nil {
+			return err
+		}
+		length := int64(binary.LittleEndian.Uint64(h[8:16]))
+		if !bytes.Equal(h[:8], packetMagic) || length < headerSize || length%4 != 0 || pos+length > size ||
+			!packetIntact(file, pos, length, h[16:32]) {
+			f.damaged = true
+			pos = nextMagic(file, pos+1, size)
+			continue
+		}
+		var id [16]byte
+		copy(id[:], h[32:48])
+		typ := string(h[48:64])
+		if typ == typeRecovery {
+			var e [4]byte
+			if _, err := file.ReadAt(e[:], pos+headerSize); err != nil {
+				return err
+			}
+			f.recovery[id] = append(f.recovery[id], recovery{exponent: binary.LittleEndian.Uint32(e[:]), path: path, offset: pos + headerSize + 4})
+		} else if m := f.small(typ); m != nil {
+			if _, ok := m[id]; !ok {
+				body := make([]byte, length-headerSize)
+				if _, err := file.ReadAt(body, pos+headerSize); err != nil {
+					return err
+				}
+				m[id] = body
+			}
+		}
+		pos += length
+	}
+	return nil
+}
+
+func (f *found) small(typ string) map[[16]byte][]byte {
+	switch typ {
+	case typeMain:
+		return f.main
+	case typeFileDesc:
+		return f.fileDesc
+	case typeIFSC:
+		return f.ifsc
+	}
+	return nil
+}
+
+// packetIntact compares the MD5 of the packet at pos (from the set ID to its end) with sum.
+func packetIntact(r io.ReaderAt, pos, length int64, sum []byte) bool {
+	d := md5.New()
+	if _, err := io.Copy(d, io.NewSectionReader(r, pos+32, length-32)); err != nil {
+		return false
+	}
+	return bytes.Equal(d.Sum(nil), sum)
+}
+
+// nextMagic returns the position of the next packet magic at or after from, or size.
+func nextMagic(r io.ReaderAt, from, size int64) int64 {
+	buf := make([]byte, 64<<10)
+	for from < size {
+		n, _ := r.ReadAt(buf, from)
+		if n == 0 {
+			break
+		}
+		if i := bytes.Index(buf[:n], packetMagic); i >= 0 {
+			return from + int64(i)
+		}
+		if int64(n) < int64(len(buf)) {
+			break
+		}
+		from += int64(n - len(packetMagic) + 1)
+	}
+	return size
+}
+
+// parseSet builds the set with the given ID from its main, file description and IFSC packets.
+func (f *found) parseSet(id [16]byte) (*set, bool) {
+	main, desc, ifsc := f.main[id], f.fileDesc[id], f.ifsc[id]
+	if len(main) < 12+16 || len(desc) < 56 || len(ifsc) < 16 || md5.Sum(main) != id {
+		return nil, false
+	}
+	s := &set{id: id, sliceSize: int64(binary.LittleEndian.Uint64(main[:8]))}
+	if binary.LittleEndian.Uint32(main[8:12]) != 1 || s.sliceSize <= 0 || s.sliceSize%4 != 0 {
+		return nil, false
+	}
+	copy(s.fileID[:], main[12:28])
+	if !bytes.Equal(desc[:16], s.fileID[:]) || !bytes.Equal(ifsc[:16], s.fileID[:]) {
+		return nil, false
+	}
+	copy(s.hash[:], desc[16:32])
+	copy(s.hash16k[:], desc[32:48])
+	s.length = int64(binary.LittleEndian.Uint64(desc[48:56]))
+	s.name = string(bytes.TrimRight(desc[56:], "\x00"))
+	if s.length <= 0 || (len(ifsc)-16)/20 != s.count() {
+		return nil, false
+	}
+	for b := ifsc[16:]; len(b) >= 20; b = b[20:] {
+		var h sliceHash
+		copy(h.md5[:], b[:16])
+		h.crc = binary.LittleEndian.Uint32(b[16:20])
+		s.slices = append(s.slices, h)
+	}
+	return s, true
+}
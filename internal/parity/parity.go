@@ -0,0 +1,236 @@
+// Package parity writes PAR2 recovery files for backup ZIPs (parity_percent) and repairs damaged ZIPs from
+// them (--verify). The files follow PAR 2.0, so par2cmdline or MultiPar can also verify and repair:
+// <zip>.par2 holds the checksums of the slices, <zip>.volN+M.par2 the recovery slices plus another copy of the
+// checksums. A ZIP with d damaged slices can be repaired with any d intact recovery slices.
+package parity
+
+import (
+	"crypto/md5"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// Ext is the extension of the parity files of a backup ZIP.
+const Ext = ".par2"
+
+const (
+	maxSlices = 200     // mehr Slices = feinere Reparatur, aber Aufwand wächst mit Slices × Recovery-Slices
+	minSlice  = 4 << 10 // kleinste Slice-Größe
+	maxBuffer = 32 << 20
+)
+
+var volRe = regexp.MustCompile(`\.vol\d+\+\d+$`)
+
+// ZipName returns the backup ZIP a parity file name belongs to (x.zip.par2, x.zip.vol0+10.par2 -> x.zip).
+func ZipName(name string) (string, bool) {
+	base, ok := strings.CutSuffix(name, Ext)
+	if !ok {
+		return "", false
+	}
+	if loc := volRe.FindStringIndex(base); loc != nil {
+		base = base[:loc[0]]
+	}
+	return base, strings.HasSuffix(strings.ToLower(base), ".zip")
+}
+
+// Files returns the parity files of zipPath in its directory.
+func Files(zipPath string) []string {
+	dir, name := filepath.Split(zipPath)
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		if z, ok := ZipName(e.Name()); ok && z == name && !e.IsDir() {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	return files
+}
+
+// Size returns the total size of the parity files of zipPath.
+func Size(zipPath string) int64 {
+	var n int64
+	for _, f := range Files(zipPath) {
+		if info, err := os.Stat(f); err == nil {
+			n += info.Size()
+		}
+	}
+	return n
+}
+
+// Remove deletes the parity files of zipPath.
+func Remove(zipPath string) error {
+	for _, f := range Files(zipPath) {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Create writes the parity files of zipPath with recovery slices for percent (1-100) of its size and returns
+// the number of recovery slices. Older parity files of the ZIP are replaced.
+func Create(zipPath string, percent int) (int, error) {
+	f, err := os.Open(zipPath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() == 0 {
+		return 0, fmt.Errorf(i18n.T("err.parity_empty"), filepath.Base(zipPath))
+	}
+	s, err := hashFile(f, filepath.Base(zipPath), info.Size())
+	if err != nil {
+		return 0, err
+	}
+	count := (s.count()*percent + 99) / 100
+	if count < 1 {
+		count = 1
+	}
+	if err := Remove(zipPath); err != nil {
+		return 0, err
+	}
+	if err := writeFile(zipPath+Ext, s.critical()); err != nil {
+		return 0, err
+	}
+	width := len(strconv.Itoa(count))
+	volPath := fmt.Sprintf("%s.vol%0*d+%0*d%s", zipPath, width, 0, width, count, Ext)
+	if err := writeVolume(f, s, count, volPath); err != nil {
+		_ = os.Remove(zipPath + Ext)
+		return 0, err
+	}
+	return count, nil
+}
+
+// hashFile computes the checksums of the file and its slices; the slice size splits it into at most maxSlices.
+func hashFile(f io.ReaderAt, name string, length int64) (*set, error) {
+	sliceSize := (length + maxSlices - 1) / maxSlices
+	sliceSize = (sliceSize + 3) &^ 3
+	if sliceSize < minSlice {
+		sliceSize = minSlice
+	}
+	s := &set{sliceSize: sliceSize, length: length, name: name}
+	whole := md5.New()
+	buf := make([]byte, sliceSize)
+	for off := int64(0); off < length; off += sliceSize {
+		if err := readSlice(f, off, buf); err != nil {
+			return nil, err
+		}
+		n := min(sliceSize, length-off)
+		whole.Write(buf[:n])
+		if off == 0 {
+			s.hash16k = md5.Sum(buf[:min(n, 16<<10)])
+		}
+		s.slices = append(s.slices, hashSlice(buf))
+	}
+	copy(s.hash[:], whole.Sum(nil))
+	s.fileID = fileID(s.hash16k, length, name)
+	s.id = md5.Sum(s.mainBody())
+	return s, nil
+}
+
+// writeVolume writes count recovery slices (exponents 0..count-1) and a copy of the critical packets to path.
+// The data is computed in column ranges of all slices at once, so memory stays below maxBuffer.
+func writeVolume(src io.ReaderAt, s *set, count int, path string) error {
+	tmp := path + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	ok := false
+	defer func() {
+		if !ok {
+			out.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+	packetLen := headerSize + 4 + s.sliceSize
+	logs := inputLogs(s.count())
+	chunk := columnChunk(s.sliceSize, count)
+	acc := make([][]byte, count)
+	for r := range acc {
+		acc[r] = make([]byte, chunk)
+	}
+	in := make([]byte, chunk)
+	for col := int64(0); col < s.sliceSize; col += chunk {
+		n := min(chunk, s.sliceSize-col)
+		for r := range acc {
+			clear(acc[r][:n])
+		}
+		for i := range logs {
+			if int64(i)*s.sliceSize+col >= s.length {
+				continue // hinter dem Dateiende nur Nullen
+			}
+			if err := readSlice(src, int64(i)*s.sliceSize+col, in[:n]); err != nil {
+				return err
+			}
+			for r := range acc {
+				mulAdd(acc[r][:n], in[:n], gfPow(logs[i], uint32(r)))
+			}
+		}
+		for r := range acc {
+			if _, err := out.WriteAt(acc[r][:n], int64(r)*packetLen+headerSize+4+col); err != nil {
+				return err
+			}
+		}
+	}
+	// Kopfzeilen mit MD5 über Set-ID, Typ, Exponent und Daten
+	for r := 0; r < count; r++ {
+		pos := int64(r) * packetLen
+		exp := []byte{byte(r), byte(r >> 8), byte(r >> 16), byte(r >> 24)}
+		if _, err := out.WriteAt(exp, pos+headerSize); err != nil {
+			return err
+		}
+		d := md5.New()
+		d.Write(s.id[:])
+		d.Write([]byte(typeRecovery))
+		if _, err := io.Copy(d, io.NewSectionReader(out, pos+headerSize, 4+s.sliceSize)); err != nil {
+			return err
+		}
+		if _, err := out.WriteAt(header(s.id, typeRecovery, int(4+s.sliceSize), sum16(d)), pos); err != nil {
+			return err
+		}
+	}
+	if _, err := out.WriteAt(s.critical(), int64(count)*packetLen); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	ok = true
+	return os.Rename(tmp, path)
+}
+
+// columnChunk returns how many bytes of each slice are processed at once with n output buffers.
+func columnChunk(sliceSize int64, n int) int64 {
+	chunk := min(sliceSize, int64(maxBuffer/max(n, 1))) &^ 3
+	return max(chunk, 4)
+}
+
+func sum16(d hash.Hash) [16]byte {
+	var s [16]byte
+	copy(s[:], d.Sum(nil))
+	return s
+}
+
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
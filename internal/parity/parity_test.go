@@ -0,0 +1,109 @@
+package parity
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepair(t *testing.T) {
+	dir := t.TempDir()
+	zip := filepath.Join(dir, "mysql_backup_20261015_host_shop.zip")
+	data := make([]byte, 300_001)
+	rand.New(rand.NewSource(1)).Read(data)
+	if err := os.WriteFile(zip, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	count, err := Create(zip, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := Files(zip)
+	if count != 8 || len(files) != 2 {
+		t.Fatalf("Create = %d recovery slices, files %v", count, files)
+	}
+	if res, err := Check(zip); err != nil || !res.OK() || res.Recovery != 8 || res.ParityDamaged {
+		t.Fatalf("Check of intact ZIP = %+v, %v", res, err)
+	}
+
+	// Bitfäule in fünf Slices, ein Recovery-Paket beschädigt, Datei gekürzt
+	damaged := append([]byte{}, data...)
+	for _, off := range []int{10, 5000, 120_000, 120_100, 250_000} {
+		damaged[off] ^= 0xff
+	}
+	damaged = damaged[:len(data)-100]
+	if err := os.WriteFile(zip, damaged, 0644); err != nil {
+		t.Fatal(err)
+	}
+	vol := files[1]
+	if filepath.Ext(files[0]) != Ext || !bytes.Contains([]byte(vol), []byte(".vol0+8")) {
+		t.Fatalf("unexpected parity files %v", files)
+	}
+	par, _ := os.ReadFile(vol)
+	par[200] ^= 1
+	if err := os.WriteFile(vol, par, 0644); err != nil {
+		t.Fatal(err)
+	}
+	res, err := Repair(zip)
+	if err != nil {
+		t.Fatalf("Repair: %v (%+v)", err, res)
+	}
+	if len(res.Damaged) != 5 || !res.SizeMismatch || res.Recovery != 7 || !res.ParityDamaged {
+		t.Errorf("Repair result = %+v", res)
+	}
+	got, _ := os.ReadFile(zip)
+	if !bytes.Equal(got, data) {
+		t.Fatal("repaired ZIP differs from the original")
+	}
+	if res, err := Check(zip); err != nil || !res.OK() {
+		t.Errorf("Check after repair = %+v, %v", res, err)
+	}
+
+	// mehr Schäden als Recovery-Slices
+	for off := 0; off < len(damaged); off += 30_000 {
+		damaged[off] ^= 0xff
+	}
+	if err := os.WriteFile(zip, damaged, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := Repair(zip); err == nil || res.Repairable() {
+		t.Errorf("Repair with too few recovery slices = %+v, %v", res, err)
+	}
+	if _, err := os.Stat(zip + ".repair"); !os.IsNotExist(err) {
+		t.Error("temporary repair file left behind")
+	}
+}
+
+func TestZipName(t *testing.T) {
+	for in, want := range map[string]string{
+		"mysql_backup_20261015_host_shop.zip.par2":          "mysql_backup_20261015_host_shop.zip",
+		"mysql_backup_20261015_host_shop.zip.vol00+12.par2": "mysql_backup_20261015_host_shop.zip",
+		"mysql_backup_20261015_host_shop.zip.sha256":        "",
+		"notes.par2": "",
+	} {
+		got, ok := ZipName(in)
+		if ok != (want != "") || (ok && got != want) {
+			t.Errorf("ZipName(%q) = %q, %v, want %q", in, got, ok, want)
+		}
+	}
+}
+
+func TestField(t *testing.T) {
+	seen := map[uint16]bool{}
+	for i := 0; i < gfLimit; i++ {
+		seen[gfExp[i]] = true
+	}
+	if len(seen) != gfLimit || seen[0] {
+		t.Fatalf("2 does not generate GF(2^16): %d elements", len(seen))
+	}
+	for _, x := range []uint16{1, 2, 0x1234, 0xffff} {
+		if gfMul(x, gfInv(x)) != 1 {
+			t.Errorf("%#x * 1/%#x != 1", x, x)
+		}
+	}
+	if logs := inputLogs(6); logs[0] != 1 || logs[3] != 7 || logs[5] != 11 {
+		t.Errorf("inputLogs = %v", logs)
+	}
+}
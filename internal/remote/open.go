@@ -0,0 +1,210 @@
+package remote
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/janmz/mysqlbackup/internal/catalog"
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/kms"
+)
+
+// readAheadSize is the block size in which remote files are read for OpenBackups (archive/zip reads in
+// small pieces; one SFTP round trip per 4 KiB would be very slow).
+const readAheadSize = 1 << 20
+
+// Backup is a backup ZIP on the remote target opened for reading in place (restore --from-remote):
+// ReaderAt returns the plain ZIP content (decrypted if the file is encrypted), Size is its length.
+type Backup struct {
+	Name string
+	io.ReaderAt
+	Size      int64
+	Signature []byte // Inhalt von <name>.sig, nil wenn nicht vorhanden
+}
+
+// OpenBackups connects to the remote target and opens the backup ZIPs matching pattern (file name or
+// wildcards as for GetFile) without downloading them. Files with an AES header are decrypted on the fly
+// with remote_aes_password. The returned close function ends the SFTP session.
+func OpenBackups(cfg *config.Config, pattern string, log interface {
+	Info(string, ...interface{})
+	Warn(string, ...interface{})
+}) ([]Backup, func() error, error) {
+	if cfg.RemoteBackupDir == "" || cfg.RemoteSSHHost == "" {
+		return nil, nil, fmt.Errorf(i18n.T("err.remote_not_configured"))
+	}
+	if !validGetfilePattern(pattern) {
+		return nil, nil, fmt.Errorf(i18n.T("err.getfile_no_path"))
+	}
+	client, err := dial(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf(i18n.T("err.ssh_dial"), err)
+	}
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		client.Close()
+		return nil, nil, fmt.Errorf(i18n.T("err.sftp"), err)
+	}
+	var files []*sftp.File
+	closeAll := func() error {
+		for _, f := range files {
+			f.Close()
+		}
+		sftpClient.Close()
+		return client.Close()
+	}
+	remoteDir := filepath.ToSlash(cfg.RemoteBackupDir)
+	names, err := matchRemote(sftpClient, remoteDir, pattern)
+	if err != nil {
+		closeAll()
+		return nil, nil, err
+	}
+	aesPassword := strings.TrimSpace(cfg.RemoteAESPassword)
+	kmsKey := strings.TrimSpace(cfg.RemoteKMSKey)
+	var backups []Backup
+	for _, name := range names {
+		f, err := sftpClient.Open(remoteDir + "/" + name)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf(i18n.Tf("err.file_failed", name), fmt.Errorf(i18n.T("err.remote_open"), err))
+		}
+		files = append(files, f)
+		info, err := f.Stat()
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf(i18n.Tf("err.file_failed", name), err)
+		}
+		b, err := openBackup(name, f, info.Size(), aesPassword, kmsKey)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf(i18n.Tf("err.file_failed", name), err)
+		}
+		if b.Signature, err = readSignature(sftpClient, remoteDir, name); err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf(i18n.Tf("err.file_failed", name), err)
+		}
+		if _, encrypted := b.ReaderAt.(*ctrReaderAt); encrypted {
+			log.Info(i18n.Tf("log.msg.remote_decrypt", name))
+		}
+		backups = append(backups, b)
+	}
+	return backups, closeAll, nil
+}
+
+// openBackup wraps the remote file r (size bytes): a ZIP (starts with "PK") is read as is, an envelope is decrypted
+// with the data key unwrapped by kmsKey (remote_kms_key; the header must name it), otherwise the
+// salt+nonce header is read and the AES-256-CTR content decrypted at any offset.
+func openBackup(name string, r io.ReaderAt, size int64, aesPassword, kmsKey string) (Backup, error) {
+	header := make([]byte, saltLen+nonceLen)
+	n, err := r.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		return Backup{}, fmt.Errorf(i18n.T("err.remote_read"), err)
+	}
+	cached := &readAhead{r: r}
+	if kms.IsEnvelope(header[:n]) {
+		h, nonce, offset, err := kms.ReadHeader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return Backup{}, err
+		}
+		key, err := h.DataKey(kmsKey)
+		if err != nil {
+			return Backup{}, err
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return Backup{}, fmt.Errorf(i18n.T("err.cipher"), err)
+		}
+		return Backup{Name: name, ReaderAt: &ctrReaderAt{r: cached, block: block, iv: nonce, offset: offset}, Size: size - offset}, nil
+	}
+	if aesPassword == "" || n < len(header) || (header[0] == 'P' && header[1] == 'K') {
+		return Backup{Name: name, ReaderAt: cached, Size: size}, nil
+	}
+	key := pbkdf2.Key([]byte(aesPassword), header[:saltLen], pbkdf2Iter, aesKeyLen, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return Backup{}, fmt.Errorf(i18n.T("err.cipher"), err)
+	}
+	return Backup{Name: name, ReaderAt: &ctrReaderAt{r: cached, block: block, iv: header[saltLen:], offset: encryptionOverhead},
+		Size: size - encryptionOverhead}, nil
+}
+
+// ctrReaderAt decrypts AES-CTR content (behind the header of offset bytes) at arbitrary offsets: the counter
+// of block i is the nonce plus i, so the key stream can start at any block.
+type ctrReaderAt struct {
+	r      io.ReaderAt
+	block  cipher.Block
+	iv     []byte
+	offset int64
+}
+
+func (c *ctrReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off+c.offset)
+	iv := make([]byte, len(c.iv))
+	copy(iv, c.iv)
+	// Big-endian-Addition der Blocknummer auf den Zähler (wie cipher.NewCTR zählt)
+	carry := uint64(off / aes.BlockSize)
+	for i := len(iv) - 1; i >= 0 && carry > 0; i-- {
+		sum := uint64(iv[i]) + carry&0xff
+		iv[i] = byte(sum)
+		carry = carry>>8 + sum>>8
+	}
+	stream := cipher.NewCTR(c.block, iv)
+	if skip := int(off % aes.BlockSize); skip > 0 {
+		var discard [aes.BlockSize]byte
+		stream.XORKeyStream(discard[:skip], discard[:skip])
+	}
+	stream.XORKeyStream(p[:n], p[:n])
+	return n, err
+}
+
+// readAhead serves reads from the last block of readAheadSize bytes read from r (sequential access by
+// archive/zip and flate); larger reads go to r directly. Not safe for concurrent use.
+type readAhead struct {
+	r   io.ReaderAt
+	off int64
+	buf []byte
+}
+
+func (c *readAhead) ReadAt(p []byte, off int64) (int, error) {
+	if len(p) > readAheadSize/2 {
+		return c.r.ReadAt(p, off)
+	}
+	if off < c.off || off+int64(len(p)) > c.off+int64(len(c.buf)) {
+		if c.buf == nil {
+			c.buf = make([]byte, readAheadSize)
+		}
+		n, err := c.r.ReadAt(c.buf[:cap(c.buf)], off)
+		if err != nil && err != io.EOF {
+			c.buf = c.buf[:0]
+			return 0, err
+		}
+		c.off, c.buf = off, c.buf[:n]
+	}
+	n := copy(p, c.buf[off-c.off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// readSignature returns the signature file of the backup name in remoteDir, nil if there is none.
+func readSignature(client *sftp.Client, remoteDir, name string) ([]byte, error) {
+	f, err := client.Open(remoteDir + "/" + name + catalog.SignatureExt)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("err.remote_open"), err)
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
@@ -0,0 +1,42 @@
+package remote
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOpenBackupDecryptsAtOffsets(t *testing.T) {
+	plain := make([]byte, 3*readAheadSize+123)
+	for i := range plain {
+		plain[i] = byte(i * 7)
+	}
+	var enc bytes.Buffer
+	if err := streamEncryptUpload(bytes.NewReader(plain), &enc, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := openBackup("x.zip", bytes.NewReader(enc.Bytes()), int64(enc.Len()), "secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Size != int64(len(plain)) {
+		t.Fatalf("Size = %d, want %d", b.Size, len(plain))
+	}
+	for _, c := range []struct{ off, n int }{{0, 10}, {5, 30}, {4095, 4097}, {readAheadSize - 3, 9}, {2 * readAheadSize, readAheadSize}, {len(plain) - 50, 50}} {
+		got := make([]byte, c.n)
+		if _, err := b.ReadAt(got, int64(c.off)); err != nil {
+			t.Fatalf("ReadAt(%d, %d): %v", c.off, c.n, err)
+		}
+		if !bytes.Equal(got, plain[c.off:c.off+c.n]) {
+			t.Errorf("ReadAt(%d, %d): content differs", c.off, c.n)
+		}
+	}
+
+	zipData := append([]byte("PK\x03\x04"), plain[:100]...)
+	b, err = openBackup("y.zip", bytes.NewReader(zipData), int64(len(zipData)), "secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.ReaderAt.(*ctrReaderAt); ok || b.Size != int64(len(zipData)) {
+		t.Errorf("plain ZIP opened as encrypted")
+	}
+}
@@ -1,9 +1,12 @@
 // Package remote copies backup files to a remote host via SFTP.
-// Optional: Verschlüsselung mit AES-256-CTR (Schlüssel aus remote_aes_password).
+// Optional: Verschlüsselung mit AES-256-CTR (Schlüssel aus remote_aes_password oder je Datei ein Datenschlüssel,
+// den das KMS von remote_kms_key verschlüsselt).
 // Sync: Lokale Dateien hochladen wenn fehlend/älter; Remote-Dateien löschen die lokal nicht mehr existieren.
 package remote
 
 import (
+	"bytes"
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
@@ -11,13 +14,23 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/janmz/mysqlbackup/internal/audit"
+	"github.com/janmz/mysqlbackup/internal/catalog"
 	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/disk"
+	"github.com/janmz/mysqlbackup/internal/errcode"
 	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/kms"
+	"github.com/janmz/mysqlbackup/internal/parity"
+	"github.com/janmz/mysqlbackup/internal/retention"
+	"github.com/janmz/mysqlbackup/internal/signing"
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/pbkdf2"
 	"golang.org/x/crypto/ssh"
@@ -31,7 +44,19 @@ const (
 	encryptionOverhead = saltLen + nonceLen
 )
 
-var backupZipRe = regexp.MustCompile(`^mysql_backup_\d{8}_.*\.zip$`)
+// partExt is appended to the remote name while a file is uploaded.
+const partExt = ".part"
+
+// abortGrace is how long an upload may take to stop by itself after ctx ended before the connection is closed.
+const abortGrace = 10 * time.Second
+
+// dialTimeout limits establishing the SSH connection (e.g. --status with an unreachable remote host).
+const dialTimeout = 30 * time.Second
+
+var (
+	backupZipRe  = regexp.MustCompile(`^mysql_backup_\d{8}_.*\.zip$`)
+	backupDateRe = regexp.MustCompile(`^mysql_backup_(\d{8})_`)
+)
 
 // localEntry holds name, modtime, size for a local backup zip.
 type localEntry struct {
@@ -49,8 +74,10 @@ type remoteEntry struct {
 }
 
 // Sync lists local backup zips and remote files; uploads local if missing or newer (optional AES-256);
-// deletes remote files that are no longer present locally.
-func Sync(cfg *config.Config, backupDir string, log interface {
+// deletes remote files that are no longer present locally. cat (may be nil) supplies the pinned backups
+// and records successful uploads; the caller saves it. When ctx ends (max_run_duration, SIGINT/SIGTERM), a
+// running upload stops and removes its partial file; a connection that does not react is closed after abortGrace.
+func Sync(ctx context.Context, cfg *config.Config, backupDir string, cat *catalog.Catalog, log interface {
 	Info(string, ...interface{})
 	Warn(string, ...interface{})
 	Error(string, ...interface{})
@@ -60,68 +87,230 @@ func Sync(cfg *config.Config, backupDir string, log interface {
 	}
 	localList, err := listLocalBackups(backupDir)
 	if err != nil {
-		return fmt.Errorf(i18n.T("err.list_local"), err)
+		return errcode.Wrap(errcode.RemoteList, fmt.Errorf(i18n.T("err.list_local"), err))
 	}
 	client, err := dial(cfg)
 	if err != nil {
-		return fmt.Errorf(i18n.T("err.ssh_dial"), err)
+		return errcode.Wrap(errcode.RemoteConnect, fmt.Errorf(i18n.T("err.ssh_dial"), err))
 	}
 	defer client.Close()
+	// Ende von ctx: Uploads brechen selbst ab (ctxReader) und räumen auf; hängt die Verbindung, wird sie getrennt
+	stop := context.AfterFunc(ctx, func() { time.AfterFunc(abortGrace, func() { client.Close() }) })
+	defer stop()
 	sftpClient, err := sftp.NewClient(client)
 	if err != nil {
-		return fmt.Errorf(i18n.T("err.sftp"), err)
+		return errcode.Wrap(errcode.RemoteSFTP, fmt.Errorf(i18n.T("err.sftp"), err))
 	}
 	defer sftpClient.Close()
 	remoteDir := filepath.ToSlash(cfg.RemoteBackupDir)
 	if err := sftpClient.MkdirAll(remoteDir); err != nil && !os.IsExist(err) {
 		log.Warn(i18n.Tf("log.warn.sftp_mkdir", remoteDir, err))
 	}
+	removeRemoteParts(sftpClient, remoteDir, log)
 	remoteList, err := listRemote(sftpClient, remoteDir)
 	if err != nil {
-		return fmt.Errorf(i18n.T("err.list_remote"), err)
+		return errcode.Wrap(errcode.RemoteList, fmt.Errorf(i18n.T("err.list_remote"), err))
 	}
 	remoteMap := make(map[string]remoteEntry)
 	for _, e := range remoteList {
 		remoteMap[e.Name] = e
 	}
-	aesPassword := strings.TrimSpace(cfg.RemoteAESPassword)
-	encrypt := aesPassword != ""
-	if encrypt {
+	enc := encryption{aesPassword: strings.TrimSpace(cfg.RemoteAESPassword), kmsKey: strings.TrimSpace(cfg.RemoteKMSKey)}
+	encrypt := enc.on()
+	switch {
+	case enc.kmsKey != "":
+		log.Info(i18n.Tf("log.msg.remote_kms_on", enc.kmsKey))
+	case encrypt:
 		log.Info(i18n.T("log.msg.remote_aes_on"))
-	} else {
+	default:
 		log.Info(i18n.T("log.msg.remote_aes_off"))
 	}
 
 	for _, loc := range localList {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		rem, exists := remoteMap[loc.Name]
 		needUpload := !exists || loc.ModTime.After(rem.ModTime)
-		if encrypt && exists {
-			expectedSize := loc.Size + encryptionOverhead
-			if rem.Size != expectedSize {
-				needUpload = true
-			}
+		if encrypt && exists && !enc.sizeMatches(loc.Size, rem.Size) {
+			needUpload = true
 		}
 		if needUpload {
 			remotePath := remoteDir + "/" + loc.Name
-			if err := uploadFile(sftpClient, loc.Path, remotePath, encrypt, aesPassword); err != nil {
-				return fmt.Errorf(i18n.Tf("err.upload", loc.Name), err)
+			if err := uploadFile(ctx, sftpClient, loc.Path, remotePath, enc); err != nil {
+				if ctx.Err() != nil {
+					log.Warn(i18n.Tf("log.warn.upload_aborted", loc.Name))
+					return ctx.Err()
+				}
+				return errcode.Wrap(errcode.RemoteUpload, fmt.Errorf(i18n.Tf("err.upload", loc.Name), err))
 			}
 			log.Info(i18n.Tf("log.msg.uploaded", loc.Name))
+			// Prüfsumme und Signatur der (unverschlüsselten) ZIP unverschlüsselt daneben ablegen
+			for _, ext := range catalog.Sidecars {
+				if _, err := os.Stat(loc.Path + ext); err == nil {
+					if err := uploadFile(ctx, sftpClient, loc.Path+ext, remotePath+ext, encryption{}); err != nil {
+						log.Warn(i18n.Tf("log.warn.sidecar_upload", loc.Name+ext, err))
+					}
+				}
+			}
+			// Paritätsdateien wie die ZIP verschlüsselt; ältere der ZIP vorher entfernen
+			for _, old := range remoteParity(sftpClient, remoteDir, loc.Name) {
+				_ = sftpClient.Remove(remoteDir + "/" + old)
+			}
+			for _, pf := range parity.Files(loc.Path) {
+				if err := uploadFile(ctx, sftpClient, pf, remoteDir+"/"+filepath.Base(pf), enc); err != nil {
+					log.Warn(i18n.Tf("log.warn.parity_upload", filepath.Base(pf), err))
+				}
+			}
+			if cat != nil {
+				cat.MarkRemote(loc.Name, encrypt)
+			}
+		} else if cat != nil {
+			// Bereits vorhanden (z. B. Katalog neu angelegt): Remote-Status nachtragen
+			if e, ok := cat.Entry(loc.Name); ok && e.RemoteAt.IsZero() {
+				cat.MarkRemote(loc.Name, encrypt)
+			}
+		}
+	}
+	pinned := cat.PinnedSet()
+	archiveDir := ""
+	if cfg.RemoteArchiveDir != "" {
+		archiveDir = filepath.ToSlash(cfg.RemoteArchiveDir)
+		if err := sftpClient.MkdirAll(archiveDir); err != nil && !os.IsExist(err) {
+			log.Warn(i18n.Tf("log.warn.sftp_mkdir", archiveDir, err))
 		}
 	}
 	for _, rem := range remoteList {
-		if _, inLocal := localListByName(localList, rem.Name); !inLocal {
+		if _, inLocal := localListByName(localList, rem.Name); !inLocal && !pinned[rem.Name] {
 			remotePath := remoteDir + "/" + rem.Name
-			if err := sftpClient.Remove(remotePath); err != nil {
+			if archiveDir != "" {
+				if err := sftpClient.PosixRename(remotePath, archiveDir+"/"+rem.Name); err != nil {
+					log.Warn(i18n.Tf("log.warn.remote_archive", rem.Name, err))
+					continue
+				}
+				// Änderungszeit = Archivierungszeit, ab der remote_archive_dir archive_retain_days zählt
+				now := time.Now()
+				_ = sftpClient.Chtimes(archiveDir+"/"+rem.Name, now, now)
+				for _, ext := range catalog.Sidecars {
+					_ = sftpClient.PosixRename(remotePath+ext, archiveDir+"/"+rem.Name+ext)
+				}
+				for _, pf := range remoteParity(sftpClient, remoteDir, rem.Name) {
+					_ = sftpClient.PosixRename(remoteDir+"/"+pf, archiveDir+"/"+pf)
+				}
+				cat.MarkRemoteRemoved(rem.Name)
+				log.Info(i18n.Tf("log.msg.archived_remote", rem.Name, archiveDir))
+				audit.Note(cfg.AuditFile, log, audit.RemoteArchive, cfg.RemoteSSHHost+":"+remotePath, "not in backup_dir -> "+archiveDir)
+				continue
+			}
+			if err := removeRemotePair(sftpClient, remotePath); err != nil {
 				log.Warn(i18n.Tf("log.warn.remote_remove", rem.Name, err))
 				continue
 			}
+			cat.MarkRemoteRemoved(rem.Name)
 			log.Info(i18n.Tf("log.msg.removed_remote", rem.Name))
+			audit.Note(cfg.AuditFile, log, audit.RemoteDelete, cfg.RemoteSSHHost+":"+remotePath, "not in backup_dir")
+		}
+	}
+	removeOrphanSidecars(sftpClient, remoteDir, log)
+	if archiveDir != "" && cfg.ArchiveRetainDays > 0 {
+		pruneRemoteArchive(sftpClient, archiveDir, time.Now().AddDate(0, 0, -cfg.ArchiveRetainDays), pinned, cfg, log)
+	}
+	return nil
+}
+
+// removeRemotePair deletes a remote backup ZIP, its checksum sidecar, signature and parity files (if any).
+func removeRemotePair(client *sftp.Client, remotePath string) error {
+	if err := client.Remove(remotePath); err != nil {
+		return err
+	}
+	for _, ext := range catalog.Sidecars {
+		if err := client.Remove(remotePath + ext); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	dir, name := path.Split(remotePath)
+	for _, pf := range remoteParity(client, dir, name) {
+		if err := client.Remove(dir + pf); err != nil && !os.IsNotExist(err) {
+			return err
 		}
 	}
 	return nil
 }
 
+// remoteParity returns the names of the parity files of the backup zipName in the remote directory dir.
+func remoteParity(client *sftp.Client, dir, zipName string) []string {
+	entries, err := client.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		if z, ok := parity.ZipName(e.Name()); ok && z == zipName && !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
+
+// removeOrphanSidecars deletes remote checksum sidecars, signatures and parity files whose ZIP no longer exists
+// (e.g. ZIP removed by an older version).
+func removeOrphanSidecars(client *sftp.Client, remoteDir string, log interface {
+	Info(string, ...interface{})
+	Warn(string, ...interface{})
+	Error(string, ...interface{})
+}) {
+	entries, err := client.ReadDir(remoteDir)
+	if err != nil {
+		return
+	}
+	present := make(map[string]bool)
+	for _, e := range entries {
+		present[e.Name()] = true
+	}
+	for _, e := range entries {
+		name := e.Name()
+		zipName, ok := parity.ZipName(name)
+		for _, ext := range catalog.Sidecars {
+			if !ok && strings.HasSuffix(name, ext) {
+				zipName, ok = strings.TrimSuffix(name, ext), true
+			}
+		}
+		if e.IsDir() || !ok || present[zipName] {
+			continue
+		}
+		if err := client.Remove(remoteDir + "/" + name); err != nil {
+			log.Warn(i18n.Tf("log.warn.remote_remove", name, err))
+			continue
+		}
+		log.Info(i18n.Tf("log.msg.removed_remote", name))
+	}
+}
+
+// pruneRemoteArchive deletes backups in the remote archive archived before cutoff (pinned ones are kept); Sync sets
+// the modification time to the archival time when it moves a backup there.
+func pruneRemoteArchive(client *sftp.Client, archiveDir string, cutoff time.Time, pinned map[string]bool, cfg *config.Config, log interface {
+	Info(string, ...interface{})
+	Warn(string, ...interface{})
+	Error(string, ...interface{})
+}) {
+	list, err := listRemote(client, archiveDir)
+	if err != nil {
+		log.Warn(i18n.Tf("log.warn.archive_list", archiveDir, err))
+		return
+	}
+	for _, e := range list {
+		if !backupDateRe.MatchString(e.Name) || !e.ModTime.Before(cutoff) || pinned[e.Name] {
+			continue
+		}
+		if err := removeRemotePair(client, archiveDir+"/"+e.Name); err != nil {
+			log.Warn(i18n.Tf("log.warn.remote_remove", e.Name, err))
+			continue
+		}
+		log.Info(i18n.Tf("log.msg.deleted_archived", e.Name))
+		audit.Note(cfg.AuditFile, log, audit.RemoteDelete, cfg.RemoteSSHHost+":"+archiveDir+"/"+e.Name, "archive_retain_days")
+	}
+}
+
 func listLocalBackups(dir string) ([]localEntry, error) {
 	dir = filepath.FromSlash(dir)
 	entries, err := os.ReadDir(dir)
@@ -184,22 +373,122 @@ func listRemote(client *sftp.Client, remoteDir string) ([]remoteEntry, error) {
 	return list, nil
 }
 
-func uploadFile(client *sftp.Client, localPath, remotePath string, encrypt bool, aesPassword string) error {
+// uploadFile writes localPath (encrypted with enc) to remotePath+partExt and renames it to remotePath when
+// complete, so an aborted upload never leaves a partial file under the name of the backup. On error the part
+// file is removed; ctx ends the transfer between two blocks.
+func uploadFile(ctx context.Context, client *sftp.Client, localPath, remotePath string, enc encryption) error {
 	src, err := os.Open(filepath.FromSlash(localPath))
 	if err != nil {
 		return err
 	}
 	defer src.Close()
-	dst, err := client.Create(remotePath)
+	partPath := remotePath + partExt
+	dst, err := client.Create(partPath)
 	if err != nil {
 		return err
 	}
-	defer dst.Close()
-	if !encrypt {
-		_, err = io.Copy(dst, src)
+	err = enc.write(&ctxReader{ctx: ctx, r: src}, dst)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = client.PosixRename(partPath, remotePath)
+	}
+	if err != nil {
+		_ = client.Remove(partPath)
+		return err
+	}
+	return nil
+}
+
+// ctxReader stops reading when ctx ends.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// removeRemoteParts deletes partial uploads (*.part) left in remoteDir by a run that was killed.
+func removeRemoteParts(client *sftp.Client, remoteDir string, log interface {
+	Info(string, ...interface{})
+	Warn(string, ...interface{})
+	Error(string, ...interface{})
+}) {
+	entries, err := client.ReadDir(remoteDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), partExt) {
+			continue
+		}
+		if err := client.Remove(remoteDir + "/" + e.Name()); err != nil {
+			log.Warn(i18n.Tf("log.warn.remote_remove", e.Name(), err))
+			continue
+		}
+		log.Info(i18n.Tf("log.msg.removed_remote", e.Name()))
+	}
+}
+
+// encryption selects how uploads are encrypted: envelope encryption with remote_kms_key takes precedence over
+// remote_aes_password; the zero value uploads the file as is.
+type encryption struct {
+	aesPassword string
+	kmsKey      string
+}
+
+func (e encryption) on() bool {
+	return e.aesPassword != "" || e.kmsKey != ""
+}
+
+// sizeMatches reports whether a remote file of remote bytes is the local file of local bytes encrypted with e.
+// The envelope header has no fixed length (wrapped key of the KMS), so only its minimum is checked; plain or
+// password-encrypted files are smaller and are uploaded again.
+func (e encryption) sizeMatches(local, remote int64) bool {
+	if e.kmsKey != "" {
+		return remote >= local+int64(kms.MinOverhead)
+	}
+	return remote == local+encryptionOverhead
+}
+
+func (e encryption) write(src io.Reader, dst io.Writer) error {
+	switch {
+	case e.kmsKey != "":
+		return streamEnvelopeUpload(src, dst, e.kmsKey)
+	case e.aesPassword != "":
+		return streamEncryptUpload(src, dst, e.aesPassword)
+	}
+	_, err := io.Copy(dst, src)
+	return err
+}
+
+// streamEnvelopeUpload encrypts src with a new data key wrapped by the KMS key uri and writes the envelope header,
+// nonce and AES-256-CTR ciphertext to dst.
+func streamEnvelopeUpload(src io.Reader, dst io.Writer, uri string) error {
+	key, h, err := kms.NewDataKey(uri)
+	if err != nil {
 		return err
 	}
-	return streamEncryptUpload(src, dst, aesPassword)
+	nonce := make([]byte, kms.NonceLen)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf(i18n.T("err.rand_nonce"), err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	if err := kms.WriteHeader(dst, h, nonce); err != nil {
+		return err
+	}
+	w := &cipher.StreamWriter{S: cipher.NewCTR(block, nonce), W: dst}
+	_, err = io.Copy(w, src)
+	return err
 }
 
 // streamEncryptUpload streams plaintext from src, encrypts with AES-256-CTR, writes salt+nonce+ciphertext to dst.
@@ -258,10 +547,95 @@ func dial(cfg *config.Config) (*ssh.Client, error) {
 		User:            cfg.RemoteSSHUser,
 		Auth:            auth,
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         dialTimeout,
 	}
 	return ssh.Dial("tcp", addr, sshConfig)
 }
 
+// Usage returns the number and total size of backup ZIPs in remote_backup_dir (for the storage report).
+func Usage(cfg *config.Config) (files int, size int64, err error) {
+	if cfg.RemoteBackupDir == "" || cfg.RemoteSSHHost == "" {
+		return 0, 0, fmt.Errorf(i18n.T("err.remote_not_configured"))
+	}
+	client, err := dial(cfg)
+	if err != nil {
+		return 0, 0, fmt.Errorf(i18n.T("err.ssh_dial"), err)
+	}
+	defer client.Close()
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		return 0, 0, fmt.Errorf(i18n.T("err.sftp"), err)
+	}
+	defer sftpClient.Close()
+	list, err := listRemote(sftpClient, filepath.ToSlash(cfg.RemoteBackupDir))
+	if err != nil {
+		return 0, 0, fmt.Errorf(i18n.T("err.list_remote"), err)
+	}
+	for _, e := range list {
+		size += e.Size
+	}
+	return len(list), size, nil
+}
+
+// Volume returns size, free space and inodes of the volume holding remote_backup_dir (for --status). Uses the
+// SFTP statvfs extension of OpenSSH; servers without it return an error.
+func Volume(cfg *config.Config) (disk.Stats, error) {
+	if cfg.RemoteBackupDir == "" || cfg.RemoteSSHHost == "" {
+		return disk.Stats{}, fmt.Errorf(i18n.T("err.remote_not_configured"))
+	}
+	client, err := dial(cfg)
+	if err != nil {
+		return disk.Stats{}, fmt.Errorf(i18n.T("err.ssh_dial"), err)
+	}
+	defer client.Close()
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		return disk.Stats{}, fmt.Errorf(i18n.T("err.sftp"), err)
+	}
+	defer sftpClient.Close()
+	st, err := sftpClient.StatVFS(filepath.ToSlash(cfg.RemoteBackupDir))
+	if err != nil {
+		return disk.Stats{}, err
+	}
+	return disk.Stats{Total: st.TotalSpace(), Free: st.Bavail * st.Frsize, Inodes: st.Files, InodesFree: st.Favail}, nil
+}
+
+// ListBackups returns the backup ZIPs in remote_backup_dir like retention.ListBackups (Path is the file name),
+// sorted by date ascending.
+func ListBackups(cfg *config.Config) ([]retention.BackupFile, error) {
+	if cfg.RemoteBackupDir == "" || cfg.RemoteSSHHost == "" {
+		return nil, fmt.Errorf(i18n.T("err.remote_not_configured"))
+	}
+	client, err := dial(cfg)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("err.ssh_dial"), err)
+	}
+	defer client.Close()
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("err.sftp"), err)
+	}
+	defer sftpClient.Close()
+	list, err := listRemote(sftpClient, filepath.ToSlash(cfg.RemoteBackupDir))
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("err.list_remote"), err)
+	}
+	var files []retention.BackupFile
+	for _, e := range list {
+		m := backupDateRe.FindStringSubmatch(e.Name)
+		if m == nil {
+			continue
+		}
+		t, err := time.ParseInLocation("20060102", m[1], time.Local)
+		if err != nil {
+			continue
+		}
+		files = append(files, retention.BackupFile{Path: e.Name, Date: t, ModTime: e.ModTime, Size: e.Size})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Date.Before(files[j].Date) })
+	return files, nil
+}
+
 // GetFile downloads one or more backup files from the remote server into destDir. The pattern
 // may be a literal filename or contain wildcards (*, ?) matched on the remote side. No path
 // components allowed in pattern (only base filename). If the remote file is encrypted, it is
@@ -290,29 +664,13 @@ func GetFile(cfg *config.Config, pattern, destDir string, log interface {
 	remoteDir := filepath.ToSlash(cfg.RemoteBackupDir)
 	destDir = filepath.FromSlash(destDir)
 
-	var toDownload []string
-	if containsWildcard(pattern) {
-		remoteList, err := listRemote(sftpClient, remoteDir)
-		if err != nil {
-			return nil, fmt.Errorf(i18n.T("err.remote_list"), err)
-		}
-		for _, e := range remoteList {
-			ok, err := filepath.Match(pattern, e.Name)
-			if err != nil {
-				return nil, fmt.Errorf(i18n.T("err.pattern"), err)
-			}
-			if ok {
-				toDownload = append(toDownload, e.Name)
-			}
-		}
-		if len(toDownload) == 0 {
-			return nil, fmt.Errorf(i18n.Tf("err.no_remote_match", pattern))
-		}
-	} else {
-		if filepath.Ext(pattern) != ".zip" || !backupZipRe.MatchString(pattern) {
-			return nil, fmt.Errorf(i18n.T("err.only_backup_zip"))
-		}
-		toDownload = []string{pattern}
+	toDownload, err := matchRemote(sftpClient, remoteDir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := signing.Trusted(cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	var saved []string
@@ -321,7 +679,12 @@ func GetFile(cfg *config.Config, pattern, destDir string, log interface {
 		if _, err := os.Stat(localPath); err == nil {
 			localPath = filepath.Join(destDir, name+".lokal")
 		}
-		if err := getOneFile(sftpClient, remoteDir, name, localPath, cfg, log); err != nil {
+		if err := getViaWorkDir(sftpClient, remoteDir, name, localPath, cfg, log); err != nil {
+			return saved, fmt.Errorf(i18n.Tf("err.file_failed", name), err)
+		}
+		if err := getSignature(sftpClient, remoteDir, name, localPath, keys, log); err != nil {
+			_ = os.Remove(localPath)
+			_ = os.Remove(localPath + catalog.SignatureExt)
 			return saved, fmt.Errorf(i18n.Tf("err.file_failed", name), err)
 		}
 		saved = append(saved, localPath)
@@ -329,6 +692,34 @@ func GetFile(cfg *config.Config, pattern, destDir string, log interface {
 	return saved, nil
 }
 
+// matchRemote returns the backup ZIP names in remoteDir matching pattern (literal file name or wildcards).
+func matchRemote(client *sftp.Client, remoteDir, pattern string) ([]string, error) {
+	if !containsWildcard(pattern) {
+		if filepath.Ext(pattern) != ".zip" || !backupZipRe.MatchString(pattern) {
+			return nil, fmt.Errorf(i18n.T("err.only_backup_zip"))
+		}
+		return []string{pattern}, nil
+	}
+	remoteList, err := listRemote(client, remoteDir)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("err.remote_list"), err)
+	}
+	var names []string
+	for _, e := range remoteList {
+		ok, err := filepath.Match(pattern, e.Name)
+		if err != nil {
+			return nil, fmt.Errorf(i18n.T("err.pattern"), err)
+		}
+		if ok {
+			names = append(names, e.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf(i18n.Tf("err.no_remote_match", pattern))
+	}
+	return names, nil
+}
+
 // validGetfilePattern ensures pattern has no path components (no /, \, ..).
 func validGetfilePattern(pattern string) bool {
 	if pattern == "" || strings.Contains(pattern, "..") {
@@ -342,6 +733,56 @@ func containsWildcard(s string) bool {
 	return strings.Contains(s, "*") || strings.Contains(s, "?")
 }
 
+// getViaWorkDir downloads like getOneFile; with work_dir the file is first written there (<name>.part) and
+// moved to localPath when complete, so a slow or remote destination only receives finished files.
+func getViaWorkDir(client *sftp.Client, remoteDir, remoteName, localPath string, cfg *config.Config, log interface {
+	Info(string, ...interface{})
+	Warn(string, ...interface{})
+}) error {
+	if cfg.WorkDir == "" {
+		return getOneFile(client, remoteDir, remoteName, localPath, cfg, log)
+	}
+	if err := os.MkdirAll(cfg.WorkDir, 0755); err != nil {
+		return fmt.Errorf(i18n.T("err.create_work_dir"), err)
+	}
+	partPath := filepath.Join(cfg.WorkDir, filepath.Base(localPath)+".part")
+	if err := getOneFile(client, remoteDir, remoteName, partPath, cfg, log); err != nil {
+		_ = os.Remove(partPath)
+		return err
+	}
+	return retention.MoveFile(partPath, localPath)
+}
+
+// getSignature stores the signature of the downloaded backup remoteName next to localPath (if there is one) and,
+// with trusted keys, verifies the download against it; a missing signature is then an error.
+func getSignature(client *sftp.Client, remoteDir, remoteName, localPath string, keys signing.Keys, log interface {
+	Info(string, ...interface{})
+	Warn(string, ...interface{})
+}) error {
+	sig, err := readSignature(client, remoteDir, remoteName)
+	if err != nil {
+		return err
+	}
+	if sig != nil {
+		if err := os.WriteFile(localPath+catalog.SignatureExt, sig, 0644); err != nil {
+			return err
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := keys.Verify(remoteName, f, sig); err != nil {
+		return err
+	}
+	log.Info(i18n.Tf("log.msg.signature_ok", remoteName))
+	return nil
+}
+
 func getOneFile(client *sftp.Client, remoteDir, remoteName, localPath string, cfg *config.Config, log interface {
 	Info(string, ...interface{})
 	Warn(string, ...interface{})
@@ -357,6 +798,33 @@ func getOneFile(client *sftp.Client, remoteDir, remoteName, localPath string, cf
 	if err != nil && err != io.EOF {
 		return fmt.Errorf(i18n.T("err.remote_read"), err)
 	}
+	if kms.IsEnvelope(header[:n]) {
+		// Der Header kommt vom Remote-Server: entpackt wird nur mit remote_kms_key
+		r := io.MultiReader(bytes.NewReader(header[:n]), src)
+		h, nonce, _, err := kms.ReadHeader(r)
+		if err != nil {
+			return err
+		}
+		key, err := h.DataKey(strings.TrimSpace(cfg.RemoteKMSKey))
+		if err != nil {
+			return err
+		}
+		log.Info(i18n.Tf("log.msg.remote_decrypt", remoteName))
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return fmt.Errorf(i18n.T("err.cipher"), err)
+		}
+		dst, err := os.Create(localPath)
+		if err != nil {
+			return fmt.Errorf(i18n.T("err.local_create"), err)
+		}
+		defer dst.Close()
+		w := &cipher.StreamWriter{S: cipher.NewCTR(block, nonce), W: dst}
+		if _, err := io.Copy(w, r); err != nil {
+			return fmt.Errorf(i18n.T("err.decrypt_write"), err)
+		}
+		return nil
+	}
 	aesPassword := strings.TrimSpace(cfg.RemoteAESPassword)
 	decrypt := aesPassword != "" && n == saltLen+nonceLen && (header[0] != 'P' || header[1] != 'K')
 	if decrypt {
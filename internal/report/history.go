@@ -0,0 +1,148 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/catalog"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// HistoryFormats are the output formats of --history ("" = text table).
+var HistoryFormats = []string{"text", "csv", "json"}
+
+// historyRow is one backup in the CSV and JSON output of History; times are RFC 3339, empty if never.
+type historyRow struct {
+	Database   string `json:"database"`
+	File       string `json:"file"`
+	Date       string `json:"date"` // YYYY-MM-DD
+	Created    string `json:"created"`
+	Size       int64  `json:"size"`
+	DurationMS int64  `json:"duration_ms"`
+	RemoteAt   string `json:"remote_at"`
+	Encrypted  bool   `json:"encrypted"`
+	Pinned     bool   `json:"pinned"`
+	CheckedAt  string `json:"checked_at"`
+	Checked    string `json:"checked"`
+	TestedAt   string `json:"tested_at"`
+	TestResult string `json:"test_result"`
+}
+
+var historyColumns = []string{"database", "file", "date", "created", "size", "duration_ms", "remote_at", "encrypted",
+	"pinned", "checked_at", "checked", "tested_at", "test_result"}
+
+func (r historyRow) fields() []string {
+	return []string{r.Database, r.File, r.Date, r.Created, strconv.FormatInt(r.Size, 10), strconv.FormatInt(r.DurationMS, 10),
+		r.RemoteAt, strconv.FormatBool(r.Encrypted), strconv.FormatBool(r.Pinned), r.CheckedAt, r.Checked, r.TestedAt, r.TestResult}
+}
+
+// History writes the backup history of --history (entries from catalog.History, pinned from
+// catalog.PinnedSet) to w: a table per database with a summary line, or one row per backup as CSV or JSON.
+func History(w io.Writer, entries []catalog.Entry, pinned map[string]bool, format string) error {
+	rows := make([]historyRow, len(entries))
+	for i, e := range entries {
+		rows[i] = historyRow{
+			Database: e.Database, File: e.File, Date: isoDate(e.Date), Created: rfc3339(e.Created), Size: e.Size,
+			DurationMS: e.DurationMS, RemoteAt: rfc3339(e.RemoteAt), Encrypted: e.Encrypted, Pinned: pinned[e.File],
+			CheckedAt: rfc3339(e.CheckedAt), Checked: e.Checked, TestedAt: rfc3339(e.TestedAt), TestResult: e.TestResult,
+		}
+	}
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write(historyColumns)
+		for _, r := range rows {
+			_ = cw.Write(r.fields())
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return historyTable(w, entries, pinned)
+}
+
+// historyTable writes the text form of History: per database one line per backup and a summary.
+func historyTable(w io.Writer, entries []catalog.Entry, pinned map[string]bool) error {
+	if len(entries) == 0 {
+		_, err := fmt.Fprintln(w, i18n.T("msg.no_backups"))
+		return err
+	}
+	var b strings.Builder
+	header := fmt.Sprintf("  %-10s %6s %8s  %-10s  %-19s  %-19s  %s", i18n.T("history.date"), i18n.T("history.size"),
+		i18n.T("history.duration"), i18n.T("history.remote"), i18n.T("history.checked"), i18n.T("history.tested"), i18n.T("history.file"))
+	for i := 0; i < len(entries); {
+		db := entries[i].Database
+		j := i
+		var total, durations int64
+		timed := 0
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		name := db
+		if name == "" {
+			name = i18n.T("history.unknown_db")
+		}
+		b.WriteString(name + "\n" + header + "\n")
+		for ; j < len(entries) && entries[j].Database == db; j++ {
+			e := entries[j]
+			total += e.Size
+			if e.DurationMS > 0 {
+				durations += e.DurationMS
+				timed++
+			}
+			duration := "-"
+			if e.DurationMS > 0 {
+				duration = (time.Duration(e.DurationMS) * time.Millisecond).Round(time.Second).String()
+			}
+			remote := "-"
+			if !e.RemoteAt.IsZero() {
+				remote = e.RemoteAt.Format("2006-01-02")
+			}
+			file := e.File
+			if pinned[e.File] {
+				file += " " + i18n.T("history.pinned")
+			}
+			b.WriteString(fmt.Sprintf("  %-10s %6s %8s  %-10s  %-19s  %-19s  %s\n", isoDate(e.Date), FormatSize(e.Size), duration,
+				remote, result(e.Checked, e.CheckedAt), result(e.TestResult, e.TestedAt), file))
+		}
+		avg := "-"
+		if timed > 0 {
+			avg = (time.Duration(durations/int64(timed)) * time.Millisecond).Round(time.Second).String()
+		}
+		b.WriteString("  " + i18n.Tf("history.summary", j-i, FormatSize(total), avg) + "\n")
+		i = j
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// result formats a check result with its date for the table ("-" if never checked).
+func result(res string, at time.Time) string {
+	if res == "" {
+		return "-"
+	}
+	return res + " " + at.Format("2006-01-02")
+}
+
+// isoDate turns YYYYMMDD into YYYY-MM-DD.
+func isoDate(d string) string {
+	if len(d) != 8 {
+		return d
+	}
+	return d[:4] + "-" + d[4:6] + "-" + d[6:]
+}
+
+func rfc3339(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,170 @@
+// Package report builds the periodic storage report email (backup counts, sizes, pruned backups)
+// and the optional success summary of a run.
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/catalog"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/retention"
+)
+
+// Remote holds the remote side of the report; Err is set if the remote host could not be listed.
+type Remote struct {
+	Configured bool
+	Files      int
+	Size       int64
+	Err        error
+}
+
+// Due reports whether a monthly report is due: none was sent yet or the last one was in an earlier month.
+func Due(last, now time.Time) bool {
+	if last.IsZero() {
+		return true
+	}
+	return last.Year() != now.Year() || last.Month() != now.Month()
+}
+
+// Storage returns the plain-text report body for the local backups, the remote usage and the
+// backups pruned since the given time.
+func Storage(host, backupDir string, files []retention.BackupFile, rem Remote, pruned []catalog.Pruned, since, now time.Time) string {
+	var b strings.Builder
+	b.WriteString(i18n.Tf("report.title", host, since.Format("2006-01-02"), now.Format("2006-01-02")) + "\n\n")
+
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+	b.WriteString(i18n.Tf("report.local", backupDir, len(files), FormatSize(total)) + "\n")
+	switch {
+	case !rem.Configured:
+		b.WriteString(i18n.T("report.remote_none") + "\n")
+	case rem.Err != nil:
+		b.WriteString(i18n.Tf("report.remote_error", rem.Err) + "\n")
+	default:
+		b.WriteString(i18n.Tf("report.remote", rem.Files, FormatSize(rem.Size)) + "\n")
+	}
+
+	type series struct {
+		count          int
+		size           int64
+		oldest, newest time.Time
+	}
+	bySeries := make(map[string]*series)
+	var names []string
+	for _, f := range files {
+		key := strings.TrimSuffix(retention.SeriesKey(f.Path), ".zip")
+		s := bySeries[key]
+		if s == nil {
+			s = &series{oldest: f.Date, newest: f.Date}
+			bySeries[key] = s
+			names = append(names, key)
+		}
+		s.count++
+		s.size += f.Size
+		if f.Date.Before(s.oldest) {
+			s.oldest = f.Date
+		}
+		if f.Date.After(s.newest) {
+			s.newest = f.Date
+		}
+	}
+	sort.Strings(names)
+	b.WriteString("\n" + i18n.T("report.per_database") + "\n")
+	if len(names) == 0 {
+		b.WriteString("  " + i18n.T("msg.no_backups") + "\n")
+	}
+	for _, name := range names {
+		s := bySeries[name]
+		b.WriteString("  " + i18n.Tf("report.series", name, s.count, FormatSize(s.size),
+			s.oldest.Format("2006-01-02"), s.newest.Format("2006-01-02")) + "\n")
+		// Kein Backup seit mehr als zwei Tagen: stiller Ausfall dieser Datenbank
+		if now.Sub(s.newest) > 48*time.Hour {
+			b.WriteString("    " + i18n.T("report.stale") + "\n")
+		}
+	}
+
+	var prunedSize int64
+	for _, p := range pruned {
+		prunedSize += p.Size
+	}
+	b.WriteString("\n" + i18n.Tf("report.pruned", len(pruned), FormatSize(prunedSize)) + "\n")
+	for _, p := range pruned {
+		action := i18n.T("report.deleted")
+		if p.Archived {
+			action = i18n.T("report.archived")
+		}
+		line := fmt.Sprintf("  %s %-10s %s", p.At.Format("2006-01-02"), action, filepath.Base(p.File))
+		if p.RemoteRemoved {
+			line += " " + i18n.T("report.remote_removed")
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// Run returns the plain-text success summary of one backup run: created ZIPs with size and dump
+// duration, backups pruned by retention and the remote sync result (uploaded = ZIPs uploaded in this run).
+func Run(host string, created []catalog.Entry, pruned []catalog.Pruned, rem Remote, uploaded int, started, finished time.Time) string {
+	var b strings.Builder
+	b.WriteString(i18n.Tf("report.run_title", host, started.Format("2006-01-02 15:04"), finished.Sub(started).Round(time.Second)) + "\n\n")
+
+	var total int64
+	b.WriteString(i18n.Tf("report.run_created", len(created)) + "\n")
+	for _, e := range created {
+		total += e.Size
+		duration := (time.Duration(e.DurationMS) * time.Millisecond).Round(time.Second)
+		b.WriteString(fmt.Sprintf("  %-30s %6s %8s  %s\n", e.Database, FormatSize(e.Size), duration, e.File))
+	}
+	b.WriteString("  " + i18n.Tf("report.run_total", FormatSize(total)) + "\n")
+
+	b.WriteString("\n" + i18n.Tf("report.pruned_run", len(pruned)) + "\n")
+	for _, p := range pruned {
+		action := i18n.T("report.deleted")
+		if p.Archived {
+			action = i18n.T("report.archived")
+		}
+		line := fmt.Sprintf("  %-10s %s", action, filepath.Base(p.File))
+		if p.RemoteRemoved {
+			line += " " + i18n.T("report.remote_removed")
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n")
+	switch {
+	case !rem.Configured:
+		b.WriteString(i18n.T("report.remote_none") + "\n")
+	case rem.Err != nil:
+		b.WriteString(i18n.Tf("report.remote_error", rem.Err) + "\n")
+	default:
+		b.WriteString(i18n.Tf("report.run_remote", uploaded) + "\n")
+	}
+	return b.String()
+}
+
+// FormatSize formats size: bytes without suffix; 1024*n as "nK", 1024²*n as "nM", 1024³*n as "nG", 1024⁴*n as "nT";
+// one decimal if value < 10, else none.
+func FormatSize(n int64) string {
+	const k = 1024
+	if n < k {
+		return strconv.FormatInt(n, 10)
+	}
+	v, unit := float64(n)/k, "K"
+	for _, u := range []string{"M", "G", "T"} {
+		if v < k {
+			break
+		}
+		v, unit = v/k, u
+	}
+	if v < 10 {
+		return fmt.Sprintf("%.1f%s", v, unit)
+	}
+	return fmt.Sprintf("%d%s", int64(v), unit)
+}
@@ -0,0 +1,118 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/catalog"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/retention"
+)
+
+func TestDue(t *testing.T) {
+	now := time.Date(2026, 10, 1, 2, 0, 0, 0, time.Local)
+	tests := []struct {
+		last time.Time
+		want bool
+	}{
+		{time.Time{}, true},
+		{time.Date(2026, 9, 30, 2, 0, 0, 0, time.Local), true},
+		{time.Date(2026, 10, 1, 1, 0, 0, 0, time.Local), false},
+		{time.Date(2025, 10, 15, 2, 0, 0, 0, time.Local), true},
+	}
+	for _, tt := range tests {
+		if got := Due(tt.last, now); got != tt.want {
+			t.Errorf("Due(%v) = %v, want %v", tt.last, got, tt.want)
+		}
+	}
+}
+
+func TestStorageListsSeriesAndPruned(t *testing.T) {
+	now := time.Date(2026, 10, 16, 3, 0, 0, 0, time.Local)
+	files := []retention.BackupFile{
+		{Path: "/b/mysql_backup_20261001_host_shop.zip", Date: time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local), Size: 2048},
+		{Path: "/b/mysql_backup_20261016_host_shop.zip", Date: time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local), Size: 2048},
+		{Path: "/b/mysql_backup_20261010_host_wiki.zip", Date: time.Date(2026, 10, 10, 0, 0, 0, 0, time.Local), Size: 100},
+	}
+	pruned := []catalog.Pruned{{File: "mysql_backup_20260901_host_shop.zip", Size: 2048, At: now.AddDate(0, 0, -3)}}
+	body := Storage("host", "/b", files, Remote{}, pruned, now.AddDate(0, -1, 0), now)
+	for _, want := range []string{"host_shop", "host_wiki", "mysql_backup_20260901_host_shop.zip"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("report body lacks %q:\n%s", want, body)
+		}
+	}
+	// wiki's newest backup is six days old -> stale warning exactly once
+	if strings.Count(body, i18n.T("report.stale")) != 1 {
+		t.Errorf("expected one stale warning:\n%s", body)
+	}
+}
+
+func TestRunSummary(t *testing.T) {
+	started := time.Date(2026, 10, 16, 22, 0, 0, 0, time.Local)
+	created := []catalog.Entry{{File: "mysql_backup_20261016_host_shop.zip", Database: "shop", Size: 4096, DurationMS: 61000}}
+	pruned := []catalog.Pruned{{File: "mysql_backup_20260901_host_shop.zip", Archived: true}}
+	body := Run("host", created, pruned, Remote{Configured: true}, 1, started, started.Add(2*time.Minute))
+	for _, want := range []string{"shop", "4.0K", "1m1s", "mysql_backup_20260901_host_shop.zip", i18n.T("report.archived"), i18n.Tf("report.run_remote", 1)} {
+		if !strings.Contains(body, want) {
+			t.Errorf("summary lacks %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	day := time.Date(2026, 10, 15, 22, 0, 0, 0, time.UTC)
+	entries := []catalog.Entry{
+		{File: "mysql_backup_20261015_host_shop.zip", Database: "shop", Date: "20261015", Size: 4096, DurationMS: 61000,
+			Created: day, RemoteAt: day.Add(time.Hour), CheckedAt: day.Add(2 * time.Hour), Checked: catalog.ResultOK},
+		{File: "mysql_backup_20261016_host_shop.zip", Database: "shop", Date: "20261016", Size: 2048, DurationMS: 59000},
+	}
+	pinned := map[string]bool{"mysql_backup_20261015_host_shop.zip": true}
+	var b strings.Builder
+	if err := History(&b, entries, pinned, "csv"); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	want := "shop,mysql_backup_20261015_host_shop.zip,2026-10-15,2026-10-15T22:00:00Z,4096,61000,2026-10-15T23:00:00Z,false,true,2026-10-16T00:00:00Z,ok,,"
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "database,file,") || lines[1] != want {
+		t.Errorf("CSV:\n%s", b.String())
+	}
+	b.Reset()
+	if err := History(&b, entries, pinned, "json"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `"remote_at": ""`) || !strings.Contains(b.String(), `"checked": "ok"`) {
+		t.Errorf("JSON:\n%s", b.String())
+	}
+	b.Reset()
+	if err := History(&b, entries, pinned, ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"shop\n", "ok 2026-10-16", i18n.Tf("history.summary", 2, "6.0K", "1m0s")} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("table lacks %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	const k = 1024
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{512, "512"},
+		{1536, "1.5K"},
+		{200 * k, "200K"},
+		{5 * k * k, "5.0M"},
+		{5 * k * k * k, "5.0G"},
+		{900 * k * k * k, "900G"},
+		{3 * k * k * k * k, "3.0T"},
+		{2048 * k * k * k * k, "2048T"},
+	}
+	for _, tt := range tests {
+		if got := FormatSize(tt.n); got != tt.want {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package restore
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// createTableRe matches the first line of a CREATE TABLE statement of mysqldump.
+	createTableRe = regexp.MustCompile("^CREATE TABLE (?:IF NOT EXISTS )?`((?:[^`]|``)+)` \\(")
+	// insertRe matches the beginning of an INSERT statement of mysqldump.
+	insertRe = regexp.MustCompile("^INSERT (?:IGNORE )?INTO `((?:[^`]|``)+)`")
+	// columnRe matches a column line inside CREATE TABLE (without the trailing comma): name and definition.
+	columnRe = regexp.MustCompile("^`((?:[^`]|``)+)` (.*)$")
+	// autoIncrementRe matches the AUTO_INCREMENT counter in the table options (changes with every insert).
+	autoIncrementRe = regexp.MustCompile(` AUTO_INCREMENT=\d+`)
+)
+
+// Schema is the structure of the tables in a dump plus their row counts (--diff).
+type Schema struct {
+	Databases []string
+	Tables    map[string]*Table
+	Views     []string
+	RowsExact bool // Zeilenzahlen aus manifest.json (beim Dump gezählt), sonst aus dem SQL geschätzt
+}
+
+// Table is one table of a Schema.
+type Table struct {
+	Columns []Column
+	Keys    []string // PRIMARY KEY, KEY, UNIQUE KEY, CONSTRAINT, ... as in the dump
+	Options string   // ENGINE, CHARSET, ... after the closing parenthesis, without AUTO_INCREMENT
+	Rows    int64    // as dumped if Schema.RowsExact, otherwise approximate: "),(" in the INSERT statements
+}
+
+// Column is one column of a Table.
+type Column struct {
+	Name       string
+	Definition string // type and attributes, e.g. "varchar(255) NOT NULL"
+}
+
+// rowsManifest is the part of manifest.json (written by the backup) with the row counts of the dump.
+type rowsManifest struct {
+	Rows map[string]int64 `json:"rows"`
+}
+
+// ReadSchema reads the table structures of the SQL file in the backup ZIP src with the row counts recorded in its
+// manifest. Backups without them (older versions) get the rows counted from the INSERT statements (extended
+// inserts: the "),(" between tuples, so values containing it count too).
+func ReadSchema(src Source) (*Schema, error) {
+	sqlFile, err := findSQL(src)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := sqlFile.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	s := &Schema{Tables: make(map[string]*Table)}
+	if err := s.scan(rc); err != nil {
+		return nil, err
+	}
+	var m rowsManifest
+	if readManifest(src, &m); m.Rows != nil {
+		for name, t := range s.Tables {
+			t.Rows = m.Rows[name]
+		}
+		s.RowsExact = true
+	}
+	return s, nil
+}
+
+func (s *Schema) scan(r io.Reader) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	var create, insert *Table // Tabelle der laufenden CREATE TABLE- bzw. INSERT-Anweisung
+	var tail []byte           // Ende des vorigen Stücks einer langen INSERT-Zeile
+	lineStart := true
+	sep := []byte("),(")
+	for {
+		chunk, err := br.ReadSlice('\n')
+		switch {
+		case len(chunk) == 0:
+		case !lineStart:
+			if insert != nil {
+				insert.Rows += int64(bytes.Count(append(tail, chunk...), sep))
+				tail = lastBytes(chunk, len(sep)-1)
+			}
+		default:
+			line := bytes.TrimRight(chunk, "\r\n")
+			insert = nil
+			switch {
+			case create != nil:
+				create = s.structureLine(create, string(line))
+			case bytes.HasPrefix(line, []byte("INSERT ")):
+				if m := insertRe.FindSubmatch(line); m != nil {
+					insert = s.table(unquote(m[1]))
+					insert.Rows += 1 + int64(bytes.Count(chunk, sep))
+					tail = lastBytes(chunk, len(sep)-1)
+				}
+			case bytes.HasPrefix(line, []byte("CREATE TABLE ")):
+				if m := createTableRe.FindSubmatch(line); m != nil {
+					create = s.table(unquote(m[1]))
+					create.Columns, create.Keys, create.Options = nil, nil, ""
+				}
+			case bytes.HasPrefix(line, []byte("-- Final view structure for view ")):
+				name := bytes.TrimPrefix(line, []byte("-- Final view structure for view "))
+				s.Views = append(s.Views, unquote(bytes.Trim(name, "`")))
+			default:
+				if m := createDatabaseRe.FindSubmatch(line); m != nil {
+					s.Databases = append(s.Databases, unquote(m[1]))
+				}
+			}
+		}
+		if len(chunk) > 0 {
+			lineStart = chunk[len(chunk)-1] == '\n'
+			if lineStart {
+				insert = nil
+			}
+		}
+		switch err {
+		case nil, bufio.ErrBufferFull:
+		case io.EOF:
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// table returns the table name of s, created on first use (INSERT before CREATE TABLE with --no-create-info).
+func (s *Schema) table(name string) *Table {
+	t := s.Tables[name]
+	if t == nil {
+		t = &Table{}
+		s.Tables[name] = t
+	}
+	return t
+}
+
+// structureLine adds a line inside CREATE TABLE (column, key or the closing line with the table options) to t
+// and returns t, or nil after the closing line.
+func (s *Schema) structureLine(t *Table, line string) *Table {
+	trimmed := strings.TrimSuffix(strings.TrimSpace(line), ",")
+	switch {
+	case strings.HasPrefix(trimmed, ")"):
+		opts := strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(trimmed, ")")), ";")
+		t.Options = strings.TrimSpace(autoIncrementRe.ReplaceAllString(opts, ""))
+		return nil
+	case strings.HasPrefix(trimmed, "`"):
+		if m := columnRe.FindStringSubmatch(trimmed); m != nil {
+			t.Columns = append(t.Columns, Column{Name: unquote([]byte(m[1])), Definition: m[2]})
+		}
+	case trimmed != "":
+		t.Keys = append(t.Keys, trimmed)
+	}
+	return t
+}
+
+// lastBytes returns a copy of the last n bytes of b (fewer if b is shorter).
+func lastBytes(b []byte, n int) []byte {
+	if len(b) > n {
+		b = b[len(b)-n:]
+	}
+	return append([]byte(nil), b...)
+}
+
+// SchemaDiff is the difference between two Schemas (--diff).
+type SchemaDiff struct {
+	AddedTables   []string
+	RemovedTables []string
+	Tables        []TableDiff // tables in both with changed structure or row count, sorted by name
+	AddedViews    []string
+	RemovedViews  []string
+}
+
+// TableDiff is the change of a table between two Schemas.
+type TableDiff struct {
+	Name           string
+	AddedColumns   []Column
+	RemovedColumns []Column
+	ChangedColumns []ColumnChange
+	AddedKeys      []string
+	RemovedKeys    []string
+	OldOptions     string // only set when the options changed
+	NewOptions     string
+	OldRows        int64
+	NewRows        int64
+}
+
+// ColumnChange is a column whose definition changed.
+type ColumnChange struct {
+	Name     string
+	Old, New string
+}
+
+// StructureChanged reports whether columns, keys or options of the table changed (not only the row count).
+func (d *TableDiff) StructureChanged() bool {
+	return len(d.AddedColumns)+len(d.RemovedColumns)+len(d.ChangedColumns)+len(d.AddedKeys)+len(d.RemovedKeys) > 0 ||
+		d.OldOptions != d.NewOptions
+}
+
+// Empty reports whether the Schemas are the same (structure and row counts).
+func (d *SchemaDiff) Empty() bool {
+	return len(d.AddedTables)+len(d.RemovedTables)+len(d.Tables)+len(d.AddedViews)+len(d.RemovedViews) == 0
+}
+
+// CompareSchemas returns the changes from a (older backup) to b (newer backup).
+func CompareSchemas(a, b *Schema) *SchemaDiff {
+	d := &SchemaDiff{}
+	for _, name := range sortedTables(b) {
+		if _, ok := a.Tables[name]; !ok {
+			d.AddedTables = append(d.AddedTables, name)
+		}
+	}
+	for _, name := range sortedTables(a) {
+		tb, ok := b.Tables[name]
+		if !ok {
+			d.RemovedTables = append(d.RemovedTables, name)
+			continue
+		}
+		td := compareTables(name, a.Tables[name], tb)
+		if td.StructureChanged() || td.OldRows != td.NewRows {
+			d.Tables = append(d.Tables, td)
+		}
+	}
+	d.AddedViews, d.RemovedViews = added(a.Views, b.Views), added(b.Views, a.Views)
+	return d
+}
+
+func compareTables(name string, a, b *Table) TableDiff {
+	d := TableDiff{Name: name, OldRows: a.Rows, NewRows: b.Rows}
+	old := make(map[string]string, len(a.Columns))
+	for _, c := range a.Columns {
+		old[c.Name] = c.Definition
+	}
+	cur := make(map[string]bool, len(b.Columns))
+	for _, c := range b.Columns {
+		cur[c.Name] = true
+		def, ok := old[c.Name]
+		switch {
+		case !ok:
+			d.AddedColumns = append(d.AddedColumns, c)
+		case def != c.Definition:
+			d.ChangedColumns = append(d.ChangedColumns, ColumnChange{Name: c.Name, Old: def, New: c.Definition})
+		}
+	}
+	for _, c := range a.Columns {
+		if !cur[c.Name] {
+			d.RemovedColumns = append(d.RemovedColumns, c)
+		}
+	}
+	d.AddedKeys, d.RemovedKeys = added(a.Keys, b.Keys), added(b.Keys, a.Keys)
+	if a.Options != b.Options {
+		d.OldOptions, d.NewOptions = a.Options, b.Options
+	}
+	return d
+}
+
+// added returns the items of b that are not in a, in the order of b.
+func added(a, b []string) []string {
+	in := make(map[string]bool, len(a))
+	for _, s := range a {
+		in[s] = true
+	}
+	var out []string
+	for _, s := range b {
+		if !in[s] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func sortedTables(s *Schema) []string {
+	names := make([]string, 0, len(s.Tables))
+	for name := range s.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
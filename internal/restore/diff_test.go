@@ -0,0 +1,80 @@
+package restore
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareSchemas(t *testing.T) {
+	zipOf := func(sql, manifest string) Source {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, _ := zw.Create("shop.sql")
+		w.Write([]byte(sql))
+		if manifest != "" {
+			w, _ = zw.Create("manifest.json")
+			w.Write([]byte(manifest))
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return Source{Name: "shop.zip", ReaderAt: bytes.NewReader(buf.Bytes()), Size: int64(buf.Len())}
+	}
+	tuples := make([]string, 30000) // eine Zeile über mehrere Puffer
+	for i := range tuples {
+		tuples[i] = "(1,'a')"
+	}
+	older := "CREATE DATABASE `shop`;\n" +
+		"CREATE TABLE `users` (\n  `id` int(11) NOT NULL AUTO_INCREMENT,\n  `email` varchar(100) NOT NULL,\n" +
+		"  `fax` varchar(20) DEFAULT NULL,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB AUTO_INCREMENT=3 DEFAULT CHARSET=utf8mb4;\n" +
+		"INSERT INTO `users` VALUES (1,'a','x'),(2,'b',NULL);\n" +
+		"CREATE TABLE `log` (\n  `id` int(11) NOT NULL\n) ENGINE=MyISAM;\n" +
+		"INSERT INTO `log` VALUES " + strings.Join(tuples, ",") + ";\n"
+	newer := "CREATE DATABASE `shop`;\n" +
+		"CREATE TABLE `users` (\n  `id` int(11) NOT NULL AUTO_INCREMENT,\n  `email` varchar(255) NOT NULL,\n" +
+		"  `phone` varchar(20) DEFAULT NULL,\n  PRIMARY KEY (`id`),\n  UNIQUE KEY `email` (`email`)\n" +
+		") ENGINE=InnoDB AUTO_INCREMENT=9 DEFAULT CHARSET=utf8mb4;\n" +
+		"INSERT INTO `users` VALUES (1,'a',NULL),(2,'b',NULL),(3,'c','1),(2');\n" +
+		"CREATE TABLE `orders` (\n  `id` int(11) NOT NULL\n) ENGINE=InnoDB;\n" +
+		"-- Final view structure for view `v_users`\n"
+
+	a, err := ReadSchema(zipOf(older, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.RowsExact || a.Tables["log"] == nil || a.Tables["log"].Rows != 30000 || a.Tables["users"].Rows != 2 {
+		t.Fatalf("rows: log %+v, users %+v", a.Tables["log"], a.Tables["users"])
+	}
+	// Zeilenzahlen aus manifest.json statt "),(" (der String in users zählt sonst mit)
+	b, err := ReadSchema(zipOf(newer, `{"database": "shop", "rows": {"users": 3}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.RowsExact || b.Tables["users"].Rows != 3 || b.Tables["orders"].Rows != 0 {
+		t.Fatalf("manifest rows: users %+v, orders %+v", b.Tables["users"], b.Tables["orders"])
+	}
+	d := CompareSchemas(a, b)
+	if !reflect.DeepEqual(d.AddedTables, []string{"orders"}) || !reflect.DeepEqual(d.RemovedTables, []string{"log"}) ||
+		!reflect.DeepEqual(d.AddedViews, []string{"v_users"}) || len(d.RemovedViews) != 0 || len(d.Tables) != 1 {
+		t.Fatalf("diff = %+v", d)
+	}
+	u := d.Tables[0]
+	want := TableDiff{
+		Name:           "users",
+		AddedColumns:   []Column{{"phone", "varchar(20) DEFAULT NULL"}},
+		RemovedColumns: []Column{{"fax", "varchar(20) DEFAULT NULL"}},
+		ChangedColumns: []ColumnChange{{"email", "varchar(100) NOT NULL", "varchar(255) NOT NULL"}},
+		AddedKeys:      []string{"UNIQUE KEY `email` (`email`)"},
+		OldRows:        2,
+		NewRows:        3,
+	}
+	if !reflect.DeepEqual(u, want) {
+		t.Errorf("users:\n got %+v\nwant %+v", u, want)
+	}
+	if d := CompareSchemas(b, b); !d.Empty() {
+		t.Errorf("same schema: %+v", d)
+	}
+}
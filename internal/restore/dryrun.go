@@ -0,0 +1,312 @@
+package restore
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// headLen is the number of bytes of a statement kept to classify it and to show it in problems.
+const headLen = 200
+
+// maxProblems is the number of problems reported per backup ZIP; more are only counted.
+const maxProblems = 20
+
+var (
+	// execCommentRe matches the start of a versioned comment ("/*!40101 ") whose content mysql executes.
+	execCommentRe = regexp.MustCompile(`^/\*!\d*\s*`)
+	// databaseStmtRe matches CREATE/DROP DATABASE and USE with the database name.
+	databaseStmtRe = regexp.MustCompile("(?i)^(CREATE|DROP) (?:DATABASE|SCHEMA) (?:/\\*!\\d+ )?(?:IF (?:NOT )?EXISTS ?(?:\\*/ ?)?)?`((?:[^`]|``)+)`|^(USE) `((?:[^`]|``)+)`")
+	// disallowedRe matches statements a backup of this tool never contains and a restore must not run.
+	disallowedRe = regexp.MustCompile(`(?i)^(?:SHUTDOWN|KILL |(?:UN)?INSTALL (?:PLUGIN|COMPONENT)|LOAD (?:DATA|XML)|CHANGE (?:MASTER|REPLICATION)|` +
+		`RESET (?:MASTER|SLAVE|REPLICA|PERSIST)|(?:START|STOP) (?:SLAVE|REPLICA|GROUP_REPLICATION)|PURGE (?:BINARY|MASTER) LOGS|` +
+		`SET (?:GLOBAL|PERSIST|PERSIST_ONLY) |SET @@(?:GLOBAL|PERSIST)\.)|INTO (?:OUTFILE|DUMPFILE)`)
+	// gtidPurgedRe matches the SET @@GLOBAL.GTID_PURGED that mysqldump itself writes on GTID servers.
+	gtidPurgedRe = regexp.MustCompile(`(?i)^SET @@GLOBAL\.GTID_PURGED`)
+)
+
+// Problem is something the dry run found in the SQL of a backup ZIP: line in the imported SQL, description
+// and the beginning of the statement.
+type Problem struct {
+	Line    int
+	Message string
+	Context string
+}
+
+// CheckResult is the outcome of the dry run (--restore --dry-run) of one backup ZIP.
+type CheckResult struct {
+	File       string
+	Database   string // expected database (name of the SQL file)
+	Statements int
+	Problems   []Problem
+	More       int // problems beyond maxProblems
+}
+
+// dryRun reads the SQL of every source through the filter of opt and a statement splitter instead of
+// importing it: a statement left open at the end (truncated file), a statement a restore must not run, another
+// database than the expected one or, for whole databases, a missing CREATE DATABASE or "-- Dump completed" are
+// reported. Nothing is sent to MySQL.
+func dryRun(sources []Source, opt Options, log Logger) error {
+	failed := 0
+	for _, src := range sources {
+		r, err := checkSource(src, opt)
+		if err != nil {
+			return fmt.Errorf(i18n.Tf("err.restore_zip", src.Name), err)
+		}
+		for _, p := range r.Problems {
+			log.Warn(i18n.Tf("log.warn.dryrun_problem", r.File, p.Line, p.Message, p.Context))
+		}
+		if r.More > 0 {
+			log.Warn(i18n.Tf("log.warn.dryrun_more", r.File, r.More))
+		}
+		if len(r.Problems) > 0 {
+			failed++
+			continue
+		}
+		log.Info(i18n.Tf("log.msg.dryrun_ok", r.File, r.Database, r.Statements))
+	}
+	if failed > 0 {
+		return fmt.Errorf(i18n.T("err.dryrun_failed"), failed, len(sources))
+	}
+	return nil
+}
+
+// checkSource runs the statement splitter over the SQL of src as it would be imported with opt.
+func checkSource(src Source, opt Options) (*CheckResult, error) {
+	db, err := Database(src)
+	if err != nil {
+		return nil, err
+	}
+	sqlFile, err := findSQL(src)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := sqlFile.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	whole := !opt.UsersOnly && len(opt.Tables) == 0
+	s := newSplitter(db, !opt.UsersOnly, whole)
+	pr, pw := io.Pipe()
+	go func() {
+		var err error
+		if filter := opt.newFilter(); filter != nil {
+			err = filter.copy(pw, rc)
+		} else {
+			_, err = io.Copy(pw, rc)
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	err = s.run(pr)
+	_ = pr.CloseWithError(err)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckResult{File: src.Name, Database: db, Statements: s.statements, Problems: s.problems, More: s.more}, nil
+}
+
+// splitter splits a SQL stream into statements like the mysql client: delimiter (DELIMITER command), quotes,
+// backticks and comments are respected; versioned comments (/*!...*/) are code. Only the beginning of each
+// statement is kept.
+type splitter struct {
+	database    string
+	wantCreate  bool // CREATE DATABASE of database expected
+	wantEnd     bool // "-- Dump completed" expected (whole dump)
+	delimiter   []byte
+	line        int
+	stmtLine    int    // line where the current statement began
+	head        []byte // beginning of the current statement (whitespace collapsed)
+	pending     bool   // current statement has code
+	quote       byte   // open quote (' " `), 0 = none
+	escaped     bool
+	comment     bool   // in /* */ comment
+	lineComment bool   // rest of the line is a comment
+	tail        []byte // last code bytes (delimiter match)
+	created     bool
+	completed   bool
+	statements  int
+	problems    []Problem
+	more        int
+}
+
+func newSplitter(database string, wantCreate, wantEnd bool) *splitter {
+	return &splitter{database: database, wantCreate: wantCreate, wantEnd: wantEnd, delimiter: []byte(";"), line: 1}
+}
+
+// run reads r to the end and records the problems found.
+func (s *splitter) run(r io.Reader) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	lineStart := true
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if len(chunk) > 0 {
+			if !(lineStart && s.clientLine(chunk, err == nil)) {
+				s.scan(chunk)
+			} else if chunk[len(chunk)-1] == '\n' {
+				s.line++
+			}
+			lineStart = chunk[len(chunk)-1] == '\n'
+		}
+		switch err {
+		case nil, bufio.ErrBufferFull:
+		case io.EOF:
+			s.finish()
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// clientLine handles a line outside any statement that the mysql client itself interprets: comment lines and
+// DELIMITER. It reports whether the line was consumed.
+func (s *splitter) clientLine(line []byte, whole bool) bool {
+	if s.pending || s.quote != 0 || s.comment {
+		return false
+	}
+	t := bytes.TrimSpace(line)
+	switch {
+	case bytes.HasPrefix(t, []byte("#")), bytes.Equal(t, []byte("--")), bytes.HasPrefix(t, []byte("-- ")), bytes.HasPrefix(t, []byte("--\t")):
+		if bytes.HasPrefix(t, []byte("-- Dump completed")) {
+			s.completed = true
+		}
+		s.lineComment = !whole
+		return true
+	case whole && len(t) > 10 && strings.EqualFold(string(t[:10]), "DELIMITER "):
+		s.delimiter = append([]byte(nil), bytes.TrimSpace(t[10:])...)
+		return true
+	}
+	return false
+}
+
+// scan feeds one chunk (at most one line) to the state machine.
+func (s *splitter) scan(chunk []byte) {
+	for i := 0; i < len(chunk); i++ {
+		c := chunk[i]
+		if c == '\n' {
+			s.line++
+			s.lineComment = false
+		}
+		switch {
+		case s.lineComment:
+			continue
+		case s.comment:
+			if c == '*' && i+1 < len(chunk) && chunk[i+1] == '/' {
+				s.comment = false
+				i++
+			}
+			continue
+		case s.quote != 0:
+			s.add(c)
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case c == '\\' && s.quote != '`':
+				s.escaped = true
+			case c == s.quote:
+				s.quote = 0
+			}
+			continue
+		}
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			s.quote = c
+		case c == '#' || (c == '-' && i+2 < len(chunk) && chunk[i+1] == '-' && (chunk[i+2] == ' ' || chunk[i+2] == '\t' || chunk[i+2] == '\n' || chunk[i+2] == '\r')):
+			s.lineComment = true
+			continue
+		case c == '/' && i+1 < len(chunk) && chunk[i+1] == '*' && (i+2 >= len(chunk) || chunk[i+2] != '!'):
+			s.comment = true
+			i++
+			continue
+		}
+		s.add(c)
+		s.tail = append(s.tail, c)
+		if len(s.tail) > len(s.delimiter) {
+			s.tail = s.tail[len(s.tail)-len(s.delimiter):]
+		}
+		if s.quote == 0 && bytes.Equal(s.tail, s.delimiter) {
+			s.end()
+		}
+	}
+}
+
+// add appends c to the current statement (head collapsed to single spaces).
+func (s *splitter) add(c byte) {
+	space := c == ' ' || c == '\t' || c == '\r' || c == '\n'
+	if !s.pending {
+		if space {
+			return
+		}
+		s.pending = true
+		s.stmtLine = s.line
+	}
+	if len(s.head) >= headLen {
+		return
+	}
+	if space {
+		if s.head[len(s.head)-1] == ' ' {
+			return
+		}
+		c = ' '
+	}
+	s.head = append(s.head, c)
+}
+
+// end completes the current statement at its delimiter.
+func (s *splitter) end() {
+	stmt := strings.TrimSpace(strings.TrimSuffix(string(s.head), string(s.delimiter)))
+	s.head, s.tail, s.pending = s.head[:0], s.tail[:0], false
+	s.statements++
+	s.check(stmt)
+}
+
+// check classifies one statement.
+func (s *splitter) check(stmt string) {
+	code := execCommentRe.ReplaceAllString(stmt, "")
+	if m := databaseStmtRe.FindStringSubmatch(code); m != nil {
+		kind, name := strings.ToUpper(m[1]), m[2]
+		if m[3] != "" {
+			kind, name = "USE", m[4]
+		}
+		name = strings.ReplaceAll(name, "``", "`")
+		switch {
+		case name != s.database:
+			s.problem(s.stmtLine, fmt.Sprintf(i18n.T("err.dryrun_other_database"), name, s.database), stmt)
+		case kind == "CREATE":
+			s.created = true
+		}
+		return
+	}
+	if disallowedRe.MatchString(code) && !gtidPurgedRe.MatchString(code) {
+		s.problem(s.stmtLine, i18n.T("err.dryrun_disallowed"), stmt)
+	}
+}
+
+// finish checks the state at the end of the stream.
+func (s *splitter) finish() {
+	switch {
+	case s.quote != 0 || s.comment:
+		s.problem(s.stmtLine, i18n.T("err.dryrun_truncated_quote"), string(s.head))
+	case s.pending:
+		s.problem(s.stmtLine, i18n.T("err.dryrun_truncated"), string(s.head))
+	case s.wantEnd && !s.completed:
+		s.problem(s.line, i18n.T("err.dryrun_no_end"), "")
+	}
+	if s.wantCreate && !s.created {
+		s.problem(0, fmt.Sprintf(i18n.T("err.dryrun_no_create"), s.database), "")
+	}
+}
+
+func (s *splitter) problem(line int, msg, context string) {
+	if len(s.problems) >= maxProblems {
+		s.more++
+		return
+	}
+	s.problems = append(s.problems, Problem{Line: line, Message: msg, Context: context})
+}
@@ -0,0 +1,49 @@
+package restore
+
+import (
+	"strings"
+	"testing"
+)
+
+const routineDump = "-- MySQL dump\n/*!40101 SET NAMES utf8mb4 */;\n" +
+	"CREATE DATABASE /*!32312 IF NOT EXISTS*/ `shop` /*!40100 DEFAULT CHARACTER SET utf8mb4 */;\nUSE `shop`;\n" +
+	"CREATE TABLE `t` (\n  `s` varchar(10) -- comment; not the end\n);\n" +
+	"INSERT INTO `t` VALUES ('a;b'),('it\\'s; -- no comment'),(\"x\");\n" +
+	"DELIMITER ;;\n/*!50003 CREATE*/ /*!50003 PROCEDURE `p`() BEGIN SELECT 1; SELECT 2; END */;;\nDELIMITER ;\n" +
+	"-- Dump completed on 2025-06-12\n"
+
+func TestSplitter(t *testing.T) {
+	s := newSplitter("shop", true, true)
+	if err := s.run(strings.NewReader(routineDump)); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.problems) > 0 || s.statements != 6 {
+		t.Errorf("statements = %d, problems = %v", s.statements, s.problems)
+	}
+
+	cases := []struct {
+		name, sql, want string
+		line            int
+	}{
+		{"truncated", routineDump[:strings.Index(routineDump, "(\"x\")")], "err.dryrun_truncated", 8},
+		{"quote", routineDump[:strings.Index(routineDump, "b'),")], "err.dryrun_truncated_quote", 8},
+		{"no end", strings.TrimSuffix(routineDump, "-- Dump completed on 2025-06-12\n"), "err.dryrun_no_end", 12},
+		{"disallowed", routineDump + "SET GLOBAL read_only = 1;\n", "err.dryrun_disallowed", 13},
+		{"other database", strings.Replace(routineDump, "USE `shop`", "USE `crm`", 1), "err.dryrun_other_database", 4},
+	}
+	for _, c := range cases {
+		s := newSplitter("shop", true, true)
+		if err := s.run(strings.NewReader(c.sql)); err != nil {
+			t.Fatal(err)
+		}
+		if len(s.problems) == 0 || s.problems[0].Line != c.line {
+			t.Errorf("%s: problems = %v, want %s in line %d", c.name, s.problems, c.want, c.line)
+		}
+	}
+
+	s = newSplitter("shop", true, true)
+	_ = s.run(strings.NewReader(strings.Replace(routineDump, "CREATE DATABASE", "-- CREATE DATABASE", 1)))
+	if len(s.problems) != 1 || s.problems[0].Line != 0 {
+		t.Errorf("missing CREATE DATABASE: problems = %v", s.problems)
+	}
+}
@@ -0,0 +1,134 @@
+package restore
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/mysql"
+)
+
+var (
+	// eventStatusRe matches the status clause of an enabled event in the CREATE EVENT header, which SHOW CREATE
+	// EVENT (and so mysqldump) writes on one line before DO.
+	eventStatusRe = regexp.MustCompile(`\b(ON COMPLETION (?:NOT )?PRESERVE )(ENABLE|DISABLE ON (?:SLAVE|REPLICA))\b`)
+	eventNameRe   = regexp.MustCompile("\\bEVENT `((?:[^`]|``)+)`")
+	eventsDBRe    = regexp.MustCompile(`^-- Dumping events for database '(.*)'`)
+)
+
+// eventFilter recreates the events of a dump DISABLED (Options.Events): in the events section of mysqldump the
+// status ENABLE (or DISABLE ON SLAVE) of each CREATE EVENT is replaced by DISABLE before the statement reaches
+// the server, so no event runs on the restored copy before it was checked. The rest is copied unchanged.
+type eventFilter struct {
+	disabled []string // `db`.`event` der deaktivierten Events
+}
+
+func (f *eventFilter) copy(w io.Writer, r io.Reader) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	lineStart, inEvents, db := true, false, ""
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if len(chunk) > 0 {
+			out := chunk
+			if lineStart && bytes.HasPrefix(chunk, []byte("-- ")) {
+				if m := eventsDBRe.FindSubmatch(bytes.TrimRight(chunk, "\r\n")); m != nil {
+					inEvents, db = true, string(m[1])
+				} else if tableSectionRe.Match(chunk) || otherSectionRe.Match(chunk) {
+					inEvents = false
+				}
+			} else if lineStart && inEvents {
+				// ganze Zeile sammeln: mysqldump schreibt CREATE EVENT samt DO-Rumpf in eine Zeile, auch über 64 KiB
+				line := append([]byte{}, chunk...)
+				for err == bufio.ErrBufferFull {
+					chunk, err = br.ReadSlice('\n')
+					line = append(line, chunk...)
+				}
+				chunk = line
+				out = f.rewrite(line, db)
+			}
+			if _, werr := w.Write(out); werr != nil {
+				return werr
+			}
+			lineStart = chunk[len(chunk)-1] == '\n'
+		}
+		switch err {
+		case nil, bufio.ErrBufferFull:
+		case io.EOF:
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// rewrite disables the event created in line (database db), if any.
+func (f *eventFilter) rewrite(line []byte, db string) []byte {
+	loc := eventStatusRe.FindSubmatchIndex(line)
+	name := eventNameRe.FindSubmatch(line)
+	if loc == nil || name == nil {
+		return line
+	}
+	f.disabled = append(f.disabled, db+"."+string(bytes.ReplaceAll(name[1], []byte("``"), []byte("`"))))
+	out := append([]byte{}, line[:loc[4]]...)
+	out = append(out, "DISABLE"...)
+	return append(out, line[loc[5]:]...)
+}
+
+// eventManifest is the part of manifest.json (written by the backup) about events.
+type eventManifest struct {
+	EventScheduler string        `json:"event_scheduler"`
+	Events         []mysql.Event `json:"events"`
+}
+
+// readEventManifest returns the events part of the manifest of src; zero without manifest or events.
+func readEventManifest(src Source) eventManifest {
+	var m eventManifest
+	readManifest(src, &m)
+	return m
+}
+
+// readManifest decodes manifest.json of the backup ZIP src into v; v stays unchanged without (readable) manifest.
+func readManifest(src Source, v interface{}) {
+	zr, err := zip.NewReader(src.ReaderAt, src.Size)
+	if err != nil {
+		return
+	}
+	for _, f := range zr.File {
+		if strings.EqualFold(filepath.Base(f.Name), "manifest.json") {
+			if data, err := readEntry(f); err == nil {
+				_ = json.Unmarshal(data, v)
+			}
+		}
+	}
+}
+
+// reportEvents logs the events disabled by events (nil = kept as dumped) and compares the event_scheduler
+// setting recorded in the manifests with that of the target server, which the restore does not change.
+func reportEvents(conn *mysql.Conn, sources []Source, events *eventFilter, log Logger) {
+	if events != nil && len(events.disabled) > 0 {
+		log.Warn(i18n.Tf("log.warn.restore_events_disabled", len(events.disabled), strings.Join(events.disabled, ", ")))
+	}
+	var recorded string
+	for _, src := range sources {
+		if m := readEventManifest(src); len(m.Events) > 0 && m.EventScheduler != "" {
+			recorded = m.EventScheduler
+		}
+	}
+	if recorded == "" {
+		return
+	}
+	target, err := conn.EventScheduler()
+	if err != nil {
+		log.Warn(i18n.Tf("log.warn.event_scheduler", err))
+		return
+	}
+	if target != recorded {
+		log.Info(i18n.Tf("log.msg.restore_event_scheduler", recorded, target))
+	}
+}
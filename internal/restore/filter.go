@@ -0,0 +1,323 @@
+package restore
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/mysql"
+)
+
+var (
+	// tableSectionRe matches the comment line mysqldump writes before the structure and the data of a table.
+	tableSectionRe = regexp.MustCompile("^-- (?:Table structure|Dumping data) for table `((?:[^`]|``)+)`")
+	// otherSectionRe matches the sections that are not part of a table (views, routines, events) and the end of the dump.
+	otherSectionRe = regexp.MustCompile("^-- (?:Temporary view structure|Temporary table structure|Final view structure|Dumping events|Dumping routines|Dump completed)")
+)
+
+// sqlFilter selects the part of a backup's SQL stream that is imported.
+type sqlFilter interface {
+	copy(w io.Writer, r io.Reader) error
+}
+
+// tableFilter copies a mysqldump stream and keeps only the header (session settings, CREATE DATABASE, USE)
+// and the structure and data sections of the selected tables. Views, routines, events and everything after
+// "-- Dump completed" (the appended user block) are dropped. Lines are streamed in chunks, so long
+// extended INSERT lines are never held in memory as a whole.
+type tableFilter struct {
+	tables map[string]bool
+	found  map[string]bool // selected tables seen in the stream
+	keep   bool
+	done   bool
+}
+
+func newTableFilter(tables []string) *tableFilter {
+	f := &tableFilter{tables: make(map[string]bool), found: make(map[string]bool)}
+	for _, t := range tables {
+		f.tables[t] = true
+	}
+	return f
+}
+
+// copy writes the filtered stream from r to w (one SQL file; found accumulates over several).
+func (f *tableFilter) copy(w io.Writer, r io.Reader) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	lineStart := true
+	f.keep, f.done = true, false
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if len(chunk) > 0 {
+			if lineStart {
+				f.line(chunk)
+			}
+			if f.keep && !f.done {
+				if _, werr := w.Write(chunk); werr != nil {
+					return werr
+				}
+			}
+			lineStart = chunk[len(chunk)-1] == '\n'
+		}
+		switch err {
+		case nil, bufio.ErrBufferFull:
+		case io.EOF:
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// line updates the state at the start of a line (section markers are comment lines beginning with "-- ").
+func (f *tableFilter) line(b []byte) {
+	if !bytes.HasPrefix(b, []byte("-- ")) {
+		return
+	}
+	if m := tableSectionRe.FindSubmatch(b); m != nil {
+		name := string(bytes.ReplaceAll(m[1], []byte("``"), []byte("`")))
+		f.keep = f.tables[name]
+		if f.keep {
+			f.found[name] = true
+		}
+		return
+	}
+	if otherSectionRe.Match(b) {
+		f.keep = false
+		if bytes.HasPrefix(b, []byte("-- Dump completed")) {
+			f.done = true
+		}
+	}
+}
+
+// countFilter copies the whole stream and counts the structure sections of tables.
+type countFilter struct {
+	tables *int
+}
+
+func (f *countFilter) copy(w io.Writer, r io.Reader) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	lineStart := true
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if len(chunk) > 0 {
+			if lineStart && bytes.HasPrefix(chunk, []byte("-- Table structure for table ")) {
+				*f.tables++
+			}
+			if _, werr := w.Write(chunk); werr != nil {
+				return werr
+			}
+			lineStart = chunk[len(chunk)-1] == '\n'
+		}
+		switch err {
+		case nil, bufio.ErrBufferFull:
+		case io.EOF:
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// usersFilter copies only the users/grants block that the backup appends after the dump
+// ("-- Dump completed" line) and counts its statements.
+type usersFilter struct {
+	statements int
+}
+
+func (f *usersFilter) copy(w io.Writer, r io.Reader) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	lineStart, after := true, false
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if len(chunk) > 0 {
+			if after {
+				if lineStart && (bytes.HasPrefix(chunk, []byte("CREATE USER")) || bytes.HasPrefix(chunk, []byte("GRANT "))) {
+					f.statements++
+				}
+				if _, werr := w.Write(chunk); werr != nil {
+					return werr
+				}
+			} else if lineStart && bytes.HasPrefix(chunk, []byte("-- Dump completed")) {
+				after = true
+			}
+			lineStart = chunk[len(chunk)-1] == '\n'
+		}
+		switch err {
+		case nil, bufio.ErrBufferFull:
+		case io.EOF:
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// contextLen is the number of bytes of a failed statement's line shown in the summary.
+const contextLen = 200
+
+// FailedStatement is a statement skipped with Options.ContinueOnError: backup ZIP, line in the imported SQL,
+// error message of mysql and the beginning of that line.
+type FailedStatement struct {
+	File    string
+	Line    int
+	Message string
+	Context string
+}
+
+// statementContext reads the SQL of src again (through filter, so the line numbers match the import) and
+// returns errs with the beginning of their lines.
+func statementContext(src Source, filter sqlFilter, errs []mysql.StatementError) []FailedStatement {
+	w := &lineCapture{line: 1, want: make(map[int]string)}
+	for _, e := range errs {
+		w.want[e.Line] = ""
+	}
+	if sqlFile, err := findSQL(src); err == nil {
+		if rc, err := sqlFile.Open(); err == nil {
+			if filter != nil {
+				_ = filter.copy(w, rc)
+			} else {
+				_, _ = io.Copy(w, rc)
+			}
+			rc.Close()
+			w.flush()
+		}
+	}
+	failed := make([]FailedStatement, 0, len(errs))
+	for _, e := range errs {
+		failed = append(failed, FailedStatement{File: src.Name, Line: e.Line, Message: e.Message, Context: w.want[e.Line]})
+	}
+	return failed
+}
+
+// lineCapture is a writer that keeps the first contextLen bytes of the wanted lines (numbered from 1).
+type lineCapture struct {
+	line int
+	want map[int]string
+	cur  []byte
+}
+
+func (w *lineCapture) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		part := p
+		if i >= 0 {
+			part = p[:i]
+		}
+		if _, ok := w.want[w.line]; ok && len(w.cur) < contextLen {
+			w.cur = append(w.cur, part[:min(len(part), contextLen-len(w.cur))]...)
+		}
+		if i < 0 {
+			break
+		}
+		w.flush()
+		w.line++
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// flush stores the captured beginning of the current line.
+func (w *lineCapture) flush() {
+	if _, ok := w.want[w.line]; ok {
+		w.want[w.line] = strings.TrimRight(string(w.cur), "\r")
+	}
+	w.cur = w.cur[:0]
+}
+
+var (
+	// charsetClauseRe matches character set clauses of CREATE DATABASE/TABLE and column definitions.
+	charsetClauseRe = regexp.MustCompile(`(?i)\b((?:DEFAULT\s+)?(?:CHARSET|CHARACTER\s+SET)(?:\s*=\s*|\s+))(\w+)`)
+	// collateClauseRe matches COLLATE clauses (with the preceding space, so they can be removed).
+	collateClauseRe = regexp.MustCompile(`(?i)(\s*)\bCOLLATE(\s*=\s*|\s+)(\w+)`)
+)
+
+// charsetFilter rewrites the character set and collation clauses of the structure statements (e.g. latin1 to
+// utf8mb4); data lines (INSERT) and SET NAMES are copied unchanged, so the data is still read in the charset of
+// the dump and converted by the server. Without a collation, COLLATE clauses are removed (default collation of
+// the new charset).
+type charsetFilter struct {
+	charset   string
+	collation string
+}
+
+// newCharsetFilter returns the filter for charset/collation, nil if both are empty. Without charset it is
+// taken from the collation (utf8mb4_unicode_ci -> utf8mb4).
+func newCharsetFilter(charset, collation string) *charsetFilter {
+	charset, collation = strings.TrimSpace(charset), strings.TrimSpace(collation)
+	if charset == "" && collation == "" {
+		return nil
+	}
+	if charset == "" {
+		charset, _, _ = strings.Cut(collation, "_")
+	}
+	return &charsetFilter{charset: charset, collation: collation}
+}
+
+func (f *charsetFilter) copy(w io.Writer, r io.Reader) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	lineStart := true
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if len(chunk) > 0 {
+			out := chunk
+			// nur vollständige Zeilen umschreiben; lange Zeilen sind Daten (INSERT)
+			if lineStart && err == nil && !bytes.HasPrefix(chunk, []byte("INSERT ")) && !bytes.Contains(chunk, []byte("SET NAMES")) {
+				out = f.rewrite(chunk)
+			}
+			if _, werr := w.Write(out); werr != nil {
+				return werr
+			}
+			lineStart = chunk[len(chunk)-1] == '\n'
+		}
+		switch err {
+		case nil, bufio.ErrBufferFull:
+		case io.EOF:
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// rewrite replaces the charset and collation clauses in one line.
+func (f *charsetFilter) rewrite(line []byte) []byte {
+	line = charsetClauseRe.ReplaceAll(line, []byte("${1}"+f.charset))
+	if f.collation != "" {
+		return collateClauseRe.ReplaceAll(line, []byte("${1}COLLATE${2}"+f.collation))
+	}
+	return collateClauseRe.ReplaceAll(line, nil)
+}
+
+// chainFilter passes the stream through first, then through second.
+type chainFilter struct {
+	first, second sqlFilter
+}
+
+// chain returns a filter applying first and then second; either may be nil.
+func chain(first, second sqlFilter) sqlFilter {
+	switch {
+	case first == nil:
+		return second
+	case second == nil:
+		return first
+	}
+	return &chainFilter{first: first, second: second}
+}
+
+func (c *chainFilter) copy(w io.Writer, r io.Reader) error {
+	pr, pw := io.Pipe()
+	firstErr := make(chan error, 1)
+	go func() {
+		err := c.first.copy(pw, r)
+		_ = pw.CloseWithError(err)
+		firstErr <- err
+	}()
+	err := c.second.copy(w, pr)
+	_ = pr.CloseWithError(err)
+	if ferr := <-firstErr; err == nil {
+		err = ferr
+	}
+	return err
+}
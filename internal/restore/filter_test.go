@@ -0,0 +1,136 @@
+package restore
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/janmz/mysqlbackup/internal/mysql"
+)
+
+const dump = "-- MySQL dump\n/*!40101 SET NAMES utf8mb4 */;\n--\n-- Current Database: `shop`\n--\n" +
+	"CREATE DATABASE IF NOT EXISTS `shop`;\nUSE `shop`;\n" +
+	"--\n-- Table structure for table `orders`\n--\nDROP TABLE IF EXISTS `orders`;\nCREATE TABLE `orders` (id int);\n" +
+	"--\n-- Dumping data for table `orders`\n--\nINSERT INTO `orders` VALUES (1),(2);\n" +
+	"--\n-- Table structure for table `users`\n--\nCREATE TABLE `users` (id int);\n" +
+	"--\n-- Dumping data for table `users`\n--\nINSERT INTO `users` VALUES (1);\n" +
+	"--\n-- Dumping routines for database 'shop'\n--\nCREATE PROCEDURE p() BEGIN END;\n" +
+	"-- Dump completed on 2025-06-12\n\n\nCREATE USER 'app'@'%';\nFLUSH PRIVILEGES;\n"
+
+func TestTableFilter(t *testing.T) {
+	f := newTableFilter([]string{"orders", "missing"})
+	var out bytes.Buffer
+	if err := f.copy(&out, strings.NewReader(dump)); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	for _, want := range []string{"SET NAMES", "USE `shop`;", "CREATE TABLE `orders`", "INSERT INTO `orders`"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	for _, not := range []string{"users", "PROCEDURE", "CREATE USER", "FLUSH"} {
+		if strings.Contains(got, not) {
+			t.Errorf("unexpected %q in:\n%s", not, got)
+		}
+	}
+	if !f.found["orders"] || f.found["missing"] {
+		t.Errorf("found = %v", f.found)
+	}
+}
+
+func TestUsersFilter(t *testing.T) {
+	dump := dump + "GRANT SELECT ON `shop`.* TO 'app'@'%';\n"
+	var f usersFilter
+	var out bytes.Buffer
+	if err := f.copy(&out, strings.NewReader(dump)); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); strings.Contains(got, "orders") || !strings.HasPrefix(strings.TrimSpace(got), "CREATE USER 'app'@'%';") {
+		t.Errorf("output:\n%s", got)
+	}
+	if f.statements != 2 {
+		t.Errorf("statements = %d, want 2", f.statements)
+	}
+}
+
+func TestStatementContext(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("shop.sql")
+	w.Write([]byte(dump))
+	zw.Close()
+	src := Source{Name: "x.zip", ReaderAt: bytes.NewReader(buf.Bytes()), Size: int64(buf.Len())}
+	got := statementContext(src, newTableFilter([]string{"users"}), []mysql.StatementError{{Line: 11, Message: "ERROR 1050"}})
+	if len(got) != 1 || got[0].Context != "CREATE TABLE `users` (id int);" {
+		t.Errorf("statementContext = %+v", got)
+	}
+}
+
+func TestCharsetFilter(t *testing.T) {
+	const latin1 = "/*!40101 SET NAMES latin1 */;\n" +
+		"CREATE DATABASE /*!32312 IF NOT EXISTS*/ `shop` /*!40100 DEFAULT CHARACTER SET latin1 */;\n" +
+		"CREATE TABLE `t` (\n  `name` varchar(10) CHARACTER SET latin1 COLLATE latin1_bin NOT NULL\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1 COLLATE=latin1_swedish_ci;\n" +
+		"INSERT INTO `t` VALUES ('DEFAULT CHARSET=latin1');\n"
+	f := chain(newCharsetFilter("", "utf8mb4_unicode_ci"), nil)
+	var out bytes.Buffer
+	if err := f.copy(&out, strings.NewReader(latin1)); err != nil {
+		t.Fatal(err)
+	}
+	want := "/*!40101 SET NAMES latin1 */;\n" +
+		"CREATE DATABASE /*!32312 IF NOT EXISTS*/ `shop` /*!40100 DEFAULT CHARACTER SET utf8mb4 */;\n" +
+		"CREATE TABLE `t` (\n  `name` varchar(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;\n" +
+		"INSERT INTO `t` VALUES ('DEFAULT CHARSET=latin1');\n"
+	if got := out.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	out.Reset()
+	tables := newTableFilter([]string{"orders"})
+	dump := strings.Replace(dump, "CREATE TABLE `orders` (id int);", "CREATE TABLE `orders` (id int) DEFAULT CHARSET=latin1 COLLATE=latin1_swedish_ci;", 1)
+	if err := chain(newCharsetFilter("utf8mb4", ""), tables).copy(&out, strings.NewReader(dump)); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "CREATE TABLE `orders` (id int) DEFAULT CHARSET=utf8mb4;\n") || strings.Contains(got, "users") {
+		t.Errorf("output:\n%s", got)
+	}
+}
+
+func TestEventFilter(t *testing.T) {
+	const events = "--\n-- Dumping events for database 'shop'\n--\n" +
+		"/*!50106 DROP EVENT IF EXISTS `cleanup` */;\nDELIMITER ;;\n" +
+		"/*!50106 CREATE*/ /*!50117 DEFINER=`root`@`localhost`*/ /*!50106 EVENT `cleanup` ON SCHEDULE EVERY 1 DAY STARTS '2026-01-01 03:00:00' ON COMPLETION NOT PRESERVE ENABLE DO DELETE FROM sessions WHERE note = 'ON COMPLETION PRESERVE ENABLE' */ ;;\n" +
+		"/*!50106 CREATE*/ /*!50117 DEFINER=`root`@`localhost`*/ /*!50106 EVENT `old` ON SCHEDULE AT '2026-01-01 00:00:00' ON COMPLETION PRESERVE DISABLE DO SELECT 1 */ ;;\n" +
+		"/*!50106 CREATE*/ /*!50117 DEFINER=`root`@`localhost`*/ /*!50106 EVENT `rep` ON SCHEDULE EVERY 1 HOUR ON COMPLETION NOT PRESERVE DISABLE ON SLAVE DO SELECT 1 */ ;;\n" +
+		"DELIMITER ;\n--\n-- Dumping routines for database 'shop'\n--\n" +
+		"INSERT INTO `t` VALUES ('EVENT `x` ON COMPLETION PRESERVE ENABLE');\n"
+	f := &eventFilter{}
+	var out bytes.Buffer
+	if err := f.copy(&out, strings.NewReader(events)); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Replace(events, "NOT PRESERVE ENABLE DO DELETE", "NOT PRESERVE DISABLE DO DELETE", 1)
+	want = strings.Replace(want, "DISABLE ON SLAVE", "DISABLE", 1)
+	if got := out.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if strings.Join(f.disabled, ",") != "shop.cleanup,shop.rep" {
+		t.Errorf("disabled = %v", f.disabled)
+	}
+
+	// Event mit langem Rumpf: die Zeile ist länger als der Lesepuffer
+	long := "--\n-- Dumping events for database 'shop'\n--\n" +
+		"/*!50106 CREATE*/ /*!50106 EVENT `big` ON SCHEDULE EVERY 1 DAY ON COMPLETION NOT PRESERVE ENABLE DO INSERT INTO log VALUES ('" +
+		strings.Repeat("x", 200*1024) + "') */ ;;\n"
+	f = &eventFilter{}
+	out.Reset()
+	if err := f.copy(&out, strings.NewReader(long)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), strings.Replace(long, "PRESERVE ENABLE", "PRESERVE DISABLE", 1); got != want || len(f.disabled) != 1 {
+		t.Errorf("long event not disabled (disabled = %v, output %d bytes)", f.disabled, out.Len())
+	}
+}
@@ -0,0 +1,117 @@
+package restore
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// createDatabaseRe matches the CREATE DATABASE statement of a mysqldump --databases dump.
+var createDatabaseRe = regexp.MustCompile("^CREATE DATABASE (?:/\\*!32312 IF NOT EXISTS\\*/ |IF NOT EXISTS )?`((?:[^`]|``)+)`")
+
+// Entry is one file in a backup ZIP.
+type Entry struct {
+	Name string
+	Size uint64 // uncompressed
+}
+
+// Contents describes what a backup ZIP contains (--inspect).
+type Contents struct {
+	Entries   []Entry
+	Manifest  []byte   // manifest.json of the ZIP, nil if it has none
+	Databases []string // CREATE DATABASE statements of the dump
+	Tables    []string // tables with structure section
+	Views     []string
+	Users     []string // CREATE USER and GRANT statements of the appended users block
+}
+
+// Inspect reads the backup ZIP src: its entries, the manifest and, by scanning the SQL file once, the
+// databases, tables, views and the users/grants block.
+func Inspect(src Source) (*Contents, error) {
+	zr, err := zip.NewReader(src.ReaderAt, src.Size)
+	if err != nil {
+		return nil, err
+	}
+	c := &Contents{}
+	for _, f := range zr.File {
+		c.Entries = append(c.Entries, Entry{Name: f.Name, Size: f.UncompressedSize64})
+		if strings.EqualFold(filepath.Base(f.Name), "manifest.json") {
+			if c.Manifest, err = readEntry(f); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sqlFile, err := findSQL(src)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := sqlFile.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	if err := c.scan(rc); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func readEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// scan collects databases, tables and views from the section comments of the dump and the statements after
+// "-- Dump completed" (users block). Only the beginning of long lines is looked at.
+func (c *Contents) scan(r io.Reader) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	lineStart, users := true, false
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if len(chunk) > 0 && lineStart {
+			line := bytes.TrimRight(chunk, "\r\n")
+			switch {
+			case users:
+				if bytes.HasPrefix(line, []byte("CREATE USER")) || bytes.HasPrefix(line, []byte("GRANT ")) {
+					c.Users = append(c.Users, string(line))
+				}
+			case bytes.HasPrefix(line, []byte("-- Dump completed")):
+				users = true
+			case bytes.HasPrefix(line, []byte("-- Table structure for table ")):
+				if m := tableSectionRe.FindSubmatch(line); m != nil {
+					c.Tables = append(c.Tables, unquote(m[1]))
+				}
+			case bytes.HasPrefix(line, []byte("-- Final view structure for view ")):
+				name := bytes.TrimPrefix(line, []byte("-- Final view structure for view "))
+				c.Views = append(c.Views, unquote(bytes.Trim(name, "`")))
+			default:
+				if m := createDatabaseRe.FindSubmatch(line); m != nil {
+					c.Databases = append(c.Databases, unquote(m[1]))
+				}
+			}
+		}
+		if len(chunk) > 0 {
+			lineStart = chunk[len(chunk)-1] == '\n'
+		}
+		switch err {
+		case nil, bufio.ErrBufferFull:
+		case io.EOF:
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// unquote undoes the doubling of backticks in a quoted identifier.
+func unquote(b []byte) string {
+	return string(bytes.ReplaceAll(b, []byte("``"), []byte("`")))
+}
@@ -0,0 +1,31 @@
+package restore
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("shop.sql")
+	w.Write([]byte(dump + "GRANT SELECT ON `shop`.* TO 'app'@'%';\n"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Inspect(Source{Name: "x.zip", ReaderAt: bytes.NewReader(buf.Bytes()), Size: int64(buf.Len())})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Entries) != 1 || c.Entries[0].Name != "shop.sql" || c.Manifest != nil {
+		t.Errorf("entries = %v, manifest = %q", c.Entries, c.Manifest)
+	}
+	if !reflect.DeepEqual(c.Databases, []string{"shop"}) || !reflect.DeepEqual(c.Tables, []string{"orders", "users"}) {
+		t.Errorf("databases = %v, tables = %v", c.Databases, c.Tables)
+	}
+	if !reflect.DeepEqual(c.Users, []string{"CREATE USER 'app'@'%';", "GRANT SELECT ON `shop`.* TO 'app'@'%';"}) {
+		t.Errorf("users = %q", c.Users)
+	}
+}
@@ -0,0 +1,138 @@
+package restore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/audit"
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/vss"
+)
+
+// physicalNameRe matches the copies of the data directory in physical_backup_dir.
+var physicalNameRe = regexp.MustCompile(`^mysql_data_\d{8}_\d{6}$`)
+
+// PhysicalBackup copies mysql_data_dir to physical_backup_dir/mysql_data_<YYYYMMDD_HHMMSS> and returns its path.
+// On Windows the files are read from a VSS snapshot while MySQL keeps running; the copy is crash-consistent and
+// InnoDB recovers it on start like after a power failure. Elsewhere a running server is stopped with
+// mysql_stop_cmd for the copy and started again with mysql_start_cmd. The copy is written as <name>.part and
+// renamed when complete; afterwards only the newest physical_backup_keep copies are kept. A copy can serve as
+// mysql_backup_dir for --restorefull.
+func PhysicalBackup(cfg *config.Config, log Logger) (string, error) {
+	dataDir := strings.TrimSpace(cfg.MySQLDataDir)
+	if dataDir == "" {
+		return "", fmt.Errorf(i18n.T("err.physical_data_dir"))
+	}
+	if cfg.PhysicalBackupDir == "" {
+		return "", fmt.Errorf(i18n.T("err.physical_backup_dir"))
+	}
+	dataDir = filepath.FromSlash(filepath.Clean(dataDir))
+	if info, err := os.Stat(dataDir); err != nil || !info.IsDir() {
+		if err == nil {
+			err = fmt.Errorf("%s: not a directory", dataDir)
+		}
+		return "", fmt.Errorf(i18n.T("err.physical_data_dir_missing"), err)
+	}
+	if err := os.MkdirAll(cfg.PhysicalBackupDir, 0755); err != nil {
+		return "", err
+	}
+	target := filepath.Join(cfg.PhysicalBackupDir, "mysql_data_"+cfg.Now().Format("20060102_150405"))
+	part := target + ".part"
+	_ = os.RemoveAll(part) // Rest eines abgebrochenen Laufs
+	var err error
+	if runtime.GOOS == "windows" {
+		err = copySnapshot(dataDir, part, log)
+	} else {
+		err = copyStopped(cfg, dataDir, part, log)
+	}
+	if err == nil {
+		err = os.Rename(part, target)
+	}
+	if err != nil {
+		_ = os.RemoveAll(part)
+		return "", err
+	}
+	prunePhysical(cfg, log)
+	return target, nil
+}
+
+// copySnapshot copies dataDir from a VSS snapshot of its volume to dst.
+func copySnapshot(dataDir, dst string, log Logger) error {
+	snap, err := vss.Create(dataDir)
+	if err != nil {
+		return fmt.Errorf(i18n.T("err.vss_create"), dataDir, err)
+	}
+	log.Info(i18n.Tf("log.msg.vss_created", snap.ID, snap.Volume))
+	defer func() {
+		if err := snap.Release(); err != nil {
+			log.Warn(i18n.Tf("log.warn.vss_release", snap.ID, err))
+		}
+	}()
+	src := snap.Path(dataDir)
+	log.Info(i18n.Tf("log.msg.physical_copy", src, dst))
+	return copyDir(src, dst)
+}
+
+// copyStopped copies dataDir to dst with the server stopped (mysql_stop_cmd) and starts it again afterwards,
+// also when the copy failed.
+func copyStopped(cfg *config.Config, dataDir, dst string, log Logger) (err error) {
+	if portReachable(cfg.MySQLHost, cfg.MySQLPort) {
+		if strings.TrimSpace(cfg.MySQLStopCmd) == "" || strings.TrimSpace(cfg.MySQLStartCmd) == "" {
+			return fmt.Errorf(i18n.T("err.physical_stop_required"))
+		}
+		log.Info(i18n.Tf("log.msg.physical_stop", cfg.MySQLStopCmd))
+		if err := runMySQLLifecycleCmd(cfg.MySQLStopCmd, log, true); err != nil {
+			return fmt.Errorf(i18n.T("err.physical_stop"), err)
+		}
+		if !waitForPortState(cfg.MySQLHost, cfg.MySQLPort, false, 30*time.Second, 1*time.Second) {
+			return fmt.Errorf(i18n.T("err.physical_stop"), fmt.Errorf("timeout"))
+		}
+		defer func() {
+			log.Info(i18n.Tf("log.msg.physical_start", cfg.MySQLStartCmd))
+			startErr := runMySQLLifecycleCmd(cfg.MySQLStartCmd, log, false)
+			if startErr == nil && !waitForPortState(cfg.MySQLHost, cfg.MySQLPort, true, 60*time.Second, 2*time.Second) {
+				startErr = fmt.Errorf("timeout")
+			}
+			if startErr != nil && err == nil {
+				err = fmt.Errorf(i18n.T("err.physical_start"), startErr)
+			}
+		}()
+	}
+	log.Info(i18n.Tf("log.msg.physical_copy", dataDir, dst))
+	return copyDir(dataDir, dst)
+}
+
+// prunePhysical deletes the oldest copies in physical_backup_dir beyond physical_backup_keep (0 = keep all).
+func prunePhysical(cfg *config.Config, log Logger) {
+	if cfg.PhysicalBackupKeep <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(cfg.PhysicalBackupDir)
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && physicalNameRe.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	for len(names) > cfg.PhysicalBackupKeep {
+		p := filepath.Join(cfg.PhysicalBackupDir, names[0])
+		names = names[1:]
+		if err := os.RemoveAll(p); err != nil {
+			log.Warn(i18n.Tf("log.warn.physical_remove", p, err))
+			continue
+		}
+		log.Info(i18n.Tf("log.msg.physical_removed", p))
+		audit.Note(cfg.AuditFile, log, audit.RetentionDelete, p, "physical_backup_keep")
+	}
+}
@@ -0,0 +1,62 @@
+//go:build !windows
+
+package restore
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+)
+
+type nopLog struct{}
+
+func (nopLog) Info(string, ...interface{}) {}
+func (nopLog) Warn(string, ...interface{}) {}
+
+func TestPhysicalBackup(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data")
+	if err := os.MkdirAll(filepath.Join(data, "shop"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(data, "shop", "orders.ibd"), []byte("pages"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Port ohne Server: es muss nichts gestoppt werden
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	cfg := config.DefaultConfig()
+	cfg.MySQLHost, cfg.MySQLPort = "127.0.0.1", port
+	cfg.MySQLDataDir = data
+	cfg.PhysicalBackupDir = filepath.Join(dir, "physical")
+	cfg.PhysicalBackupKeep = 2
+	for _, old := range []string{"mysql_data_20200101_000000", "mysql_data_20210101_000000", "notes"} {
+		if err := os.MkdirAll(filepath.Join(cfg.PhysicalBackupDir, old), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	target, err := PhysicalBackup(cfg, nopLog{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filepath.Join(target, "shop", "orders.ibd")); err != nil || string(b) != "pages" {
+		t.Fatalf("copy of the data directory: %q, %v", b, err)
+	}
+	entries, _ := os.ReadDir(cfg.PhysicalBackupDir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	if len(names) != 3 || names[0] != "mysql_data_20210101_000000" || names[1] != filepath.Base(target) || names[2] != "notes" {
+		t.Errorf("physical_backup_dir after pruning = %v", names)
+	}
+}
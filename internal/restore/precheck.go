@@ -0,0 +1,141 @@
+package restore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/disk"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/mysql"
+)
+
+var (
+	// serverVersionRe matches the "-- Server version" line of the mysqldump header.
+	serverVersionRe = regexp.MustCompile(`^-- Server version\s+(\S+)`)
+	// setNamesRe matches the character set of the dump ("/*!40101 SET NAMES utf8mb4 */;").
+	setNamesRe = regexp.MustCompile(`^/\*!\d+ SET NAMES (\w+)`)
+	// versionRe extracts major and minor version (MariaDB may report a "5.5.5-" prefix).
+	versionRe = regexp.MustCompile(`^(?:5\.5\.5-)?(\d+)\.(\d+)`)
+)
+
+// headerLines is the number of lines read from the start of a dump to find version and charset.
+const headerLines = 50
+
+// dumpInfo is what the mysqldump header records about the source server.
+type dumpInfo struct {
+	Version string
+	Charset string
+}
+
+// readDumpInfo reads version and character set from the header of a dump.
+func readDumpInfo(r io.Reader) dumpInfo {
+	var d dumpInfo
+	br := bufio.NewReaderSize(r, 64*1024)
+	for i := 0; i < headerLines; i++ {
+		line, err := br.ReadSlice('\n')
+		if m := serverVersionRe.FindSubmatch(line); m != nil {
+			d.Version = string(m[1])
+		}
+		if m := setNamesRe.FindSubmatch(line); m != nil && d.Charset == "" {
+			d.Charset = string(m[1])
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			break
+		}
+	}
+	return d
+}
+
+// serverVersion returns major and minor version and whether v is a MariaDB version; ok is false if v cannot be parsed.
+func serverVersion(v string) (major, minor int, mariadb, ok bool) {
+	m := versionRe.FindStringSubmatch(v)
+	if m == nil {
+		return 0, 0, false, false
+	}
+	major, _ = strconv.Atoi(m[1])
+	minor, _ = strconv.Atoi(m[2])
+	return major, minor, strings.Contains(strings.ToLower(v), "mariadb"), true
+}
+
+// precheck compares the dumps of sources with the target server before anything is imported: an older
+// major.minor version of the same server (MySQL/MariaDB) or a character set the target does not know is an error,
+// another server product is a warning. With whole (complete databases, not -tables/-restore-users) the
+// uncompressed size of the SQL files must also fit into the free space of the target's data directory (if it
+// is on this machine: dataDir, otherwise @@datadir of a local server).
+func precheck(conn *mysql.Conn, sources []Source, whole bool, dataDir string, log Logger) error {
+	target, err := conn.Info()
+	if err != nil {
+		return err
+	}
+	tMajor, tMinor, tMaria, tOK := serverVersion(target.Version)
+	var required uint64
+	checked := make(map[string]bool)
+	for _, src := range sources {
+		sqlFile, err := findSQL(src)
+		if err != nil {
+			return fmt.Errorf(i18n.Tf("err.restore_zip", src.Name), err)
+		}
+		required += sqlFile.UncompressedSize64
+		rc, err := sqlFile.Open()
+		if err != nil {
+			return fmt.Errorf(i18n.Tf("err.restore_zip", src.Name), err)
+		}
+		d := readDumpInfo(rc)
+		rc.Close()
+		if major, minor, maria, ok := serverVersion(d.Version); ok && tOK {
+			switch {
+			case maria != tMaria:
+				log.Warn(i18n.Tf("log.warn.restore_server_product", src.Name, d.Version, target.Version))
+			case major < tMajor || (major == tMajor && minor <= tMinor):
+			default:
+				return fmt.Errorf(i18n.T("err.restore_older_server"), src.Name, d.Version, target.Version)
+			}
+		}
+		if d.Charset != "" && !checked[d.Charset] {
+			checked[d.Charset] = true
+			supported, err := conn.CharsetSupported(d.Charset)
+			if err != nil {
+				return err
+			}
+			if !supported {
+				return fmt.Errorf(i18n.T("err.restore_charset"), src.Name, d.Charset, target.Version)
+			}
+			if !strings.EqualFold(d.Charset, target.Charset) {
+				log.Info(i18n.Tf("log.msg.restore_charset", src.Name, d.Charset, target.Charset))
+			}
+		}
+	}
+	if !whole {
+		return nil
+	}
+	dir := dataDir
+	if dir == "" && localHost(conn.Host) {
+		dir = target.DataDir
+	}
+	if dir == "" {
+		return nil
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil // Datenverzeichnis nicht auf diesem Rechner
+	}
+	avail, err := disk.Available(dir)
+	if err != nil {
+		log.Warn(i18n.Tf("log.warn.disk_check", err))
+		return nil
+	}
+	if avail < required {
+		return fmt.Errorf(i18n.T("err.restore_disk_space"), dir, avail>>20, required>>20)
+	}
+	return nil
+}
+
+// localHost reports whether host is this machine.
+func localHost(host string) bool {
+	h := strings.ToLower(strings.TrimSpace(host))
+	return h == "" || h == "localhost" || h == "127.0.0.1" || h == "::1"
+}
@@ -0,0 +1,34 @@
+package restore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadDumpInfo(t *testing.T) {
+	header := "-- MySQL dump 10.13  Distrib 8.0.36, for Linux (x86_64)\n--\n-- Host: localhost    Database: shop\n" +
+		"-- ------------------------------------------------------\n-- Server version\t8.0.36-0ubuntu0.22.04.1\n\n" +
+		"/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;\n/*!50503 SET NAMES utf8mb4 */;\n"
+	d := readDumpInfo(strings.NewReader(header))
+	if d.Version != "8.0.36-0ubuntu0.22.04.1" || d.Charset != "utf8mb4" {
+		t.Errorf("readDumpInfo = %+v", d)
+	}
+}
+
+func TestServerVersion(t *testing.T) {
+	for _, c := range []struct {
+		v            string
+		major, minor int
+		mariadb      bool
+	}{
+		{"8.0.36", 8, 0, false},
+		{"5.7.44-log", 5, 7, false},
+		{"10.11.6-MariaDB-1:10.11.6+maria~ubu2204", 10, 11, true},
+		{"5.5.5-10.6.16-MariaDB", 10, 6, true},
+	} {
+		major, minor, mariadb, ok := serverVersion(c.v)
+		if !ok || major != c.major || minor != c.minor || mariadb != c.mariadb {
+			t.Errorf("serverVersion(%q) = %d, %d, %v, %v", c.v, major, minor, mariadb, ok)
+		}
+	}
+}
@@ -26,62 +26,332 @@ type Logger interface {
 	Warn(string, ...interface{})
 }
 
+// Options selects what RestoreFromZips imports from the backups.
+type Options struct {
+	Tables    []string // only these tables (structure and data); empty = the whole backup
+	UsersOnly bool     // only the appended users/grants block (CREATE USER, GRANT), no data
+	// A whole-database restore refuses a target database that already has tables, unless Force is set:
+	// then the database is dropped and recreated after Confirm (typed database name) returned true.
+	Force   bool
+	Confirm func(db string) bool
+	Fresh   bool // target instance was just reinitialized (--restorefull): no check
+	// Before the import the dumps are compared with the target (server version, character set, free space in
+	// DataDir = mysql_data_dir or the server's datadir if local), unless SkipChecks is set.
+	SkipChecks bool
+	DataDir    string
+	// ContinueOnError skips failing statements (mysql --force) instead of aborting; they are logged with their
+	// line at the end and make the restore return an error (e.g. dumps of a slightly different server version).
+	ContinueOnError bool
+	// DumpTables, if not nil, receives the number of tables whose structure the imported SQL contained
+	// (e.g. to compare with the restored tables in --verify-restore).
+	DumpTables *int
+	// Charset/Collation convert the structure on import (e.g. legacy latin1 dumps onto utf8mb4-only servers):
+	// CHARSET/CHARACTER SET/COLLATE clauses are rewritten, the data is converted by the server (see charsetFilter).
+	Charset   string
+	Collation string
+	// DryRun only reads the SQL through a statement splitter and reports truncation, statements a restore must
+	// not run and a missing CREATE DATABASE; nothing is sent to MySQL (see dryRun).
+	DryRun bool
+	// Events selects how the events of the dumps are recreated: EventsDisable (also "") creates them DISABLED, so a
+	// restored copy (e.g. staging) does not start running the scheduled jobs of production; EventsKeep keeps the
+	// status of the dump.
+	Events string
+}
+
+// Event modes of Options.Events (--events).
+const (
+	EventsDisable = "disable"
+	EventsKeep    = "keep"
+)
+
+// EventModes are the values of --events.
+var EventModes = []string{EventsDisable, EventsKeep}
+
+// TargetCharset returns the character set of Charset/Collation (for mysql --default-character-set), "" if none.
+func (opt Options) TargetCharset() string {
+	if f := newCharsetFilter(opt.Charset, opt.Collation); f != nil {
+		return f.charset
+	}
+	return ""
+}
+
+// Source is a backup ZIP to import: Name (for messages) and the ZIP content via ReaderAt with its Size.
+// Local files and files opened on the remote target (--from-remote) are read the same way.
+type Source struct {
+	Name string
+	io.ReaderAt
+	Size int64
+}
+
 // RestoreFromZips imports SQL from each backup zip file in order.
-func RestoreFromZips(conn *mysql.Conn, files []retention.BackupFile, log Logger) error {
+func RestoreFromZips(conn *mysql.Conn, files []retention.BackupFile, opt Options, log Logger) error {
 	if len(files) == 0 {
 		return fmt.Errorf(i18n.T("err.restore_no_backups"))
 	}
+	var sources []Source
 	for _, f := range files {
-		log.Info(i18n.Tf("log.msg.restore_zip", filepath.Base(f.Path)))
-		if err := restoreZip(conn, f.Path); err != nil {
+		fh, err := os.Open(f.Path)
+		if err != nil {
 			return fmt.Errorf(i18n.Tf("err.restore_zip", filepath.Base(f.Path)), err)
 		}
+		defer fh.Close()
+		info, err := fh.Stat()
+		if err != nil {
+			return fmt.Errorf(i18n.Tf("err.restore_zip", filepath.Base(f.Path)), err)
+		}
+		sources = append(sources, Source{Name: filepath.Base(f.Path), ReaderAt: fh, Size: info.Size()})
+	}
+	return RestoreFromSources(conn, sources, opt, log)
+}
+
+// RestoreFromSources imports SQL from each backup ZIP in order (see Options for the selection).
+func RestoreFromSources(conn *mysql.Conn, sources []Source, opt Options, log Logger) error {
+	if len(sources) == 0 {
+		return fmt.Errorf(i18n.T("err.restore_no_backups"))
+	}
+	var filter sqlFilter
+	var tables *tableFilter
+	var users *usersFilter
+	switch {
+	case opt.UsersOnly:
+		users = &usersFilter{}
+		filter = users
+		log.Info(i18n.T("log.msg.restore_users"))
+	case len(opt.Tables) > 0:
+		tables = newTableFilter(opt.Tables)
+		filter = tables
+		log.Info(i18n.Tf("log.msg.restore_tables", strings.Join(opt.Tables, ", ")))
+	case opt.DumpTables != nil:
+		*opt.DumpTables = 0
+		filter = &countFilter{tables: opt.DumpTables}
+	}
+	// Ohne Tabellen-/Benutzerauswahl werden die Events mit importiert
+	var events *eventFilter
+	if tables == nil && users == nil && opt.Events != EventsKeep {
+		events = &eventFilter{}
+		filter = chain(events, filter)
+	}
+	if cs := newCharsetFilter(opt.Charset, opt.Collation); cs != nil {
+		filter = chain(cs, filter)
+		if cs.collation != "" {
+			log.Info(i18n.Tf("log.msg.restore_convert_collation", cs.charset, cs.collation))
+		} else {
+			log.Info(i18n.Tf("log.msg.restore_convert_charset", cs.charset))
+		}
+	}
+	if opt.DryRun {
+		return dryRun(sources, opt, log)
+	}
+	if !opt.SkipChecks {
+		if err := precheck(conn, sources, tables == nil && users == nil, opt.DataDir, log); err != nil {
+			return err
+		}
+	}
+	if tables == nil && users == nil && !opt.Fresh {
+		if err := prepareTargets(conn, sources, opt, log); err != nil {
+			return err
+		}
+	}
+	var failed []FailedStatement
+	for _, src := range sources {
+		log.Info(i18n.Tf("log.msg.restore_zip", src.Name))
+		errs, err := restoreZip(conn, src, filter, opt.ContinueOnError, log)
+		if err != nil {
+			return fmt.Errorf(i18n.Tf("err.restore_zip", src.Name), err)
+		}
+		if len(errs) > 0 {
+			failed = append(failed, statementContext(src, opt.newFilter(), errs)...)
+		}
+	}
+	if users != nil && users.statements == 0 {
+		return errors.New(i18n.T("err.restore_users_missing"))
+	}
+	if tables != nil {
+		var missing []string
+		for _, t := range opt.Tables {
+			if !tables.found[t] {
+				missing = append(missing, t)
+			}
+		}
+		if len(missing) == len(opt.Tables) {
+			return fmt.Errorf(i18n.T("err.restore_tables_missing"), strings.Join(missing, ", "))
+		}
+		if len(missing) > 0 {
+			log.Warn(i18n.Tf("log.warn.restore_tables_missing", strings.Join(missing, ", ")))
+		}
+	}
+	if tables == nil && users == nil {
+		reportEvents(conn, sources, events, log)
+	}
+	if len(failed) > 0 {
+		for _, f := range failed {
+			log.Warn(i18n.Tf("log.warn.restore_statement", f.File, f.Line, f.Message, f.Context))
+		}
+		return fmt.Errorf(i18n.T("err.restore_statements_failed"), len(failed), len(sources))
+	}
+	log.Info(i18n.Tf("log.msg.restore_done", len(sources)))
+	return nil
+}
+
+// newFilter returns a new filter for the selection of opt (nil = whole SQL), e.g. to read the SQL a second time.
+func (opt Options) newFilter() sqlFilter {
+	var filter sqlFilter
+	switch {
+	case opt.UsersOnly:
+		filter = &usersFilter{}
+	case len(opt.Tables) > 0:
+		filter = newTableFilter(opt.Tables)
+	case opt.Events != EventsKeep:
+		filter = &eventFilter{}
+	}
+	if cs := newCharsetFilter(opt.Charset, opt.Collation); cs != nil {
+		return chain(cs, filter)
+	}
+	return filter
+}
+
+// prepareTargets checks the target database of every ZIP before anything is imported: a database with tables
+// is an error without opt.Force; with opt.Force it is dropped once opt.Confirm accepted it (the backup
+// recreates it with CREATE DATABASE).
+func prepareTargets(conn *mysql.Conn, sources []Source, opt Options, log Logger) error {
+	var drop []string
+	for _, src := range sources {
+		db, err := Database(src)
+		if err != nil {
+			return fmt.Errorf(i18n.Tf("err.restore_zip", src.Name), err)
+		}
+		n, err := conn.TableCount(db)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			continue
+		}
+		if !opt.Force {
+			return fmt.Errorf(i18n.T("err.restore_db_not_empty"), db, n)
+		}
+		if opt.Confirm == nil || !opt.Confirm(db) {
+			return fmt.Errorf(i18n.T("err.restore_not_confirmed"), db)
+		}
+		drop = append(drop, db)
+	}
+	for _, db := range drop {
+		if err := conn.DropDatabase(db); err != nil {
+			return err
+		}
+		log.Warn(i18n.Tf("log.warn.restore_dropped", db))
 	}
-	log.Info(i18n.Tf("log.msg.restore_done", len(files)))
 	return nil
 }
 
-func restoreZip(conn *mysql.Conn, zipPath string) error {
-	zr, err := zip.OpenReader(zipPath)
+// Database returns the database of a backup ZIP: the name of its SQL file (<db>.sql).
+func Database(src Source) (string, error) {
+	sqlFile, err := findSQL(src)
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer zr.Close()
+	return strings.TrimSuffix(filepath.Base(sqlFile.Name), filepath.Ext(sqlFile.Name)), nil
+}
 
-	var sqlFile *zip.File
+// FileDatabase returns the database of the backup ZIP at path (see Database).
+func FileDatabase(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	return Database(Source{Name: filepath.Base(path), ReaderAt: f, Size: info.Size()})
+}
+
+// findSQL returns the SQL file in the backup ZIP src.
+func findSQL(src Source) (*zip.File, error) {
+	zr, err := zip.NewReader(src.ReaderAt, src.Size)
+	if err != nil {
+		return nil, err
+	}
 	for _, f := range zr.File {
 		if strings.EqualFold(filepath.Ext(f.Name), ".sql") {
-			sqlFile = f
-			break
+			return f, nil
 		}
 	}
-	if sqlFile == nil {
-		return fmt.Errorf(i18n.T("err.restore_sql_missing"), filepath.Base(zipPath))
+	return nil, fmt.Errorf(i18n.T("err.restore_sql_missing"), src.Name)
+}
+
+// restoreZip streams the SQL file of src into mysql, through filter if not nil, and logs the progress. With
+// force, failing statements are skipped and returned.
+func restoreZip(conn *mysql.Conn, src Source, filter sqlFilter, force bool, log Logger) ([]mysql.StatementError, error) {
+	sqlFile, err := findSQL(src)
+	if err != nil {
+		return nil, err
 	}
 
-	in, err := sqlFile.Open()
+	rc, err := sqlFile.Open()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer in.Close()
+	defer rc.Close()
+	in := &progressReader{r: rc, name: src.Name, total: sqlFile.UncompressedSize64, log: log, next: time.Now().Add(progressInterval)}
 
 	pr, pw := io.Pipe()
 	copyErr := make(chan error, 1)
 	go func() {
-		_, err := io.Copy(pw, in)
+		var err error
+		if filter != nil {
+			err = filter.copy(pw, in)
+		} else {
+			_, err = io.Copy(pw, in)
+		}
 		_ = pw.CloseWithError(err)
 		copyErr <- err
 	}()
 
-	importErr := conn.ImportSQL(pr)
+	var failed []mysql.StatementError
+	var importErr error
+	if force {
+		failed, importErr = conn.ImportSQLForce(pr)
+	} else {
+		importErr = conn.ImportSQL(pr)
+	}
 	_ = pr.Close()
 	if err := <-copyErr; err != nil {
-		return err
+		return nil, err
 	}
 	if importErr != nil {
-		return importErr
+		return nil, importErr
 	}
-	return nil
+	return failed, nil
+}
+
+// progressInterval is the interval of the progress messages during an import.
+const progressInterval = 30 * time.Second
+
+// progressReader counts the bytes read from the SQL file and logs MB and percentage (of the uncompressed size
+// from the ZIP header) every progressInterval, so long imports do not sit silent.
+type progressReader struct {
+	r     io.Reader
+	name  string
+	n     uint64
+	total uint64
+	log   Logger
+	next  time.Time
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.n += uint64(n)
+	if now := time.Now(); now.After(p.next) {
+		p.next = now.Add(progressInterval)
+		percent := 100
+		if p.total > 0 && p.n < p.total {
+			percent = int(p.n * 100 / p.total)
+		}
+		p.log.Info(i18n.Tf("log.msg.restore_progress", p.name, p.n>>20, p.total>>20, percent))
+	}
+	return n, err
 }
 
 // FullReinit replaces MySQL/MariaDB data directory with the instance backup template and starts the server.
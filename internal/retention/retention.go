@@ -2,50 +2,206 @@
 package retention
 
 import (
-	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/janmz/mysqlbackup/internal/audit"
+	"github.com/janmz/mysqlbackup/internal/catalog"
+	"github.com/janmz/mysqlbackup/internal/config"
 	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/parity"
 )
 
 const backupPrefix = "mysql_backup_"
 
 var dateInFilename = regexp.MustCompile(`mysql_backup_(\d{8})_`)
 
-// Classify returns the retention period for a date as a localized string (e.g. German "täglichen", "wöchentlichen").
-// Order: yearly (31.12) > monthly (last day of month, not 31.12) > weekly (Sunday) > daily (rest).
+var datedName = regexp.MustCompile(`^mysql_backup_\d{8}_`)
+
+// FileBackupExt ends the names of the ZIPs of file_backups (mysql_backup_<date>_<host>_<name>.files.zip); database
+// names cannot contain ".", so they never end like this.
+const FileBackupExt = ".files.zip"
+
+// IsFileBackup reports whether path is the ZIP of a file backup (file_backups) instead of a database dump.
+func IsFileBackup(path string) bool {
+	return strings.HasSuffix(filepath.Base(path), FileBackupExt)
+}
+
+// DatabaseBackups returns files without the ZIPs of file backups (restore, test restore).
+func DatabaseBackups(files []BackupFile) []BackupFile {
+	var dbs []BackupFile
+	for _, f := range files {
+		if !IsFileBackup(f.Path) {
+			dbs = append(dbs, f)
+		}
+	}
+	return dbs
+}
+
+// Policy holds the retention counts and the anchor days used to classify backups.
+type Policy struct {
+	Daily   int
+	Weekly  int
+	Monthly int
+	Yearly  int
+
+	WeeklyDay   time.Weekday // weekly backups are those taken on this weekday (default Sunday)
+	YearlyMonth time.Month   // yearly cut-over month (default December)
+	YearlyDay   int          // yearly cut-over day (default 31)
+
+	MaxDirSize int64 // optional cap for the sum of all backup ZIPs in bytes (0 = no cap)
+
+	ArchiveDir        string // if set, expired backups are moved here instead of being deleted
+	ArchiveRetainDays int    // days to keep backups in ArchiveDir (0 = forever)
+
+	Pinned map[string]bool // base names of pinned backups; never expired (see catalog)
+
+	OnExpire func(f BackupFile, archived bool) // optional: called after a backup was deleted or archived
+
+	AuditFile string // optional: audit file that records every deleted or archived backup (see package audit)
+
+	Catalog *catalog.Catalog // optional: catalog of the local backup_dir; Apply reads the backups from it instead of scanning
+
+	Location *time.Location // timezone that decides "today" (nil = system timezone)
+
+	Undated bool // include ZIPs without date in the name, classified by modification time
+
+	Databases map[string]Counts // retention counts per database (databases[].retain_*), matched by file name suffix
+}
+
+// Counts overrides the retention counts of Policy for one database; nil fields keep the policy's count.
+type Counts struct {
+	Daily, Weekly, Monthly, Yearly *int
+}
+
+// DefaultPolicy returns the built-in policy: 14 daily, 3 weekly (Sunday), 3 monthly, 3 yearly (31.12).
+func DefaultPolicy() Policy {
+	return Policy{
+		Daily:       14,
+		Weekly:      3,
+		Monthly:     3,
+		Yearly:      3,
+		WeeklyDay:   time.Sunday,
+		YearlyMonth: time.December,
+		YearlyDay:   31,
+	}
+}
+
+// PolicyFromConfig builds the policy from retain_* settings. Invalid anchors (already rejected by config.Load) fall back to the defaults.
+func PolicyFromConfig(cfg *config.Config) Policy {
+	p := DefaultPolicy()
+	p.Daily = cfg.RetainDaily
+	p.Weekly = cfg.RetainWeekly
+	p.Monthly = cfg.RetainMonthly
+	p.Yearly = cfg.RetainYearly
+	if d, err := cfg.WeeklyAnchor(); err == nil {
+		p.WeeklyDay = d
+	}
+	if m, d, err := cfg.YearlyAnchor(); err == nil {
+		p.YearlyMonth, p.YearlyDay = m, d
+	}
+	if n, err := cfg.MaxBackupDirBytes(); err == nil {
+		p.MaxDirSize = n
+	}
+	p.ArchiveDir = cfg.ArchiveDir
+	p.ArchiveRetainDays = cfg.ArchiveRetainDays
+	p.AuditFile = cfg.AuditFile
+	if loc, err := cfg.Location(); err == nil {
+		p.Location = loc
+	}
+	p.Undated = cfg.RetainUndatedByMtime
+	for _, d := range cfg.Databases {
+		if d.RetainDaily != nil || d.RetainWeekly != nil || d.RetainMonthly != nil || d.RetainYearly != nil {
+			if p.Databases == nil {
+				p.Databases = make(map[string]Counts)
+			}
+			p.Databases[d.Name] = Counts{Daily: d.RetainDaily, Weekly: d.RetainWeekly, Monthly: d.RetainMonthly, Yearly: d.RetainYearly}
+		}
+	}
+	return p
+}
+
+// forFile returns the policy for the backup at path: p with the counts of its database (p.Databases) applied,
+// and the database name ("" = global counts). The longest database name whose "_<name>.zip" ends the file name wins.
+func (p Policy) forFile(path string) (Policy, string) {
+	name := filepath.Base(path)
+	db := ""
+	for d := range p.Databases {
+		if len(d) > len(db) && strings.HasSuffix(name, "_"+d+".zip") {
+			db = d
+		}
+	}
+	if db == "" {
+		return p, ""
+	}
+	c := p.Databases[db]
+	for _, o := range []struct {
+		dst *int
+		src *int
+	}{{&p.Daily, c.Daily}, {&p.Weekly, c.Weekly}, {&p.Monthly, c.Monthly}, {&p.Yearly, c.Yearly}} {
+		if o.src != nil {
+			*o.dst = *o.src
+		}
+	}
+	return p, db
+}
+
+// Classify returns the retention period for a date using the default anchors (Sunday, 31.12).
 func Classify(t time.Time) string {
-	if t.Month() == 12 && t.Day() == 31 {
+	return DefaultPolicy().Classify(t)
+}
+
+// Classify returns the retention period for a date as a localized string (e.g. German "täglichen", "wöchentlichen").
+// Order: yearly (yearly anchor date) > monthly (last day of month) > weekly (anchor weekday) > daily (rest).
+func (p Policy) Classify(t time.Time) string {
+	if p.isYearlyAnchor(t) {
 		return i18n.T("retention.yearly")
 	}
 	if isLastDayOfMonth(t) {
 		return i18n.T("retention.monthly")
 	}
-	if t.Weekday() == time.Sunday {
+	if t.Weekday() == p.WeeklyDay {
 		return i18n.T("retention.weekly")
 	}
 	return i18n.T("retention.daily")
 }
 
+func (p Policy) isYearlyAnchor(t time.Time) bool {
+	return t.Month() == p.YearlyMonth && t.Day() == p.YearlyDay
+}
+
 func isLastDayOfMonth(t time.Time) bool {
 	next := t.AddDate(0, 0, 1) // next calendar day
 	return next.Month() != t.Month()
 }
 
 // BackupFile holds path, parsed date, file modification time and size for a backup zip.
+// Undated is set for ZIPs without date in the name whose Date was taken from the modification time.
 type BackupFile struct {
 	Path    string
 	Date    time.Time
 	ModTime time.Time
 	Size    int64
+	Undated bool
 }
 
 // ListBackups returns all mysql_backup_*.zip in dir with parsed dates, sorted by date ascending.
 func ListBackups(dir string) ([]BackupFile, error) {
+	return listDir(dir, false)
+}
+
+// ListBackupsUndated is ListBackups plus all other *.zip files in dir, dated by their modification time
+// (renamed or imported backups; retain_undated_by_mtime).
+func ListBackupsUndated(dir string) ([]BackupFile, error) {
+	return listDir(dir, true)
+}
+
+func listDir(dir string, undated bool) ([]BackupFile, error) {
 	dir = filepath.FromSlash(dir)
 	entries, err := os.ReadDir(dir)
 	if err != nil {
@@ -60,7 +216,15 @@ func ListBackups(dir string) ([]BackupFile, error) {
 			continue
 		}
 		name := e.Name()
-		if len(name) < len(backupPrefix)+8+2 || !regexp.MustCompile(`^mysql_backup_\d{8}_`).MatchString(name) || filepath.Ext(name) != ".zip" {
+		if filepath.Ext(name) != ".zip" {
+			continue
+		}
+		if len(name) < len(backupPrefix)+8+2 || !datedName.MatchString(name) {
+			if undated {
+				if bf, ok := undatedFile(filepath.Join(dir, name)); ok {
+					files = append(files, bf)
+				}
+			}
 			continue
 		}
 		matches := dateInFilename.FindStringSubmatch(name)
@@ -83,6 +247,54 @@ func ListBackups(dir string) ([]BackupFile, error) {
 	return files, nil
 }
 
+// undatedFile returns a BackupFile for a ZIP without date in its name, dated by its modification time. ZIPs
+// without an SQL dump (catalog.HasDump) are not backups and never subject to retention.
+func undatedFile(path string) (BackupFile, bool) {
+	info, err := os.Stat(path)
+	if err != nil || !catalog.HasDump(path) {
+		return BackupFile{}, false
+	}
+	m := info.ModTime()
+	return BackupFile{
+		Path:    path,
+		Date:    time.Date(m.Year(), m.Month(), m.Day(), 0, 0, 0, 0, time.Local),
+		ModTime: m,
+		Size:    info.Size(),
+		Undated: true,
+	}, true
+}
+
+// ForDatabase returns the backups of database db among files (sorted by date ascending, as from ListBackups),
+// newest first. The file name is mysql_backup_<date>_<host>_<db>.zip; host and database may both contain "_",
+// so a backup of a database ending in "_<db>" matches as well and callers confirm the SQL file in the ZIP.
+func ForDatabase(files []BackupFile, db string) []BackupFile {
+	suffix := "_" + db + ".zip"
+	var list []BackupFile
+	for i := len(files) - 1; i >= 0; i-- {
+		if !files[i].Undated && strings.HasSuffix(filepath.Base(files[i].Path), suffix) {
+			list = append(list, files[i])
+		}
+	}
+	return list
+}
+
+// ListCatalog returns the backups recorded in cat, reconciled against its directory, sorted like ListBackups.
+func ListCatalog(cat *catalog.Catalog) ([]BackupFile, error) {
+	if _, err := cat.Reconcile(); err != nil {
+		return nil, err
+	}
+	var files []BackupFile
+	for _, e := range cat.Backups {
+		t, err := time.ParseInLocation("20060102", e.Date, time.Local)
+		if err != nil {
+			continue
+		}
+		files = append(files, BackupFile{Path: filepath.Join(cat.Dir(), e.File), Date: t, ModTime: e.Created, Size: e.Size, Undated: e.Undated})
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Date.Before(files[j].Date) })
+	return files, nil
+}
+
 // LastBackupBefore returns all backup ZIPs from one backup day.
 // If beforeDate is nil, it returns all files from the latest day.
 // If beforeDate is set, it returns all files from the latest day strictly before beforeDate.
@@ -128,95 +340,264 @@ func dateKey(t time.Time) string {
 	return t.Format("20060102")
 }
 
-// Apply deletes backups that fall outside the retention windows.
-// retain_daily 14 = keep all daily backups from the last 14 calendar days (by backup date).
-// retain_weekly 3 = keep all weekly backups from the last 3 Sundays; retain_monthly/yearly = last N month-ends / year-ends.
+// Apply deletes (or archives) the backups in st that fall outside the retention windows of p.
+// Daily 14 = keep all backups from the last 14 calendar days (by backup date).
+// Weekly 3 = keep all backups from the last 3 anchor weekdays; Monthly/Yearly = last N month-ends / yearly anchor dates.
+// Only anchor dates on or before today count. Month-ends that are yearly anchors count as yearly, not monthly.
 // So we delete by date window, not by "last N files", so multiple DBs per day/week are all kept within the window.
-func Apply(dir string, retainDaily, retainWeekly, retainMonthly, retainYearly int, log interface {
+// If p.MaxDirSize is set, the oldest remaining backups are deleted afterwards until the sum fits the cap (see applySizeCap).
+// p.ArchiveDir is a directory (object key prefix) on the same storage, see Storage.Sub.
+func Apply(st Storage, p Policy, log interface {
 	Info(string, ...interface{})
 	Warn(string, ...interface{})
 }) error {
-	files, err := ListBackups(dir)
+	var files []BackupFile
+	var err error
+	if p.Catalog != nil {
+		p.Catalog.IncludeUndated = p.Undated
+		files, err = ListCatalog(p.Catalog)
+	} else {
+		files, err = st.List(p.Undated)
+	}
 	if err != nil {
 		return err
 	}
 	if len(files) == 0 {
 		return nil
 	}
+	for _, f := range files {
+		if f.Undated {
+			log.Warn(i18n.Tf("log.warn.undated_backup", filepath.Base(f.Path), f.Date.Format("2006-01-02")))
+		}
+	}
 
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-
-	// Cutoff: keep daily backups with date >= today - retainDaily
-	dailyCutoff := today.AddDate(0, 0, -retainDaily)
-
-	// Last N Sundays (set of dates to keep for weekly)
-	keepSundays := make(map[string]bool)
-	// Finde den letzten Sonntag (inklusive heute, falls heute Sonntag ist)
-	lastSunday := today
-	for lastSunday.Weekday() != time.Sunday {
-		lastSunday = lastSunday.AddDate(0, 0, -1)
-	}
-	// Gehe jeweils 7 Tage nach vorne, retainWeekly-mal
-	for i := 0; i < retainWeekly; i++ {
-		sunday := lastSunday.AddDate(0, 0, 7*i)
-		keepSundays[dateKey(sunday)] = true
-		if sunday.Year() < 2000 {
-			break
-		}
-	}
-
-	// Last N month-ends (not 31.12; those are yearly)
-	keepMonthEnds := make(map[string]bool)
-	// Gehe nur die Monate zurück und berechne das Monatsende jedes Monats.
-	for m, count := today, 0; count < retainMonthly; m = m.AddDate(0, -1, 0) {
-		// Monatsende berechnen: zum 1. des nächsten Monats springen, dann einen Tag zurück.
-		firstOfNextMonth := time.Date(m.Year(), m.Month(), 1, 0, 0, 0, 0, m.Location()).AddDate(0, 1, 0)
-		monthEnd := firstOfNextMonth.AddDate(0, 0, -1)
-		keepMonthEnds[dateKey(monthEnd)] = true
-		count++
-		if monthEnd.Year() < 2000 {
-			break
+	today := p.today()
+	expired, remaining := p.outsideWindows(files, today)
+	for _, f := range expired {
+		if !p.expire(st, f, "retention", log) {
+			remaining = append(remaining, f)
+			continue
 		}
+		log.Info(i18n.Tf("log.msg.deleted_old_backup", p.Classify(f.Date), filepath.Base(f.Path)))
+	}
+	if p.MaxDirSize > 0 {
+		p.applySizeCap(st, remaining, log)
 	}
+	if p.ArchiveDir != "" && p.ArchiveRetainDays > 0 {
+		p.pruneArchive(st.Sub(p.ArchiveDir), time.Now().AddDate(0, 0, -p.ArchiveRetainDays), log)
+	}
+	return nil
+}
 
-	// Last N year-ends (31.12)
-	keepYearEnds := make(map[string]bool)
-	for y, count := today.Year(), 0; count < retainYearly && y >= 2000; y, count = y-1, count+1 {
-		lastDay := time.Date(y, 12, 31, 0, 0, 0, 0, today.Location())
-		keepYearEnds[dateKey(lastDay)] = true
+// today returns the current date in p.Location as midnight in time.Local, comparable with the parsed file name dates.
+func (p Policy) today() time.Time {
+	now := time.Now()
+	if p.Location != nil {
+		now = now.In(p.Location)
 	}
+	// Nur Jahr/Monat/Tag zählen: "heute" in der konfigurierten Zeitzone, als Datum wie die Dateinamen
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+}
 
+// outsideWindows splits files (sorted by date) into those outside all retention windows and those to keep.
+// Pinned backups are always kept.
+// Backups of databases with own counts (p.Databases) use those windows.
+func (p Policy) outsideWindows(files []BackupFile, today time.Time) (expired, kept []BackupFile) {
+	type window struct {
+		keep        map[string]bool
+		dailyCutoff string // keep daily backups with date >= today - Daily
+	}
+	windows := make(map[string]window)
 	for _, f := range files {
+		fp, db := p.forFile(f.Path)
+		w, ok := windows[db]
+		if !ok {
+			w = window{fp.keepSet(today), dateKey(today.AddDate(0, 0, -fp.Daily))}
+			windows[db] = w
+		}
 		key := dateKey(f.Date)
-		keep := !f.Date.Before(dailyCutoff)
-		keep = keep || keepSundays[key]
-		keep = keep || keepMonthEnds[key]
-		keep = keep || keepYearEnds[key]
-		if keep {
+		if key >= w.dailyCutoff || w.keep[key] || p.Pinned[filepath.Base(f.Path)] {
+			kept = append(kept, f)
+		} else {
+			expired = append(expired, f)
+		}
+	}
+	return expired, kept
+}
+
+// expire removes one backup from st: moves it to p.ArchiveDir when set, otherwise deletes it. Returns false (and logs a warning) on failure.
+// reason (retention, max_backup_dir_size) goes to the audit file.
+func (p Policy) expire(st Storage, f BackupFile, reason string, log interface {
+	Info(string, ...interface{})
+	Warn(string, ...interface{})
+}) bool {
+	if p.ArchiveDir == "" {
+		if err := st.Remove(f.Path); err != nil {
+			log.Warn(i18n.Tf("log.warn.retention_delete", f.Path, err))
+			return false
+		}
+		audit.Note(p.AuditFile, log, audit.RetentionDelete, f.Path, reason)
+		if p.OnExpire != nil {
+			p.OnExpire(f, false)
+		}
+		return true
+	}
+	target, err := st.Move(f.Path, p.ArchiveDir)
+	if err != nil {
+		log.Warn(i18n.Tf("log.warn.archive_move", f.Path, err))
+		if target == "" {
+			return false
+		}
+	}
+	log.Info(i18n.Tf("log.msg.archived_backup", filepath.Base(f.Path), p.ArchiveDir))
+	audit.Note(p.AuditFile, log, audit.RetentionArchive, f.Path, reason+" -> "+target)
+	if p.OnExpire != nil {
+		p.OnExpire(f, true)
+	}
+	return true
+}
+
+// removeWithSidecar deletes a backup ZIP, its checksum sidecar, signature and parity files (if any).
+func removeWithSidecar(path string) error {
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	for _, ext := range catalog.Sidecars {
+		if err := os.Remove(path + ext); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return parity.Remove(path)
+}
+
+// MoveFile renames src to dst (creating dst's directory); across volumes it copies and removes src.
+func MoveFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+	in.Close()
+	if info, err := os.Stat(src); err == nil {
+		_ = os.Chtimes(dst, info.ModTime(), info.ModTime())
+	}
+	return os.Remove(src)
+}
+
+// pruneArchive deletes the backups in archive (p.ArchiveDir) archived before cutoff, except pinned ones. The archival
+// time is the modification time, which Storage.Move sets when moving a backup, so a backup held long by the yearly
+// window is still kept archive_retain_days in the archive.
+func (p Policy) pruneArchive(archive Storage, cutoff time.Time, log interface {
+	Info(string, ...interface{})
+	Warn(string, ...interface{})
+}) {
+	files, err := archive.List(false)
+	if err != nil {
+		log.Warn(i18n.Tf("log.warn.archive_list", p.ArchiveDir, err))
+		return
+	}
+	for _, f := range files {
+		if !f.ModTime.Before(cutoff) || p.Pinned[filepath.Base(f.Path)] {
 			continue
 		}
-		if err := os.Remove(f.Path); err != nil {
+		if err := archive.Remove(f.Path); err != nil {
 			log.Warn(i18n.Tf("log.warn.retention_delete", f.Path, err))
 			continue
 		}
-		log.Info(i18n.Tf("log.msg.deleted_old_backup", Classify(f.Date), filepath.Base(f.Path)))
+		log.Info(i18n.Tf("log.msg.deleted_archived", filepath.Base(f.Path)))
+		audit.Note(p.AuditFile, log, audit.RetentionDelete, f.Path, "archive_retain_days")
 	}
-	return nil
 }
 
-// ApplyToDirs runs Apply on backupDir and optionally remoteBackupDir (if non-empty).
-func ApplyToDirs(backupDir, remoteBackupDir string, retainDaily, retainWeekly, retainMonthly, retainYearly int, log interface {
+// applySizeCap expires the oldest backups (files sorted by date ascending) until their total size is at most p.MaxDirSize.
+// Minimum-keep rule: the newest backup of each series (same file name apart from the date, i.e. host and database) is never deleted.
+func (p Policy) applySizeCap(st Storage, files []BackupFile, log interface {
 	Info(string, ...interface{})
 	Warn(string, ...interface{})
-}) error {
-	if err := Apply(backupDir, retainDaily, retainWeekly, retainMonthly, retainYearly, log); err != nil {
-		return fmt.Errorf(i18n.T("err.retention_local"), err)
+}) {
+	maxSize := p.MaxDirSize
+	var total int64
+	newest := make(map[string]string)
+	size := make(map[string]int64, len(files))
+	for _, f := range files {
+		size[f.Path] = st.Size(f) // Paritätsdateien zählen mit
+		total += size[f.Path]
+		newest[SeriesKey(f.Path)] = f.Path // files are sorted ascending, last one wins
 	}
-	if remoteBackupDir != "" {
-		if err := Apply(remoteBackupDir, retainDaily, retainWeekly, retainMonthly, retainYearly, log); err != nil {
-			return fmt.Errorf(i18n.T("err.retention_remote"), err)
+	for _, f := range files {
+		if total <= maxSize {
+			return
+		}
+		if newest[SeriesKey(f.Path)] == f.Path || p.Pinned[filepath.Base(f.Path)] {
+			continue
 		}
+		if !p.expire(st, f, "max_backup_dir_size", log) {
+			continue
+		}
+		total -= size[f.Path]
+		log.Info(i18n.Tf("log.msg.deleted_size_cap", filepath.Base(f.Path), maxSize))
 	}
-	return nil
+	if total > maxSize {
+		log.Warn(i18n.Tf("log.warn.size_cap_exceeded", total, maxSize))
+	}
+}
+
+// SeriesKey returns the backup file name without its date (e.g. "host_db.zip"), identifying one host/database series.
+func SeriesKey(path string) string {
+	return dateInFilename.ReplaceAllString(filepath.Base(path), "")
+}
+
+// keepSet returns the date keys (YYYYMMDD) of the last Weekly anchor weekdays, Monthly month-ends and Yearly anchor dates up to today.
+func (p Policy) keepSet(today time.Time) map[string]bool {
+	keep := make(map[string]bool)
+
+	// Letzter Stichtag-Wochentag (inklusive heute), dann jeweils 7 Tage zurück
+	anchor := today
+	for anchor.Weekday() != p.WeeklyDay {
+		anchor = anchor.AddDate(0, 0, -1)
+	}
+	for i := 0; i < p.Weekly && anchor.Year() >= 2000; i++ {
+		keep[dateKey(anchor)] = true
+		anchor = anchor.AddDate(0, 0, -7)
+	}
+
+	// Monatsenden rückwärts ab dem 1. des aktuellen Monats (Tag 1 vermeidet Überlauf bei AddDate)
+	first := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+	for count := 0; count < p.Monthly && first.Year() >= 2000; first = first.AddDate(0, -1, 0) {
+		monthEnd := first.AddDate(0, 1, -1)
+		if monthEnd.After(today) || p.isYearlyAnchor(monthEnd) {
+			continue
+		}
+		keep[dateKey(monthEnd)] = true
+		count++
+	}
+
+	// Jährliche Stichtage rückwärts ab dem letzten Stichtag <= heute
+	y := today.Year()
+	if time.Date(y, p.YearlyMonth, p.YearlyDay, 0, 0, 0, 0, today.Location()).After(today) {
+		y--
+	}
+	for count := 0; count < p.Yearly && y >= 2000; y, count = y-1, count+1 {
+		keep[dateKey(time.Date(y, p.YearlyMonth, p.YearlyDay, 0, 0, 0, 0, today.Location()))] = true
+	}
+	return keep
 }
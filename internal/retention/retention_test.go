@@ -1,8 +1,12 @@
 package retention
 
 import (
+	"archive/zip"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -64,7 +68,7 @@ func TestApplyRetentionByDateWindow(t *testing.T) {
 		t.Fatal(err)
 	}
 	// retain_daily 14 = keep last 14 days; 3-day-old backup must be kept
-	err := Apply(dir, 14, 3, 3, 3, log)
+	err := Apply(DirStorage(dir), DefaultPolicy(), log)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -99,6 +103,33 @@ func TestLastBackupBeforeLatestDay(t *testing.T) {
 	}
 }
 
+func TestForDatabase(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"mysql_backup_20250101_host_shop.zip",
+		"mysql_backup_20250102_host_my_shop.zip",
+		"mysql_backup_20250102_host_crm.zip",
+		"mysql_backup_20250103_host_shop.zip",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := ListBackups(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range ForDatabase(files, "shop") {
+		got = append(got, filepath.Base(f.Path))
+	}
+	// Neueste zuerst; my_shop passt auch (Aufrufer prüfen die SQL-Datei)
+	want := []string{"mysql_backup_20250103_host_shop.zip", "mysql_backup_20250102_host_my_shop.zip", "mysql_backup_20250101_host_shop.zip"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ForDatabase = %v, want %v", got, want)
+	}
+}
+
 func TestLastBackupBeforeWithDate(t *testing.T) {
 	dir := t.TempDir()
 	for _, name := range []string{
@@ -147,3 +178,281 @@ type testLogger struct{ t *testing.T }
 
 func (l *testLogger) Info(format string, args ...interface{}) { l.t.Logf("[INFO] "+format, args...) }
 func (l *testLogger) Warn(format string, args ...interface{}) { l.t.Logf("[WARN] "+format, args...) }
+
+func TestPolicyClassifyCustomAnchors(t *testing.T) {
+	p := DefaultPolicy()
+	p.WeeklyDay = time.Saturday
+	p.YearlyMonth, p.YearlyDay = time.June, 30
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC), "retention.yearly"},
+		{time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC), "retention.monthly"},
+		{time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), "retention.weekly"}, // Saturday
+		{time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC), "retention.daily"},  // Sunday
+	}
+	for _, tt := range tests {
+		if got, want := p.Classify(tt.t), i18n.T(tt.want); got != want {
+			t.Errorf("Classify(%v) = %q, want %q (key %s)", tt.t, got, want, tt.want)
+		}
+	}
+}
+
+func TestPolicyKeepSetGoesBackwards(t *testing.T) {
+	p := DefaultPolicy()
+	p.WeeklyDay = time.Saturday
+	p.YearlyMonth, p.YearlyDay = time.June, 30
+	today := time.Date(2025, 8, 13, 0, 0, 0, 0, time.UTC) // Wednesday
+	keep := p.keepSet(today)
+	for _, want := range []string{
+		"20250809", "20250802", "20250726", // last 3 Saturdays
+		"20250731", "20250531", "20250430", // last 3 month-ends (30.06 is yearly)
+		"20250630", "20240630", "20230630", // last 3 yearly anchors
+	} {
+		if !keep[want] {
+			t.Errorf("keepSet: %s should be kept", want)
+		}
+	}
+	for _, notWant := range []string{"20250816", "20250831", "20251231"} {
+		if keep[notWant] {
+			t.Errorf("keepSet: future date %s must not be counted", notWant)
+		}
+	}
+}
+
+func TestApplySizeCapKeepsNewestPerSeries(t *testing.T) {
+	dir := t.TempDir()
+	log := &testLogger{t: t}
+	today := time.Now()
+	var names []string
+	for i := 3; i >= 0; i-- {
+		d := today.AddDate(0, 0, -i).Format("20060102")
+		for _, db := range []string{"db1", "db2"} {
+			name := "mysql_backup_" + d + "_host_" + db + ".zip"
+			if err := os.WriteFile(filepath.Join(dir, name), make([]byte, 100), 0644); err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, name)
+		}
+	}
+	p := DefaultPolicy()
+	p.MaxDirSize = 250 // room for two files only
+	if err := Apply(DirStorage(dir), p, log); err != nil {
+		t.Fatal(err)
+	}
+	files, err := ListBackups(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("size cap: got %d files, want 2 (newest of each database)", len(files))
+	}
+	for _, f := range files {
+		if dateKey(f.Date) != today.Format("20060102") {
+			t.Errorf("size cap kept %s, want only today's backups", filepath.Base(f.Path))
+		}
+	}
+}
+
+func TestApplyKeepsPinned(t *testing.T) {
+	dir := t.TempDir()
+	log := &testLogger{t: t}
+	old := "mysql_backup_" + time.Now().AddDate(0, 0, -400).Format("20060102") + "_host_db.zip"
+	if err := os.WriteFile(filepath.Join(dir, old), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := DefaultPolicy()
+	p.Pinned = map[string]bool{old: true}
+	if err := Apply(DirStorage(dir), p, log); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, old)); err != nil {
+		t.Errorf("pinned backup %s was removed: %v", old, err)
+	}
+}
+
+// memStorage is an in-memory Storage, standing in for an object store: keys "<prefix>/<name>" with the backup date.
+type memStorage struct {
+	objects map[string]time.Time
+	prefix  string
+}
+
+func (m memStorage) List(undated bool) ([]BackupFile, error) {
+	var files []BackupFile
+	for key, d := range m.objects {
+		if path.Dir(key) == m.prefix {
+			files = append(files, BackupFile{Path: key, Date: d, ModTime: d})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Date.Before(files[j].Date) })
+	return files, nil
+}
+
+func (m memStorage) Remove(key string) error {
+	delete(m.objects, key)
+	return nil
+}
+
+func (m memStorage) Move(key, dir string) (string, error) {
+	target := dir + "/" + path.Base(key)
+	m.objects[target] = m.objects[key]
+	delete(m.objects, key)
+	return target, nil
+}
+
+func (m memStorage) Size(f BackupFile) int64 { return f.Size }
+
+func (m memStorage) Sub(dir string) Storage { return memStorage{m.objects, dir} }
+
+func TestApplyUsesStorage(t *testing.T) {
+	log := &testLogger{t: t}
+	today := time.Now()
+	day := func(n int) time.Time {
+		d := today.AddDate(0, 0, -n)
+		return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.Local)
+	}
+	st := memStorage{map[string]time.Time{}, "bucket"}
+	for _, n := range []int{0, 1, 40, 400} {
+		d := day(n)
+		st.objects["bucket/mysql_backup_"+d.Format("20060102")+"_host_db.zip"] = d
+	}
+	p := DefaultPolicy()
+	p.Weekly, p.Monthly, p.Yearly = 0, 0, 0 // only the daily window counts
+	p.ArchiveDir = "archive"
+	var archived int
+	p.OnExpire = func(f BackupFile, toArchive bool) {
+		if toArchive {
+			archived++
+		}
+	}
+	if err := Apply(st, p, log); err != nil {
+		t.Fatal(err)
+	}
+	kept, _ := st.List(false)
+	moved, _ := st.Sub("archive").List(false)
+	if len(kept) != 2 || len(moved) != 2 || archived != 2 {
+		t.Errorf("Apply kept %d and archived %d (OnExpire %d) objects, want 2/2/2: %v", len(kept), len(moved), archived, st.objects)
+	}
+}
+
+func TestListBackupsUndatedUsesModTime(t *testing.T) {
+	dir := t.TempDir()
+	renamed := filepath.Join(dir, "shop_before_migration.zip")
+	writeZIP(t, renamed, "shop.sql")
+	// andere ZIPs im backup_dir sind keine Backups und bleiben unberührt
+	writeZIP(t, filepath.Join(dir, "invoices.zip"), "2025/01.pdf")
+	if err := os.WriteFile(filepath.Join(dir, "broken.zip"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2025, 3, 9, 14, 0, 0, 0, time.Local)
+	if err := os.Chtimes(renamed, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	files, err := ListBackups(dir)
+	if err != nil || len(files) != 0 {
+		t.Fatalf("ListBackups: %v, %d files, want none", err, len(files))
+	}
+	files, err = ListBackupsUndated(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || !files[0].Undated || dateKey(files[0].Date) != "20250309" {
+		t.Errorf("ListBackupsUndated = %+v, want one undated file dated 20250309", files)
+	}
+}
+
+// writeZIP creates a ZIP at path with one empty entry name.
+func writeZIP(t *testing.T, path, name string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	if _, err := zw.Create(name); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyRemovesSidecar(t *testing.T) {
+	dir := t.TempDir()
+	log := &testLogger{t: t}
+	d := time.Now().AddDate(0, 0, -400)
+	if d.Month() == time.December && d.Day() == 31 {
+		d = d.AddDate(0, 0, -1) // yearly anchor would be kept
+	}
+	old := filepath.Join(dir, "mysql_backup_"+d.Format("20060102")+"_host_db.zip")
+	for _, p := range []string{old, old + ".sha256"} {
+		if err := os.WriteFile(p, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Apply(DirStorage(dir), DefaultPolicy(), log); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{old, old + ".sha256"} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after retention", filepath.Base(p))
+		}
+	}
+}
+
+func TestArchiveRetainDaysCountsFromArchival(t *testing.T) {
+	dir, archive := t.TempDir(), t.TempDir()
+	log := &testLogger{t: t}
+	d := time.Now().AddDate(0, 0, -400)
+	if d.Month() == time.December && d.Day() == 31 {
+		d = d.AddDate(0, 0, -1) // yearly anchor would be kept
+	}
+	expired := "mysql_backup_" + d.Format("20060102") + "_host_db.zip"
+	if err := os.WriteFile(filepath.Join(dir, expired), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// seit 40 Tagen im Archiv
+	stale := filepath.Join(archive, "mysql_backup_"+time.Now().AddDate(0, 0, -45).Format("20060102")+"_host_db.zip")
+	if err := os.WriteFile(stale, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	then := time.Now().AddDate(0, 0, -40)
+	if err := os.Chtimes(stale, then, then); err != nil {
+		t.Fatal(err)
+	}
+	p := DefaultPolicy()
+	p.ArchiveDir, p.ArchiveRetainDays = archive, 30
+	if err := Apply(DirStorage(dir), p, log); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(archive, expired)); err != nil {
+		t.Errorf("backup archived in this run was pruned: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("backup archived 40 days ago still exists")
+	}
+}
+
+func TestOutsideWindowsPerDatabase(t *testing.T) {
+	p := DefaultPolicy()
+	p.Daily, p.Weekly, p.Monthly, p.Yearly = 2, 0, 0, 0
+	seven := 7
+	p.Databases = map[string]Counts{"shop": {Daily: &seven}, "shop_log": {}}
+	today := time.Date(2025, 8, 13, 0, 0, 0, 0, time.Local)
+	old := today.AddDate(0, 0, -5)
+	var files []BackupFile
+	for _, db := range []string{"shop", "shop_log", "crm"} {
+		files = append(files, BackupFile{Path: "mysql_backup_" + old.Format("20060102") + "_host_" + db + ".zip", Date: old})
+	}
+	expired, kept := p.outsideWindows(files, today)
+	if len(kept) != 1 || filepath.Base(kept[0].Path) != "mysql_backup_20250808_host_shop.zip" {
+		t.Errorf("kept = %v", kept)
+	}
+	if len(expired) != 2 {
+		t.Errorf("expired = %v", expired)
+	}
+}
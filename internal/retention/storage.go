@@ -0,0 +1,78 @@
+package retention
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/catalog"
+	"github.com/janmz/mysqlbackup/internal/parity"
+)
+
+// Storage is a backup location Apply lists, deletes and archives in: a (mounted) directory today,
+// object stores such as S3, B2 or WebDAV as further implementations. BackupFile.Path is the
+// backend's name of the object (file path or object key); its base name must follow the
+// mysql_backup_YYYYMMDD_*.zip pattern so Date can be classified.
+type Storage interface {
+	// List returns the backups sorted by date ascending; undated adds ZIPs without date in the name,
+	// dated by their modification time (retain_undated_by_mtime).
+	List(undated bool) ([]BackupFile, error)
+	// Remove deletes one backup with its sidecars.
+	Remove(path string) error
+	// Move moves one backup with its sidecars to dir and sets its modification time to the archival time.
+	// It returns the new path; a new path together with an error means a sidecar was not moved.
+	Move(path, dir string) (string, error)
+	// Size returns the bytes of one backup counted against max_backup_dir_size (with its parity files).
+	Size(f BackupFile) int64
+	// Sub returns the storage of dir on the same backend (archive_dir).
+	Sub(dir string) Storage
+}
+
+// DirStorage is a Storage on a local or mounted directory.
+type DirStorage string
+
+// List returns the backup ZIPs in the directory (see ListBackups, ListBackupsUndated).
+func (d DirStorage) List(undated bool) ([]BackupFile, error) {
+	return listDir(string(d), undated)
+}
+
+// Remove deletes one backup file, its sidecars and parity files.
+func (d DirStorage) Remove(path string) error {
+	return removeWithSidecar(path)
+}
+
+// Move moves one backup file, its sidecars and parity files to dir (see MoveFile).
+func (d DirStorage) Move(path, dir string) (string, error) {
+	target := filepath.Join(dir, filepath.Base(path))
+	if err := MoveFile(path, target); err != nil {
+		return "", err
+	}
+	// Änderungszeit = Archivierungszeit: archive_retain_days zählt ab dem Verschieben (pruneArchive)
+	now := time.Now()
+	_ = os.Chtimes(target, now, now)
+	var errs []error
+	for _, ext := range catalog.Sidecars {
+		if _, err := os.Stat(path + ext); err == nil {
+			if err := MoveFile(path+ext, target+ext); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	for _, pf := range parity.Files(path) {
+		if err := MoveFile(pf, filepath.Join(dir, filepath.Base(pf))); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return target, errors.Join(errs...)
+}
+
+// Size returns the size of the backup file plus its parity files.
+func (d DirStorage) Size(f BackupFile) int64 {
+	return f.Size + parity.Size(f.Path)
+}
+
+// Sub returns the directory dir as DirStorage.
+func (d DirStorage) Sub(dir string) Storage {
+	return DirStorage(dir)
+}
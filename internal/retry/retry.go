@@ -0,0 +1,45 @@
+// Package retry repeats a failed step of the backup run (dump, remote sync, notifications) with a growing
+// pause, so a short network or server hiccup does not fail the whole night.
+package retry
+
+import (
+	"context"
+	"time"
+)
+
+// Policy is the retry setting of one step: Retries more attempts after the first, the pause before the
+// first retry is Backoff and doubles with each further one.
+type Policy struct {
+	Retries int
+	Backoff time.Duration
+}
+
+// Wait returns the pause before retry n (1 = first retry).
+func (p Policy) Wait(n int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < n && d < time.Hour; i++ {
+		d *= 2
+	}
+	return d
+}
+
+// Do runs fn until it succeeds, the retries of p are used up or ctx ends, and returns the last error of fn.
+// Before each retry onRetry (may be nil) is called with the retry number, the pause and the error.
+func Do(ctx context.Context, p Policy, fn func() error, onRetry func(n int, wait time.Duration, err error)) error {
+	err := fn()
+	for n := 1; err != nil && n <= p.Retries && ctx.Err() == nil; n++ {
+		wait := p.Wait(n)
+		if onRetry != nil {
+			onRetry(n, wait, err)
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		err = fn()
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	fail := errors.New("fail")
+	tests := []struct {
+		name      string
+		retries   int
+		failFirst int // fn fails this many times, then succeeds
+		wantErr   bool
+		wantCalls int
+	}{
+		{"success", 2, 0, false, 1},
+		{"no retries", 0, 1, true, 1},
+		{"recovers", 2, 2, false, 3},
+		{"retries used up", 2, 5, true, 3},
+	}
+	for _, tt := range tests {
+		calls, retried := 0, 0
+		err := Do(context.Background(), Policy{Retries: tt.retries, Backoff: time.Millisecond}, func() error {
+			calls++
+			if calls <= tt.failFirst {
+				return fail
+			}
+			return nil
+		}, func(n int, wait time.Duration, err error) { retried++ })
+		if (err != nil) != tt.wantErr || calls != tt.wantCalls || retried != calls-1 {
+			t.Errorf("%s: err = %v, calls = %d, retries = %d", tt.name, err, calls, retried)
+		}
+	}
+}
+
+func TestDoCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := Do(ctx, Policy{Retries: 3, Backoff: time.Hour}, func() error {
+		calls++
+		return errors.New("fail")
+	}, func(int, time.Duration, error) { cancel() })
+	if err == nil || calls != 1 {
+		t.Errorf("err = %v, calls = %d; want error after 1 call", err, calls)
+	}
+}
+
+func TestWait(t *testing.T) {
+	p := Policy{Backoff: 30 * time.Second}
+	for n, want := range map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 3: 2 * time.Minute} {
+		if got := p.Wait(n); got != want {
+			t.Errorf("Wait(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
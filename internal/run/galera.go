@@ -0,0 +1,63 @@
+package run
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/logger"
+	"github.com/janmz/mysqlbackup/internal/mysql"
+)
+
+// selectDonor points conn at the first node of galera_nodes that is Synced in the primary component; nodes that
+// cannot be reached or are not synced (e.g. joining or donor of a state transfer) are logged and skipped.
+func selectDonor(cfg *config.Config, conn *mysql.Conn, log *logger.Logger) (mysql.ClusterStatus, error) {
+	nodes, err := cfg.GaleraAddrs()
+	if err != nil {
+		return mysql.ClusterStatus{}, err
+	}
+	for _, n := range nodes {
+		probe := &mysql.Conn{Host: n.Host, Port: n.Port, User: conn.User, Password: conn.Password, BinDir: conn.BinDir, TempDir: conn.TempDir}
+		status, err := probe.ClusterStatus()
+		probe.Close()
+		if err != nil {
+			log.Warn(i18n.Tf("log.warn.galera_node", n, err))
+			continue
+		}
+		if !status.Synced() {
+			log.Info(i18n.Tf("log.msg.galera_node_skipped", n, status.LocalState, status.ClusterStatus))
+			continue
+		}
+		conn.Host, conn.Port = n.Host, n.Port
+		log.Info(i18n.Tf("log.msg.galera_donor", n, status.NodeName, status.ClusterSize, status.LastCommitted))
+		return status, nil
+	}
+	return mysql.ClusterStatus{}, fmt.Errorf(i18n.T("err.galera_no_donor"), len(nodes))
+}
+
+// desyncDonor switches wsrep_desync on for the dumps (galera_desync) and returns the function that switches it
+// off again and waits for the node to catch up. A node that is already desynced (e.g. by another tool) is left
+// alone; if desync fails, the dumps run without it.
+func desyncDonor(conn *mysql.Conn, status mysql.ClusterStatus, log *logger.Logger) func() {
+	if status.Desync {
+		log.Warn(i18n.Tf("log.warn.galera_desynced", status.Node))
+		return func() {}
+	}
+	if err := conn.SetDesync(true); err != nil {
+		log.Warn(i18n.Tf("log.warn.galera_desync", err))
+		return func() {}
+	}
+	log.Info(i18n.Tf("log.msg.galera_desync", status.Node))
+	return func() {
+		if err := conn.SetDesync(false); err != nil {
+			log.Error(i18n.Tf("log.error.galera_resync", status.Node, err))
+			return
+		}
+		if s, ok := conn.WaitSynced(2*time.Minute, 2*time.Second); ok {
+			log.Info(i18n.Tf("log.msg.galera_synced", status.Node, s.LastCommitted))
+		} else {
+			log.Warn(i18n.Tf("log.warn.galera_not_synced", status.Node, s.LocalState))
+		}
+	}
+}
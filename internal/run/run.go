@@ -3,37 +3,321 @@ package run
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/janmz/mysqlbackup/internal/backup"
+	"github.com/janmz/mysqlbackup/internal/catalog"
 	"github.com/janmz/mysqlbackup/internal/config"
 	"github.com/janmz/mysqlbackup/internal/disk"
 	"github.com/janmz/mysqlbackup/internal/email"
+	"github.com/janmz/mysqlbackup/internal/errcode"
+	"github.com/janmz/mysqlbackup/internal/eventlog"
 	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/lock"
 	"github.com/janmz/mysqlbackup/internal/logger"
+	"github.com/janmz/mysqlbackup/internal/metrics"
 	"github.com/janmz/mysqlbackup/internal/mysql"
+	"github.com/janmz/mysqlbackup/internal/notify"
 	"github.com/janmz/mysqlbackup/internal/remote"
+	"github.com/janmz/mysqlbackup/internal/report"
 	"github.com/janmz/mysqlbackup/internal/retention"
+	"github.com/janmz/mysqlbackup/internal/retry"
+	"github.com/janmz/mysqlbackup/internal/runreport"
+	"github.com/janmz/mysqlbackup/internal/state"
+	"github.com/janmz/mysqlbackup/internal/verify"
 )
 
 // Backup runs the full backup flow: disk check, ensure schedule, list DBs, export users, parse, dump+append+zip, retention, remote copy. On critical error sends email and returns error.
-func Backup(cfg *config.Config, log *logger.Logger) error {
+// Start and result are recorded in state.json (catch-up, status). A run lock in backup_dir prevents
+// overlapping runs; if it is still held after lock_wait_minutes, an error wrapping lock.ErrLocked is returned.
+// Cancelling ctx (SIGINT/SIGTERM) stops the running dump or upload, removes its partial files and ends the run
+// with an error.
+func Backup(ctx context.Context, cfg *config.Config, log *logger.Logger, opt Options) error {
+	_ = os.MkdirAll(filepath.FromSlash(cfg.BackupDir), 0755)
+	runLock, err := lock.Acquire(cfg.BackupDir, time.Duration(cfg.LockWaitMinutes)*time.Minute)
+	if err != nil {
+		return err
+	}
+	defer runLock.Release()
+	st, err := state.Load(cfg.BackupDir)
+	if err != nil {
+		log.Warn(i18n.Tf("log.warn.state", err))
+	}
+	res := &runResult{Started: time.Now(), state: st, warnStart: log.WarnCount(), failedStep: -1}
+	log.SetRunID(newRunID(res.Started))
+	defer log.SetRunID("")
+	if info, err := os.Stat(log.Path()); err == nil {
+		res.logOffset = info.Size()
+	}
+	if cfg.WindowsEventLog {
+		if err := eventlog.Start(i18n.Tf("event.start", cfg.HostnameForBackup())); err != nil {
+			log.Debug(i18n.Tf("log.debug.eventlog", err))
+		}
+	}
+	if err := sendRetry(cfg, log, "healthcheck", func() error { return notify.Healthcheck(cfg, notify.PingStart, nil) }); err != nil {
+		log.Warn(i18n.Tf("log.warn.healthcheck", err))
+	}
+	st.Started(res.Started)
+	if err := st.Save(); err != nil {
+		log.Warn(i18n.Tf("log.warn.state", err))
+	}
+	stopLog := context.AfterFunc(ctx, func() { log.Warn(i18n.T("log.warn.interrupted")) })
+	defer stopLog()
+	if d, _ := cfg.RunTimeout(); d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	err = backupRun(ctx, cfg, log, res, opt)
+	postRunCmds(cfg, err, log)
+	res.Finished, res.Err = time.Now(), err
+	if err == nil {
+		if count, since := st.Recovered(); count > 0 {
+			notifyRecovery(cfg, log, count, since)
+		}
+	}
+	st.Finished(res.Finished, err)
+	if cfg.WindowsEventLog {
+		var evErr error
+		if err != nil {
+			evErr = eventlog.Failure(i18n.Tf("event.failure", cfg.HostnameForBackup(), err))
+		} else {
+			evErr = eventlog.Success(i18n.Tf("event.success", cfg.HostnameForBackup(), len(res.Created), res.Finished.Sub(res.Started).Round(time.Second)))
+		}
+		if evErr != nil {
+			log.Debug(i18n.Tf("log.debug.eventlog", evErr))
+		}
+	}
+	if err := st.Save(); err != nil {
+		log.Warn(i18n.Tf("log.warn.state", err))
+	}
+	if cfg.MetricsFile != "" || cfg.MetricsPushgateway != "" || cfg.MetricsStatsD != "" {
+		writeMetrics(cfg, res, st, log)
+	}
+	rep := res.report(cfg, log.Warnings(res.warnStart))
+	if err := runreport.Save(cfg.BackupDir, rep); err != nil {
+		log.Warn(i18n.Tf("log.warn.run_report", err))
+	}
+	if ev := event(rep); ev.Status == "failure" || cfg.NotifyAll() || (ev.Status == "warning" && cfg.NotifyWarnings()) {
+		if err := sendRetry(cfg, log, "webhook", func() error { return notify.Webhook(cfg, ev) }); err != nil {
+			log.Warn(i18n.Tf("log.warn.webhook", err))
+		}
+	}
+	if cfg.HealthcheckURL != "" {
+		signal := notify.PingSuccess
+		if err != nil {
+			signal = notify.PingFail
+		}
+		// Log dieses Laufs (ab Startposition in der Logdatei) als Body des Pings
+		body := readLogFrom(log.Path(), res.logOffset)
+		if err := sendRetry(cfg, log, "healthcheck", func() error { return notify.Healthcheck(cfg, signal, body) }); err != nil {
+			log.Warn(i18n.Tf("log.warn.healthcheck", err))
+		}
+	}
+	return err
+}
+
+// Options are the settings of one run given on the command line.
+type Options struct {
+	// Resume skips databases that already have a complete backup ZIP of today (--backup --resume), e.g. after
+	// a run that crashed in the middle.
+	Resume bool
+}
+
+// runResult collects the outcome of one run for its report (last_run.json) and the notifications sent after it
+// (webhook, healthcheck, metrics).
+type runResult struct {
+	Started  time.Time
+	Finished time.Time
+	Created  []catalog.Entry // backups written in this run
+	Err      error
+	RemoteOK bool                 // remote sync of this run succeeded
+	partial  *backup.PartialError // databases that failed with dump_continue_on_error
+	window   bool                 // backup_window_end reached during the dumps
+	paused   bool                 // backup_window_end reached during the upload
+	Pruned   []runreport.Pruned   // removed or archived by retention
+	Uploaded int                  // ZIPs uploaded by the remote sync
+
+	failedStep   int          // step that failed (notifyError), -1 = none
+	failedDetail string       // its error text
+	failedCode   errcode.Code // its error code
+	logOffset    int64        // size of the log file at start: the run's own lines follow it
+	warnStart    int          // log.WarnCount() at start: later warnings belong to this run
+	state        *state.State // failure series for notify_repeat (saved by Backup)
+}
+
+// report converts the result and the warnings of the run into its report: the steps as in the HTML email
+// (before the failed one OK, after it skipped).
+func (r *runResult) report(cfg *config.Config, warnings []string) *runreport.Report {
+	rep := &runreport.Report{
+		Host:      cfg.HostnameForBackup(),
+		Started:   r.Started,
+		Finished:  r.Finished,
+		Duration:  r.Finished.Sub(r.Started).Seconds(),
+		Status:    runreport.StatusSuccess,
+		Databases: []runreport.Database{},
+		Pruned:    r.Pruned,
+		Remote:    runreport.Remote{Configured: cfg.RemoteBackupDir != "" && cfg.RemoteSSHHost != "", OK: r.RemoteOK, Uploaded: r.Uploaded},
+		Warnings:  warnings,
+	}
+	if rep.Pruned == nil {
+		rep.Pruned = []runreport.Pruned{}
+	}
+	if rep.Warnings == nil {
+		rep.Warnings = []string{}
+	}
+	if len(warnings) > 0 {
+		rep.Status = runreport.StatusWarning
+	}
+	if r.Err != nil {
+		rep.Status = runreport.StatusFailure
+		rep.Error = r.Err.Error()
+		rep.Code = string(errcode.Of(r.Err))
+	}
+	if r.failedStep == stepRemote {
+		rep.Remote.Error = r.failedDetail
+	}
+	if r.partial != nil {
+		if r.failedStep == stepDump {
+			rep.Status = runreport.StatusPartial
+		}
+		for _, f := range r.partial.Failed {
+			rep.Failed = append(rep.Failed, runreport.FailedDatabase{Name: f.DB, Error: f.Err.Error(), Code: string(errcode.Of(f.Err))})
+		}
+	}
+	listed := listedSteps(r.failedStep)
+	for i, s := range r.steps() {
+		step := runreport.Step{Name: strings.TrimPrefix(stepNames[listed[i]], "email.step."), Status: runreport.StatusSuccess, Detail: s.Detail}
+		switch s.Status {
+		case email.StepFailed:
+			step.Status = runreport.StatusFailure
+			step.Code = string(r.failedCode)
+		case email.StepSkipped:
+			step.Status = runreport.StatusSkipped
+		}
+		rep.Steps = append(rep.Steps, step)
+	}
+	for _, e := range r.Created {
+		rep.Databases = append(rep.Databases, runreport.Database{Name: e.Database, File: e.File, Size: e.Size, DurationMS: e.DurationMS, SHA256: e.SHA256})
+	}
+	return rep
+}
+
+// steps returns the step table of the run (see runSteps): after a partial dump the later steps ran.
+func (r *runResult) steps() []email.Step {
+	return runSteps(r.failedStep, r.failedDetail, r.partial != nil && r.failedStep == stepDump)
+}
+
+// event converts the run report into the webhook payload; a partial run is a failure there.
+func event(rep *runreport.Report) notify.Event {
+	ev := notify.Event{
+		Status:    rep.Status,
+		Host:      rep.Host,
+		Databases: []string{},
+		Duration:  rep.Duration,
+		Warnings:  rep.Warnings,
+		Error:     rep.Error,
+		Code:      rep.Code,
+		Started:   rep.Started,
+		Finished:  rep.Finished,
+		TotalSize: rep.TotalSize(),
+	}
+	if ev.Status == runreport.StatusPartial {
+		ev.Status = runreport.StatusFailure
+	}
+	for _, d := range rep.Databases {
+		ev.Databases = append(ev.Databases, d.Name)
+	}
+	for _, f := range rep.Failed {
+		ev.Failed = append(ev.Failed, f.Name)
+	}
+	return ev
+}
+
+// backupRun runs the steps of Backup. When ctx ends (max_run_duration or SIGINT/SIGTERM), the running step is
+// aborted (dump, upload) or the run stops after it (retention), and the timeout or interruption is notified
+// instead of the step's error. At backup_window_end the running dump is stopped and the ZIPs completed so far
+// go through catalog and retention without upload; an upload still running then pauses until the next run.
+func backupRun(ctx context.Context, cfg *config.Config, log *logger.Logger, res *runResult, opt Options) error {
+	started := res.Started
+	// backup_window_end: Dumps und Upload enden mit dem Fenster, der Lauf (Aufbewahrung, Meldungen) nicht
+	wctx := ctx
+	windowEnd, open, _ := cfg.WindowEnd(started)
+	if !windowEnd.IsZero() {
+		if !open {
+			// Start nach dem Fensterende (manuell, Nachholen): nicht in die Geschäftszeit hinein sichern
+			err := errcode.Wrap(errcode.Window, fmt.Errorf(i18n.T("err.backup_window_closed"), windowEnd.Format("15:04")))
+			notifyError(cfg, log, res, stepDump, i18n.T("email.subject.window"), err.Error(), err)
+			return err
+		}
+		var cancel context.CancelFunc
+		wctx, cancel = context.WithDeadline(ctx, windowEnd)
+		defer cancel()
+		log.Info(i18n.Tf("log.msg.window_end", windowEnd.Format("2006-01-02 15:04")))
+	}
+	aborted := func(step int) error {
+		err := errcode.Wrap(errcode.Timeout, fmt.Errorf(i18n.T("err.run_timeout"), cfg.MaxRunDuration))
+		subject := i18n.T("email.subject.timeout")
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = errcode.Wrap(errcode.Interrupted, fmt.Errorf(i18n.T("err.run_interrupted")))
+			subject = i18n.T("email.subject.interrupted")
+		}
+		notifyError(cfg, log, res, step, subject, err.Error(), err)
+		return err
+	}
+	if strings.TrimSpace(cfg.PreRunCmd) != "" {
+		if err := runCmd(ctx, cfg, "pre_run_cmd", cfg.PreRunCmd, nil, log); err != nil {
+			if ctx.Err() != nil {
+				return aborted(stepPreRun)
+			}
+			err = errcode.Wrap(errcode.PreRun, err)
+			notifyError(cfg, log, res, stepPreRun, i18n.T("email.subject.pre_run"), err.Error(), err)
+			return err
+		}
+	}
 	backupDir := filepath.FromSlash(cfg.BackupDir)
 	avail, err := disk.Available(backupDir)
 	if err != nil {
 		log.Warn(i18n.Tf("log.warn.disk_check", err))
-	} else if avail < disk.MinFreeBytes {
-		err := fmt.Errorf(i18n.T("err.disk_space"), avail, disk.MinFreeBytes)
-		sendErrorEmail(cfg, log, i18n.T("email.subject.disk"), err.Error(), nil)
-		return err
+	} else {
+		// Bedarf aus der Größe des letzten Laufs: die Aufbewahrung gibt Platz erst nach dem Dump frei
+		previous := previousRunSize(cfg)
+		need := disk.Required(previous, cfg.DiskSpaceFactor)
+		log.Info(i18n.Tf("log.msg.disk_space", report.FormatSize(int64(avail)), report.FormatSize(int64(need)), report.FormatSize(previous)))
+		if avail < need {
+			err := errcode.Wrap(errcode.DiskSpace, fmt.Errorf(i18n.T("err.disk_space"), avail, need))
+			notifyError(cfg, log, res, stepDisk, i18n.T("email.subject.disk"), err.Error(), err)
+			return err
+		}
+		if previous > 0 && avail < need+uint64(previous) {
+			log.Warn(i18n.Tf("log.warn.disk_low", report.FormatSize(int64(avail)), report.FormatSize(int64(need))))
+		}
+	}
+	// Inodes: ein Volume ohne freie Inodes scheitert trotz freier Bytes mit "no space left on device"
+	if free, total, err := disk.Inodes(backupDir); err == nil && total > 0 {
+		log.Info(i18n.Tf("log.msg.disk_inodes", free, total))
+		if free < disk.MinFreeInodes {
+			err := errcode.Wrap(errcode.DiskInodes, fmt.Errorf(i18n.T("err.disk_inodes"), free, total, disk.MinFreeInodes))
+			notifyError(cfg, log, res, stepDisk, i18n.T("email.subject.disk_inodes"), err.Error(), err)
+			return err
+		}
+		if free < total/20 {
+			log.Warn(i18n.Tf("log.warn.inodes_low", free, total))
+		}
 	}
 
 	conn := &mysql.Conn{
@@ -42,7 +326,9 @@ func Backup(cfg *config.Config, log *logger.Logger) error {
 		User:     "root",
 		Password: cfg.RootPassword,
 		BinDir:   cfg.MySQLBin,
+		TempDir:  cfg.WorkDir,
 	}
+	defer conn.Close()
 
 	weStartedMySQL := false
 	if cfg.MySQLAutoStartStop && cfg.MySQLStartCmd != "" && cfg.MySQLStopCmd != "" {
@@ -54,12 +340,14 @@ func Backup(cfg *config.Config, log *logger.Logger) error {
 			} else {
 				log.Info(i18n.Tf("log.msg.mysql_starting", cfg.MySQLStartCmd))
 				if err := runMySQLLifecycleCmd(cfg.MySQLStartCmd, log, false); err != nil {
-					sendErrorEmail(cfg, log, i18n.T("email.subject.mysql_start"), err.Error(), nil)
+					err = errcode.Wrap(errcode.MySQLStart, err)
+					notifyError(cfg, log, res, stepMySQL, i18n.T("email.subject.mysql_start"), err.Error(), err)
 					return fmt.Errorf(i18n.T("err.mysql_start"), err)
 				}
 				if !waitForMySQL(conn, 60*time.Second, 2*time.Second) {
-					sendErrorEmail(cfg, log, i18n.T("email.subject.mysql_timeout"), i18n.T("email.body.mysql_timeout"), nil)
-					return fmt.Errorf(i18n.T("err.mysql_timeout"))
+					err := errcode.Wrap(errcode.MySQLTimeout, fmt.Errorf(i18n.T("err.mysql_timeout")))
+					notifyError(cfg, log, res, stepMySQL, i18n.T("email.subject.mysql_timeout"), i18n.T("email.body.mysql_timeout"), err)
+					return err
 				}
 				weStartedMySQL = true
 				log.Info(i18n.T("log.msg.mysql_started"))
@@ -67,15 +355,32 @@ func Backup(cfg *config.Config, log *logger.Logger) error {
 		}
 	}
 
+	// Galera: Dumps vom ersten synchronen Knoten, optional für die Dauer der Dumps desynchronisiert
+	resync := func() {}
+	if len(cfg.GaleraNodes) > 0 {
+		status, err := selectDonor(cfg, conn, log)
+		if err != nil {
+			err = errcode.Wrap(errcode.GaleraDonor, err)
+			notifyError(cfg, log, res, stepMySQL, i18n.T("email.subject.galera_donor"), err.Error(), err)
+			return err
+		}
+		if cfg.GaleraDesync {
+			resync = sync.OnceFunc(desyncDonor(conn, status, log))
+			defer resync()
+		}
+	}
+
 	isMariaDB, err := conn.IsMariaDB()
 	if err != nil {
-		sendErrorEmail(cfg, log, i18n.T("email.subject.mysql_server"), err.Error(), nil)
+		err = errcode.Wrap(errcode.MySQLServer, err)
+		notifyError(cfg, log, res, stepMySQL, i18n.T("email.subject.mysql_server"), err.Error(), err)
 		return fmt.Errorf(i18n.T("err.mysql_server"), err)
 	}
 
 	dbs, err := conn.ListDatabases()
 	if err != nil {
-		sendErrorEmail(cfg, log, i18n.T("email.subject.list_dbs"), err.Error(), nil)
+		err = errcode.Wrap(errcode.ListDatabases, err)
+		notifyError(cfg, log, res, stepDatabases, i18n.T("email.subject.list_dbs"), err.Error(), err)
 		return fmt.Errorf(i18n.T("err.list_databases"), err)
 	}
 	if len(dbs) == 0 {
@@ -90,21 +395,147 @@ func Backup(cfg *config.Config, log *logger.Logger) error {
 		userSQL = []byte{}
 	}
 
-	_, err = backup.Run(cfg, conn, userSQL, dbs, isMariaDB, log)
+	var resumed []catalog.Entry
+	if opt.Resume {
+		dbs, resumed = resumeDatabases(cfg, dbs, log)
+	}
+	created, err := backup.Run(wctx, cfg, conn, userSQL, dbs, isMariaDB, log)
+	resync()
+	res.Created = created
+	if ctx.Err() != nil {
+		return aborted(stepDump)
+	}
+	if windowStop(ctx, wctx, err) {
+		log.Warn(i18n.Tf("log.warn.window_dumps", windowEnd.Format("15:04"), len(created)))
+		res.window, err = true, nil
+	}
+	// dump_continue_on_error: Aufbewahrung und Remote-Sync laufen für die gesicherten Datenbanken weiter,
+	// gemeldet wird am Ende des Laufs
+	if errors.As(err, &res.partial) {
+		err = nil
+	}
 	if err != nil {
-		sendErrorEmail(cfg, log, i18n.T("email.subject.dump"), err.Error(), nil)
+		err = errcode.Wrap(errcode.Dump, err)
+		notifyError(cfg, log, res, stepDump, i18n.T("email.subject.dump"), err.Error(), err)
 		return fmt.Errorf(i18n.T("err.backup"), err)
 	}
 
-	if err := retention.ApplyToDirs(cfg.BackupDir, cfg.RemoteBackupDir, cfg.RetainDaily, cfg.RetainWeekly, cfg.RetainMonthly, cfg.RetainYearly, log); err != nil {
+	policy := retention.PolicyFromConfig(cfg)
+	cat, err := catalog.Load(cfg.BackupDir)
+	if err != nil {
+		log.Warn(i18n.Tf("log.warn.catalog_load", err))
+		cat = nil
+	} else {
+		for _, e := range resumed {
+			if _, ok := cat.Entry(e.File); !ok {
+				cat.Record(e)
+			}
+		}
+		for _, e := range created {
+			cat.Record(e)
+		}
+		policy.Catalog = cat
+		policy.Pinned = cat.PinnedSet()
+	}
+	policy.OnExpire = func(f retention.BackupFile, archived bool) {
+		res.Pruned = append(res.Pruned, runreport.Pruned{File: filepath.Base(f.Path), Size: f.Size, Archived: archived})
+		if cat != nil {
+			cat.Remove(filepath.Base(f.Path))
+			cat.AddPruned(filepath.Base(f.Path), f.Size, archived)
+		}
+	}
+	if err := retention.Apply(retention.DirStorage(cfg.BackupDir), policy, log); err != nil {
 		log.Warn(i18n.Tf("log.warn.retention", err))
 	}
+	if cat != nil {
+		// Platz nach der Aufbewahrung für die Prognose (disk_forecast_days)
+		if free, err := disk.Available(backupDir); err == nil {
+			cat.RecordUsage(time.Now(), free)
+		}
+		// Pruned-Einträge für den Bericht: zwei Monate reichen für einen Monatsbericht
+		cat.TrimPruned(time.Now().AddDate(0, -2, 0))
+		if err := cat.Save(); err != nil {
+			log.Warn(i18n.Tf("log.warn.catalog_save", err))
+		}
+	}
+	if ctx.Err() != nil {
+		return aborted(stepRetention)
+	}
 
-	if err := remote.Sync(cfg, cfg.BackupDir, log); err != nil {
-		sendErrorEmail(cfg, log, i18n.T("email.subject.remote"), err.Error(), nil)
+	// remote_retries: Sync ist wiederholbar (lädt nur fehlende/neuere Dateien hoch)
+	remotePolicy, _ := cfg.RetryPolicy("remote")
+	if res.window {
+		err = nil // Fenster schon während der Dumps geschlossen: kein Upload
+	} else {
+		err = retry.Do(wctx, remotePolicy, func() error { return remote.Sync(wctx, cfg, cfg.BackupDir, cat, log) }, func(n int, wait time.Duration, err error) {
+			log.Warn(i18n.Tf("log.warn.retry_remote", n, remotePolicy.Retries, wait, err))
+		})
+	}
+	if cat != nil {
+		if err := cat.Save(); err != nil {
+			log.Warn(i18n.Tf("log.warn.catalog_save", err))
+		}
+	}
+	res.RemoteOK = err == nil && !res.window
+	if cat != nil {
+		for _, e := range cat.Backups {
+			if !e.RemoteAt.Before(started) {
+				res.Uploaded++
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return aborted(stepRemote)
+	}
+	if windowStop(ctx, wctx, err) {
+		// Upload pausiert: der nächste Lauf lädt die fehlenden Dateien hoch
+		log.Warn(i18n.Tf("log.warn.window_upload", windowEnd.Format("15:04"), res.Uploaded))
+		res.RemoteOK, res.paused, err = false, true, nil
+	}
+	if err != nil {
+		err = errcode.Wrap(errcode.Remote, err)
+		notifyError(cfg, log, res, stepRemote, i18n.T("email.subject.remote"), err.Error(), err)
 		return fmt.Errorf(i18n.T("err.remote_sync"), err)
 	}
 
+	if cfg.VerifyAfterBackup && (res.window || res.paused) {
+		log.Warn(i18n.T("log.warn.window_verify"))
+	} else if cfg.VerifyAfterBackup {
+		if ctx.Err() != nil {
+			return aborted(stepVerify)
+		}
+		results, err := verify.Run(cfg, log)
+		verify.Record(cfg, cat, results, log)
+		if err != nil {
+			err = errcode.Wrap(errcode.Verify, err)
+			notifyError(cfg, log, res, stepVerify, i18n.T("email.subject.verify"), err.Error(), err)
+			return fmt.Errorf(i18n.T("err.verify_restore"), err)
+		}
+	}
+
+	if cat != nil && cfg.MonthlyReport && len(cfg.Recipients()) > 0 {
+		sendStorageReport(cfg, cat, log)
+	}
+	if cat != nil && cfg.DiskForecastDays > 0 {
+		diskForecast(cfg, cat, log)
+	}
+	// notify_level: Läufe mit Warnungen bzw. alle Läufe auf allen Kanälen melden
+	warnings := log.Warnings(res.warnStart)
+	byLevel := cfg.NotifyAll() || (len(warnings) > 0 && cfg.NotifyWarnings())
+	mailSuccess := (cfg.SuccessEmail || byLevel) && len(cfg.Recipients()) > 0 && res.partial == nil && !res.window && !res.paused
+	telegramSuccess := (cfg.TelegramSuccess || byLevel) && cfg.TelegramEnabled() && res.partial == nil && !res.window && !res.paused
+	if mailSuccess || telegramSuccess {
+		subject, body := successReport(cfg, cat, created, started, warnings)
+		if mailSuccess {
+			sendSuccessEmail(cfg, subject, body, log)
+		}
+		if telegramSuccess {
+			if err := sendRetry(cfg, log, "Telegram", func() error { return notify.Telegram(cfg, subject+"\n\n"+body) }); err != nil {
+				log.Warn(i18n.Tf("log.warn.telegram", err))
+			}
+		}
+	}
+
 	if weStartedMySQL && cfg.MySQLAutoStartStop && cfg.MySQLStopCmd != "" {
 		log.Info(i18n.Tf("log.msg.mysql_stopping", cfg.MySQLStopCmd))
 		if err := runMySQLLifecycleCmd(cfg.MySQLStopCmd, log, true); err != nil {
@@ -112,9 +543,55 @@ func Backup(cfg *config.Config, log *logger.Logger) error {
 		}
 	}
 
+	if res.window {
+		err := errcode.Wrap(errcode.Window, fmt.Errorf(i18n.T("err.backup_window"), windowEnd.Format("15:04"), len(created)))
+		notifyError(cfg, log, res, stepDump, i18n.T("email.subject.window"), err.Error(), err)
+		return err
+	}
+	if res.paused {
+		err := errcode.Wrap(errcode.Window, fmt.Errorf(i18n.T("err.backup_window_upload"), windowEnd.Format("15:04"), res.Uploaded))
+		notifyError(cfg, log, res, stepRemote, i18n.T("email.subject.window_upload"), err.Error(), err)
+		return err
+	}
+	if p := res.partial; p != nil {
+		var detail []string
+		for _, f := range p.Failed {
+			detail = append(detail, f.DB+": "+f.Err.Error())
+		}
+		err := errcode.Wrap(errcode.DumpPartial, p)
+		notifyError(cfg, log, res, stepDump, i18n.Tf("email.subject.dump_partial", len(p.Failed), p.Total), strings.Join(detail, "\n"), err)
+		return fmt.Errorf(i18n.T("err.backup"), err)
+	}
 	return nil
 }
 
+// resumeDatabases splits dbs for --resume: databases with a complete backup ZIP of today (backup.Completed) are
+// skipped and their entries returned for the catalog; the others are backed up.
+func resumeDatabases(cfg *config.Config, dbs []string, log *logger.Logger) (todo []string, done []catalog.Entry) {
+	for _, db := range dbs {
+		if e, ok := backup.Completed(cfg, db); ok {
+			log.Info(i18n.Tf("log.msg.resume_skip", db, e.File))
+			done = append(done, e)
+			continue
+		}
+		todo = append(todo, db)
+	}
+	log.Info(i18n.Tf("log.msg.resume", i18n.Named("done", len(done)), i18n.Named("todo", len(todo))))
+	return todo, done
+}
+
+// previousRunSize returns the size of the previous run: from last_run.json, else the newest backup day of the
+// catalog; 0 if neither is known.
+func previousRunSize(cfg *config.Config) int64 {
+	if rep, err := runreport.Load(cfg.BackupDir); err == nil && rep != nil && len(rep.Databases) > 0 {
+		return rep.TotalSize()
+	}
+	if cat, err := catalog.Load(cfg.BackupDir); err == nil {
+		return cat.LastDaySize()
+	}
+	return 0
+}
+
 // runMySQLLifecycleCmd runs a start or stop command. On Windows, .bat/.cmd are run via cmd /c.
 // waitForExit: true for stop (wait for process to finish, with timeout); false for start (daemon runs in foreground and never exits — start in background and return immediately).
 func runMySQLLifecycleCmd(cmd string, log *logger.Logger, waitForExit bool) error {
@@ -238,41 +715,319 @@ func portReachable(host string, port int) bool {
 	return true
 }
 
-func sendErrorEmail(cfg *config.Config, log *logger.Logger, subject, errDetail string, logExcerpt []byte) {
-	var excerpt string
-	if len(logExcerpt) > 0 {
-		excerpt = string(logExcerpt)
-		if len(excerpt) > 4096 {
-			excerpt = excerpt[len(excerpt)-4096:]
+// sendStorageReport mails the monthly storage report once per calendar month and records it in the catalog.
+func sendStorageReport(cfg *config.Config, cat *catalog.Catalog, log *logger.Logger) {
+	now := cfg.Now()
+	if !report.Due(cat.LastReport, now) {
+		return
+	}
+	since := cat.LastReport
+	if since.IsZero() {
+		since = now.AddDate(0, -1, 0)
+	}
+	files, err := retention.ListCatalog(cat)
+	if err != nil {
+		log.Warn(i18n.Tf("log.warn.report", err))
+		return
+	}
+	rem := report.Remote{Configured: cfg.RemoteBackupDir != "" && cfg.RemoteSSHHost != ""}
+	if rem.Configured {
+		rem.Files, rem.Size, rem.Err = remote.Usage(cfg)
+	}
+	host := cfg.HostnameForBackup()
+	body := report.Storage(host, cfg.BackupDir, files, rem, cat.PrunedSince(since), since, now)
+	if err := sendRetry(cfg, log, "SMTP", func() error {
+		return email.Send(cfg, i18n.Tf("email.subject.report", host, now.Format("2006-01")), body)
+	}); err != nil {
+		log.Warn(i18n.Tf("log.warn.report", err))
+		return
+	}
+	cat.LastReport = now
+	if err := cat.Save(); err != nil {
+		log.Warn(i18n.Tf("log.warn.catalog_save", err))
+	}
+	log.Info(i18n.T("log.msg.report_sent"))
+}
+
+// diskForecast warns when the trend of the free space in backup_dir (catalog.Forecast) falls below the space a
+// run needs within disk_forecast_days: as warning of the run and, at most once a week, by email and Telegram.
+func diskForecast(cfg *config.Config, cat *catalog.Catalog, log *logger.Logger) {
+	need := disk.Required(cat.LastDaySize(), cfg.DiskSpaceFactor)
+	full, perDay, ok := cat.Forecast(need)
+	now := time.Now()
+	if !ok || full.Sub(now) > time.Duration(cfg.DiskForecastDays)*24*time.Hour {
+		return
+	}
+	days := int(full.Sub(now).Hours() / 24)
+	if days < 0 {
+		days = 0
+	}
+	free := report.FormatSize(int64(cat.Usage[len(cat.Usage)-1].Free))
+	rate, date := report.FormatSize(int64(perDay)), full.Format("2006-01-02")
+	log.Warn(i18n.Tf("log.warn.disk_forecast", days, date, free, rate, report.FormatSize(int64(need))))
+	if now.Sub(cat.ForecastWarned) < 7*24*time.Hour {
+		return
+	}
+	subject := i18n.Tf("email.subject.disk_forecast", cfg.HostnameForBackup(), days)
+	body := i18n.Tf("email.body.disk_forecast", cfg.BackupDir, free, rate, date, report.FormatSize(int64(need)))
+	sent := false
+	if len(cfg.Recipients()) > 0 {
+		if err := sendRetry(cfg, log, "SMTP", func() error { return email.Send(cfg, subject, body) }); err != nil {
+			log.Warn(i18n.Tf("log.warn.email", err))
+		} else {
+			sent = true
+		}
+	}
+	if cfg.TelegramEnabled() {
+		if err := sendRetry(cfg, log, "Telegram", func() error { return notify.Telegram(cfg, subject+"\n\n"+body) }); err != nil {
+			log.Warn(i18n.Tf("log.warn.telegram", err))
+		} else {
+			sent = true
+		}
+	}
+	if sent {
+		cat.ForecastWarned = now
+		if err := cat.Save(); err != nil {
+			log.Warn(i18n.Tf("log.warn.catalog_save", err))
+		}
+	}
+}
+
+// successReport returns subject and body of the run summary, with the warnings of the run (if any) appended.
+// cat may be nil; then retention and upload details are missing.
+func successReport(cfg *config.Config, cat *catalog.Catalog, created []catalog.Entry, started time.Time, warnings []string) (subject, body string) {
+	var pruned []catalog.Pruned
+	uploaded := 0
+	if cat != nil {
+		pruned = cat.PrunedSince(started)
+		for _, e := range cat.Backups {
+			if !e.RemoteAt.Before(started) {
+				uploaded++
+			}
+		}
+	}
+	rem := report.Remote{Configured: cfg.RemoteBackupDir != "" && cfg.RemoteSSHHost != ""}
+	host := cfg.HostnameForBackup()
+	body = report.Run(host, created, pruned, rem, uploaded, started, time.Now())
+	if len(warnings) == 0 {
+		return i18n.Tf("email.subject.success", host, len(created)), body
+	}
+	body += "\n" + i18n.Tf("report.run_warnings", len(warnings)) + "\n"
+	for _, w := range warnings {
+		body += "  - " + w + "\n"
+	}
+	return i18n.Tf("email.subject.warnings", host, len(warnings)), body
+}
+
+// sendRetry sends one notification with the retries of notify_retries; channel names it in the log.
+func sendRetry(cfg *config.Config, log *logger.Logger, channel string, send func() error) error {
+	policy, _ := cfg.RetryPolicy("notify")
+	return retry.Do(context.Background(), policy, send, func(n int, wait time.Duration, err error) {
+		log.Warn(i18n.Tf("log.warn.retry_notify", channel, n, policy.Retries, wait, err))
+	})
+}
+
+// sendSuccessEmail sends the run summary (success_email).
+func sendSuccessEmail(cfg *config.Config, subject, body string, log *logger.Logger) {
+	html := email.FormatHTML(subject, runSteps(-1, "", false), body)
+	if err := sendRetry(cfg, log, "SMTP", func() error { return email.SendHTML(cfg, subject, body, html) }); err != nil {
+		log.Warn(i18n.Tf("log.warn.success_email", err))
+	}
+}
+
+// Schritte eines Laufs für die Tabelle der HTML-E-Mails (Reihenfolge wie in backupRun).
+const (
+	stepPreRun = iota // nur mit pre_run_cmd, siehe runSteps
+	stepDisk
+	stepMySQL
+	stepDatabases
+	stepDump
+	stepRetention
+	stepRemote
+	stepVerify // nur mit verify_after_backup, siehe runSteps
+)
+
+// maxErrDetail limits the error text in the mail body when the full details are attached.
+const maxErrDetail = 1000
+
+var stepNames = []string{"email.step.pre_run", "email.step.disk", "email.step.mysql", "email.step.databases", "email.step.dump", "email.step.retention", "email.step.remote", "email.step.verify"}
+
+// listedSteps returns the steps of the table for a run failed at failed: the pre-run command and the restore
+// verification are listed only if they failed.
+func listedSteps(failed int) []int {
+	var steps []int
+	for i := range stepNames {
+		if (i == stepPreRun || i == stepVerify) && failed != i {
+			continue
+		}
+		steps = append(steps, i)
+	}
+	return steps
+}
+
+// runSteps returns the step table (see listedSteps): steps before failed are OK, failed carries detail, later
+// steps were skipped, or OK if continued (the run went on after the failure, see dump_continue_on_error).
+// failed < 0 marks all steps OK (successful run).
+func runSteps(failed int, detail string, continued bool) []email.Step {
+	var steps []email.Step
+	for _, i := range listedSteps(failed) {
+		step := email.Step{Name: i18n.T(stepNames[i])}
+		switch {
+		case failed < 0 || i < failed || (continued && i > failed):
+			step.Status = email.StepOK
+		case i == failed:
+			step.Status = email.StepFailed
+			step.Detail = detail
+		default:
+			step.Status = email.StepSkipped
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// notifyError sends the error email and the Telegram message (if configured). The code of cause (errcode) is put in
+// front of the subject. The email body stays short;
+// the last mail_log_kb of this run's log lines (logger.Recent) and the stderr of a failed mysqldump (cause) are attached as text files.
+// After notify_repeat identical failures in a row only one notification per day is sent (see state.RecordFailure).
+// If cause concerns databases (backup.DatabaseError, backup.PartialError), their databases[].mail_to also receive
+// the email.
+func notifyError(cfg *config.Config, log *logger.Logger, res *runResult, step int, subject, errDetail string, cause error) {
+	code := errcode.Of(cause)
+	res.failedStep, res.failedDetail, res.failedCode = step, errDetail, code
+	subject = errcode.Tag(code, subject)
+	if st := res.state; st != nil {
+		if !st.RecordFailure(errorFingerprint(subject, errDetail), time.Now(), cfg.NotifyRepeat) {
+			log.Info(i18n.Tf("log.msg.notify_suppressed", st.ErrorCount))
+			return
+		}
+		if cfg.NotifyRepeat > 0 && st.ErrorCount > cfg.NotifyRepeat {
+			subject = i18n.Tf("email.subject.repeated", subject, st.ErrorCount, st.ErrorSince.Format("2006-01-02"))
 		}
 	}
-	body := email.FormatErrorBody(subject, errDetail, excerpt)
-	if err := email.Send(cfg, subject, body); err != nil {
+	var attachments []email.Attachment
+	if excerpt := log.Recent(); len(excerpt) > 0 {
+		attachments = append(attachments, email.Attachment{Name: "mysqlbackup-log.txt", Data: excerpt})
+	}
+	failedDBs := failedDatabases(cause)
+	for _, dbErr := range failedDBs {
+		var cmdErr *mysql.CommandError
+		if errors.As(dbErr, &cmdErr) && strings.TrimSpace(cmdErr.Stderr) != "" {
+			name := "mysqldump-stderr.txt"
+			if len(failedDBs) > 1 {
+				name = "mysqldump-stderr-" + dbErr.DB + ".txt"
+			}
+			attachments = append(attachments, email.Attachment{Name: name, Data: []byte(cmdErr.Stderr)})
+		}
+	}
+	if len(errDetail) > maxErrDetail && len(attachments) > 0 {
+		errDetail = strings.ToValidUTF8(errDetail[:maxErrDetail], "") + " …"
+	}
+	var note string
+	if len(attachments) > 0 {
+		note = i18n.T("email.body.attached")
+	}
+	body := email.FormatErrorBody(subject, errDetail, note)
+	htmlBody := email.FormatHTML(subject, res.steps(), note)
+	mailCfg := cfg
+	for _, dbErr := range failedDBs {
+		if dc := cfg.Database(dbErr.DB); dc != nil && len(dc.MailTo) > 0 {
+			c := *mailCfg
+			c.MailTo = append(mailCfg.Recipients(), dc.MailTo...)
+			mailCfg = &c
+		}
+	}
+	if err := sendRetry(cfg, log, "SMTP", func() error { return email.SendHTML(mailCfg, subject, body, htmlBody, attachments...) }); err != nil {
 		log.Warn(i18n.Tf("log.warn.email", err))
 	}
+	if err := sendRetry(cfg, log, "Telegram", func() error { return notify.Telegram(cfg, subject+" ("+cfg.HostnameForBackup()+")\n\n"+errDetail) }); err != nil {
+		log.Warn(i18n.Tf("log.warn.telegram", err))
+	}
 }
 
-// CaptureLogExcerpt reads the last N bytes from log file for error emails (optional).
-func CaptureLogExcerpt(logPath string, maxBytes int) []byte {
-	if logPath == "" || maxBytes <= 0 {
-		return nil
+// failedDatabases returns the databases cause concerns: all of a *backup.PartialError, else the one of a
+// *backup.DatabaseError.
+func failedDatabases(cause error) []*backup.DatabaseError {
+	var partial *backup.PartialError
+	if errors.As(cause, &partial) {
+		return partial.Failed
 	}
-	f, err := os.Open(filepath.FromSlash(logPath))
-	if err != nil {
-		return nil
+	var dbErr *backup.DatabaseError
+	if errors.As(cause, &dbErr) {
+		return []*backup.DatabaseError{dbErr}
 	}
-	defer f.Close()
-	info, err := f.Stat()
-	if err != nil {
+	return nil
+}
+
+// writeMetrics writes the Prometheus metrics of the run to metrics_file and/or pushes them to metrics_pushgateway
+// and sends them to the StatsD receiver metrics_statsd.
+func writeMetrics(cfg *config.Config, res *runResult, st *state.State, log *logger.Logger) {
+	m := metrics.Run{
+		Started:          res.Started,
+		Finished:         res.Finished,
+		Success:          res.Err == nil,
+		LastSuccess:      st.LastSuccess,
+		Failures:         st.Failures,
+		RemoteConfigured: cfg.RemoteBackupDir != "" && cfg.RemoteSSHHost != "",
+		RemoteOK:         res.RemoteOK,
+	}
+	if cat, err := catalog.Load(cfg.BackupDir); err == nil {
+		m.Backups = cat.Backups
+	}
+	data := metrics.Format(m)
+	if cfg.MetricsFile != "" {
+		if err := metrics.WriteFile(cfg.MetricsFile, data); err != nil {
+			log.Warn(i18n.Tf("log.warn.metrics", err))
+		}
+	}
+	if cfg.MetricsPushgateway != "" {
+		if err := metrics.Push(cfg.MetricsPushgateway, cfg.HostnameForBackup(), data); err != nil {
+			log.Warn(i18n.Tf("log.warn.metrics", err))
+		}
+	}
+	if cfg.MetricsStatsD != "" {
+		if err := metrics.SendStatsD(cfg.MetricsStatsD, metrics.StatsD(m, cfg.HostnameForBackup())); err != nil {
+			log.Warn(i18n.Tf("log.warn.metrics", err))
+		}
+	}
+}
+
+// newRunID returns an ID for the log lines of one run (JSON log field run_id): start time plus random suffix.
+func newRunID(t time.Time) string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b)
+	return t.Format("20060102T150405") + "-" + hex.EncodeToString(b)
+}
+
+// readLogFrom returns the log file content from offset to the end (the lines of the current run).
+func readLogFrom(logPath string, offset int64) []byte {
+	b, err := os.ReadFile(filepath.FromSlash(logPath))
+	if err != nil || offset < 0 || offset > int64(len(b)) {
 		return nil
 	}
-	size := info.Size()
-	if size <= int64(maxBytes) {
-		b := make([]byte, size)
-		_, _ = f.Read(b)
-		return b
+	return b[offset:]
+}
+
+// digitsRe matches numbers in error texts (ports, sizes, times), which must not change the fingerprint.
+var digitsRe = regexp.MustCompile(`[0-9]+`)
+
+// errorFingerprint identifies "the same error" across runs: subject and error text without numbers.
+func errorFingerprint(subject, errDetail string) string {
+	h := fnv.New64a()
+	h.Write([]byte(subject + "\n" + digitsRe.ReplaceAllString(errDetail, "#")))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// notifyRecovery reports by email and Telegram that a run succeeded again after count failed runs since since.
+func notifyRecovery(cfg *config.Config, log *logger.Logger, count int, since time.Time) {
+	host := cfg.HostnameForBackup()
+	subject := i18n.Tf("email.subject.recovered", host)
+	body := i18n.Tf("email.body.recovered", count, since.Format("2006-01-02 15:04"))
+	html := email.FormatHTML(subject, runSteps(-1, "", false), body)
+	if err := sendRetry(cfg, log, "SMTP", func() error { return email.SendHTML(cfg, subject, body, html) }); err != nil {
+		log.Warn(i18n.Tf("log.warn.email", err))
+	}
+	if err := sendRetry(cfg, log, "Telegram", func() error { return notify.Telegram(cfg, subject+"\n\n"+body) }); err != nil {
+		log.Warn(i18n.Tf("log.warn.telegram", err))
 	}
-	b := make([]byte, maxBytes)
-	_, _ = f.ReadAt(b, size-int64(maxBytes))
-	return b
 }
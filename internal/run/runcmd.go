@@ -0,0 +1,57 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/logger"
+)
+
+// runCmd runs the run-level command name (pre_run_cmd, post_run_cmd, ...) via the shell (cmd /C on Windows,
+// sh -c otherwise) with placeholders expanded as in config.Expand and MYSQLBACKUP_BACKUP_DIR plus env in the
+// environment. Output is logged; ctx ends the command (max_run_duration).
+func runCmd(ctx context.Context, cfg *config.Config, name, command string, env []string, log *logger.Logger) error {
+	command = config.Expand(strings.TrimSpace(command), cfg.Now(), "")
+	log.Info(i18n.Tf("log.msg.run_cmd", name, command))
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(append(os.Environ(), "MYSQLBACKUP_BACKUP_DIR="+cfg.BackupDir), env...)
+	out, err := cmd.CombinedOutput()
+	if s := strings.TrimSpace(string(out)); s != "" {
+		log.Info(i18n.Tf("log.msg.run_cmd_output", name, s))
+	}
+	if err != nil {
+		return fmt.Errorf(i18n.T("err.run_cmd"), name, err)
+	}
+	return nil
+}
+
+// postRunCmds runs post_run_success_cmd or post_run_failure_cmd and then post_run_cmd after a run that ended with
+// runErr, with MYSQLBACKUP_STATUS (success/failure) and MYSQLBACKUP_ERROR in the environment. Failures are
+// warnings: the result of the run stays as it is.
+func postRunCmds(cfg *config.Config, runErr error, log *logger.Logger) {
+	env := []string{"MYSQLBACKUP_STATUS=success"}
+	name, command := "post_run_success_cmd", cfg.PostRunSuccessCmd
+	if runErr != nil {
+		env = []string{"MYSQLBACKUP_STATUS=failure", "MYSQLBACKUP_ERROR=" + runErr.Error()}
+		name, command = "post_run_failure_cmd", cfg.PostRunFailureCmd
+	}
+	for _, c := range [][2]string{{name, command}, {"post_run_cmd", cfg.PostRunCmd}} {
+		if strings.TrimSpace(c[1]) == "" {
+			continue
+		}
+		if err := runCmd(context.Background(), cfg, c[0], c[1], env, log); err != nil {
+			log.Warn(i18n.Tf("log.warn.post_run_cmd", err))
+		}
+	}
+}
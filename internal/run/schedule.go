@@ -0,0 +1,90 @@
+package run
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/email"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/lock"
+	"github.com/janmz/mysqlbackup/internal/logger"
+	"github.com/janmz/mysqlbackup/internal/notify"
+	"github.com/janmz/mysqlbackup/internal/state"
+)
+
+// scheduleGrace is how long after its scheduled time a run may start before the window counts as missed: one
+// hour plus start_jitter_minutes and lock_wait_minutes, by which a regular run may start late.
+func scheduleGrace(cfg *config.Config) time.Duration {
+	return time.Hour + time.Duration(cfg.StartJitterMinutes+cfg.LockWaitMinutes)*time.Minute
+}
+
+// CheckSchedule detects a broken schedule after schedule.EnsureInstalled (--backup, --status): a scheduled run
+// of one of targets that did not start (state.MissedRun) and the job repairs of EnsureInstalled (repaired) are
+// logged and sent once as a "schedule broken" notification. Then the next scheduled run is recorded in the
+// state of each target, under the run lock so a running backup does not lose its own state; a target whose
+// backup is running is skipped. Repairs before the first recorded run (new installation) are not reported.
+func CheckSchedule(cfg *config.Config, targets []*config.Config, repaired []string, log *logger.Logger) {
+	now := time.Now()
+	var lines []string
+	tracked := false
+	for _, t := range targets {
+		spec, err := t.ScheduleSpec()
+		if err != nil {
+			continue
+		}
+		runLock, err := lock.Acquire(t.BackupDir, 0)
+		if err != nil {
+			if !errors.Is(err, lock.ErrLocked) {
+				log.Warn(i18n.Tf("log.warn.state", err))
+			}
+			continue
+		}
+		st, err := state.Load(t.BackupDir)
+		if err != nil {
+			runLock.Release()
+			log.Warn(i18n.Tf("log.warn.state", err))
+			continue
+		}
+		tracked = tracked || !st.NextRun.IsZero()
+		if missed := st.MissedRun(now, scheduleGrace(t)); !missed.IsZero() {
+			last := i18n.T("schedule.never")
+			if !st.LastStart.IsZero() {
+				last = st.LastStart.Local().Format("2006-01-02 15:04")
+			}
+			line := i18n.Tf("log.warn.schedule_missed", missed.Local().Format("2006-01-02 15:04"), last)
+			if name := t.ServerName(); name != "" {
+				line = name + ": " + line
+			}
+			log.Warn(line)
+			lines = append(lines, line)
+		}
+		st.NextRun = spec.Next(now)
+		if err := st.Save(); err != nil {
+			log.Warn(i18n.Tf("log.warn.state", err))
+		}
+		runLock.Release()
+	}
+	if tracked {
+		for _, r := range repaired {
+			lines = append(lines, i18n.Tf("schedule.repaired", r))
+		}
+	}
+	if len(lines) > 0 {
+		notifySchedule(cfg, log, lines)
+	}
+}
+
+// notifySchedule reports the problems of a broken schedule (lines) by email and Telegram.
+func notifySchedule(cfg *config.Config, log *logger.Logger, lines []string) {
+	subject := i18n.Tf("email.subject.schedule_broken", cfg.HostnameForBackup())
+	body := i18n.Tf("email.body.schedule_broken", strings.Join(lines, "\n"))
+	html := email.FormatHTML(subject, nil, body)
+	if err := sendRetry(cfg, log, "SMTP", func() error { return email.SendHTML(cfg, subject, body, html) }); err != nil {
+		log.Warn(i18n.Tf("log.warn.email", err))
+	}
+	if err := sendRetry(cfg, log, "Telegram", func() error { return notify.Telegram(cfg, subject+"\n\n"+body) }); err != nil {
+		log.Warn(i18n.Tf("log.warn.telegram", err))
+	}
+}
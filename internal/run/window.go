@@ -0,0 +1,13 @@
+package run
+
+import (
+	"context"
+	"errors"
+)
+
+// windowStop reports whether a step (dumps, upload) ended with err because the backup window (backup_window_end,
+// wctx) closed: the window deadline has passed while the run itself (ctx) goes on, and err is that deadline,
+// not an error of the step that happened to coincide with it.
+func windowStop(ctx, wctx context.Context, err error) bool {
+	return ctx.Err() == nil && errors.Is(wctx.Err(), context.DeadlineExceeded) && errors.Is(err, context.DeadlineExceeded)
+}
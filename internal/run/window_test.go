@@ -0,0 +1,41 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWindowStop(t *testing.T) {
+	ctx := context.Background()
+	closed, cancel := context.WithDeadline(ctx, time.Now().Add(-time.Minute))
+	defer cancel()
+	open, cancelOpen := context.WithDeadline(ctx, time.Now().Add(time.Hour))
+	defer cancelOpen()
+	stopped, stop := context.WithCancel(ctx)
+	stop()
+	stoppedWindow, cancelStopped := context.WithDeadline(stopped, time.Now().Add(-time.Minute))
+	defer cancelStopped()
+
+	dbErr := errors.New("mysqldump: access denied")
+	tests := []struct {
+		name      string
+		ctx, wctx context.Context
+		err       error
+		want      bool
+	}{
+		{"window closed", ctx, closed, closed.Err(), true},
+		{"wrapped deadline", ctx, closed, fmt.Errorf("upload: %w", context.DeadlineExceeded), true},
+		{"real error at window end", ctx, closed, dbErr, false},
+		{"no error at window end", ctx, closed, nil, false},
+		{"window open", ctx, open, dbErr, false},
+		{"run interrupted", stopped, stoppedWindow, context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		if got := windowStop(tt.ctx, tt.wctx, tt.err); got != tt.want {
+			t.Errorf("%s: windowStop = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Package runreport writes the report of each backup run: last_run.json in backup_dir and a dated copy in
+// backup_dir/runs (history). --status and the notifications read it instead of deriving the state again.
+package runreport
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileName is the report of the last run in backup_dir.
+const FileName = "last_run.json"
+
+// HistoryDir is the directory in backup_dir with the reports of earlier runs (run_YYYYMMDD_HHMMSS.json).
+const HistoryDir = "runs"
+
+// HistoryKeep is the number of reports kept in HistoryDir.
+const HistoryKeep = 100
+
+// Status of a run or step.
+const (
+	StatusSuccess = "success"
+	StatusWarning = "warning"
+	StatusFailure = "failure"
+	StatusPartial = "partial" // some databases failed (dump_continue_on_error), the others were backed up
+	StatusSkipped = "skipped"
+)
+
+// Step is one step of the run (pre_run, disk, mysql, databases, dump, retention, remote, verify).
+type Step struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // success, failure, skipped
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code,omitempty"` // error code of a failed step (MB-xxxx, see errcode)
+}
+
+// Database is the backup of one database in the run.
+type Database struct {
+	Name       string `json:"name"`
+	File       string `json:"file"`
+	Size       int64  `json:"size"`
+	DurationMS int64  `json:"duration_ms"`
+	SHA256     string `json:"sha256,omitempty"`
+}
+
+// FailedDatabase is a database whose backup failed in a run with dump_continue_on_error.
+type FailedDatabase struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
+}
+
+// Pruned is a backup removed or archived by retention in the run.
+type Pruned struct {
+	File     string `json:"file"`
+	Size     int64  `json:"size"`
+	Archived bool   `json:"archived,omitempty"`
+}
+
+// Remote is the result of the remote sync of the run.
+type Remote struct {
+	Configured bool   `json:"configured"`
+	OK         bool   `json:"ok"`
+	Uploaded   int    `json:"uploaded"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Report is the content of last_run.json.
+type Report struct {
+	Host      string           `json:"host"`
+	Started   time.Time        `json:"started"`
+	Finished  time.Time        `json:"finished"`
+	Duration  float64          `json:"duration_seconds"`
+	Status    string           `json:"status"` // success, warning, partial, failure
+	Error     string           `json:"error,omitempty"`
+	Code      string           `json:"code,omitempty"` // stable error code (MB-xxxx) of Error
+	Steps     []Step           `json:"steps"`
+	Databases []Database       `json:"databases"`
+	Failed    []FailedDatabase `json:"failed_databases,omitempty"`
+	Pruned    []Pruned         `json:"pruned"`
+	Remote    Remote           `json:"remote"`
+	Warnings  []string         `json:"warnings"`
+}
+
+// TotalSize returns the size of all ZIPs of the run.
+func (r *Report) TotalSize() int64 {
+	var n int64
+	for _, d := range r.Databases {
+		n += d.Size
+	}
+	return n
+}
+
+// Save writes r to dir/last_run.json (temp file + rename) and to the history, of which only the newest
+// HistoryKeep reports are kept.
+func Save(dir string, r *Report) error {
+	dir = filepath.FromSlash(dir)
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, FileName)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	histDir := filepath.Join(dir, HistoryDir)
+	if err := os.MkdirAll(histDir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(histDir, "run_"+r.Started.Format("20060102_150405")+".json"), data, 0644); err != nil {
+		return err
+	}
+	return prune(histDir, HistoryKeep)
+}
+
+// Load reads dir/last_run.json; without a file (no run yet) it returns nil and no error.
+func Load(dir string) (*Report, error) {
+	data, err := os.ReadFile(filepath.Join(filepath.FromSlash(dir), FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// History returns the names of the reports in dir/runs without ".json" (run_YYYYMMDD_HHMMSS), newest first;
+// none without a history.
+func History(dir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(filepath.FromSlash(dir), HistoryDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, e := range entries {
+		if id, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() && historyRe.MatchString(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
+
+// LoadHistory reads the report id (see History) from dir/runs; os.ErrNotExist for an unknown or invalid id.
+func LoadHistory(dir, id string) (*Report, error) {
+	if !historyRe.MatchString(id) {
+		return nil, os.ErrNotExist
+	}
+	data, err := os.ReadFile(filepath.Join(filepath.FromSlash(dir), HistoryDir, id+".json"))
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+var historyRe = regexp.MustCompile(`^run_\d{8}_\d{6}$`)
+
+// prune removes the oldest reports in dir beyond keep (names sort by start time).
+func prune(dir string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), "run_") && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	for len(names) > keep {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
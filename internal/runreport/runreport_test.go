@@ -0,0 +1,72 @@
+package runreport
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	if r, err := Load(dir); r != nil || err != nil {
+		t.Fatalf("Load without file = %v, %v; want nil, nil", r, err)
+	}
+	started := time.Date(2026, 10, 15, 22, 0, 0, 0, time.UTC)
+	r := &Report{Host: "db1", Started: started, Status: StatusWarning,
+		Databases: []Database{{Name: "shop", Size: 100}, {Name: "crm", Size: 50}}}
+	if err := Save(dir, r); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Host != "db1" || got.Status != StatusWarning || !got.Started.Equal(started) || got.TotalSize() != 150 {
+		t.Errorf("Load = %+v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, HistoryDir, "run_20261015_220000.json")); err != nil {
+		t.Errorf("history report missing: %v", err)
+	}
+	r.Started = started.Add(24 * time.Hour)
+	if err := Save(dir, r); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := History(dir)
+	if err != nil || len(ids) != 2 || ids[0] != "run_20261016_220000" {
+		t.Fatalf("History = %v, %v", ids, err)
+	}
+	if h, err := LoadHistory(dir, ids[1]); err != nil || !h.Started.Equal(started) {
+		t.Errorf("LoadHistory(%s) = %+v, %v", ids[1], h, err)
+	}
+	if _, err := LoadHistory(dir, "../last_run"); !os.IsNotExist(err) {
+		t.Errorf("LoadHistory with path: %v, want not exist", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"run_20261013_220000.json", "run_20261015_220000.json", "run_20261014_220000.json", "other.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := prune(dir, 2); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{"other.json", "run_20261014_220000.json", "run_20261015_220000.json"}
+	if len(names) != len(want) {
+		t.Fatalf("after prune: %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("after prune: %v, want %v", names, want)
+			break
+		}
+	}
+}
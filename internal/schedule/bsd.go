@@ -0,0 +1,137 @@
+package schedule
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/logger"
+)
+
+const (
+	// FreeBSD/DragonFly: cron also reads this directory (system crontab format with user field).
+	bsdCronDir = "/usr/local/etc/cron.d"
+	// FreeBSD/DragonFly: scripts run by periodic(8) once a day (time set in /etc/crontab, default 03:01).
+	bsdPeriodicDir = "/usr/local/etc/periodic/daily"
+)
+
+// isBSD reports whether the system is a BSD without systemd (macOS uses launchd, see ensureLaunchd).
+func isBSD() bool {
+	switch runtime.GOOS {
+	case "freebsd", "openbsd", "netbsd", "dragonfly":
+		return true
+	}
+	return false
+}
+
+// hasLocalCronDir reports whether cron reads /usr/local/etc/cron.d (FreeBSD, DragonFly).
+func hasLocalCronDir() bool {
+	return runtime.GOOS == "freebsd" || runtime.GOOS == "dragonfly"
+}
+
+// ensureBSD installs the schedule on BSD: schedule_scope "periodic" as periodic(8) daily script,
+// "system" as cron.d file (FreeBSD/DragonFly) or root crontab, otherwise the user crontab.
+func ensureBSD(cfg *config.Config, configPath string, ch *changes, log *logger.Logger) error {
+	switch {
+	case cfg.PeriodicScope():
+		return ensureBSDPeriodic(cfg, configPath, ch, log)
+	case cfg.SystemScope() && hasLocalCronDir():
+		return ensureBSDCronDir(cfg, configPath, ch, log)
+	case cfg.SystemScope() && os.Geteuid() != 0:
+		return fmt.Errorf(i18n.T("err.schedule_system_root"))
+	}
+	return ensureUnixCron(cfg, configPath, ch, log)
+}
+
+// ensureBSDCronDir writes /usr/local/etc/cron.d/mysqlbackup, running as schedule_user.
+func ensureBSDCronDir(cfg *config.Config, configPath string, ch *changes, log *logger.Logger) error {
+	if os.Geteuid() != 0 {
+		return fmt.Errorf(i18n.T("err.schedule_system_root"))
+	}
+	when, lines, err := cronLines(cfg, configPath, scheduleUser(cfg))
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(bsdCronDir, serviceName)
+	content := "# " + cronMarker + "\nSHELL=/bin/sh\nPATH=/etc:/bin:/sbin:/usr/bin:/usr/sbin:/usr/local/bin\n" + strings.Join(lines, "\n") + "\n"
+	if old, err := os.ReadFile(path); err == nil && string(old) == content {
+		log.Info(i18n.Tf("log.msg.cron_present_file", path))
+		return nil
+	}
+	if err := os.MkdirAll(bsdCronDir, 0755); err != nil {
+		return fmt.Errorf(i18n.Tf("err.write_path", path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf(i18n.Tf("err.write_cron_need_root", path), err, strings.Join(lines, "\n"))
+	}
+	installed(cfg, log, ch, path, i18n.Tf("log.msg.cron_added_file", path, when))
+	return nil
+}
+
+// ensureBSDPeriodic writes a periodic(8) daily script. periodic runs once a day at its own time, so
+// schedule/start_time only apply as far as "daily" goes; other schedules are rejected.
+func ensureBSDPeriodic(cfg *config.Config, configPath string, ch *changes, log *logger.Logger) error {
+	if os.Geteuid() != 0 {
+		return fmt.Errorf(i18n.T("err.schedule_system_root"))
+	}
+	spec, err := cfg.ScheduleSpec()
+	if err != nil {
+		return err
+	}
+	if !spec.IsDaily() {
+		return fmt.Errorf(i18n.T("err.periodic_daily"), spec.Expr)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf(i18n.T("err.executable_path"), err)
+	}
+	exe = filepath.Clean(exe)
+	runAs := scheduleUser(cfg)
+	command := quoteForCron(exe) + " --backup -config " + quoteForCron(configPath)
+	if runAs != "root" {
+		command = "su -m " + quoteForCron(runAs) + " -c " + quoteForCron(command)
+	}
+	content := "#!/bin/sh\n# " + cronMarker + ": MySQL Backup, run daily by periodic(8)\n\n" +
+		"cd " + quoteForCron(filepath.Dir(configPath)) + " || exit 2\n" + command + "\n"
+	path := filepath.Join(bsdPeriodicDir, bsdPeriodicScript)
+	if old, err := os.ReadFile(path); err == nil && string(old) == content {
+		log.Info(i18n.Tf("log.msg.periodic_exists", path))
+		return nil
+	}
+	if err := os.MkdirAll(bsdPeriodicDir, 0755); err != nil {
+		return fmt.Errorf(i18n.Tf("err.write_path", path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		return fmt.Errorf(i18n.Tf("err.write_path", path), err)
+	}
+	installed(cfg, log, ch, path, i18n.Tf("log.msg.periodic_created", path))
+	return nil
+}
+
+// bsdStatus returns the path of an installed periodic script or cron.d file, or "".
+func bsdStatus() string {
+	for _, p := range []string{filepath.Join(bsdPeriodicDir, bsdPeriodicScript), filepath.Join(bsdCronDir, serviceName)} {
+		if fileExists(p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// uninstallBSD removes the periodic script and cron.d file (root), the crontab entries are removed by Uninstall.
+func uninstallBSD(info func(string, ...interface{})) error {
+	for _, p := range []string{filepath.Join(bsdPeriodicDir, bsdPeriodicScript), filepath.Join(bsdCronDir, serviceName)} {
+		if !fileExists(p) {
+			continue
+		}
+		if err := os.Remove(p); err != nil {
+			return fmt.Errorf(i18n.Tf("err.write_path", p), err)
+		}
+		info("%s removed", p)
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package schedule
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/logger"
+)
+
+// ContainerTargets are the targets of --init --target: deployment files for running the backups in a
+// container instead of a job on the host.
+var ContainerTargets = []string{"docker", "kubernetes"}
+
+// containerMarker is the first line of the generated files; files without it were written or changed by hand
+// and are not overwritten.
+const containerMarker = "# Generated by mysqlbackup --init --target; rewritten by the next call unless this line is removed."
+
+var k8sNameInvalid = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// containerFile is one generated file (name relative to the directory of the config).
+type containerFile struct {
+	name    string
+	content string
+	mode    os.FileMode
+}
+
+// WriteContainer writes the deployment files of target (docker: Dockerfile, entrypoint and a compose service
+// running --daemon; kubernetes: Dockerfile, entrypoint and a CronJob running --backup) next to the config.
+// The container sees the config, backup_dir and the other directories under the same paths as the host, so the
+// same config drives both deployments.
+func WriteContainer(cfg *config.Config, configPath, target string, log *logger.Logger) error {
+	useJob(cfg, configPath)
+	abs, err := filepath.Abs(configPath)
+	if err != nil {
+		return err
+	}
+	files, err := containerFiles(cfg, abs, target)
+	if err != nil {
+		return err
+	}
+	if isLoopback(cfg.MySQLHost) {
+		log.Warn(i18n.Tf("log.warn.container_loopback", cfg.MySQLHost))
+	}
+	for _, f := range files {
+		p := filepath.Join(filepath.Dir(abs), f.name)
+		if old, err := os.ReadFile(p); err == nil {
+			if string(old) == f.content {
+				log.Info(i18n.Tf("log.msg.container_unchanged", p))
+				continue
+			}
+			if !bytes.HasPrefix(old, []byte(containerMarker)) && !bytes.Contains(old, []byte("\n"+containerMarker)) {
+				log.Warn(i18n.Tf("log.warn.container_exists", p))
+				continue
+			}
+		}
+		if err := os.WriteFile(p, []byte(f.content), f.mode); err != nil {
+			return fmt.Errorf(i18n.T("err.container_write"), p, err)
+		}
+		installed(cfg, log, nil, p, i18n.Tf("log.msg.container_written", p))
+	}
+	return nil
+}
+
+// containerFiles returns the files of target for the config at configPath (absolute).
+func containerFiles(cfg *config.Config, configPath, target string) ([]containerFile, error) {
+	files := []containerFile{
+		{name: "Dockerfile", content: dockerfile, mode: 0644},
+		{name: "docker-entrypoint.sh", content: entrypoint, mode: 0755},
+	}
+	suffix := strings.TrimPrefix(serviceName, "mysqlbackup")
+	switch target {
+	case "docker":
+		name := "docker-compose.yml"
+		if suffix != "" {
+			name = "docker-compose" + suffix + ".yml"
+		}
+		return append(files, containerFile{name: name, content: composeService(cfg, configPath), mode: 0644}), nil
+	case "kubernetes":
+		job, err := cronJob(cfg, configPath)
+		if err != nil {
+			return nil, err
+		}
+		return append(files, containerFile{name: serviceName + "-cronjob.yaml", content: job, mode: 0644}), nil
+	}
+	return nil, fmt.Errorf(i18n.T("err.container_target"), target, strings.Join(ContainerTargets, ", "))
+}
+
+const dockerfile = containerMarker + `
+# Image for --init --target docker/kubernetes. Build context: this directory with the Linux binary
+# "mysqlbackup" (GOOS=linux) next to this file.
+FROM debian:bookworm-slim
+RUN apt-get update \
+ && apt-get install -y --no-install-recommends default-mysql-client ca-certificates tzdata \
+ && rm -rf /var/lib/apt/lists/*
+COPY mysqlbackup docker-entrypoint.sh /usr/local/bin/
+RUN chmod 0755 /usr/local/bin/mysqlbackup /usr/local/bin/docker-entrypoint.sh
+ENTRYPOINT ["/usr/local/bin/docker-entrypoint.sh"]
+CMD ["daemon"]
+`
+
+const entrypoint = "#!/bin/sh\n" + containerMarker + `
+# daemon (default): backups on the schedule of the config (--daemon), for a long-running container.
+# backup: a single run (--backup), for schedulers such as a Kubernetes CronJob.
+# Other arguments are passed to mysqlbackup, e.g. "--status".
+set -e
+config="${MYSQLBACKUP_CONFIG:-/config/config.json}"
+case "${1:-daemon}" in
+daemon) exec mysqlbackup --daemon -config "$config" ;;
+backup) exec mysqlbackup --backup --no-schedule -config "$config" ;;
+*) exec mysqlbackup -config "$config" "$@" ;;
+esac
+`
+
+// composeService returns the compose file with one service running --daemon.
+func composeService(cfg *config.Config, configPath string) string {
+	var b strings.Builder
+	b.WriteString(containerMarker + "\n")
+	b.WriteString("# Start: docker compose up -d --build (Linux binary \"mysqlbackup\" in this directory)\n")
+	b.WriteString("services:\n")
+	fmt.Fprintf(&b, "  %s:\n", serviceName)
+	b.WriteString("    build: .\n    image: mysqlbackup\n    command: [\"daemon\"]\n    restart: unless-stopped\n")
+	if h := containerHostname(); h != "" {
+		fmt.Fprintf(&b, "    hostname: %s\n", strconv.Quote(h))
+	}
+	if isLoopback(cfg.MySQLHost) {
+		b.WriteString("    network_mode: host\n")
+	}
+	b.WriteString("    environment:\n")
+	fmt.Fprintf(&b, "      MYSQLBACKUP_CONFIG: %s\n", strconv.Quote(filepath.ToSlash(configPath)))
+	if cfg.Timezone != "" {
+		fmt.Fprintf(&b, "      TZ: %s\n", strconv.Quote(cfg.Timezone))
+	}
+	b.WriteString("    volumes:\n")
+	for _, dir := range containerDirs(cfg, filepath.Dir(configPath)) {
+		fmt.Fprintf(&b, "      - %s\n", strconv.Quote(dir+":"+dir))
+	}
+	return b.String()
+}
+
+// cronJob returns the Kubernetes CronJob running --backup on the schedule of the config. The config comes
+// from a Secret, the directories from a PersistentVolumeClaim (work_dir: emptyDir).
+func cronJob(cfg *config.Config, configPath string) (string, error) {
+	spec, err := cfg.ScheduleSpec()
+	if err != nil {
+		return "", err
+	}
+	configPath = filepath.ToSlash(configPath)
+	// Kubernetes-Namen: Kleinbuchstaben, Ziffern und "-"
+	name := strings.Trim(k8sNameInvalid.ReplaceAllString(strings.ToLower(serviceName), "-"), "-")
+	var b strings.Builder
+	b.WriteString(containerMarker + "\n")
+	b.WriteString("# Image: build the Dockerfile in this directory and push it to your registry (adjust image below).\n")
+	fmt.Fprintf(&b, "# Config: kubectl create secret generic %s-config --from-file=config.json=%s\n", name, configPath)
+	fmt.Fprintf(&b, "# Backups: PersistentVolumeClaim %s-backups\n", name)
+	b.WriteString("apiVersion: batch/v1\nkind: CronJob\nmetadata:\n")
+	fmt.Fprintf(&b, "  name: %s\n", name)
+	b.WriteString("spec:\n")
+	fmt.Fprintf(&b, "  schedule: %s\n", strconv.Quote(spec.Expr))
+	if cfg.Timezone != "" {
+		fmt.Fprintf(&b, "  timeZone: %s\n", strconv.Quote(cfg.Timezone))
+	}
+	b.WriteString("  concurrencyPolicy: Forbid\n")
+	if cfg.CatchUp {
+		// Verpasste Läufe (Cluster nicht verfügbar) innerhalb eines halben Tages nachholen
+		b.WriteString("  startingDeadlineSeconds: 43200\n")
+	}
+	b.WriteString("  jobTemplate:\n    spec:\n      backoffLimit: 0\n      template:\n        spec:\n          restartPolicy: Never\n")
+	if h := containerHostname(); h != "" {
+		fmt.Fprintf(&b, "          hostname: %s\n", strconv.Quote(strings.ToLower(h)))
+	}
+	b.WriteString("          containers:\n            - name: mysqlbackup\n              image: mysqlbackup:latest\n              args: [\"backup\"]\n")
+	b.WriteString("              env:\n                - name: MYSQLBACKUP_CONFIG\n")
+	fmt.Fprintf(&b, "                  value: %s\n", strconv.Quote(configPath))
+	b.WriteString("              volumeMounts:\n                - name: config\n")
+	fmt.Fprintf(&b, "                  mountPath: %s\n                  subPath: config.json\n                  readOnly: true\n", strconv.Quote(configPath))
+	workDir, work := "", false
+	if cfg.WorkDir != "" {
+		workDir = slashAbs(cfg.WorkDir)
+	}
+	for _, dir := range containerDirs(cfg) {
+		switch dir {
+		case workDir:
+			fmt.Fprintf(&b, "                - name: work\n                  mountPath: %s\n", strconv.Quote(dir))
+			work = true
+		default:
+			fmt.Fprintf(&b, "                - name: backups\n                  mountPath: %s\n                  subPath: %s\n", strconv.Quote(dir),
+				strconv.Quote(strings.TrimLeft(dir, "/")))
+		}
+	}
+	b.WriteString("          volumes:\n            - name: config\n              secret:\n")
+	fmt.Fprintf(&b, "                secretName: %s-config\n", name)
+	b.WriteString("            - name: backups\n              persistentVolumeClaim:\n")
+	fmt.Fprintf(&b, "                claimName: %s-backups\n", name)
+	if work {
+		b.WriteString("            - name: work\n              emptyDir: {}\n")
+	}
+	return b.String(), nil
+}
+
+// containerDirs returns the directories the container needs under their host paths: extra (e.g. the directory
+// of the config), backup_dir, work_dir, archive_dir and the directory of log_filename, without those inside
+// another one. A {date} placeholder changes the path every day, so the directory above it is used.
+func containerDirs(cfg *config.Config, extra ...string) []string {
+	backupDir, logFilename := cfg.PathTemplates()
+	candidates := append(extra, staticDir(backupDir))
+	if cfg.WorkDir != "" {
+		candidates = append(candidates, cfg.WorkDir)
+	}
+	if cfg.ArchiveDir != "" {
+		candidates = append(candidates, cfg.ArchiveDir)
+	}
+	if strings.TrimSpace(logFilename) != "" {
+		candidates = append(candidates, staticDir(filepath.Dir(logFilename)))
+	}
+	for i, c := range candidates {
+		candidates[i] = slashAbs(c)
+	}
+	sort.Strings(candidates)
+	var dirs []string
+	for _, d := range candidates {
+		inside := false
+		for _, o := range dirs {
+			inside = inside || d == o || strings.HasPrefix(d, strings.TrimSuffix(o, "/")+"/")
+		}
+		if !inside {
+			dirs = append(dirs, d)
+		}
+	}
+	return dirs
+}
+
+// staticDir cuts a path template before the first path element with {date}; other placeholders are expanded.
+func staticDir(tpl string) string {
+	parts := strings.Split(filepath.ToSlash(tpl), "/")
+	for i, p := range parts {
+		if strings.Contains(p, "{date}") {
+			parts = parts[:i]
+			break
+		}
+	}
+	return config.Expand(strings.Join(parts, "/"), time.Now(), "")
+}
+
+// slashAbs returns the absolute path of p (relative to the working directory = directory of the config) with
+// forward slashes.
+func slashAbs(p string) string {
+	if abs, err := filepath.Abs(filepath.FromSlash(p)); err == nil {
+		p = abs
+	}
+	return filepath.ToSlash(filepath.Clean(p))
+}
+
+// containerHostname returns the short host name: the container gets it so {hostname} in the config and the
+// host part of the backup names stay the same.
+func containerHostname() string {
+	h, _ := os.Hostname()
+	h, _, _ = strings.Cut(h, ".")
+	return h
+}
+
+// isLoopback reports whether host is this machine (localhost, 127.x, ::1); from a container it is not reachable
+// without host networking.
+func isLoopback(host string) bool {
+	host = strings.TrimSpace(host)
+	if host == "" || strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,82 @@
+package schedule
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+)
+
+// Names of the scheduled job. useJob appends the job_name of the configuration, so several
+// configurations on one host get their own task/unit; without job_name the names stay as before
+// and existing schedules are still found.
+var (
+	taskNameWindows   = "MySQLBackup"
+	serviceName       = "mysqlbackup"
+	cronMarker        = "mysqlbackup-schedule"
+	launchdLabel      = "com.github.janmz.mysqlbackup"
+	bsdPeriodicScript = "500.mysqlbackup"
+)
+
+var jobNameInvalid = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// jobSuffix returns the name part for job_name: "" = default job, "auto" = derived from the config path,
+// otherwise the name with characters not allowed in unit/task names replaced by "_".
+func jobSuffix(jobName, configPath string) string {
+	name := strings.TrimSpace(jobName)
+	if name == "" {
+		return ""
+	}
+	if strings.EqualFold(name, "auto") {
+		h := fnv.New32a()
+		h.Write([]byte(filepath.Clean(configPath)))
+		return fmt.Sprintf("%08x", h.Sum32())
+	}
+	return jobNameInvalid.ReplaceAllString(name, "_")
+}
+
+// useJob sets the job names for cfg (nil = default job). Called by the exported entry points.
+func useJob(cfg *config.Config, configPath string) {
+	suffix := ""
+	if cfg != nil {
+		suffix = jobSuffix(cfg.JobName, configPath)
+	}
+	taskNameWindows, serviceName, cronMarker, launchdLabel, bsdPeriodicScript =
+		"MySQLBackup", "mysqlbackup", "mysqlbackup-schedule", "com.github.janmz.mysqlbackup", "500.mysqlbackup"
+	if suffix != "" {
+		taskNameWindows += "-" + suffix
+		serviceName += "-" + suffix
+		cronMarker += "-" + suffix
+		launchdLabel += "." + suffix
+		bsdPeriodicScript += "-" + suffix
+	}
+}
+
+// isMarkerLine reports whether a crontab line belongs to this job: it carries "# <cronMarker>" followed by
+// nothing or a space (the default marker is a prefix of the markers of named jobs).
+func isMarkerLine(line string) bool {
+	rest := line
+	for {
+		i := strings.Index(rest, "# "+cronMarker)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+2+len(cronMarker):]
+		if rest == "" || rest[0] == ' ' {
+			return true
+		}
+	}
+}
+
+// hasMarkerLine reports whether data contains a line of this job.
+func hasMarkerLine(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		if isMarkerLine(strings.TrimSpace(line)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package schedule
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+)
+
+func TestJobNamesAndMarker(t *testing.T) {
+	defer useJob(nil, "")
+	line := "0 22 * * * /usr/bin/mysqlbackup --backup -config /etc/a.json # mysqlbackup-schedule"
+	named := "0 23 * * * /usr/bin/mysqlbackup --backup -config /etc/b.json # mysqlbackup-schedule-shop catch-up"
+
+	useJob(nil, "")
+	if !isMarkerLine(line) || isMarkerLine(named) {
+		t.Errorf("default job: marker match wrong")
+	}
+	useJob(&config.Config{JobName: "shop"}, "/etc/b.json")
+	if serviceName != "mysqlbackup-shop" || taskNameWindows != "MySQLBackup-shop" {
+		t.Errorf("names = %q, %q", serviceName, taskNameWindows)
+	}
+	if isMarkerLine(line) || !isMarkerLine(named) {
+		t.Errorf("named job: marker match wrong")
+	}
+	if got := jobSuffix("my shop/1", ""); got != "my_shop_1" {
+		t.Errorf("jobSuffix = %q", got)
+	}
+	if a, b := jobSuffix("auto", "/etc/a.json"), jobSuffix("auto", "/etc/b.json"); a == b || len(a) != 8 {
+		t.Errorf("auto suffixes %q, %q", a, b)
+	}
+}
+
+func TestContainerDirs(t *testing.T) {
+	cfg := &config.Config{BackupDir: "/srv/backup/{date}", WorkDir: "/srv/backup/work", ArchiveDir: "/srv/archive",
+		LogFilename: "/var/log/mysqlbackup/{date}.log"}
+	got := containerDirs(cfg, "/etc/mysqlbackup")
+	want := []string{"/etc/mysqlbackup", "/srv/archive", "/srv/backup", "/var/log/mysqlbackup"}
+	if runtime.GOOS != "windows" && !slices.Equal(got, want) {
+		t.Errorf("containerDirs = %v, want %v", got, want)
+	}
+	if _, err := containerFiles(cfg, "/etc/mysqlbackup/config.json", "podman"); err == nil {
+		t.Error("containerFiles accepted an unknown target")
+	}
+	if !isLoopback("127.0.0.1") || !isLoopback("localhost") || isLoopback("db.example.com") {
+		t.Error("isLoopback")
+	}
+}
@@ -0,0 +1,160 @@
+package schedule
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/cron"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/logger"
+)
+
+const (
+	launchDaemonsDir  = "/Library/LaunchDaemons"
+	maxLaunchdEntries = 64
+)
+
+// launchdPlistPath returns the plist path: ~/Library/LaunchAgents for the user scope,
+// /Library/LaunchDaemons for schedule_scope "system".
+func launchdPlistPath(system bool) (string, error) {
+	if system {
+		return filepath.Join(launchDaemonsDir, launchdLabel+".plist"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf(i18n.T("err.home_dir"), err)
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist"), nil
+}
+
+// ensureLaunchd writes the launchd plist (macOS) and (re)loads it when its content changed.
+func ensureLaunchd(cfg *config.Config, configPath string, ch *changes, log *logger.Logger) error {
+	system := cfg.SystemScope()
+	if system && os.Geteuid() != 0 {
+		return fmt.Errorf(i18n.T("err.schedule_system_root"))
+	}
+	plistPath, err := launchdPlistPath(system)
+	if err != nil {
+		return err
+	}
+	runAs := ""
+	if system {
+		runAs = scheduleUser(cfg)
+	}
+	content, when, err := launchdPlist(cfg, configPath, runAs)
+	if err != nil {
+		return err
+	}
+	if old, err := os.ReadFile(plistPath); err == nil && bytes.Equal(old, content) {
+		log.Info(i18n.Tf("log.msg.launchd_exists", plistPath))
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(plistPath), 0755); err != nil {
+		return fmt.Errorf(i18n.T("err.write_launchd"), err)
+	}
+	// unload the old definition first, launchctl load ignores changes of an already loaded job
+	_, _ = runWithDebug(log, exec.Command("launchctl", "unload", plistPath))
+	if err := os.WriteFile(plistPath, content, 0644); err != nil {
+		return fmt.Errorf(i18n.T("err.write_launchd"), err)
+	}
+	if out, err := runWithDebug(log, exec.Command("launchctl", "load", "-w", plistPath)); err != nil {
+		return fmt.Errorf(i18n.T("err.launchctl_load"), err, strings.TrimSpace(string(out)))
+	}
+	installed(cfg, log, ch, plistPath, i18n.Tf("log.msg.launchd_created", plistPath, when))
+	return nil
+}
+
+// launchdPlist returns the plist content and the schedule description. launchd has no random
+// delay, so start_jitter_minutes shifts a daily start time by the per-host offset (as for cron).
+func launchdPlist(cfg *config.Config, configPath, runAs string) ([]byte, string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, "", fmt.Errorf(i18n.T("err.executable_path"), err)
+	}
+	exe = filepath.Clean(exe)
+	spec, err := cfg.ScheduleSpec()
+	if err != nil {
+		return nil, "", err
+	}
+	if offset := jitterOffset(cfg.StartJitterMinutes, configPath); offset > 0 && spec.IsDaily() {
+		t := spec.Hour[0]*60 + spec.Minute[0] + offset
+		spec = cron.Daily(t/60%24, t%60)
+	}
+	intervals, err := spec.CalendarIntervals(maxLaunchdEntries)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var b bytes.Buffer
+	str := func(s string) string {
+		var e bytes.Buffer
+		_ = xml.EscapeText(&e, []byte(s))
+		return "<string>" + e.String() + "</string>"
+	}
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	fmt.Fprintf(&b, "\t<key>Label</key>\n\t%s\n", str(launchdLabel))
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range []string{exe, "--backup", "-config", configPath} {
+		fmt.Fprintf(&b, "\t\t%s\n", str(arg))
+	}
+	b.WriteString("\t</array>\n")
+	fmt.Fprintf(&b, "\t<key>WorkingDirectory</key>\n\t%s\n", str(filepath.Dir(configPath)))
+	if runAs != "" {
+		fmt.Fprintf(&b, "\t<key>UserName</key>\n\t%s\n", str(runAs))
+	}
+	b.WriteString("\t<key>StartCalendarInterval</key>\n\t<array>\n")
+	for _, entry := range intervals {
+		keys := make([]string, 0, len(entry))
+		for k := range entry {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("\t\t<dict>\n")
+		for _, k := range keys {
+			fmt.Fprintf(&b, "\t\t\t<key>%s</key>\n\t\t\t<integer>%d</integer>\n", k, entry[k])
+		}
+		b.WriteString("\t\t</dict>\n")
+	}
+	b.WriteString("\t</array>\n</dict>\n</plist>\n")
+	return b.Bytes(), describe(spec), nil
+}
+
+// launchdStatus returns the plist path of an installed job (daemon first), or "".
+func launchdStatus() string {
+	for _, system := range []bool{true, false} {
+		if p, err := launchdPlistPath(system); err == nil && fileExists(p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// uninstallLaunchd unloads and removes the launchd job(s). Removing the daemon needs root.
+func uninstallLaunchd(log *logger.Logger, info func(string, ...interface{})) error {
+	for _, system := range []bool{true, false} {
+		p, err := launchdPlistPath(system)
+		if err != nil || !fileExists(p) {
+			continue
+		}
+		if system && os.Geteuid() != 0 {
+			return fmt.Errorf(i18n.T("err.schedule_system_root"))
+		}
+		_, _ = runWithDebug(log, exec.Command("launchctl", "unload", "-w", p))
+		if err := os.Remove(p); err != nil {
+			return fmt.Errorf(i18n.T("err.write_launchd"), err)
+		}
+		info("launchd job %s removed", p)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package schedule
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+)
+
+// RunInfo is what the scheduler reports about the installed job. Zero values mean unknown.
+type RunInfo struct {
+	Next       time.Time
+	LastRun    time.Time
+	LastResult string // scheduler result: Windows task result code, systemd service result
+}
+
+// Info queries the scheduler for the next and last run (Windows: Get-ScheduledTaskInfo, systemd:
+// systemctl show). For cron and launchd, which keep no such data, Next is computed from the schedule.
+func Info(cfg *config.Config, configPath string) RunInfo {
+	useJob(cfg, configPath)
+	var info RunInfo
+	switch {
+	case runtime.GOOS == "windows":
+		info = windowsRunInfo()
+	case runtime.GOOS == "linux":
+		info = systemdRunInfo()
+	}
+	if info.Next.IsZero() {
+		if spec, err := cfg.ScheduleSpec(); err == nil {
+			info.Next = spec.Next(time.Now())
+		}
+	}
+	return info
+}
+
+// windowsRunInfo reads NextRunTime, LastRunTime and LastTaskResult. Get-ScheduledTaskInfo returns
+// culture-independent values, unlike the localized columns of schtasks /Query /V.
+func windowsRunInfo() RunInfo {
+	script := `$i = Get-ScheduledTaskInfo -TaskName '` + taskNameWindows + `' -ErrorAction Stop; ` +
+		`foreach ($t in $i.NextRunTime, $i.LastRunTime) { if ($t) { $t.ToString('o') } else { '' } }; $i.LastTaskResult`
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script).Output()
+	if err != nil {
+		return RunInfo{}
+	}
+	lines := strings.Split(strings.ReplaceAll(string(out), "\r", ""), "\n")
+	if len(lines) < 3 {
+		return RunInfo{}
+	}
+	info := RunInfo{}
+	info.Next, _ = time.Parse(time.RFC3339Nano, strings.TrimSpace(lines[0]))
+	info.LastRun, _ = time.Parse(time.RFC3339Nano, strings.TrimSpace(lines[1]))
+	// LastRunTime 1999-11-30 / result 267011 (SCHED_S_TASK_HAS_NOT_RUN): never run
+	if code, err := strconv.Atoi(strings.TrimSpace(lines[2])); err == nil && code != 267011 {
+		info.LastResult = "0x" + strings.ToUpper(strconv.FormatUint(uint64(uint32(code)), 16))
+		if code == 0 {
+			info.LastResult = "0 (OK)"
+		}
+	} else {
+		info.LastRun = time.Time{}
+	}
+	return info
+}
+
+// systemdRunInfo reads the timer's next elapse and the service's last result from the system or user unit.
+func systemdRunInfo() RunInfo {
+	args := []string{"--user"}
+	if fileExists(filepath.Join(systemdSystemDir, serviceName+".timer")) {
+		args = nil
+	}
+	show := func(unit string, props ...string) map[string]string {
+		cmd := exec.Command("systemctl", append(args, "show", unit, "-p", strings.Join(props, ","))...)
+		out, err := cmd.Output()
+		if err != nil {
+			return nil
+		}
+		values := make(map[string]string)
+		for _, line := range strings.Split(string(out), "\n") {
+			if k, v, ok := strings.Cut(line, "="); ok {
+				values[k] = strings.TrimSpace(v)
+			}
+		}
+		return values
+	}
+	info := RunInfo{}
+	if t := show(serviceName+".timer", "NextElapseUSecRealtime", "LastTriggerUSec"); t != nil {
+		info.Next = parseSystemdTime(t["NextElapseUSecRealtime"])
+		info.LastRun = parseSystemdTime(t["LastTriggerUSec"])
+	}
+	if s := show(serviceName+".service", "Result", "ExecMainStatus"); s != nil && !info.LastRun.IsZero() {
+		info.LastResult = s["Result"]
+		if code := s["ExecMainStatus"]; code != "" && code != "0" {
+			info.LastResult += " (exit " + code + ")"
+		}
+	}
+	return info
+}
+
+// parseSystemdTime parses systemctl show timestamps such as "Fri 2026-10-16 22:00:00 CEST".
+func parseSystemdTime(s string) time.Time {
+	if s == "" || s == "n/a" || s == "0" {
+		return time.Time{}
+	}
+	t, err := time.ParseInLocation("Mon 2006-01-02 15:04:05 MST", s, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
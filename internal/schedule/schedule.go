@@ -1,4 +1,5 @@
-// Package schedule ensures a backup schedule is installed (Windows Task Scheduler, Linux systemd, or cron fallback).
+// Package schedule ensures a backup schedule is installed (Windows Task Scheduler, Linux systemd, macOS launchd,
+// or cron fallback).
 package schedule
 
 import (
@@ -6,35 +7,84 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"strconv"
 	"strings"
 
+	"github.com/janmz/mysqlbackup/internal/audit"
 	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/cron"
+	"github.com/janmz/mysqlbackup/internal/eventlog"
 	"github.com/janmz/mysqlbackup/internal/i18n"
 	"github.com/janmz/mysqlbackup/internal/logger"
 )
 
 const (
-	taskNameWindows   = "MySQLBackup"
-	serviceName       = "mysqlbackup"
-	cronMarker        = "mysqlbackup-schedule"
 	systemCrontabUser = "root" // user for /etc/crontab line (format: min hour * * * user command)
+
+	systemdSystemDir      = "/etc/systemd/system"
+	scheduleMarkerWindows = "mysqlbackup schedule: " // task description prefix, followed by the cron expression
+	maxWindowsTriggers    = 48
+	windowsPasswordEnv    = "MYSQLBACKUP_TASK_PASSWORD"
 )
 
 // systemCrontabPaths: tried in order when crontab executable is not available (e.g. Synology).
 var systemCrontabPaths = []string{"/etc/crontab", "/usr/etc/crontab"}
 
-// runWithDebug runs cmd via CombinedOutput; when log.Verbose, logs command and output with [DEBUG].
+// changes collects what one EnsureInstalled call did to the job, for its report of repairs.
+type changes struct {
+	created []string // Job angelegt oder neu geschrieben (installed)
+	enabled []string // deaktivierter Job wieder aktiviert (reenabled)
+}
+
+// installed logs msg about the created or changed job, records it in the audit file (audit_file) and in ch
+// (may be nil).
+func installed(cfg *config.Config, log *logger.Logger, ch *changes, target, msg string) {
+	log.Info(msg)
+	audit.Note(cfg.AuditFile, log, audit.ScheduleInstall, target, msg)
+	if ch != nil {
+		ch.created = append(ch.created, msg)
+	}
+}
+
+// reenabled logs msg about a disabled job that was enabled again and records it like installed.
+func reenabled(cfg *config.Config, log *logger.Logger, ch *changes, target, msg string) {
+	log.Warn(msg)
+	audit.Note(cfg.AuditFile, log, audit.ScheduleInstall, target, msg)
+	ch.enabled = append(ch.enabled, msg)
+}
+
+// describe returns the schedule for messages: "daily at HH:MM" or the cron expression.
+func describe(spec *cron.Spec) string {
+	if spec.IsDaily() {
+		return i18n.Tf("schedule.daily", fmt.Sprintf("%02d:%02d", spec.Hour[0], spec.Minute[0]))
+	}
+	return i18n.Tf("schedule.cron", spec.Expr)
+}
+
+// jitterOffset returns a stable start offset in [0, jitterMinutes) minutes, derived from host name and
+// config path, so each machine keeps its own start time across reinstalls.
+func jitterOffset(jitterMinutes int, configPath string) int {
+	if jitterMinutes <= 0 {
+		return 0
+	}
+	host, _ := os.Hostname()
+	h := fnv.New32a()
+	h.Write([]byte(host + "\x00" + configPath))
+	return int(h.Sum32() % uint32(jitterMinutes))
+}
+
+// runWithDebug runs cmd via CombinedOutput; when debug logging is on, logs command and output with [DEBUG].
 func runWithDebug(log *logger.Logger, cmd *exec.Cmd) ([]byte, error) {
-	if log != nil && log.Verbose {
+	debug := log != nil && log.DebugEnabled()
+	if debug {
 		log.Debug("exec: %s %v", cmd.Path, cmd.Args)
 	}
 	out, err := cmd.CombinedOutput()
-	if log != nil && log.Verbose {
+	if debug {
 		if len(out) > 0 {
 			log.Debug("output: %s", string(out))
 		}
@@ -45,13 +95,32 @@ func runWithDebug(log *logger.Logger, cmd *exec.Cmd) ([]byte, error) {
 	return out, err
 }
 
+// Supported reports whether EnsureInstalled can set up a schedule on this platform.
+func Supported() bool {
+	switch runtime.GOOS {
+	case "windows", "linux", "darwin":
+		return true
+	}
+	return isBSD()
+}
+
 // EnsureInstalled checks if a schedule exists and is up to date (paths match); if not or paths changed, (re)creates it.
-// On Windows also applies WakeToRun, StartWhenAvailable, ExecutionTimeLimit 12h. Call from --backup and --status.
-func EnsureInstalled(cfg *config.Config, configPath string, log *logger.Logger) error {
+// On Windows also applies WakeToRun, StartWhenAvailable (catch_up), ExecutionTimeLimit 12h. Call from --backup and --status.
+// repaired lists what had to be fixed: a missing job that was created again, a disabled task or timer that was
+// enabled again (Windows, systemd); a job only updated for a changed config is not a repair.
+func EnsureInstalled(cfg *config.Config, configPath string, log *logger.Logger) (repaired []string, err error) {
+	useJob(cfg, configPath)
+	key, _ := Status(cfg, configPath)
+	ch := &changes{}
 	if runtime.GOOS == "windows" {
-		return ensureWindows(cfg, configPath, log)
+		err = ensureWindows(cfg, configPath, ch, log)
+	} else {
+		err = ensureUnix(cfg, configPath, ch, log)
 	}
-	return ensureUnix(cfg, configPath, log)
+	if key == "" {
+		repaired = append(repaired, ch.created...)
+	}
+	return append(repaired, ch.enabled...), err
 }
 
 // windowsTaskGetRunString returns the current task's run string (Execute + Arguments) for comparison.
@@ -83,6 +152,17 @@ func windowsTaskGetRunString(log *logger.Logger) (string, error) {
 	return "", fmt.Errorf(i18n.T("err.task_cmd_not_found"))
 }
 
+// windowsTaskGetDescription returns the task description (holds the installed schedule, see scheduleMarkerWindows).
+func windowsTaskGetDescription(log *logger.Logger) string {
+	script := `$t = Get-ScheduledTask -TaskName '` + taskNameWindows + `' -ErrorAction SilentlyContinue; if ($t) { $t.Description }`
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	out, err := runWithDebug(log, cmd)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
 // windowsTaskGetCommand returns the current task's exe and config path from schtasks /Query /FO LIST /V.
 // Supports: "exe" --backup -config "config"; cmd /c cd /d "dir" && "exe" ... (new); cmd /c "cd /d \"dir\" && \"exe\" ..." (legacy).
 func windowsTaskGetCommand(log *logger.Logger) (exe, configPath string, err error) {
@@ -268,10 +348,95 @@ func resolveDriveToUNC(path string, log *logger.Logger) string {
 	return uncRoot + rest
 }
 
-func applyWindowsTaskSettings(log *logger.Logger) {
-	// Set-ScheduledTask -InputObject does not apply Settings; use -TaskName -Settings with New-ScheduledTaskSettingsSet.
-	script := `$s = New-ScheduledTaskSettingsSet -WakeToRun -StartWhenAvailable -ExecutionTimeLimit (New-TimeSpan -Hours 12); Set-ScheduledTask -TaskName '` + taskNameWindows + `' -Settings $s`
+// windowsAccount is the task principal from windows_task_user / windows_task_logon_type.
+// The zero value registers the task for the invoking user (previous behavior).
+type windowsAccount struct {
+	user, logonType string
+	password        string // only for logon type Password with a stored password (not gMSA)
+}
+
+// windowsTaskAccount resolves the configured account. Logon type "" is derived from the user:
+// built-in service accounts → ServiceAccount, gMSA (name ends with $) or password given → Password, else S4U.
+func windowsTaskAccount(cfg *config.Config) windowsAccount {
+	a := windowsAccount{user: strings.TrimSpace(cfg.WindowsTaskUser)}
+	logon := strings.ToLower(strings.TrimSpace(cfg.WindowsTaskLogonType))
+	if a.user == "" && logon == "" {
+		return a
+	}
+	if a.user == "" {
+		a.user = os.Getenv("USERDOMAIN") + `\` + os.Getenv("USERNAME")
+	}
+	gmsa := strings.HasSuffix(a.user, "$")
+	if logon == "" {
+		switch strings.ToUpper(a.user) {
+		case "SYSTEM", `NT AUTHORITY\SYSTEM`, "LOCAL SERVICE", `NT AUTHORITY\LOCAL SERVICE`, "NETWORK SERVICE", `NT AUTHORITY\NETWORK SERVICE`:
+			logon = "serviceaccount"
+		default:
+			if gmsa || cfg.WindowsTaskPassword != "" {
+				logon = "password"
+			} else {
+				logon = "s4u"
+			}
+		}
+	}
+	a.logonType = map[string]string{"password": "Password", "s4u": "S4U", "serviceaccount": "ServiceAccount", "interactive": "Interactive"}[logon]
+	if a.logonType == "Password" && !gmsa {
+		a.password = cfg.WindowsTaskPassword
+	}
+	return a
+}
+
+// registerArgs returns the script prefix and Register-ScheduledTask parameters for the principal.
+// A stored password cannot be combined with -Principal, so -User/-Password are used then.
+func (a windowsAccount) registerArgs() (prefix, params string) {
+	switch {
+	case a.user == "":
+		return "", ""
+	case a.password != "":
+		return "", ` -User '` + escapeForPSSingleQuoted(a.user) + `' -Password $env:` + windowsPasswordEnv + ` -RunLevel Highest`
+	default:
+		return `$p = New-ScheduledTaskPrincipal -UserId '` + escapeForPSSingleQuoted(a.user) + `' -LogonType ` + a.logonType + ` -RunLevel Highest; `, ` -Principal $p`
+	}
+}
+
+// setArgs returns Set-ScheduledTask parameters: tasks with a stored password need the credentials again.
+func (a windowsAccount) setArgs() string {
+	if a.password == "" {
+		return ""
+	}
+	return ` -User '` + escapeForPSSingleQuoted(a.user) + `' -Password $env:` + windowsPasswordEnv
+}
+
+// describe returns the account for the task description ("" for the invoking user).
+func (a windowsAccount) describe() string {
+	if a.user == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (run as %s, %s)", a.user, a.logonType)
+}
+
+// powershell runs script; the task password is passed via environment, not on the command line.
+func (a windowsAccount) powershell(script string) *exec.Cmd {
 	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	if a.password != "" {
+		cmd.Env = append(os.Environ(), windowsPasswordEnv+"="+a.password)
+	}
+	return cmd
+}
+
+// windowsTaskSettings returns the New-ScheduledTaskSettingsSet call; StartWhenAvailable (run a missed start
+// as soon as possible) only with catch_up.
+func windowsTaskSettings(catchUp bool) string {
+	if catchUp {
+		return `New-ScheduledTaskSettingsSet -WakeToRun -StartWhenAvailable -ExecutionTimeLimit (New-TimeSpan -Hours 12)`
+	}
+	return `New-ScheduledTaskSettingsSet -WakeToRun -ExecutionTimeLimit (New-TimeSpan -Hours 12)`
+}
+
+func applyWindowsTaskSettings(catchUp bool, acct windowsAccount, log *logger.Logger) {
+	// Set-ScheduledTask -InputObject does not apply Settings; use -TaskName -Settings with New-ScheduledTaskSettingsSet.
+	script := `$s = ` + windowsTaskSettings(catchUp) + `; Set-ScheduledTask -TaskName '` + taskNameWindows + `' -Settings $s` + acct.setArgs()
+	cmd := acct.powershell(script)
 	if _, err := runWithDebug(log, cmd); err != nil {
 		if log != nil {
 			log.Warn(i18n.Tf("log.warn.powershell_settings", err))
@@ -283,12 +448,23 @@ func applyWindowsTaskSettings(log *logger.Logger) {
 	}
 }
 
+// enableWindowsTask enables the task again if it was disabled (by hand, a policy or a cleanup tool); an
+// up-to-date task is otherwise left alone and would never run.
+func enableWindowsTask(cfg *config.Config, acct windowsAccount, ch *changes, log *logger.Logger) {
+	script := `$t = Get-ScheduledTask -TaskName '` + taskNameWindows + `' -ErrorAction SilentlyContinue; ` +
+		`if ($t -and $t.State -eq 'Disabled') { Enable-ScheduledTask -TaskName '` + taskNameWindows + `' | Out-Null; 'enabled' }`
+	out, err := runWithDebug(log, acct.powershell(script))
+	if err == nil && strings.TrimSpace(string(out)) == "enabled" {
+		reenabled(cfg, log, ch, taskNameWindows, i18n.Tf("log.warn.schedule_reenabled", taskNameWindows))
+	}
+}
+
 // applyWindowsTaskWorkingDir sets the task action's WorkingDirectory so relative log/backup paths resolve (e.g. on UNC shares).
-func applyWindowsTaskWorkingDir(workDir string, log *logger.Logger) {
+func applyWindowsTaskWorkingDir(workDir string, acct windowsAccount, log *logger.Logger) {
 	// Escape single quotes for PowerShell: ' -> ''
 	esc := escapeForPSSingleQuoted(workDir)
-	script := `$t = Get-ScheduledTask -TaskName '` + taskNameWindows + `' -ErrorAction SilentlyContinue; if ($t) { $a = $t.Actions[0]; $a.WorkingDirectory = '` + esc + `'; Set-ScheduledTask -TaskName '` + taskNameWindows + `' -Action $a }`
-	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	script := `$t = Get-ScheduledTask -TaskName '` + taskNameWindows + `' -ErrorAction SilentlyContinue; if ($t) { $a = $t.Actions[0]; $a.WorkingDirectory = '` + esc + `'; Set-ScheduledTask -TaskName '` + taskNameWindows + `' -Action $a` + acct.setArgs() + ` }`
+	cmd := acct.powershell(script)
 	if _, err := runWithDebug(log, cmd); err != nil {
 		if log != nil {
 			log.Warn(i18n.Tf("log.warn.powershell_workdir", err))
@@ -300,22 +476,25 @@ func applyWindowsTaskWorkingDir(workDir string, log *logger.Logger) {
 	}
 }
 
-// escapeForPSSingleQuoted escapes a string for use inside a PowerShell single-quoted string (' -> '').
+// escapeForPSSingleQuoted escapes a string for use inside a PowerShell single-quoted string (a single quote is doubled).
 func escapeForPSSingleQuoted(s string) string {
 	return strings.ReplaceAll(s, "'", "''")
 }
 
 // createWindowsTaskViaPowerShell creates the scheduled task via PowerShell so the exact command and WorkingDirectory are stored (no schtasks re-quoting).
-func createWindowsTaskViaPowerShell(taskName, cmdArgument, workingDir, startTime string, log *logger.Logger) error {
+// triggers are New-ScheduledTaskTrigger expressions (see cron.Spec.WindowsTriggers); description records the schedule;
+// catchUp enables StartWhenAvailable; acct selects the principal (see windowsTaskAccount).
+func createWindowsTaskViaPowerShell(taskName, cmdArgument, workingDir string, triggers []string, description string, catchUp bool, acct windowsAccount, log *logger.Logger) error {
 	argEsc := escapeForPSSingleQuoted(cmdArgument)
 	wdEsc := escapeForPSSingleQuoted(workingDir)
+	principal, principalArgs := acct.registerArgs()
 	// WorkingDirectory must be in quotes in the script when path has spaces; pass as single-quoted so it is stored literally including the path
-	script := `$arg = '` + argEsc + `'; $wd = '` + wdEsc + `'; ` +
+	script := principal + `$arg = '` + argEsc + `'; $wd = '` + wdEsc + `'; ` +
 		`$a = New-ScheduledTaskAction -Execute 'cmd.exe' -Argument $arg -WorkingDirectory $wd; ` +
-		`$t = New-ScheduledTaskTrigger -Daily -At '` + startTime + `'; ` +
-		`$s = New-ScheduledTaskSettingsSet -WakeToRun -StartWhenAvailable -ExecutionTimeLimit (New-TimeSpan -Hours 12); ` +
-		`Register-ScheduledTask -TaskName '` + taskName + `' -Action $a -Trigger $t -Settings $s -Force`
-	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+		`$t = @(` + strings.Join(triggers, ", ") + `); ` +
+		`$s = ` + windowsTaskSettings(catchUp) + `; ` +
+		`Register-ScheduledTask -TaskName '` + taskName + `' -Description '` + escapeForPSSingleQuoted(description) + `' -Action $a -Trigger $t -Settings $s` + principalArgs + ` -Force`
+	cmd := acct.powershell(script)
 	out, err := runWithDebug(log, cmd)
 	if err != nil {
 		return fmt.Errorf("%w: %s", err, string(out))
@@ -323,7 +502,7 @@ func createWindowsTaskViaPowerShell(taskName, cmdArgument, workingDir, startTime
 	return nil
 }
 
-func ensureWindows(cfg *config.Config, configPath string, log *logger.Logger) error {
+func ensureWindows(cfg *config.Config, configPath string, ch *changes, log *logger.Logger) error {
 	exe, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf(i18n.T("err.executable_path"), err)
@@ -339,14 +518,26 @@ func ensureWindows(cfg *config.Config, configPath string, log *logger.Logger) er
 	configPathTask := resolveDriveToUNC(configPath, log)
 	workDirTask := resolveDriveToUNC(workDir, log)
 
-	startTime := cfg.StartTime
-	if startTime == "" {
-		startTime = "22:00"
+	spec, err := cfg.ScheduleSpec()
+	if err != nil {
+		return err
 	}
-	startTime = strings.TrimSpace(startTime)
-	if len(startTime) != 5 || startTime[2] != ':' {
-		startTime = "22:00"
+	triggers, err := spec.WindowsTriggers(maxWindowsTriggers)
+	if err != nil {
+		return err
 	}
+	description := scheduleMarkerWindows + spec.Expr
+	if cfg.StartJitterMinutes > 0 {
+		for i := range triggers {
+			triggers[i] = "(" + triggers[i] + fmt.Sprintf(" -RandomDelay (New-TimeSpan -Minutes %d))", cfg.StartJitterMinutes)
+		}
+		description += fmt.Sprintf(" (jitter %dm)", cfg.StartJitterMinutes)
+	}
+	if !cfg.CatchUp {
+		description += " (no catch-up)"
+	}
+	acct := windowsTaskAccount(cfg)
+	description += acct.describe()
 
 	// Build the exact command we store: "cmd.exe /c cd /d "workDir" && "exe" --backup -config "configPath"" (paths with " escaped as "")
 	pathForTR := func(s string) string { return strings.ReplaceAll(s, `"`, `""`) }
@@ -359,9 +550,10 @@ func ensureWindows(cfg *config.Config, configPath string, log *logger.Logger) er
 	taskExists := errQuery == nil
 	if taskExists {
 		existingRun, errGet := windowsTaskGetRunString(log)
-		if errGet == nil && strings.TrimSpace(existingRun) == strings.TrimSpace(plannedTaskRun) {
-			applyWindowsTaskSettings(log)
-			applyWindowsTaskWorkingDir(workDirTask, log)
+		if errGet == nil && strings.TrimSpace(existingRun) == strings.TrimSpace(plannedTaskRun) && windowsTaskGetDescription(log) == description {
+			applyWindowsTaskSettings(cfg.CatchUp, acct, log)
+			applyWindowsTaskWorkingDir(workDirTask, acct, log)
+			enableWindowsTask(cfg, acct, ch, log)
 			log.Info(i18n.Tf("log.msg.windows_task_uptodate", taskNameWindows))
 			return nil
 		}
@@ -374,17 +566,33 @@ func ensureWindows(cfg *config.Config, configPath string, log *logger.Logger) er
 	}
 
 	// Create via PowerShell so the exact Argument and WorkingDirectory are stored (no outer quotes, no backslash-escaping)
-	if err := createWindowsTaskViaPowerShell(taskNameWindows, cmdArgument, workDirTask, startTime, log); err != nil {
+	if err := createWindowsTaskViaPowerShell(taskNameWindows, cmdArgument, workDirTask, triggers, description, cfg.CatchUp, acct, log); err != nil {
 		return fmt.Errorf("%s: %w", i18n.T("err.schtasks_create"), err)
 	}
-	log.Info(i18n.Tf("log.msg.windows_task_created", taskNameWindows, startTime))
-	applyWindowsTaskSettings(log)
-	applyWindowsTaskWorkingDir(workDirTask, log)
+	installed(cfg, log, ch, taskNameWindows, i18n.Tf("log.msg.windows_task_created", taskNameWindows, describe(spec)))
+	if cfg.WindowsEventLog {
+		if err := eventlog.Register(); err != nil {
+			log.Debug(i18n.Tf("log.debug.eventlog", err))
+		}
+	}
+	applyWindowsTaskSettings(cfg.CatchUp, acct, log)
+	applyWindowsTaskWorkingDir(workDirTask, acct, log)
 	return nil
 }
 
 // ensureUnix tries systemd user timer first; if not available (e.g. no user session), falls back to cron.
-func ensureUnix(cfg *config.Config, configPath string, log *logger.Logger) error {
+// An existing timer is kept and only rewritten when the schedule or paths changed.
+// schedule_scope "system" installs system units instead (see ensureSystemdSystem).
+func ensureUnix(cfg *config.Config, configPath string, ch *changes, log *logger.Logger) error {
+	if runtime.GOOS == "darwin" {
+		return ensureLaunchd(cfg, configPath, ch, log)
+	}
+	if isBSD() {
+		return ensureBSD(cfg, configPath, ch, log)
+	}
+	if cfg.SystemScope() {
+		return ensureSystemdSystem(cfg, configPath, ch, log)
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return fmt.Errorf(i18n.T("err.home_dir"), err)
@@ -392,14 +600,13 @@ func ensureUnix(cfg *config.Config, configPath string, log *logger.Logger) error
 	userDir := filepath.Join(home, ".config", "systemd", "user")
 	timerPath := filepath.Join(userDir, serviceName+".timer")
 	if _, err := os.Stat(timerPath); err == nil {
-		log.Info(i18n.Tf("log.msg.systemd_exists", timerPath))
-		return nil
+		return ensureLinuxSystemd(cfg, configPath, ch, log)
 	}
 	if systemdUserAvailable(log) {
-		return ensureLinuxSystemd(cfg, configPath, log)
+		return ensureLinuxSystemd(cfg, configPath, ch, log)
 	}
 	log.Warn(i18n.T("log.warn.systemd_fallback"))
-	return ensureUnixCron(cfg, configPath, log)
+	return ensureUnixCron(cfg, configPath, ch, log)
 }
 
 // systemdUserAvailable returns true if systemctl --user can be used (user session present).
@@ -412,65 +619,151 @@ func systemdUserAvailable(log *logger.Logger) bool {
 	return true
 }
 
-func ensureLinuxSystemd(cfg *config.Config, configPath string, log *logger.Logger) error {
+func ensureLinuxSystemd(cfg *config.Config, configPath string, ch *changes, log *logger.Logger) error {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return fmt.Errorf(i18n.T("err.home_dir"), err)
 	}
 	userDir := filepath.Join(home, ".config", "systemd", "user")
 	timerPath := filepath.Join(userDir, serviceName+".timer")
+	serviceContent, timerContent, err := systemdUnits(cfg, configPath, "", "default.target")
+	if err != nil {
+		return err
+	}
+	servicePath := filepath.Join(userDir, serviceName+".service")
+	if unitsUpToDate(servicePath, timerPath, serviceContent, timerContent) {
+		log.Info(i18n.Tf("log.msg.systemd_exists", timerPath))
+		enableSystemdTimer(cfg, log, ch, timerPath, "--user")
+		return nil
+	}
+
+	if err := os.MkdirAll(userDir, 0755); err != nil {
+		return fmt.Errorf(i18n.T("err.mkdir_systemd_user"), err)
+	}
+	if err := os.WriteFile(servicePath, []byte(serviceContent), 0644); err != nil {
+		return fmt.Errorf(i18n.T("err.write_service"), err)
+	}
+	if err := os.WriteFile(timerPath, []byte(timerContent), 0644); err != nil {
+		return fmt.Errorf(i18n.T("err.write_timer"), err)
+	}
+	installed(cfg, log, ch, timerPath, i18n.Tf("log.msg.systemd_created", userDir, serviceName))
+	return nil
+}
+
+// ensureSystemdSystem installs service and timer in /etc/systemd/system (schedule_scope "system", needs root),
+// runs as schedule_user and enables the timer; no dependency on a logged-in user session.
+func ensureSystemdSystem(cfg *config.Config, configPath string, ch *changes, log *logger.Logger) error {
+	if os.Geteuid() != 0 {
+		return fmt.Errorf(i18n.T("err.schedule_system_root"))
+	}
+	runAs := scheduleUser(cfg)
+	serviceContent, timerContent, err := systemdUnits(cfg, configPath, runAs, "multi-user.target")
+	if err != nil {
+		return err
+	}
+	servicePath := filepath.Join(systemdSystemDir, serviceName+".service")
+	timerPath := filepath.Join(systemdSystemDir, serviceName+".timer")
+	if unitsUpToDate(servicePath, timerPath, serviceContent, timerContent) {
+		log.Info(i18n.Tf("log.msg.systemd_exists", timerPath))
+		enableSystemdTimer(cfg, log, ch, timerPath)
+		return nil
+	}
+	if err := os.WriteFile(servicePath, []byte(serviceContent), 0644); err != nil {
+		return fmt.Errorf(i18n.T("err.write_service"), err)
+	}
+	if err := os.WriteFile(timerPath, []byte(timerContent), 0644); err != nil {
+		return fmt.Errorf(i18n.T("err.write_timer"), err)
+	}
+	if out, err := runWithDebug(log, exec.Command("systemctl", "daemon-reload")); err != nil {
+		return fmt.Errorf(i18n.T("err.systemctl"), "daemon-reload", err, strings.TrimSpace(string(out)))
+	}
+	if out, err := runWithDebug(log, exec.Command("systemctl", "enable", "--now", serviceName+".timer")); err != nil {
+		return fmt.Errorf(i18n.T("err.systemctl"), "enable --now", err, strings.TrimSpace(string(out)))
+	}
+	installed(cfg, log, ch, timerPath, i18n.Tf("log.msg.systemd_system_created", timerPath, runAs))
+	return nil
+}
+
+// enableSystemdTimer enables and starts the timer again if systemctl reports it as disabled; scope is "--user"
+// for the user instance. Other answers (enabled, no user session) are left alone.
+func enableSystemdTimer(cfg *config.Config, log *logger.Logger, ch *changes, timerPath string, scope ...string) {
+	timer := serviceName + ".timer"
+	out, _ := runWithDebug(log, exec.Command("systemctl", append(scope, "is-enabled", timer)...))
+	if strings.TrimSpace(string(out)) != "disabled" {
+		return
+	}
+	if out, err := runWithDebug(log, exec.Command("systemctl", append(scope, "enable", "--now", timer)...)); err != nil {
+		log.Warn(i18n.Tf("log.warn.schedule_enable", timer, err, strings.TrimSpace(string(out))))
+		return
+	}
+	reenabled(cfg, log, ch, timerPath, i18n.Tf("log.warn.schedule_reenabled", timer))
+}
+
+// scheduleUser returns the account for system-level jobs: schedule_user, else the user who invoked sudo, else root.
+func scheduleUser(cfg *config.Config) string {
+	if u := strings.TrimSpace(cfg.ScheduleUser); u != "" {
+		return u
+	}
+	if u := os.Getenv("SUDO_USER"); u != "" {
+		return u
+	}
+	return "root"
+}
+
+// systemdUnits returns the service and timer unit content. runAs sets User= (system units only).
+func systemdUnits(cfg *config.Config, configPath, runAs, wantedBy string) (service, timer string, err error) {
 	exe, err := os.Executable()
 	if err != nil {
-		return fmt.Errorf(i18n.T("err.executable_path"), err)
+		return "", "", fmt.Errorf(i18n.T("err.executable_path"), err)
 	}
 	exe = filepath.Clean(exe)
-	startTime := cfg.StartTime
-	if startTime == "" {
-		startTime = "22:00"
+	spec, err := cfg.ScheduleSpec()
+	if err != nil {
+		return "", "", err
+	}
+	var onCalendar strings.Builder
+	for _, oc := range spec.OnCalendar() {
+		onCalendar.WriteString("OnCalendar=" + oc + "\n")
 	}
-	parts := strings.SplitN(startTime, ":", 2)
-	hour, min := "22", "00"
-	if len(parts) >= 2 {
-		hour, min = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	randomizedDelay := ""
+	if cfg.StartJitterMinutes > 0 {
+		randomizedDelay = fmt.Sprintf("RandomizedDelaySec=%dm\n", cfg.StartJitterMinutes)
+	}
+	user := ""
+	if runAs != "" {
+		user = "User=" + runAs + "\n"
 	}
-	onCalendar := fmt.Sprintf("*-*-* %s:%s:00", hour, min)
 
-	serviceContent := fmt.Sprintf(`[Unit]
+	service = fmt.Sprintf(`[Unit]
 Description=MySQL Backup
 After=network.target
 
 [Service]
 Type=oneshot
-ExecStart=%s --backup -config %s
+%sExecStart=%s --backup -config %s
 WorkingDirectory=%s
 
 [Install]
-WantedBy=default.target
-`, exe, configPath, filepath.Dir(configPath))
+WantedBy=%s
+`, user, exe, configPath, filepath.Dir(configPath), wantedBy)
 
-	timerContent := fmt.Sprintf(`[Unit]
-Description=Run MySQL Backup daily
+	timer = fmt.Sprintf(`[Unit]
+Description=Run MySQL Backup (%s)
 
 [Timer]
-OnCalendar=%s
-Persistent=true
+%s%sPersistent=%t
 
 [Install]
 WantedBy=timers.target
-`, onCalendar)
+`, spec.Expr, onCalendar.String(), randomizedDelay, cfg.CatchUp)
+	return service, timer, nil
+}
 
-	if err := os.MkdirAll(userDir, 0755); err != nil {
-		return fmt.Errorf(i18n.T("err.mkdir_systemd_user"), err)
-	}
-	servicePath := filepath.Join(userDir, serviceName+".service")
-	if err := os.WriteFile(servicePath, []byte(serviceContent), 0644); err != nil {
-		return fmt.Errorf(i18n.T("err.write_service"), err)
-	}
-	if err := os.WriteFile(timerPath, []byte(timerContent), 0644); err != nil {
-		return fmt.Errorf(i18n.T("err.write_timer"), err)
-	}
-	log.Info(i18n.Tf("log.msg.systemd_created", userDir, serviceName))
-	return nil
+// unitsUpToDate reports whether both unit files exist with exactly the given content.
+func unitsUpToDate(servicePath, timerPath, service, timer string) bool {
+	oldService, errService := os.ReadFile(servicePath)
+	oldTimer, errTimer := os.ReadFile(timerPath)
+	return errService == nil && errTimer == nil && string(oldService) == service && string(oldTimer) == timer
 }
 
 // quoteForCron returns s quoted for use in a crontab command line so that spaces and special characters are preserved.
@@ -480,82 +773,118 @@ func quoteForCron(s string) string {
 }
 
 // ensureUnixCron adds a crontab entry for the current user (fallback when systemd user is not available).
-func ensureUnixCron(cfg *config.Config, configPath string, log *logger.Logger) error {
-	exe, err := os.Executable()
+func ensureUnixCron(cfg *config.Config, configPath string, ch *changes, log *logger.Logger) error {
+	when, linesUser, err := cronLines(cfg, configPath, "")
 	if err != nil {
-		return fmt.Errorf(i18n.T("err.executable_path"), err)
+		return err
 	}
-	exe = filepath.Clean(exe)
-	hour, min := 22, 0
-	if t := strings.TrimSpace(cfg.StartTime); t != "" {
-		parts := strings.SplitN(t, ":", 2)
-		if len(parts) >= 2 {
-			if h, err := strconv.Atoi(strings.TrimSpace(parts[0])); err == nil && h >= 0 && h <= 23 {
-				hour = h
-			}
-			if m, err := strconv.Atoi(strings.TrimSpace(parts[1])); err == nil && m >= 0 && m <= 59 {
-				min = m
-			}
-		}
+	_, linesSystem, err := cronLines(cfg, configPath, systemCrontabUser)
+	if err != nil {
+		return err
 	}
-	exeQ := quoteForCron(exe)
-	configQ := quoteForCron(configPath)
-	cronLineUser := fmt.Sprintf("%d %d * * * %s --backup -config %s # %s", min, hour, exeQ, configQ, cronMarker)
-	cronLineSystem := fmt.Sprintf("%d %d * * * %s %s --backup -config %s # %s", min, hour, systemCrontabUser, exeQ, configQ, cronMarker)
 	existing, err := getCrontab()
 	if err != nil {
 		if errors.Is(err, exec.ErrNotFound) {
-			return ensureUnixCronSystemFile(hour, min, exe, cronLineSystem, log)
+			return ensureUnixCronSystemFile(cfg, when, linesSystem, ch, log)
 		}
 		return fmt.Errorf(i18n.T("err.crontab_l"), err)
 	}
-	var newCrontab bytes.Buffer
-	scanner := bufio.NewScanner(bytes.NewReader(existing))
-	foundMarker := false
-	replacedOrAppended := false
+	newCrontab, changed, err := replaceMarkerLines(existing, linesUser)
+	if err != nil {
+		return fmt.Errorf(i18n.T("err.crontab_l"), err)
+	}
+	if !changed {
+		log.Info(i18n.T("log.msg.cron_present"))
+		return nil
+	}
+	if err := setCrontab(newCrontab); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return ensureUnixCronSystemFile(cfg, when, linesSystem, ch, log)
+		}
+		return fmt.Errorf(i18n.T("err.crontab"), err)
+	}
+	installed(cfg, log, ch, "crontab", i18n.Tf("log.msg.cron_added", when))
+	return nil
+}
+
+// cronLines returns the schedule description and the cron lines (backup, plus the hourly catch-up check
+// with catch_up). user != "" adds the user field of system crontabs (/etc/crontab, cron.d).
+func cronLines(cfg *config.Config, configPath, user string) (when string, lines []string, err error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", nil, fmt.Errorf(i18n.T("err.executable_path"), err)
+	}
+	exe = filepath.Clean(exe)
+	spec, err := cfg.ScheduleSpec()
+	if err != nil {
+		return "", nil, err
+	}
+	// cron has no random delay: shift a daily time by a per-host offset, otherwise sleep before the start
+	expr, sleep := spec.Expr, ""
+	if offset := jitterOffset(cfg.StartJitterMinutes, configPath); offset > 0 {
+		if spec.IsDaily() {
+			t := spec.Hour[0]*60 + spec.Minute[0] + offset
+			spec = cron.Daily(t/60%24, t%60)
+			expr = spec.Expr
+		} else {
+			sleep = fmt.Sprintf("sleep %d && ", offset*60)
+		}
+	}
+	if user != "" {
+		user += " "
+	}
+	exeQ := quoteForCron(exe)
+	configQ := quoteForCron(configPath)
+	lines = []string{fmt.Sprintf("%s %s%s%s --backup -config %s # %s", expr, user, sleep, exeQ, configQ, cronMarker)}
+	if cfg.CatchUp {
+		// cron skips runs while the machine is off or asleep: check hourly whether a run was missed
+		minute := jitterOffset(60, configPath)
+		lines = append(lines, fmt.Sprintf("%d * * * * %s%s --catchup -config %s # %s catch-up", minute, user, exeQ, configQ, cronMarker))
+	}
+	return describe(spec), lines, nil
+}
+
+// replaceMarkerLines replaces all lines of this job (see isMarkerLine) by lines (at the position of the first one,
+// otherwise appended). changed is false when the marker lines already equal lines.
+func replaceMarkerLines(data []byte, lines []string) (out []byte, changed bool, err error) {
+	var buf bytes.Buffer
+	var existing []string
+	written := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
 	for scanner.Scan() {
 		line := scanner.Bytes()
 		lineStr := strings.TrimSpace(string(line))
-		if strings.Contains(lineStr, cronMarker) {
-			foundMarker = true
-			if lineStr == cronLineUser {
-				log.Info(i18n.T("log.msg.cron_present"))
-				return nil
-			}
-			// Replace first matching line; skip any further lines that also contain the marker
-			if !replacedOrAppended {
-				newCrontab.WriteString(cronLineUser)
-				newCrontab.WriteByte('\n')
-				replacedOrAppended = true
+		if isMarkerLine(lineStr) {
+			existing = append(existing, lineStr)
+			if !written {
+				for _, l := range lines {
+					buf.WriteString(l)
+					buf.WriteByte('\n')
+				}
+				written = true
 			}
 			continue
 		}
-		newCrontab.Write(line)
-		newCrontab.WriteByte('\n')
+		buf.Write(line)
+		buf.WriteByte('\n')
 	}
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf(i18n.T("err.crontab_l"), err)
+		return nil, false, err
 	}
-	if !foundMarker {
-		newCrontab.WriteString(cronLineUser)
-		newCrontab.WriteByte('\n')
-		replacedOrAppended = true
+	if strings.Join(existing, "\n") == strings.Join(lines, "\n") {
+		return data, false, nil
 	}
-	if !replacedOrAppended {
-		return nil
-	}
-	if err := setCrontab(newCrontab.Bytes()); err != nil {
-		if errors.Is(err, exec.ErrNotFound) {
-			return ensureUnixCronSystemFile(hour, min, exe, cronLineSystem, log)
+	if !written {
+		for _, l := range lines {
+			buf.WriteString(l)
+			buf.WriteByte('\n')
 		}
-		return fmt.Errorf(i18n.T("err.crontab"), err)
 	}
-	log.Info(i18n.Tf("log.msg.cron_added", hour, min))
-	return nil
+	return buf.Bytes(), true, nil
 }
 
-// ensureUnixCronSystemFile appends the cron line to /etc/crontab (or /usr/etc/crontab) when crontab executable is not available.
-func ensureUnixCronSystemFile(hour, min int, exe, cronLine string, log *logger.Logger) error {
+// ensureUnixCronSystemFile writes the cron lines to /etc/crontab (or /usr/etc/crontab) when crontab executable is not available.
+func ensureUnixCronSystemFile(cfg *config.Config, when string, cronLines []string, ch *changes, log *logger.Logger) error {
 	var path string
 	var data []byte
 	var err error
@@ -566,47 +895,22 @@ func ensureUnixCronSystemFile(hour, min int, exe, cronLine string, log *logger.L
 			break
 		}
 	}
+	cronLine := strings.Join(cronLines, "\n")
 	if path == "" {
 		return fmt.Errorf(i18n.T("err.crontab_manual"), err, cronLine)
 	}
-	var newContent bytes.Buffer
-	scanner := bufio.NewScanner(bytes.NewReader(data))
-	foundMarker := false
-	replacedOrAppended := false
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		lineStr := strings.TrimSpace(string(line))
-		if strings.Contains(lineStr, cronMarker) {
-			foundMarker = true
-			if lineStr == cronLine {
-				log.Info(i18n.Tf("log.msg.cron_present_file", path))
-				return nil
-			}
-			if !replacedOrAppended {
-				newContent.WriteString(cronLine)
-				newContent.WriteByte('\n')
-				replacedOrAppended = true
-			}
-			continue
-		}
-		newContent.Write(line)
-		newContent.WriteByte('\n')
-	}
-	if err := scanner.Err(); err != nil {
+	newContent, changed, err := replaceMarkerLines(data, cronLines)
+	if err != nil {
 		return fmt.Errorf(i18n.T("err.crontab_manual"), err, cronLine)
 	}
-	if !foundMarker {
-		newContent.WriteString(cronLine)
-		newContent.WriteByte('\n')
-		replacedOrAppended = true
-	}
-	if !replacedOrAppended {
+	if !changed {
+		log.Info(i18n.Tf("log.msg.cron_present_file", path))
 		return nil
 	}
-	if err := os.WriteFile(path, newContent.Bytes(), 0644); err != nil {
+	if err := os.WriteFile(path, newContent, 0644); err != nil {
 		return fmt.Errorf(i18n.Tf("err.write_cron_need_root", path), err, cronLine)
 	}
-	log.Info(i18n.Tf("log.msg.cron_added_file", path, hour, min))
+	installed(cfg, log, ch, path, i18n.Tf("log.msg.cron_added_file", path, when))
 	return nil
 }
 
@@ -632,9 +936,10 @@ func setCrontab(data []byte) error {
 
 // Status returns a translation key and args for the current job (exists, next run, command). Empty key if no job.
 func Status(cfg *config.Config, configPath string) (key string, args []interface{}) {
-	startTime := cfg.StartTime
-	if startTime == "" {
-		startTime = "22:00"
+	useJob(cfg, configPath)
+	when := i18n.Tf("schedule.daily", "22:00")
+	if spec, err := cfg.ScheduleSpec(); err == nil {
+		when = describe(spec)
 	}
 	if runtime.GOOS == "windows" {
 		cmd := exec.Command("schtasks", "/Query", "/TN", taskNameWindows)
@@ -644,7 +949,22 @@ func Status(cfg *config.Config, configPath string) (key string, args []interface
 			return "", nil
 		}
 		exe, _ := os.Executable()
-		return "job.windows", []interface{}{taskNameWindows, startTime, exe, configPath}
+		return "job.windows", []interface{}{taskNameWindows, when, exe, configPath}
+	}
+	if isBSD() {
+		if path := bsdStatus(); path != "" {
+			exe, _ := os.Executable()
+			return "job.bsd", []interface{}{path, when, exe, configPath}
+		}
+	}
+	if runtime.GOOS == "darwin" {
+		if plistPath := launchdStatus(); plistPath != "" {
+			exe, _ := os.Executable()
+			return "job.launchd", []interface{}{plistPath, when, exe, configPath}
+		}
+	}
+	if systemTimer := filepath.Join(systemdSystemDir, serviceName+".timer"); fileExists(systemTimer) {
+		return "job.systemd", []interface{}{systemTimer, when, serviceName, configPath}
 	}
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -652,18 +972,23 @@ func Status(cfg *config.Config, configPath string) (key string, args []interface
 	}
 	timerPath := filepath.Join(home, ".config", "systemd", "user", serviceName+".timer")
 	if _, err := os.Stat(timerPath); err == nil {
-		return "job.systemd", []interface{}{timerPath, startTime, serviceName, configPath}
+		return "job.systemd", []interface{}{timerPath, when, serviceName, configPath}
 	}
 	if crontabHasMarker() {
 		exe, _ := os.Executable()
-		return "job.cron", []interface{}{startTime, exe, configPath}
+		return "job.cron", []interface{}{when, exe, configPath}
 	}
 	return "", nil
 }
 
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
 func crontabHasMarker() bool {
 	data, err := getCrontab()
-	if err == nil && bytes.Contains(data, []byte(cronMarker)) {
+	if err == nil && hasMarkerLine(data) {
 		return true
 	}
 	return systemCrontabHasMarker()
@@ -675,7 +1000,7 @@ func systemCrontabHasMarker() bool {
 		if err != nil {
 			continue
 		}
-		if bytes.Contains(data, []byte(cronMarker)) {
+		if hasMarkerLine(data) {
 			return true
 		}
 	}
@@ -684,12 +1009,12 @@ func systemCrontabHasMarker() bool {
 
 func removeCrontabMarker() error {
 	data, err := getCrontab()
-	if err == nil && bytes.Contains(data, []byte(cronMarker)) {
+	if err == nil && hasMarkerLine(data) {
 		var out bytes.Buffer
 		sc := bufio.NewScanner(bytes.NewReader(data))
 		for sc.Scan() {
 			line := sc.Bytes()
-			if bytes.Contains(line, []byte(cronMarker)) {
+			if isMarkerLine(strings.TrimSpace(string(line))) {
 				continue
 			}
 			out.Write(line)
@@ -711,14 +1036,14 @@ func removeSystemCrontabMarker() error {
 		if err != nil {
 			continue
 		}
-		if !bytes.Contains(data, []byte(cronMarker)) {
+		if !hasMarkerLine(data) {
 			continue
 		}
 		var out bytes.Buffer
 		sc := bufio.NewScanner(bytes.NewReader(data))
 		for sc.Scan() {
 			line := sc.Bytes()
-			if bytes.Contains(line, []byte(cronMarker)) {
+			if isMarkerLine(strings.TrimSpace(string(line))) {
 				continue
 			}
 			out.Write(line)
@@ -735,8 +1060,10 @@ func removeSystemCrontabMarker() error {
 	return nil
 }
 
-// Uninstall removes the scheduled task (Windows), systemd timer (Linux), or cron entry. log may be nil.
-func Uninstall(log *logger.Logger) error {
+// Uninstall removes the scheduled task (Windows), systemd timer (Linux), launchd job (macOS), or cron entry
+// of the job of cfg (job_name; nil = default job). log may be nil.
+func Uninstall(cfg *config.Config, configPath string, log *logger.Logger) error {
+	useJob(cfg, configPath)
 	info := func(format string, a ...interface{}) {
 		if log != nil {
 			log.Info(format, a...)
@@ -751,6 +1078,26 @@ func Uninstall(log *logger.Logger) error {
 		info("Windows task %s removed", taskNameWindows)
 		return nil
 	}
+	if runtime.GOOS == "darwin" {
+		if err := uninstallLaunchd(log, info); err != nil {
+			return err
+		}
+	}
+	if isBSD() {
+		if err := uninstallBSD(info); err != nil {
+			return err
+		}
+	}
+	if systemTimer := filepath.Join(systemdSystemDir, serviceName+".timer"); fileExists(systemTimer) {
+		if os.Geteuid() != 0 {
+			return fmt.Errorf(i18n.T("err.schedule_system_root"))
+		}
+		_, _ = runWithDebug(log, exec.Command("systemctl", "disable", "--now", serviceName+".timer"))
+		_ = os.Remove(systemTimer)
+		_ = os.Remove(filepath.Join(systemdSystemDir, serviceName+".service"))
+		_, _ = runWithDebug(log, exec.Command("systemctl", "daemon-reload"))
+		info("systemd timer and service removed from %s", systemdSystemDir)
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return err
@@ -0,0 +1,235 @@
+// Package signing signs backup ZIPs with an Ed25519 key (signing_key_file) and checks the signatures against the
+// trusted public keys before a backup is restored, downloaded or verified. The signature is stored next to the ZIP
+// as <zip>.sig in the SSH signature format (namespace "mysqlbackup", hash SHA-256 of the unencrypted ZIP), so it
+// can also be checked without mysqlbackup:
+//
+//	ssh-keygen -Y verify -f allowed_signers -I backup -n mysqlbackup -s <zip>.sig < <zip>
+package signing
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/janmz/mysqlbackup/internal/catalog"
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+)
+
+// Namespace is the SSH signature namespace of backup signatures (ssh-keygen -n).
+const Namespace = "mysqlbackup"
+
+const (
+	magic      = "SSHSIG"
+	armorBegin = "-----BEGIN SSH SIGNATURE-----"
+	armorEnd   = "-----END SSH SIGNATURE-----"
+)
+
+// sigBlob is the SSH signature after the magic (PROTOCOL.sshsig).
+type sigBlob struct {
+	Version   uint32
+	PublicKey []byte
+	Namespace string
+	Reserved  string
+	HashAlg   string
+	Signature []byte
+}
+
+// signedData is what the key actually signs: the hash of the message with namespace and hash algorithm.
+type signedData struct {
+	Namespace string
+	Reserved  string
+	HashAlg   string
+	Hash      []byte
+}
+
+// LoadKey reads the Ed25519 private key of signing_key_file (OpenSSH or PKCS#8, without passphrase).
+func LoadKey(path string) (ssh.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("err.signing_key"), path, err)
+	}
+	raw, err := ssh.ParseRawPrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("err.signing_key"), path, err)
+	}
+	if k, ok := raw.(*ed25519.PrivateKey); ok {
+		raw = *k
+	}
+	if _, ok := raw.(ed25519.PrivateKey); !ok {
+		return nil, fmt.Errorf(i18n.T("err.signing_key_type"), path)
+	}
+	return ssh.NewSignerFromKey(raw)
+}
+
+// ParsePublicKey parses an Ed25519 public key in authorized_keys format ("ssh-ed25519 AAAA… [comment]").
+func ParsePublicKey(s string) (ssh.PublicKey, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(s))
+	if err != nil || key.Type() != ssh.KeyAlgoED25519 {
+		return nil, fmt.Errorf(i18n.T("err.config_signing_public_key"), s)
+	}
+	return key, nil
+}
+
+// Keys are the public keys signatures are checked against; empty = signatures are not checked.
+type Keys []ssh.PublicKey
+
+// Trusted returns the trusted keys of cfg: the public key of signing_key_file and signing_public_keys.
+func Trusted(cfg *config.Config) (Keys, error) {
+	var keys Keys
+	if cfg.SigningKeyFile != "" {
+		signer, err := LoadKey(cfg.SigningKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, signer.PublicKey())
+	}
+	for _, s := range cfg.SigningPublicKeys {
+		key, err := ParsePublicKey(s)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Sign returns the armored signature of a file whose SHA-256 is sum.
+func Sign(signer ssh.Signer, sum []byte) ([]byte, error) {
+	sig, err := signer.Sign(rand.Reader, message("sha256", sum))
+	if err != nil {
+		return nil, err
+	}
+	blob := append([]byte(magic), ssh.Marshal(sigBlob{
+		Version:   1,
+		PublicKey: signer.PublicKey().Marshal(),
+		Namespace: Namespace,
+		HashAlg:   "sha256",
+		Signature: ssh.Marshal(sig),
+	})...)
+	enc := base64.StdEncoding.EncodeToString(blob)
+	var b strings.Builder
+	b.WriteString(armorBegin + "\n")
+	for len(enc) > 70 {
+		b.WriteString(enc[:70] + "\n")
+		enc = enc[70:]
+	}
+	b.WriteString(enc + "\n" + armorEnd + "\n")
+	return []byte(b.String()), nil
+}
+
+// WriteFile signs zipPath by its SHA-256 (hex, as computed while writing the ZIP) and writes <zip>.sig.
+func WriteFile(signer ssh.Signer, zipPath, sha256Hex string) error {
+	sum, err := hex.DecodeString(sha256Hex)
+	if err != nil {
+		return err
+	}
+	sig, err := Sign(signer, sum)
+	if err != nil {
+		return err
+	}
+	tmp := zipPath + catalog.SignatureExt + ".tmp"
+	if err := os.WriteFile(tmp, sig, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, zipPath+catalog.SignatureExt)
+}
+
+// Verify checks the signature sig of the backup name whose content is r. It fails when sig is missing or
+// unreadable, was made by a key that is not trusted or does not match the content.
+func (k Keys) Verify(name string, r io.Reader, sig []byte) error {
+	if sig == nil {
+		return fmt.Errorf(i18n.T("err.signature_missing"), name, name+catalog.SignatureExt)
+	}
+	blob, err := parse(sig)
+	if err != nil {
+		return fmt.Errorf(i18n.T("err.signature_format"), name+catalog.SignatureExt, err)
+	}
+	pub, err := ssh.ParsePublicKey(blob.PublicKey)
+	if err != nil {
+		return fmt.Errorf(i18n.T("err.signature_format"), name+catalog.SignatureExt, err)
+	}
+	trusted := false
+	for _, key := range k {
+		trusted = trusted || bytes.Equal(key.Marshal(), pub.Marshal())
+	}
+	if !trusted {
+		return fmt.Errorf(i18n.T("err.signature_untrusted"), name, ssh.FingerprintSHA256(pub))
+	}
+	var h hash.Hash
+	switch blob.HashAlg {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf(i18n.T("err.signature_format"), name+catalog.SignatureExt, blob.HashAlg)
+	}
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+	s := new(ssh.Signature)
+	if err := ssh.Unmarshal(blob.Signature, s); err != nil {
+		return fmt.Errorf(i18n.T("err.signature_format"), name+catalog.SignatureExt, err)
+	}
+	if err := pub.Verify(message(blob.HashAlg, h.Sum(nil)), s); err != nil {
+		return fmt.Errorf(i18n.T("err.signature_invalid"), name)
+	}
+	return nil
+}
+
+// VerifyFile checks the signature file <zip>.sig of zipPath (see Verify).
+func (k Keys) VerifyFile(zipPath string) error {
+	sig, err := os.ReadFile(zipPath + catalog.SignatureExt)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	f, err := os.Open(zipPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return k.Verify(filepath.Base(zipPath), f, sig)
+}
+
+func message(hashAlg string, sum []byte) []byte {
+	return append([]byte(magic), ssh.Marshal(signedData{Namespace: Namespace, HashAlg: hashAlg, Hash: sum})...)
+}
+
+// parse decodes an armored SSH signature of the namespace Namespace.
+func parse(sig []byte) (*sigBlob, error) {
+	s := strings.TrimSpace(string(sig))
+	body, okBegin := strings.CutPrefix(s, armorBegin)
+	body, okEnd := strings.CutSuffix(body, armorEnd)
+	if !okBegin || !okEnd {
+		return nil, fmt.Errorf("%s ... %s", armorBegin, armorEnd)
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+	if err != nil {
+		return nil, err
+	}
+	rest, ok := bytes.CutPrefix(data, []byte(magic))
+	if !ok {
+		return nil, fmt.Errorf("%s", magic)
+	}
+	blob := new(sigBlob)
+	if err := ssh.Unmarshal(rest, blob); err != nil {
+		return nil, err
+	}
+	if blob.Version != 1 || blob.Namespace != Namespace {
+		return nil, fmt.Errorf("version %d, namespace %q", blob.Version, blob.Namespace)
+	}
+	return blob, nil
+}
@@ -0,0 +1,77 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/janmz/mysqlbackup/internal/catalog"
+)
+
+func TestSignVerify(t *testing.T) {
+	dir := t.TempDir()
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKey(priv, "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "signing_key")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := LoadKey(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zip := filepath.Join(dir, "mysql_backup_20261015_host_shop.zip")
+	data := []byte("PK\x03\x04 backup content")
+	if err := os.WriteFile(zip, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	if err := WriteFile(signer, zip, hex.EncodeToString(sum[:])); err != nil {
+		t.Fatal(err)
+	}
+	keys := Keys{signer.PublicKey()}
+	if err := keys.VerifyFile(zip); err != nil {
+		t.Fatalf("VerifyFile of signed ZIP: %v", err)
+	}
+
+	_, other, _ := ed25519.GenerateKey(rand.Reader)
+	otherSigner, _ := ssh.NewSignerFromKey(other)
+	if err := (Keys{otherSigner.PublicKey()}).VerifyFile(zip); err == nil || !strings.Contains(err.Error(), "SHA256:") {
+		t.Errorf("VerifyFile with untrusted key = %v", err)
+	}
+	if err := os.WriteFile(zip, append(data, 0), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.VerifyFile(zip); err == nil {
+		t.Error("VerifyFile accepted a modified ZIP")
+	}
+	if err := os.Remove(zip + catalog.SignatureExt); err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.VerifyFile(zip); err == nil {
+		t.Error("VerifyFile accepted a ZIP without signature")
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	key, _ := ssh.NewPublicKey(pub)
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))) + " backup@host"
+	if _, err := ParsePublicKey(line); err != nil {
+		t.Errorf("ParsePublicKey(%q): %v", line, err)
+	}
+	if _, err := ParsePublicKey("ssh-rsa AAAAB3NzaC1yc2E"); err == nil {
+		t.Error("ParsePublicKey accepted a non-Ed25519 key")
+	}
+}
@@ -0,0 +1,127 @@
+// Package state keeps the result of the last backup runs (state.json in backup_dir) for catch-up and status.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/cron"
+)
+
+// FileName is the state file in backup_dir.
+const FileName = "state.json"
+
+// State is the persisted run state.
+type State struct {
+	LastStart   time.Time `json:"last_start,omitempty"`
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	Failures    int       `json:"failures,omitempty"` // fehlgeschlagene Läufe in Folge seit dem letzten Erfolg
+	NextRun     time.Time `json:"next_run,omitempty"` // nächster geplanter Lauf laut Zeitplan (Drift-Erkennung)
+
+	// Wiederholte gleiche Fehler (Fingerprint) für die Drosselung der Benachrichtigungen
+	ErrorFingerprint string    `json:"error_fingerprint,omitempty"`
+	ErrorCount       int       `json:"error_count,omitempty"`
+	ErrorSince       time.Time `json:"error_since,omitempty"`
+	ErrorNotified    time.Time `json:"error_notified,omitempty"`
+
+	path string
+}
+
+// Load reads state.json from dir; a missing file yields an empty state.
+func Load(dir string) (*State, error) {
+	s := &State{path: filepath.Join(filepath.FromSlash(dir), FileName)}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// Save writes the state (temp file + rename).
+func (s *State) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Started records the start of a run.
+func (s *State) Started(t time.Time) {
+	s.LastStart = t
+}
+
+// Finished records the result of a run.
+func (s *State) Finished(t time.Time, err error) {
+	if err != nil {
+		s.LastError = err.Error()
+		s.Failures++
+		return
+	}
+	s.LastSuccess = t
+	s.LastError, s.Failures = "", 0
+}
+
+// CatchUpDue reports whether a scheduled run was missed: the first scheduled time after the last
+// successful backup lies more than grace before now and no run has been started since then.
+// A failed run is therefore not repeated, only a run that did not happen at all (e.g. machine asleep).
+// Without any recorded run a backup is due.
+func (s *State) CatchUpDue(spec *cron.Spec, now time.Time, grace time.Duration) bool {
+	if s.LastSuccess.IsZero() {
+		return s.LastStart.IsZero()
+	}
+	missed := spec.Next(s.LastSuccess.In(now.Location()))
+	if missed.IsZero() || now.Before(missed.Add(grace)) {
+		return false
+	}
+	return s.LastStart.Before(missed)
+}
+
+// MissedRun returns the recorded next scheduled run (NextRun) if it lies more than grace before now and no
+// run has been started since then, i.e. the scheduled job did not run; zero otherwise.
+func (s *State) MissedRun(now time.Time, grace time.Duration) time.Time {
+	if s.NextRun.IsZero() || now.Before(s.NextRun.Add(grace)) || !s.LastStart.Before(s.NextRun) {
+		return time.Time{}
+	}
+	return s.NextRun
+}
+
+// RecordFailure counts a failure with fingerprint fp and reports whether it should be notified:
+// the first repeat identical failures in a row are, after that at most one per calendar day (digest).
+// A different fingerprint starts a new series. repeat <= 0 notifies every failure.
+func (s *State) RecordFailure(fp string, now time.Time, repeat int) (notify bool) {
+	if fp != s.ErrorFingerprint || s.ErrorCount == 0 {
+		s.ErrorFingerprint, s.ErrorCount, s.ErrorSince = fp, 0, now
+		s.ErrorNotified = time.Time{}
+	}
+	s.ErrorCount++
+	last := s.ErrorNotified.In(now.Location())
+	notify = repeat <= 0 || s.ErrorCount <= repeat || s.ErrorNotified.IsZero() ||
+		last.Year() != now.Year() || last.YearDay() != now.YearDay()
+	if notify {
+		s.ErrorNotified = now
+	}
+	return notify
+}
+
+// Recovered ends a failure series after a successful run and returns its length and start (count 0 = no series).
+func (s *State) Recovered() (count int, since time.Time) {
+	count, since = s.ErrorCount, s.ErrorSince
+	s.ErrorFingerprint, s.ErrorCount = "", 0
+	s.ErrorSince, s.ErrorNotified = time.Time{}, time.Time{}
+	return count, since
+}
@@ -0,0 +1,110 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/cron"
+)
+
+func TestCatchUpDue(t *testing.T) {
+	loc := time.UTC
+	spec := cron.Daily(22, 0)
+	lastSuccess := time.Date(2026, 10, 14, 22, 30, 0, 0, loc)
+	tests := []struct {
+		name      string
+		lastStart time.Time
+		now       time.Time
+		want      bool
+	}{
+		{"before next run", lastSuccess.Add(-30 * time.Minute), time.Date(2026, 10, 15, 21, 0, 0, 0, loc), false},
+		{"within grace", lastSuccess.Add(-30 * time.Minute), time.Date(2026, 10, 15, 22, 30, 0, 0, loc), false},
+		{"missed run", lastSuccess.Add(-30 * time.Minute), time.Date(2026, 10, 16, 7, 0, 0, 0, loc), true},
+		{"run started and failed", time.Date(2026, 10, 15, 22, 0, 0, 0, loc), time.Date(2026, 10, 16, 7, 0, 0, 0, loc), false},
+	}
+	for _, tt := range tests {
+		s := &State{LastStart: tt.lastStart, LastSuccess: lastSuccess}
+		if got := s.CatchUpDue(spec, tt.now, time.Hour); got != tt.want {
+			t.Errorf("%s: CatchUpDue = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if !(&State{}).CatchUpDue(spec, time.Now(), time.Hour) {
+		t.Error("empty state: expected catch-up")
+	}
+}
+
+func TestMissedRun(t *testing.T) {
+	loc := time.UTC
+	next := time.Date(2026, 10, 15, 22, 0, 0, 0, loc)
+	tests := []struct {
+		name      string
+		next      time.Time
+		lastStart time.Time
+		now       time.Time
+		missed    bool
+	}{
+		{"not tracked", time.Time{}, time.Time{}, time.Date(2026, 10, 16, 7, 0, 0, 0, loc), false},
+		{"before window", next, next.Add(-24 * time.Hour), time.Date(2026, 10, 15, 21, 0, 0, 0, loc), false},
+		{"within grace", next, next.Add(-24 * time.Hour), time.Date(2026, 10, 15, 22, 30, 0, 0, loc), false},
+		{"ran late", next, next.Add(10 * time.Minute), time.Date(2026, 10, 16, 7, 0, 0, 0, loc), false},
+		{"missed", next, next.Add(-24 * time.Hour), time.Date(2026, 10, 16, 7, 0, 0, 0, loc), true},
+	}
+	for _, tt := range tests {
+		s := &State{NextRun: tt.next, LastStart: tt.lastStart}
+		if got := s.MissedRun(tt.now, time.Hour); got.IsZero() == tt.missed {
+			t.Errorf("%s: MissedRun = %v, want missed %v", tt.name, got, tt.missed)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC)
+	s.Started(now)
+	s.Finished(now.Add(time.Minute), errors.New("dump failed"))
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.LastStart.Equal(now) || !got.LastSuccess.IsZero() || got.LastError != "dump failed" || got.Failures != 1 {
+		t.Errorf("loaded %+v", got)
+	}
+	got.Finished(now.Add(2*time.Minute), nil)
+	if got.Failures != 0 || got.LastError != "" {
+		t.Errorf("after success %+v", got)
+	}
+}
+
+func TestRecordFailure(t *testing.T) {
+	s := &State{}
+	day := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	var sent []bool
+	for i := 0; i < 5; i++ {
+		sent = append(sent, s.RecordFailure("remote", day.Add(time.Duration(i)*time.Hour), 2))
+	}
+	// 2 notifications, then suppressed for the rest of the day; next day one digest
+	if want := []bool{true, true, false, false, false}; fmt.Sprint(sent) != fmt.Sprint(want) {
+		t.Errorf("sent = %v, want %v", sent, want)
+	}
+	if !s.RecordFailure("remote", day.AddDate(0, 0, 1), 2) || s.RecordFailure("remote", day.AddDate(0, 0, 1).Add(time.Hour), 2) {
+		t.Error("expected one digest on the next day")
+	}
+	if !s.RecordFailure("dump", day.AddDate(0, 0, 1).Add(2*time.Hour), 2) || s.ErrorCount != 1 {
+		t.Errorf("new fingerprint must start a new series, count %d", s.ErrorCount)
+	}
+	if count, since := s.Recovered(); count != 1 || !since.Equal(day.AddDate(0, 0, 1).Add(2*time.Hour)) {
+		t.Errorf("Recovered = %d, %v", count, since)
+	}
+	if count, _ := s.Recovered(); count != 0 {
+		t.Errorf("second Recovered = %d", count)
+	}
+}
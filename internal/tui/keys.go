@@ -0,0 +1,71 @@
+package tui
+
+import "unicode/utf8"
+
+// Keys returned by parseKey; other keys are the typed character itself ("q", "v", ...).
+const (
+	keyUp       = "up"
+	keyDown     = "down"
+	keyPageUp   = "pgup"
+	keyPageDown = "pgdown"
+	keyHome     = "home"
+	keyEnd      = "end"
+	keyEnter    = "enter"
+	keyEsc      = "esc"
+	keyBack     = "backspace"
+	keyCtrlC    = "ctrl-c"
+)
+
+// parseKey returns the key at the start of b (input of a terminal in raw mode) and the number of bytes it
+// takes. Escape sequences of cursor keys come as ESC [ x, ESC O x (application mode) or ESC [ n ~; unknown
+// sequences are skipped as "". A lone ESC is the Escape key.
+func parseKey(b []byte) (string, int) {
+	switch b[0] {
+	case '\r', '\n':
+		return keyEnter, 1
+	case 3:
+		return keyCtrlC, 1
+	case 8, 127:
+		return keyBack, 1
+	case 0x1b:
+		if len(b) == 1 || (b[1] != '[' && b[1] != 'O') {
+			return keyEsc, 1
+		}
+		if len(b) < 3 {
+			return "", len(b)
+		}
+		switch b[2] {
+		case 'A':
+			return keyUp, 3
+		case 'B':
+			return keyDown, 3
+		case 'H':
+			return keyHome, 3
+		case 'F':
+			return keyEnd, 3
+		}
+		// ESC [ Parameter ... Endzeichen (0x40-0x7e)
+		end := 2
+		for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+			end++
+		}
+		if end == len(b) {
+			return "", len(b)
+		}
+		if b[end] == '~' {
+			switch string(b[2:end]) {
+			case "1", "7":
+				return keyHome, end + 1
+			case "4", "8":
+				return keyEnd, end + 1
+			case "5":
+				return keyPageUp, end + 1
+			case "6":
+				return keyPageDown, end + 1
+			}
+		}
+		return "", end + 1
+	}
+	r, n := utf8.DecodeRune(b)
+	return string(r), n
+}
@@ -0,0 +1,442 @@
+// Package tui is the interactive backup browser of --tui: the backups in backup_dir and on the remote target in
+// one list, the details of the selected one (catalog entry, manifest, contents) and keys to verify, download or
+// restore it. Plain ANSI escape sequences and the raw mode of golang.org/x/term, no UI framework.
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"github.com/janmz/mysqlbackup/internal/catalog"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/logger"
+	"github.com/janmz/mysqlbackup/internal/report"
+	"github.com/janmz/mysqlbackup/internal/retention"
+)
+
+// Item is one backup ZIP of the list: in backup_dir, on the remote target or both.
+type Item struct {
+	Name   string
+	Date   time.Time // Datum aus dem Dateinamen
+	Size   int64     // lokale Größe, sonst die auf dem Remote-Ziel
+	Local  string    // path in backup_dir, "" = only on the remote target
+	Remote bool
+	Entry  *catalog.Entry // nil if the catalog does not know the file
+	Pinned bool
+}
+
+// Merge joins the backups in backup_dir (local) and on the remote target (remote, Path is the file name) by
+// file name, newest first; cat (may be nil) adds the catalog entries and pins.
+func Merge(local, remote []retention.BackupFile, cat *catalog.Catalog) []Item {
+	items := make([]Item, 0, len(local)+len(remote)) // nie nil: leere Liste ist kein Ladefehler
+	idx := make(map[string]int)
+	for _, f := range local {
+		name := filepath.Base(f.Path)
+		idx[name] = len(items)
+		items = append(items, Item{Name: name, Date: f.Date, Size: f.Size, Local: f.Path})
+	}
+	for _, f := range remote {
+		if i, ok := idx[f.Path]; ok {
+			items[i].Remote = true
+			continue
+		}
+		idx[f.Path] = len(items)
+		items = append(items, Item{Name: f.Path, Date: f.Date, Size: f.Size, Remote: true})
+	}
+	for i := range items {
+		if cat == nil {
+			break
+		}
+		if e, ok := cat.Entry(items[i].Name); ok {
+			items[i].Entry = &e
+		}
+		items[i].Pinned = cat.IsPinned(items[i].Name)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].Date.Equal(items[j].Date) {
+			return items[i].Date.After(items[j].Date)
+		}
+		return items[i].Name < items[j].Name
+	})
+	return items
+}
+
+// where returns the location column of it.
+func (it Item) where() string {
+	switch {
+	case it.Local != "" && it.Remote:
+		return i18n.T("tui.where_both")
+	case it.Local != "":
+		return i18n.T("tui.where_local")
+	}
+	return i18n.T("tui.where_remote")
+}
+
+// Options connects the browser to the commands of main. An error of Load together with items is shown in the
+// status line (e.g. remote target not reachable).
+type Options struct {
+	Load     func() ([]Item, error)       // lists the backups (again after every action)
+	Details  func(Item) ([]string, error) // contents of the ZIP for the detail view (like --inspect)
+	Verify   func(Item) error             // test restore of a local backup (verify_* sandbox)
+	Download func(Item) error             // fetches a remote backup into backup_dir
+	Restore  func(Item) error             // restores the backup into MySQL (after confirmation)
+}
+
+// Run shows the browser until q, Esc in the list or Ctrl+C. Verify, download and restore leave the full-screen
+// view, print their output like the commands and return to the list after Enter.
+func Run(opt Options) error {
+	in := int(os.Stdin.Fd())
+	if !term.IsTerminal(in) || !logger.VirtualTerminal() {
+		return fmt.Errorf(i18n.T("err.tui_terminal"))
+	}
+	items, err := opt.Load()
+	if err != nil && items == nil {
+		return err
+	}
+	s := &screen{items: items}
+	if err != nil {
+		s.msg = err.Error()
+	}
+	state, err := enter(in)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 64)
+	for {
+		w, h, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil || w <= 0 || h <= 0 {
+			w, h = 80, 24 // z. B. serielle Konsole ohne Größenangabe
+		}
+		os.Stdout.WriteString(s.render(w, h))
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			leave(in, state)
+			return err
+		}
+		for b := buf[:n]; len(b) > 0; {
+			k, l := parseKey(b)
+			b = b[l:]
+			action := s.handle(k, listRows(h))
+			switch action {
+			case "":
+				continue
+			case actionQuit:
+				leave(in, state)
+				return nil
+			case actionDetails:
+				s.msg = i18n.T("tui.loading")
+				os.Stdout.WriteString(s.render(w, h))
+				s.showDetails(opt.Details)
+				continue
+			}
+			it := s.items[s.sel]
+			leave(in, state)
+			actionErr := runAction(action, it, opt)
+			if state, err = enter(in); err != nil {
+				return err
+			}
+			items, loadErr := opt.Load()
+			if items != nil {
+				s.reload(items, it.Name)
+			}
+			s.detail = nil
+			switch {
+			case actionErr != nil:
+				s.msg = i18n.Tf("tui.failed", it.Name, actionErr)
+			case loadErr != nil:
+				s.msg = loadErr.Error()
+			default:
+				s.msg = i18n.Tf("tui.done", it.Name)
+			}
+			break // Rest der Eingabe verwerfen (vor der Aktion getippt)
+		}
+	}
+}
+
+// Aktionen von screen.handle
+const (
+	actionQuit     = "quit"
+	actionDetails  = "details"
+	actionVerify   = "verify"
+	actionDownload = "download"
+	actionRestore  = "restore"
+)
+
+// runAction runs a verify, download or restore of it on the normal screen and waits for Enter.
+func runAction(action string, it Item, opt Options) error {
+	var err error
+	switch action {
+	case actionVerify:
+		fmt.Println(i18n.Tf("tui.action_verify", it.Name))
+		err = opt.Verify(it)
+	case actionDownload:
+		fmt.Println(i18n.Tf("tui.action_download", it.Name))
+		err = opt.Download(it)
+	case actionRestore:
+		fmt.Println(i18n.Tf("tui.action_restore", it.Name))
+		err = opt.Restore(it)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Tf("tui.failed", it.Name, err))
+	}
+	fmt.Print(i18n.T("tui.press_enter"))
+	_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+	return err
+}
+
+// enter switches to raw mode and the alternate screen with hidden cursor.
+func enter(in int) (*term.State, error) {
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return nil, err
+	}
+	os.Stdout.WriteString("\x1b[?1049h\x1b[?25l")
+	return state, nil
+}
+
+// leave restores the terminal (cursor, normal screen, cooked mode).
+func leave(in int, state *term.State) {
+	os.Stdout.WriteString("\x1b[?25h\x1b[?1049l")
+	_ = term.Restore(in, state)
+}
+
+// listRows is the number of list rows on a terminal of height h (title, column header, status and key lines).
+func listRows(h int) int {
+	if h < 6 {
+		return 1
+	}
+	return h - 4
+}
+
+// screen is the state of the browser: the list with selection or the detail view of the selected backup.
+type screen struct {
+	items   []Item
+	sel     int
+	top     int      // erste sichtbare Zeile der Liste
+	detail  []string // Zeilen der Detailansicht, nil = Liste
+	dtop    int
+	msg     string // Statuszeile
+	confirm bool   // Restore wartet auf "y"
+}
+
+// handle applies key k and returns the action to run ("" = none).
+func (s *screen) handle(k string, rows int) string {
+	if s.confirm {
+		s.confirm = false
+		if k == "y" || k == "Y" {
+			s.msg = ""
+			return actionRestore
+		}
+		s.msg = i18n.T("tui.cancelled")
+		return ""
+	}
+	switch k {
+	case "q", keyCtrlC:
+		return actionQuit
+	case keyEsc, keyBack:
+		if s.detail == nil {
+			return actionQuit
+		}
+		s.detail = nil
+		return ""
+	}
+	if len(s.items) == 0 {
+		return ""
+	}
+	s.msg = ""
+	it := s.items[s.sel]
+	switch k {
+	case keyEnter, "i":
+		if s.detail == nil {
+			return actionDetails
+		}
+		s.detail = nil
+	case "v":
+		if it.Local == "" {
+			s.msg = i18n.T("tui.local_only")
+			return ""
+		}
+		return actionVerify
+	case "d":
+		if !it.Remote || it.Local != "" {
+			s.msg = i18n.T("tui.remote_only")
+			return ""
+		}
+		return actionDownload
+	case "r":
+		s.confirm = true
+		s.msg = i18n.Tf("tui.confirm_restore", it.Name)
+	case keyUp, "k":
+		s.move(-1, rows)
+	case keyDown, "j":
+		s.move(1, rows)
+	case keyPageUp:
+		s.move(-rows, rows)
+	case keyPageDown:
+		s.move(rows, rows)
+	case keyHome:
+		s.move(-len(s.items)-len(s.detail), rows)
+	case keyEnd:
+		s.move(len(s.items)+len(s.detail), rows)
+	}
+	return ""
+}
+
+// move moves the selection (list) or scrolls (detail view) by n rows.
+func (s *screen) move(n, rows int) {
+	if s.detail != nil {
+		s.dtop = clamp(s.dtop+n, 0, len(s.detail)-rows)
+		return
+	}
+	s.sel = clamp(s.sel+n, 0, len(s.items)-1)
+	if s.sel < s.top {
+		s.top = s.sel
+	}
+	if s.sel >= s.top+rows {
+		s.top = s.sel - rows + 1
+	}
+}
+
+func clamp(v, lo, hi int) int {
+	if v > hi {
+		v = hi
+	}
+	if v < lo {
+		v = lo
+	}
+	return v
+}
+
+// reload replaces the list after an action and selects name again (or the nearest position).
+func (s *screen) reload(items []Item, name string) {
+	s.items = items
+	s.sel = clamp(s.sel, 0, len(items)-1)
+	for i, it := range items {
+		if it.Name == name {
+			s.sel = i
+			break
+		}
+	}
+	s.top = clamp(s.top, 0, s.sel)
+}
+
+// showDetails fills the detail view of the selected backup: catalog entry and contents of the ZIP.
+func (s *screen) showDetails(details func(Item) ([]string, error)) {
+	it := s.items[s.sel]
+	lines := []string{i18n.Tf("tui.detail_where", it.where())}
+	if it.Pinned {
+		lines = append(lines, i18n.T("tui.detail_pinned"))
+	}
+	if e := it.Entry; e != nil {
+		if e.Database != "" {
+			lines = append(lines, i18n.Tf("tui.detail_database", e.Database))
+		}
+		lines = append(lines, i18n.Tf("tui.detail_created", e.Created.Local().Format("2006-01-02 15:04:05")))
+		if e.DurationMS > 0 {
+			lines = append(lines, i18n.Tf("tui.detail_duration", (time.Duration(e.DurationMS)*time.Millisecond).Round(time.Second)))
+		}
+		if e.SHA256 != "" {
+			lines = append(lines, i18n.Tf("tui.detail_sha256", e.SHA256))
+		}
+		if !e.RemoteAt.IsZero() {
+			lines = append(lines, i18n.Tf("tui.detail_uploaded", e.RemoteAt.Local().Format("2006-01-02 15:04:05")))
+			if e.Encrypted {
+				lines = append(lines, i18n.T("tui.detail_encrypted"))
+			}
+		}
+	}
+	lines = append(lines, "")
+	contents, err := details(it)
+	if err != nil {
+		contents = []string{i18n.Tf("tui.failed", it.Name, err)}
+	}
+	s.detail = append(lines, contents...)
+	s.dtop = 0
+	s.msg = ""
+}
+
+// render returns the escape sequences that draw the screen on a terminal of w x h characters.
+func (s *screen) render(w, h int) string {
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	line := func(text string, style string) {
+		text = fit(text, w)
+		if style != "" {
+			text = style + text + strings.Repeat(" ", w-utf8.RuneCountInString(text)) + "\x1b[0m"
+		}
+		b.WriteString(text + "\x1b[K\r\n")
+	}
+	rows := listRows(h)
+	local, remote := 0, 0
+	for _, it := range s.items {
+		if it.Local != "" {
+			local++
+		}
+		if it.Remote {
+			remote++
+		}
+	}
+	line(i18n.Tf("tui.title", len(s.items), local, remote), "\x1b[1m")
+	if s.detail != nil {
+		it := s.items[s.sel]
+		line(it.Name+"  "+report.FormatSize(it.Size), "\x1b[1m")
+		for i := 0; i < rows; i++ {
+			if n := s.dtop + i; n < len(s.detail) {
+				line("  "+s.detail[n], "")
+			} else {
+				line("", "")
+			}
+		}
+	} else {
+		line(fmt.Sprintf("  %-10s  %10s  %-14s  %s", i18n.T("tui.col_date"), i18n.T("tui.col_size"), i18n.T("tui.col_where"), i18n.T("tui.col_file")), "\x1b[1m")
+		if len(s.items) == 0 {
+			line("  "+i18n.T("tui.empty"), "")
+			rows--
+		}
+		for i := 0; i < rows; i++ {
+			n := s.top + i
+			if n >= len(s.items) {
+				line("", "")
+				continue
+			}
+			it := s.items[n]
+			name := it.Name
+			if it.Pinned {
+				name += " (" + i18n.T("status.pinned") + ")"
+			}
+			row := fmt.Sprintf("  %-10s  %10s  %-14s  %s", it.Date.Format("2006-01-02"), report.FormatSize(it.Size), it.where(), name)
+			if n == s.sel {
+				line(row, "\x1b[7m")
+			} else {
+				line(row, "")
+			}
+		}
+	}
+	line(s.msg, "")
+	keys := i18n.T("tui.keys")
+	if s.detail != nil {
+		keys = i18n.T("tui.keys_detail")
+	}
+	b.WriteString("\x1b[7m" + fit(keys, w) + "\x1b[0m\x1b[K\x1b[J")
+	return b.String()
+}
+
+// fit cuts s to w characters.
+func fit(s string, w int) string {
+	if utf8.RuneCountInString(s) <= w {
+		return s
+	}
+	r := []rune(s)
+	if w <= 1 {
+		return string(r[:max(w, 0)])
+	}
+	return string(r[:w-1]) + "…"
+}
@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/catalog"
+	"github.com/janmz/mysqlbackup/internal/retention"
+)
+
+func TestMerge(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC) }
+	dir := t.TempDir()
+	local := []retention.BackupFile{
+		{Path: filepath.Join(dir, "mysql_backup_20261014.zip"), Date: day(14), Size: 100},
+		{Path: filepath.Join(dir, "mysql_backup_20261015.zip"), Date: day(15), Size: 200},
+	}
+	remote := []retention.BackupFile{
+		{Path: "mysql_backup_20261013.zip", Date: day(13), Size: 50},
+		{Path: "mysql_backup_20261015.zip", Date: day(15), Size: 210},
+	}
+	cat := &catalog.Catalog{
+		Backups: []catalog.Entry{{File: "mysql_backup_20261014.zip", Database: "shop"}},
+		Pins:    []catalog.Pin{{File: "mysql_backup_20261013.zip"}},
+	}
+	items := Merge(local, remote, cat)
+	if len(items) != 3 {
+		t.Fatalf("got %d items, want 3", len(items))
+	}
+	want := []struct {
+		name   string
+		local  bool
+		remote bool
+		size   int64
+	}{
+		{"mysql_backup_20261015.zip", true, true, 200},
+		{"mysql_backup_20261014.zip", true, false, 100},
+		{"mysql_backup_20261013.zip", false, true, 50},
+	}
+	for i, w := range want {
+		it := items[i]
+		if it.Name != w.name || (it.Local != "") != w.local || it.Remote != w.remote || it.Size != w.size {
+			t.Errorf("item %d = %+v, want %+v", i, it, w)
+		}
+	}
+	if items[1].Entry == nil || items[1].Entry.Database != "shop" || items[0].Entry != nil {
+		t.Errorf("catalog entries not joined: %+v", items)
+	}
+	if !items[2].Pinned || items[0].Pinned {
+		t.Errorf("pins not joined: %+v", items)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	for in, want := range map[string]struct {
+		key string
+		n   int
+	}{
+		"\x1b[A":    {keyUp, 3},
+		"\x1bOB":    {keyDown, 3},
+		"\x1b[5~":   {keyPageUp, 4},
+		"\x1b[6~x":  {keyPageDown, 4},
+		"\x1b[1;5C": {"", 6},
+		"\x1b":      {keyEsc, 1},
+		"\r":        {keyEnter, 1},
+		"\x03":      {keyCtrlC, 1},
+		"vq":        {"v", 1},
+		"ü":         {"ü", 2},
+	} {
+		if key, n := parseKey([]byte(in)); key != want.key || n != want.n {
+			t.Errorf("parseKey(%q) = %q, %d, want %q, %d", in, key, n, want.key, want.n)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// Package verify test-restores the newest backup of each database into a throwaway MySQL instance and probes
+// the restored tables (--verify-restore, verify_after_backup): untested backups are not backups.
+package verify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/janmz/mysqlbackup/internal/catalog"
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/errcode"
+	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/lock"
+	"github.com/janmz/mysqlbackup/internal/mysql"
+	"github.com/janmz/mysqlbackup/internal/restore"
+	"github.com/janmz/mysqlbackup/internal/retention"
+)
+
+// startTimeout is how long Run waits for the sandbox instance to accept connections (a new docker container
+// initializes its data directory first).
+const startTimeout = 3 * time.Minute
+
+// Result is the outcome of the test restore of one backup ZIP.
+type Result struct {
+	File       string // base name of the ZIP
+	Database   string
+	DumpTables int // tables in the dump
+	Tables     int // tables after the restore
+	Rows       int64
+	Duration   time.Duration
+	Err        error
+}
+
+// Run restores the newest backup of each database in backup_dir into the sandbox instance (docker container from
+// verify_docker_image or the running instance at verify_mysql_host:verify_mysql_port), probes every table with
+// COUNT(*) and CHECKSUM TABLE and drops the database again. It returns one Result per backup and an error if
+// the sandbox could not be used or any backup failed.
+func Run(cfg *config.Config, log restore.Logger) ([]Result, error) {
+	files, err := retention.ListBackups(cfg.BackupDir)
+	if err != nil {
+		return nil, err
+	}
+	files = newestPerSeries(retention.DatabaseBackups(files))
+	if len(files) == 0 {
+		return nil, fmt.Errorf(i18n.T("err.restore_no_backups"))
+	}
+	conn, stop, err := startSandbox(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+	defer conn.Close()
+
+	var results []Result
+	failed := 0
+	for _, f := range files {
+		r := verifyFile(conn, f.Path, log)
+		if r.Err != nil {
+			failed++
+			log.Warn(i18n.Tf("log.warn.verify_failed", r.File, r.Err))
+		} else {
+			log.Info(i18n.Tf("log.msg.verify_ok", r.File, r.Tables, r.Rows, r.Duration.Round(time.Second)))
+		}
+		results = append(results, r)
+	}
+	if failed > 0 {
+		return results, errcode.Wrap(errcode.Verify, fmt.Errorf(i18n.T("err.verify_failed"), failed, len(results)))
+	}
+	return results, nil
+}
+
+// File test-restores the single backup ZIP at path into the sandbox like Run (--tui).
+func File(cfg *config.Config, path string, log restore.Logger) (Result, error) {
+	conn, stop, err := startSandbox(cfg, log)
+	if err != nil {
+		return Result{File: filepath.Base(path)}, err
+	}
+	defer stop()
+	defer conn.Close()
+	r := verifyFile(conn, path, log)
+	if r.Err != nil {
+		log.Warn(i18n.Tf("log.warn.verify_failed", r.File, r.Err))
+		return r, errcode.Wrap(errcode.Verify, r.Err)
+	}
+	log.Info(i18n.Tf("log.msg.verify_ok", r.File, r.Tables, r.Rows, r.Duration.Round(time.Second)))
+	return r, nil
+}
+
+// Record stores the results of test restores in the catalog (see catalog.MarkTested, --history) and saves it;
+// with cat nil (outside a backup run) the catalog of backup_dir is loaded, marked and saved under the run lock,
+// waiting up to lock_wait_minutes, so a running backup does not lose its entries.
+func Record(cfg *config.Config, cat *catalog.Catalog, results []Result, log restore.Logger) {
+	if len(results) == 0 {
+		return
+	}
+	if cat == nil {
+		runLock, err := lock.Acquire(cfg.BackupDir, time.Duration(cfg.LockWaitMinutes)*time.Minute)
+		if err != nil {
+			log.Warn(i18n.Tf("log.warn.catalog_save", err))
+			return
+		}
+		defer runLock.Release()
+		if cat, err = catalog.Load(cfg.BackupDir); err != nil {
+			log.Warn(i18n.Tf("log.warn.catalog_load", err))
+			return
+		}
+	}
+	now := time.Now()
+	for _, r := range results {
+		cat.MarkTested(r.File, r.Err == nil, now)
+	}
+	if err := cat.Save(); err != nil {
+		log.Warn(i18n.Tf("log.warn.catalog_save", err))
+	}
+}
+
+// newestPerSeries returns the newest ZIP of each host/database series (files sorted by date ascending).
+func newestPerSeries(files []retention.BackupFile) []retention.BackupFile {
+	idx := make(map[string]int)
+	var newest []retention.BackupFile
+	for _, f := range files {
+		key := retention.SeriesKey(f.Path)
+		if i, ok := idx[key]; ok {
+			newest[i] = f
+			continue
+		}
+		idx[key] = len(newest)
+		newest = append(newest, f)
+	}
+	return newest
+}
+
+// verifyFile restores one ZIP into the sandbox, probes its tables and drops the database again. A database
+// that already has tables in the sandbox is not touched.
+func verifyFile(conn *mysql.Conn, path string, log restore.Logger) (r Result) {
+	r.File = filepath.Base(path)
+	started := time.Now()
+	defer func() { r.Duration = time.Since(started) }()
+	r.Database, r.Err = restore.FileDatabase(path)
+	if r.Err != nil {
+		return r
+	}
+	if n, err := conn.TableCount(r.Database); err != nil || n > 0 {
+		if err == nil {
+			err = fmt.Errorf(i18n.T("err.verify_db_exists"), r.Database, n)
+		}
+		r.Err = err
+		return r
+	}
+	defer func() {
+		if err := conn.DropDatabase(r.Database); err != nil {
+			log.Warn(i18n.Tf("log.warn.verify_drop", r.Database, err))
+		}
+	}()
+	files := []retention.BackupFile{{Path: path}}
+	if err := restore.RestoreFromZips(conn, files, restore.Options{Fresh: true, DumpTables: &r.DumpTables}, log); err != nil {
+		r.Err = err
+		return r
+	}
+	stats, err := conn.TableStats(r.Database)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	r.Tables = len(stats)
+	var unreadable []string
+	for _, s := range stats {
+		r.Rows += s.Rows
+		if s.Checksum == "" || s.Checksum == "NULL" {
+			unreadable = append(unreadable, s.Name)
+		}
+	}
+	switch {
+	case r.Tables < r.DumpTables:
+		r.Err = fmt.Errorf(i18n.T("err.verify_tables"), r.Tables, r.DumpTables)
+	case len(unreadable) > 0:
+		r.Err = fmt.Errorf(i18n.T("err.verify_checksum"), strings.Join(unreadable, ", "))
+	}
+	return r
+}
+
+// startSandbox returns a connection to the sandbox instance and a function that removes it again: a docker
+// container of verify_docker_image (empty root password, port verify_mysql_port on 127.0.0.1) or the configured
+// running instance, which must not be the backed-up one.
+func startSandbox(cfg *config.Config, log restore.Logger) (*mysql.Conn, func(), error) {
+	conn := &mysql.Conn{
+		Host:    cfg.VerifyMySQLHost,
+		Port:    cfg.VerifyMySQLPort,
+		User:    "root",
+		BinDir:  cfg.MySQLBin,
+		TempDir: cfg.WorkDir,
+	}
+	stop := func() {}
+	image := strings.TrimSpace(cfg.VerifyDockerImage)
+	if image == "" {
+		if sameInstance(cfg) {
+			return nil, nil, fmt.Errorf(i18n.T("err.verify_same_instance"), cfg.VerifyMySQLHost, cfg.VerifyMySQLPort)
+		}
+		conn.Password = cfg.VerifyMySQLPassword
+	} else {
+		conn.Host = "127.0.0.1"
+		name := "mysqlbackup-verify-" + strconv.Itoa(os.Getpid())
+		log.Info(i18n.Tf("log.msg.verify_docker", image, conn.Port))
+		out, err := exec.Command("docker", "run", "-d", "--rm", "--name", name,
+			"-e", "MYSQL_ALLOW_EMPTY_PASSWORD=yes", "-e", "MARIADB_ALLOW_EMPTY_ROOT_PASSWORD=yes",
+			"-p", "127.0.0.1:"+strconv.Itoa(conn.Port)+":3306", image).CombinedOutput()
+		if err != nil {
+			return nil, nil, fmt.Errorf(i18n.T("err.verify_docker"), err, strings.TrimSpace(string(out)))
+		}
+		stop = func() {
+			if out, err := exec.Command("docker", "rm", "-f", name).CombinedOutput(); err != nil {
+				log.Warn(i18n.Tf("log.warn.verify_docker_rm", name, err, strings.TrimSpace(string(out))))
+			}
+		}
+	}
+	deadline := time.Now().Add(startTimeout)
+	for {
+		err := conn.Reachable()
+		if err == nil {
+			return conn, stop, nil
+		}
+		if time.Now().After(deadline) {
+			stop()
+			return nil, nil, fmt.Errorf(i18n.T("err.verify_unreachable"), err)
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// sameInstance reports whether verify_mysql_host:verify_mysql_port is the instance that is backed up.
+func sameInstance(cfg *config.Config) bool {
+	if cfg.VerifyMySQLPort != cfg.MySQLPort {
+		return false
+	}
+	local := func(h string) bool {
+		h = strings.ToLower(strings.TrimSpace(h))
+		return h == "" || h == "localhost" || h == "127.0.0.1" || h == "::1"
+	}
+	a, b := strings.TrimSpace(cfg.VerifyMySQLHost), strings.TrimSpace(cfg.MySQLHost)
+	return strings.EqualFold(a, b) || (local(a) && local(b))
+}
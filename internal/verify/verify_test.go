@@ -0,0 +1,36 @@
+package verify
+
+import (
+	"testing"
+
+	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/retention"
+)
+
+func TestNewestPerSeries(t *testing.T) {
+	files := []retention.BackupFile{
+		{Path: "mysql_backup_20250101_host_shop.zip"},
+		{Path: "mysql_backup_20250101_host_crm.zip"},
+		{Path: "mysql_backup_20250102_host_shop.zip"},
+	}
+	got := newestPerSeries(files)
+	if len(got) != 2 || got[0].Path != "mysql_backup_20250102_host_shop.zip" || got[1].Path != "mysql_backup_20250101_host_crm.zip" {
+		t.Errorf("newestPerSeries = %v", got)
+	}
+}
+
+func TestSameInstance(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.MySQLHost = "localhost"
+	if sameInstance(cfg) {
+		t.Error("default sandbox port 3307 reported as the backed-up instance")
+	}
+	cfg.VerifyMySQLPort = cfg.MySQLPort
+	if !sameInstance(cfg) {
+		t.Error("127.0.0.1 and localhost on the same port not detected")
+	}
+	cfg.VerifyMySQLHost = "sandbox.example.com"
+	if sameInstance(cfg) {
+		t.Error("other host reported as the backed-up instance")
+	}
+}
@@ -0,0 +1,26 @@
+// Package vss creates Volume Shadow Copy snapshots on Windows, so a directory whose files are open and changing
+// (the MySQL data directory) can be copied in a consistent state without stopping the program that writes them.
+// Creating a snapshot needs administrator rights.
+package vss
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupported is returned by Create outside Windows.
+var ErrUnsupported = errors.New("VSS snapshots are only available on Windows")
+
+// Snapshot is a shadow copy of one volume, reachable through a directory link.
+type Snapshot struct {
+	ID     string // Schattenkopie-ID ({…})
+	Volume string // Laufwerk der Schattenkopie, z. B. "C:\"
+	link   string // Verzeichnis-Link auf das Gerät der Schattenkopie
+}
+
+// Path returns where p (a path on the snapshot's volume) is found inside the snapshot.
+func (s *Snapshot) Path(p string) string {
+	rel := strings.TrimPrefix(p[len(filepath.VolumeName(p)):], `\`)
+	return filepath.Join(s.link, rel)
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package vss
+
+// Create returns ErrUnsupported outside Windows.
+func Create(path string) (*Snapshot, error) { return nil, ErrUnsupported }
+
+// Release does nothing outside Windows.
+func (s *Snapshot) Release() error { return nil }
@@ -0,0 +1,65 @@
+//go:build windows
+
+package vss
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Create makes a shadow copy of the local volume holding path (Win32_ShadowCopy, context ClientAccessible) and
+// links it to a directory in the temp directory. The caller must call Release.
+func Create(path string) (*Snapshot, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	volume := filepath.VolumeName(abs)
+	if len(volume) != 2 || volume[1] != ':' {
+		return nil, fmt.Errorf("%s: VSS snapshots need a local drive", path)
+	}
+	volume += `\`
+	script := `$r = Invoke-CimMethod -ClassName Win32_ShadowCopy -MethodName Create -Arguments @{Volume='` + volume + `'; Context='ClientAccessible'}; ` +
+		`if ($r.ReturnValue -ne 0) { throw "Win32_ShadowCopy.Create: $($r.ReturnValue)" }; ` +
+		`$s = Get-CimInstance Win32_ShadowCopy -Filter "ID='$($r.ShadowID)'"; $s.ID; $s.DeviceObject`
+	out, err := powershell(script)
+	if err != nil {
+		return nil, err
+	}
+	// ID und DeviceObject enthalten keine Leerzeichen
+	f := strings.Fields(out)
+	if len(f) < 2 {
+		return nil, fmt.Errorf("Win32_ShadowCopy: unexpected output %q", out)
+	}
+	s := &Snapshot{ID: f[len(f)-2], Volume: volume}
+	link := filepath.Join(os.TempDir(), "mysqlbackup-vss-"+strconv.Itoa(os.Getpid()))
+	_ = os.Remove(link)
+	// mklink braucht den abschließenden Backslash am Gerätepfad, sonst ist der Link kein Verzeichnis
+	if b, err := exec.Command("cmd", "/c", "mklink", "/d", link, f[len(f)-1]+`\`).CombinedOutput(); err != nil {
+		_ = s.Release()
+		return nil, fmt.Errorf("mklink: %v: %s", err, strings.TrimSpace(string(b)))
+	}
+	s.link = link
+	return s, nil
+}
+
+// Release removes the link and deletes the shadow copy.
+func (s *Snapshot) Release() error {
+	if s.link != "" {
+		_ = os.Remove(s.link)
+	}
+	_, err := powershell(`Get-CimInstance Win32_ShadowCopy -Filter "ID='` + s.ID + `'" | Remove-CimInstance`)
+	return err
+}
+
+func powershell(script string) (string, error) {
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
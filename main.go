@@ -5,51 +5,148 @@ package main
 //
 // Donationware für CFI Kinderhilfe. Lizenz: MIT mit Namensnennung.
 //
-// Version: 1.2.0.65 (in version.go zu ändern)
+// Version: 1.3.0.66 (in version.go zu ändern)
 //
 // ChangeLog:
+// 16.10.26	1.3.0	Feature: configurable retention anchors (retain_weekly_day, retain_yearly_date)
 // 11.02.26	1.2.0	Feature: included an way to fully restore a database
 // 09.02.26	1.1.5	Fixed: Quotes for task scheduler arguments corrected
 // 09.02.26	1.1.4	Fixed structure to comply with prepreaBuild
 //
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"runtime"
-	"strconv"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
+	_ "time/tzdata" // Zeitzonen-Datenbank einbetten (timezone), Windows hat keine
 
+	"golang.org/x/term"
+
+	"github.com/janmz/mysqlbackup/internal/api"
+	"github.com/janmz/mysqlbackup/internal/audit"
+	"github.com/janmz/mysqlbackup/internal/catalog"
 	"github.com/janmz/mysqlbackup/internal/config"
+	"github.com/janmz/mysqlbackup/internal/daemon"
+	"github.com/janmz/mysqlbackup/internal/disk"
+	"github.com/janmz/mysqlbackup/internal/errcode"
+	"github.com/janmz/mysqlbackup/internal/fleet"
 	"github.com/janmz/mysqlbackup/internal/i18n"
+	"github.com/janmz/mysqlbackup/internal/keyring"
+	"github.com/janmz/mysqlbackup/internal/lock"
 	"github.com/janmz/mysqlbackup/internal/logger"
 	"github.com/janmz/mysqlbackup/internal/mysql"
+	"github.com/janmz/mysqlbackup/internal/parity"
 	"github.com/janmz/mysqlbackup/internal/remote"
+	"github.com/janmz/mysqlbackup/internal/report"
 	"github.com/janmz/mysqlbackup/internal/restore"
 	"github.com/janmz/mysqlbackup/internal/retention"
 	"github.com/janmz/mysqlbackup/internal/run"
+	"github.com/janmz/mysqlbackup/internal/runreport"
 	"github.com/janmz/mysqlbackup/internal/schedule"
+	"github.com/janmz/mysqlbackup/internal/signing"
+	"github.com/janmz/mysqlbackup/internal/state"
+	"github.com/janmz/mysqlbackup/internal/tui"
+	"github.com/janmz/mysqlbackup/internal/verify"
 )
 
+// colorOutput: farbige Konsolenausgabe (stdout ist ein Terminal, kein --no-color/NO_COLOR).
+var colorOutput bool
+
+// serverName: mit servers der per --server gewählte Server ("" = alle bzw. keiner gewählt).
+var serverName string
+
 func main() {
 	// No Chdir here: ConfigPath must see real cwd so "invoked dir" (e.g. ./mysqlbackup from Elisa/) is resolved correctly; we Chdir to config dir after path is chosen.
 
 	configPath := flag.String("config", "", "Pfad zur JSON-Config (Standard: aktuelles Verz. oder Home)")
 	doVerbose := flag.Bool("v", false, "detaillierte Ausgaben mit [DEBUG], inkl. Exec-Aufrufe und Ausgaben")
 	doVerboseLong := flag.Bool("verbose", false, "")
+	noSchedule := flag.Bool("no-schedule", false, "Zeitplan bei --backup/--status nicht prüfen oder einrichten")
 	doInit := flag.Bool("init", false, "Jobs erstellen (Task Scheduler / systemd-Timer)")
+	initTarget := flag.String("target", "", "Mit --init: statt eines Jobs Container-Dateien erzeugen (docker: Compose-Dienst, kubernetes: CronJob)")
 	doCleanConfig := flag.Bool("cleanconfig", false, "Config-Datei mit Klartextpasswörtern schreiben")
 	doRemove := flag.Bool("remove", false, "Jobs löschen")
 	doStatus := flag.Bool("status", false, "Config prüfen, Backupdateien und Job-Einstellung anzeigen")
 	doBackup := flag.Bool("backup", false, "Backup ausführen (wird von Jobs übergeben)")
+	doResume := flag.Bool("resume", false, "Mit --backup: Datenbanken mit vollständiger ZIP von heute überspringen (nach abgebrochenem Lauf)")
+	doCatchUp := flag.Bool("catchup", false, "Backup nur ausführen, wenn ein geplanter Lauf verpasst wurde (Cron)")
 	doRestore := flag.Bool("restore", false, "Restore aus letztem Backup oder letztem vor optionalem Datum YYYYMMDD")
 	doRestoreFull := flag.Bool("restorefull", false, "Full-Restore: data->data.old, Instanz-backup nach data, dann Import (optional YYYYMMDD)")
+	doRestoreUsers := flag.Bool("restore-users", false, "Nur User und Grants aus dem Backup wiederherstellen (optional YYYYMMDD oder ZIP)")
+	restoreForce := flag.Bool("force", false, "Mit -restore: vorhandene Datenbank nach Eingabe ihres Namens löschen und neu anlegen")
+	restoreTables := flag.String("tables", "", "Mit -restore: nur diese Tabellen wiederherstellen (kommagetrennt)")
+	continueOnError := flag.Bool("continue-on-error", false, "Mit -restore/-restore-users/-restorefull: fehlerhafte Anweisungen überspringen und am Ende auflisten")
+	skipChecks := flag.Bool("skip-checks", false, "Mit -restore/-restore-users/-restorefull: Prüfung von Serverversion, Zeichensatz und Speicherplatz vor dem Import überspringen")
+	restoreCharset := flag.String("charset", "", "Mit -restore/-restore-users/-restorefull: Zeichensatz der Tabellen umstellen (z. B. utf8mb4), überschreibt restore_charset")
+	restoreCollation := flag.String("collation", "", "Mit -restore/-restore-users/-restorefull: Collation der Tabellen umstellen (z. B. utf8mb4_unicode_ci), überschreibt restore_collation")
+	restoreEvents := flag.String("events", "", "Mit -restore/-restorefull: disable (Standard) legt die Events deaktiviert an, keep übernimmt ihren Status aus dem Dump")
+	dryRun := flag.Bool("dry-run", false, "Mit -restore/-restore-users: SQL nur prüfen (abgeschnittene Datei, unzulässige Anweisungen, CREATE DATABASE), nichts an MySQL senden")
+	fromRemote := flag.String("from-remote", "", "Mit -restore/-restore-users: Backup-ZIPs (Name oder Wildcards) direkt vom Remote-Ziel einspielen")
+	doVerifyRestore := flag.Bool("verify-restore", false, "Jüngstes Backup jeder Datenbank testweise in eine Wegwerf-Instanz einspielen und prüfen")
+	doVerify := flag.Bool("verify", false, "Backup-ZIPs mit Prüfsumme, Paritätsdateien und Signatur prüfen, beschädigte aus den Paritätsdaten reparieren (optional YYYYMMDD oder ZIP)")
+	doPhysical := flag.Bool("physical-backup", false, "Datenverzeichnis der Instanz nach physical_backup_dir kopieren (Windows: aus VSS-Snapshot bei laufendem Server)")
 	getFile := flag.String("getfile", "", "Datei von Remote laden (ZIP-Backup-Dateiname)")
+	inspectFile := flag.String("inspect", "", "Inhalt einer Backup-ZIP anzeigen (Einträge, Datenbanken, Tabellen, User/Grants)")
+	diffFile := flag.String("diff", "", "Zwei Backups derselben Datenbank vergleichen: --diff <zipA> <zipB> (Struktur, Tabellen, ungefähre Zeilenzahlen)")
+	pinFile := flag.String("pin", "", "Backup-Datei vor Retention und Remote-Löschung schützen")
+	unpinFile := flag.String("unpin", "", "Schutz einer Backup-Datei aufheben")
+	keyringSet := flag.String("keyring-set", "", "Secret (z. B. AES-Schlüssel) im Schlüsselspeicher des Systems ablegen, Verweis keyring://<name> in der Config")
+	doHistory := flag.Bool("history", false, "Backup-Verlauf je Datenbank aus dem Katalog ausgeben (optional nur eine Datenbank)")
+	historyFormat := flag.String("format", "", "Mit --history: Ausgabe als text, csv oder json")
+	doPrintConfig := flag.Bool("print-config", false, "Wirksame Konfiguration (Standardwerte + Datei + Flags) ohne Passwörter ausgeben")
+	doDaemon := flag.Bool("daemon", false, "Im Vordergrund laufen und Backups nach Zeitplan ausführen (Dienst/Container); Config-Änderungen ohne Neustart")
+	doTUI := flag.Bool("tui", false, "Interaktive Übersicht der lokalen und Remote-Backups: Details, Prüfen, Laden, Restore")
+	doExampleConfig := flag.Bool("example-config", false, "Config-Vorlage mit allen Schlüsseln und Standardwerten ausgeben (optional in Datei)")
+	noColor := flag.Bool("no-color", false, "keine farbigen Ausgaben (auch über NO_COLOR)")
+	server := flag.String("server", "", "Mit servers: nur diesen Server bearbeiten")
 	flag.Usage = printUsage
 	flag.Parse()
+	serverName = strings.TrimSpace(*server)
 	verbose := *doVerbose || *doVerboseLong
+	colorOutput = !*noColor && logger.ColorSupported()
+
+	// Zieldatei von --example-config relativ zum Aufrufverzeichnis, also vor dem Chdir auflösen
+	exampleOut := ""
+	if *doExampleConfig && flag.NArg() == 1 {
+		exampleOut, _ = filepath.Abs(flag.Arg(0))
+	}
+	// ZIP von --restore/--verify ebenso, falls relativ zum Aufrufverzeichnis vorhanden (sonst Dateiname in backup_dir)
+	restoreArg := ""
+	if (*doRestore || *doRestoreFull || *doRestoreUsers || *doVerify) && flag.NArg() == 1 {
+		restoreArg = strings.TrimSpace(flag.Arg(0))
+		if isZipArg(restoreArg) {
+			if _, err := os.Stat(restoreArg); err == nil {
+				restoreArg, _ = filepath.Abs(restoreArg)
+			}
+		}
+	}
+
+	if *inspectFile != "" {
+		if _, err := os.Stat(*inspectFile); err == nil {
+			*inspectFile, _ = filepath.Abs(*inspectFile)
+		}
+	}
+	// --diff <zipA> <zipB>: die zweite ZIP ist das erste freie Argument
+	var diffArgs []string
+	if *diffFile != "" {
+		diffArgs = append([]string{*diffFile}, flag.Args()...)
+		for i, a := range diffArgs {
+			if _, err := os.Stat(a); err == nil {
+				diffArgs[i], _ = filepath.Abs(a)
+			}
+		}
+	}
 
 	invokedDir := invokedDirectory()
 	path := config.ConfigPath(*configPath, invokedDir)
@@ -79,15 +176,60 @@ func main() {
 	if *doBackup {
 		n++
 	}
+	if *doCatchUp {
+		n++
+	}
 	if *doRestore {
 		n++
 	}
 	if *doRestoreFull {
 		n++
 	}
+	if *doRestoreUsers {
+		n++
+	}
+	if *doVerifyRestore {
+		n++
+	}
+	if *doVerify {
+		n++
+	}
+	if *doPhysical {
+		n++
+	}
 	if *getFile != "" {
 		n++
 	}
+	if *inspectFile != "" {
+		n++
+	}
+	if *diffFile != "" {
+		n++
+	}
+	if *pinFile != "" {
+		n++
+	}
+	if *unpinFile != "" {
+		n++
+	}
+	if *keyringSet != "" {
+		n++
+	}
+	if *doHistory {
+		n++
+	}
+	if *doPrintConfig {
+		n++
+	}
+	if *doExampleConfig {
+		n++
+	}
+	if *doDaemon {
+		n++
+	}
+	if *doTUI {
+		n++
+	}
 	args := flag.Args()
 	if len(args) > 1 {
 		printStartupHeader(path)
@@ -95,21 +237,83 @@ func main() {
 		fmt.Fprintln(os.Stderr, i18n.T("error.restore_too_many_args"))
 		os.Exit(1)
 	}
-	dateArg := ""
-	if len(args) == 1 {
-		if !*doRestore && !*doRestoreFull {
-			printStartupHeader(path)
-			printUsage()
-			fmt.Fprintln(os.Stderr, i18n.T("error.restoredate_requires_restore"))
-			os.Exit(1)
-		}
-		dateArg = strings.TrimSpace(args[0])
+	if len(args) == 1 && !*doRestore && !*doRestoreFull && !*doRestoreUsers && !*doVerify && !*doExampleConfig && *diffFile == "" && !*doHistory {
+		printStartupHeader(path)
+		printUsage()
+		fmt.Fprintln(os.Stderr, i18n.T("error.restoredate_requires_restore"))
+		os.Exit(1)
 	}
 	if n == 0 {
 		printStartupHeader(path)
 		printUsage()
 		os.Exit(0)
 	}
+	if *restoreForce && (!*doRestore || *restoreTables != "") {
+		printStartupHeader(path)
+		printUsage()
+		fmt.Fprintln(os.Stderr, i18n.T("error.force_requires_restore"))
+		os.Exit(1)
+	}
+	if *restoreTables != "" && !*doRestore {
+		printStartupHeader(path)
+		printUsage()
+		fmt.Fprintln(os.Stderr, i18n.T("error.tables_requires_restore"))
+		os.Exit(1)
+	}
+	if *historyFormat != "" && (!*doHistory || !slices.Contains(report.HistoryFormats, *historyFormat)) {
+		printStartupHeader(path)
+		printUsage()
+		fmt.Fprintln(os.Stderr, i18n.T("error.format_requires_history"))
+		os.Exit(1)
+	}
+	if *initTarget != "" && (!*doInit || !slices.Contains(schedule.ContainerTargets, *initTarget)) {
+		printStartupHeader(path)
+		printUsage()
+		fmt.Fprintln(os.Stderr, i18n.T("error.target_requires_init"))
+		os.Exit(1)
+	}
+	if *doResume && !*doBackup {
+		printStartupHeader(path)
+		printUsage()
+		fmt.Fprintln(os.Stderr, i18n.T("error.resume_requires_backup"))
+		os.Exit(1)
+	}
+	if *continueOnError && !*doRestore && !*doRestoreUsers && !*doRestoreFull {
+		printStartupHeader(path)
+		printUsage()
+		fmt.Fprintln(os.Stderr, i18n.T("error.continue_requires_restore"))
+		os.Exit(1)
+	}
+	if *skipChecks && !*doRestore && !*doRestoreUsers && !*doRestoreFull {
+		printStartupHeader(path)
+		printUsage()
+		fmt.Fprintln(os.Stderr, i18n.T("error.skip_checks_requires_restore"))
+		os.Exit(1)
+	}
+	if (*restoreCharset != "" || *restoreCollation != "") && !*doRestore && !*doRestoreUsers && !*doRestoreFull {
+		printStartupHeader(path)
+		printUsage()
+		fmt.Fprintln(os.Stderr, i18n.T("error.charset_requires_restore"))
+		os.Exit(1)
+	}
+	if *restoreEvents != "" && ((!*doRestore && !*doRestoreFull) || !slices.Contains(restore.EventModes, *restoreEvents)) {
+		printStartupHeader(path)
+		printUsage()
+		fmt.Fprintln(os.Stderr, i18n.Tf("error.events_mode", strings.Join(restore.EventModes, ", ")))
+		os.Exit(1)
+	}
+	if *dryRun && !*doRestore && !*doRestoreUsers {
+		printStartupHeader(path)
+		printUsage()
+		fmt.Fprintln(os.Stderr, i18n.T("error.dry_run_requires_restore"))
+		os.Exit(1)
+	}
+	if *fromRemote != "" && (!(*doRestore || *doRestoreUsers) || len(args) > 0) {
+		printStartupHeader(path)
+		printUsage()
+		fmt.Fprintln(os.Stderr, i18n.T("error.from_remote_requires_restore"))
+		os.Exit(1)
+	}
 	if n > 1 {
 		printStartupHeader(path)
 		printUsage()
@@ -119,7 +323,7 @@ func main() {
 
 	switch {
 	case *doInit:
-		runInit(path, verbose)
+		runInit(path, *initTarget, verbose)
 		return
 	case *doCleanConfig:
 		runCleanConfig(path, verbose)
@@ -128,20 +332,72 @@ func main() {
 		runRemove(path, verbose)
 		return
 	case *doStatus:
-		runStatus(path, verbose)
+		runStatus(path, verbose, *noSchedule)
 		return
 	case *doBackup:
-		runBackup(path, verbose)
+		runBackup(path, verbose, *noSchedule, run.Options{Resume: *doResume}, nil)
+		return
+	case *doCatchUp:
+		runCatchUp(path, verbose, *noSchedule)
 		return
 	case *doRestore:
-		runRestore(path, dateArg, false, verbose)
+		opt := restore.Options{Tables: splitList(*restoreTables), Force: *restoreForce, ContinueOnError: *continueOnError, SkipChecks: *skipChecks,
+			Charset: *restoreCharset, Collation: *restoreCollation, DryRun: *dryRun, Events: *restoreEvents}
+		if opt.Force && !opt.DryRun {
+			opt.Confirm = confirmDrop(bufio.NewReader(os.Stdin))
+		}
+		runRestore(path, restoreArg, *fromRemote, false, opt, verbose)
 		return
 	case *doRestoreFull:
-		runRestore(path, dateArg, true, verbose)
+		runRestore(path, restoreArg, "", true, restore.Options{Fresh: true, ContinueOnError: *continueOnError, SkipChecks: *skipChecks,
+			Charset: *restoreCharset, Collation: *restoreCollation, Events: *restoreEvents}, verbose)
+		return
+	case *doRestoreUsers:
+		runRestore(path, restoreArg, *fromRemote, false, restore.Options{UsersOnly: true, ContinueOnError: *continueOnError, SkipChecks: *skipChecks,
+			Charset: *restoreCharset, Collation: *restoreCollation, DryRun: *dryRun}, verbose)
+		return
+	case *doVerifyRestore:
+		runVerifyRestore(path, verbose)
+		return
+	case *doVerify:
+		runVerify(path, restoreArg, verbose)
+		return
+	case *doPhysical:
+		runPhysicalBackup(path, verbose)
 		return
 	case *getFile != "":
 		runGetfile(path, *getFile, verbose)
 		return
+	case *inspectFile != "":
+		runInspect(path, *inspectFile, verbose)
+		return
+	case *diffFile != "":
+		runDiff(path, diffArgs, verbose)
+		return
+	case *pinFile != "":
+		runPin(path, *pinFile, true, verbose)
+		return
+	case *unpinFile != "":
+		runPin(path, *unpinFile, false, verbose)
+		return
+	case *keyringSet != "":
+		runKeyringSet(path, *keyringSet)
+		return
+	case *doHistory:
+		runHistory(path, strings.TrimSpace(flag.Arg(0)), *historyFormat)
+		return
+	case *doPrintConfig:
+		runPrintConfig(path, verbose, *noSchedule)
+		return
+	case *doExampleConfig:
+		runExampleConfig(exampleOut)
+		return
+	case *doDaemon:
+		runDaemon(path, verbose)
+		return
+	case *doTUI:
+		runTUI(path, verbose)
+		return
 	}
 }
 
@@ -176,6 +432,8 @@ func printUsage() {
 	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.verbose_desc"))
 	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.init"))
 	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.init_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.init_target"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.init_target_desc"))
 	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.cleanconfig"))
 	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.cleanconfig_desc"))
 	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.remove"))
@@ -184,33 +442,134 @@ func printUsage() {
 	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.status_desc"))
 	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.backup"))
 	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.backup_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.resume"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.resume_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.daemon"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.daemon_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.catchup"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.catchup_desc"))
 	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.restore"))
 	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.restore_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.tables"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.tables_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.force"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.force_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.continue_on_error"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.continue_on_error_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.skip_checks"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.skip_checks_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.dry_run"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.dry_run_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.charset"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.charset_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.events"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.events_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.from_remote"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.from_remote_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.restore_users"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.restore_users_desc"))
 	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.restorefull"))
 	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.restorefull_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.verify_restore"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.verify_restore_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.verify"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.verify_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.physical_backup"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.physical_backup_desc"))
 	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.getfile"))
 	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.getfile_desc"))
 	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.getfile_wildcards"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.inspect"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.inspect_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.diff"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.diff_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.tui"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.tui_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.pin"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.pin_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.unpin"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.unpin_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.keyring_set"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.keyring_set_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.history"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.history_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.print_config"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.print_config_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.example_config"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.example_config_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.server"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.server_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.no_schedule"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.no_schedule_desc"))
+	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.no_color"))
+	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.no_color_desc"))
 	fmt.Fprintf(os.Stderr, "  %s\n", i18n.T("usage.help"))
 	fmt.Fprintf(os.Stderr, "      %s\n", i18n.T("usage.help_desc"))
 }
 
 func loadConfigAndLog(path string, verbose bool) (*config.Config, *logger.Logger, error) {
-	cfg, err := config.Load(path, false)
-	if err != nil {
-		return nil, nil, err
+	return loadConfigAndLogFile(path, verbose, false, false)
+}
+
+// loadConfigAndRunLog is loadConfigAndLog for backup runs: with log_per_run each run gets its own
+// timestamped log file, and per-run logs older than log_retain_days are deleted.
+func loadConfigAndRunLog(path string, verbose bool) (*config.Config, *logger.Logger, error) {
+	return loadConfigAndLogFile(path, verbose, true, true)
+}
+
+// selectServer returns the server chosen with --server, otherwise cfg. Commands about one backup_dir (all = false)
+// need --server when servers are configured; the others (all = true) handle every server of cfg themselves.
+func selectServer(cfg *config.Config, all bool) (*config.Config, error) {
+	if serverName != "" {
+		return cfg.Server(serverName)
 	}
-	logPath := cfg.LogFilename
-	if logPath == "" {
-		if exe, err := os.Executable(); err == nil {
-			if exeDir := filepath.Dir(exe); exeDir != "" {
-				logPath = filepath.Join(exeDir, "mysqlbackup.log")
-			}
+	if servers := cfg.ServerConfigs(); len(servers) > 0 && !all {
+		names := make([]string, len(servers))
+		for i, s := range servers {
+			names[i] = s.ServerName()
 		}
-		if logPath == "" {
-			logPath = filepath.Join(cfg.BackupDir, "mysqlbackup.log")
+		return nil, fmt.Errorf(i18n.T("err.server_required"), strings.Join(names, ", "))
+	}
+	return cfg, nil
+}
+
+// backupTargets returns the configs a backup run processes: every server of cfg, otherwise cfg itself.
+func backupTargets(cfg *config.Config) []*config.Config {
+	if servers := cfg.ServerConfigs(); len(servers) > 0 {
+		return servers
+	}
+	return []*config.Config{cfg}
+}
+
+// logFilePath returns log_filename or, if empty, mysqlbackup.log next to the executable (fallback: backup_dir).
+func logFilePath(cfg *config.Config) string {
+	if cfg.LogFilename != "" {
+		return cfg.LogFilename
+	}
+	if exe, err := os.Executable(); err == nil {
+		if exeDir := filepath.Dir(exe); exeDir != "" {
+			return filepath.Join(exeDir, "mysqlbackup.log")
 		}
 	}
+	return filepath.Join(cfg.BackupDir, "mysqlbackup.log")
+}
+
+// loadConfigAndLogFile loads the config, selects the server of --server (see selectServer) and opens the log with
+// the settings of the main config.
+func loadConfigAndLogFile(path string, verbose, backupRun, all bool) (*config.Config, *logger.Logger, error) {
+	mainCfg, err := config.Load(path, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg, err := selectServer(mainCfg, all)
+	if err != nil {
+		return nil, nil, err
+	}
+	logPath := logFilePath(mainCfg)
+	mainLog := logPath
+	if backupRun && mainCfg.LogPerRun {
+		logPath = logger.RunLogPath(mainLog, time.Now())
+	}
 	log, err := logger.New(logPath)
 	if err != nil {
 		return nil, nil, err
@@ -218,11 +577,66 @@ func loadConfigAndLog(path string, verbose bool) (*config.Config, *logger.Logger
 	if absLog, err := filepath.Abs(logPath); err == nil {
 		fmt.Fprintln(os.Stderr, i18n.Tf("section.log_file", absLog))
 	}
-	log.Verbose = verbose
+	configureLog(log, mainCfg, verbose, backupRun)
 	logStartup(log)
+	for _, c := range mainCfg.Migrations() {
+		log.Info(i18n.Tf("log.msg.config_migrated", mainCfg.Version, c))
+		audit.Note(mainCfg.AuditFile, log, audit.ConfigMigration, path, c)
+	}
+	if backupRun && mainCfg.LogPerRun && mainCfg.LogRetainDays > 0 {
+		removed, err := logger.PruneRunLogs(mainLog, time.Now().AddDate(0, 0, -mainCfg.LogRetainDays))
+		for _, f := range removed {
+			log.Info(i18n.Tf("log.msg.deleted_run_log", filepath.Base(f)))
+		}
+		if err != nil {
+			log.Warn(i18n.Tf("log.warn.run_log_prune", err))
+		}
+	}
 	return cfg, log, nil
 }
 
+// configureLog applies log_format, log_journald and the per-sink levels (log_level_console, _file, _syslog).
+// Under systemd the journal replaces stdout unless log_level_console is set; -v sets console and file to debug.
+// Backup runs without a terminal (Task Scheduler, cron) do not echo to stdout unless log_level_console is set.
+func configureLog(log *logger.Logger, cfg *config.Config, verbose, backupRun bool) {
+	level := func(s string, def logger.Level) logger.Level {
+		if strings.TrimSpace(s) == "" {
+			return def
+		}
+		lvl, _ := logger.ParseLevel(s) // bereits von config.Validate geprüft
+		return lvl
+	}
+	log.JSON = cfg.JSONLog()
+	log.SetSecrets(cfg.Secrets()...)
+	syslogLevel := level(cfg.LogLevelSyslog, logger.LevelInfo)
+	journal, system := false, false
+	if syslogLevel != logger.LevelOff {
+		journal = cfg.LogJournald && log.UseJournal()
+		// Lokaler syslog nur auf ausdrücklichen Wunsch (log_level_syslog gesetzt)
+		system = journal || (strings.TrimSpace(cfg.LogLevelSyslog) != "" && log.UseSyslog())
+	}
+	consoleDefault := logger.LevelInfo
+	if journal {
+		consoleDefault = logger.LevelOff // stdout des Dienstes landet ohnehin im Journal
+	} else if backupRun && !logger.Interactive() {
+		consoleDefault = logger.LevelOff // geplanter Lauf: Ausgabe liest niemand, das Log genügt
+	}
+	console := level(cfg.LogLevelConsole, consoleDefault)
+	file := level(cfg.LogLevelFile, logger.LevelInfo)
+	if verbose {
+		if console != logger.LevelOff {
+			console = logger.LevelDebug
+		}
+		file = logger.LevelDebug
+	}
+	log.SetLevel(logger.SinkConsole, console)
+	log.SetLevel(logger.SinkFile, file)
+	log.SetLevel(logger.SinkSyslog, syslogLevel)
+	log.SetColor(colorOutput)
+	log.SetRecent(cfg.MailLogKB * 1024)
+	i18n.RecordMessages(log.JSON || system)
+}
+
 // logStartup schreibt Aufrufpfad, Versionsnummer und Aufrufparameter ins Log (beim Start).
 func logStartup(log *logger.Logger) {
 	exe, err := os.Executable()
@@ -252,15 +666,25 @@ func printStartupHeader(configPath string) {
 	}
 }
 
-func runInit(path string, verbose bool) {
+// runInit installs the schedule on this host, or with target (--target docker/kubernetes) writes the files for a
+// containerized deployment next to the config instead.
+func runInit(path, target string, verbose bool) {
 	printStartupHeader(path)
-	cfg, log, err := loadConfigAndLog(path, verbose)
+	cfg, log, err := loadConfigAndLogFile(path, verbose, false, true)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, i18n.T("error.config")+"\n", err)
 		os.Exit(1)
 	}
 	defer log.Close()
-	if err := schedule.EnsureInstalled(cfg, path, log); err != nil {
+	if target != "" {
+		if err := schedule.WriteContainer(cfg, path, target, log); err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("error.init")+"\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(i18n.Tf("msg.container_created", target, filepath.Dir(path)))
+		return
+	}
+	if _, err := schedule.EnsureInstalled(cfg, path, log); err != nil {
 		fmt.Fprintf(os.Stderr, i18n.T("error.init")+"\n", err)
 		os.Exit(1)
 	}
@@ -276,66 +700,299 @@ func runCleanConfig(path string, verbose bool) {
 		fmt.Fprintf(os.Stderr, i18n.T("error.cleanconfig")+"\n", err)
 		os.Exit(1)
 	}
+	if cfg, err := config.Load(path, false); err == nil {
+		if err := audit.Record(cfg.AuditFile, audit.ConfigClean, path, ""); err != nil {
+			fmt.Fprintln(os.Stderr, i18n.Tf("log.warn.audit", cfg.AuditFile, err))
+		}
+	}
 	fmt.Println(i18n.Tf("msg.cleanconfig_done", path))
 }
 
 func runRemove(path string, verbose bool) {
 	printStartupHeader(path)
 	var log *logger.Logger
-	if cfg, err := config.Load(path, false); err == nil {
+	cfg, err := config.Load(path, false)
+	if err == nil {
 		logPath := cfg.LogFilename
 		if logPath == "" {
 			logPath = filepath.Join(cfg.BackupDir, "mysqlbackup.log")
 		}
-		log, _ = logger.New(logPath)
+		if log, _ = logger.New(logPath); log != nil {
+			configureLog(log, cfg, verbose, false)
+		}
 	}
 	if log == nil {
-		log, _ = logger.New("mysqlbackup.log")
+		if log, _ = logger.New("mysqlbackup.log"); log != nil && verbose {
+			log.SetLevel(logger.SinkConsole, logger.LevelDebug)
+			log.SetLevel(logger.SinkFile, logger.LevelDebug)
+		}
 	}
 	if log != nil {
-		log.Verbose = verbose
 		logStartup(log)
 		defer log.Close()
 	}
-	if err := schedule.Uninstall(log); err != nil {
+	if err := schedule.Uninstall(cfg, path, log); err != nil {
 		fmt.Fprintf(os.Stderr, i18n.T("error.remove")+"\n", err)
 		os.Exit(1)
 	}
+	if cfg != nil && log != nil {
+		audit.Note(cfg.AuditFile, log, audit.ScheduleRemove, path, "")
+	}
 	fmt.Println(i18n.T("msg.jobs_removed"))
 }
 
-func runStatus(path string, verbose bool) {
+// runPrintConfig gibt die wirksame Konfiguration als JSON auf stdout aus: Standardwerte, Config-Datei (mit aufgelösten
+// Secret-Verweisen und normalisierten Pfaden) und Flags (--no-schedule, -v); Passwörter maskiert. Leere Werte, für die
+// das Programm selbst einen Wert bestimmt (log_filename), werden mit diesem ausgegeben.
+func runPrintConfig(path string, verbose, noSchedule bool) {
+	cfg, err := config.Load(path, false)
+	if err == nil {
+		cfg, err = selectServer(cfg, true)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.config")+"\n", err)
+		os.Exit(1)
+	}
+	cfg.AutoSchedule = autoSchedule(cfg, noSchedule)
+	cfg.LogFilename = logFilePath(cfg)
+	if verbose {
+		cfg.LogLevelConsole, cfg.LogLevelFile = "debug", "debug"
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	fmt.Fprintln(os.Stderr, i18n.Tf("section.config_file", path))
+	out, err := json.MarshalIndent(cfg.Masked(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.config")+"\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+}
+
+// runExampleConfig schreibt die Config-Vorlage (alle Schlüssel mit Standardwerten) auf stdout oder in die Datei out;
+// eine vorhandene Datei wird nicht überschrieben.
+func runExampleConfig(out string) {
+	data, err := config.ExampleJSON()
+	if err == nil && out != "" {
+		var f *os.File
+		if f, err = os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600); err == nil {
+			_, err = f.Write(data)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if err == nil {
+			fmt.Fprintln(os.Stderr, i18n.Tf("msg.example_config_written", out))
+			return
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.example_config")+"\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(string(data))
+}
+
+// printServers prints the overview of --status with servers: per server MySQL, backup_dir, last success or
+// error and next scheduled run (details with --server).
+func printServers(servers []*config.Config) {
+	fmt.Println(logger.Heading(i18n.T("section.servers"), colorOutput))
+	for _, s := range servers {
+		fmt.Println(i18n.Tf("status.server", s.ServerName(), s.MySQLHost, s.MySQLPort, s.BackupDir))
+		if st, err := state.Load(s.BackupDir); err == nil {
+			if !st.LastSuccess.IsZero() {
+				fmt.Println("  " + i18n.Tf("status.last_success", st.LastSuccess.Local().Format("2006-01-02 15:04")))
+			}
+			if st.LastError != "" {
+				fmt.Println("  " + i18n.Tf("status.last_error", st.LastStart.Local().Format("2006-01-02 15:04"), st.LastError))
+			}
+		}
+		if spec, err := s.ScheduleSpec(); err == nil {
+			if next := spec.Next(time.Now()); !next.IsZero() {
+				fmt.Println("  " + i18n.Tf("status.next_run", next.Local().Format("2006-01-02 15:04")))
+			}
+		}
+	}
+	fmt.Println()
+	fmt.Println(i18n.T("status.server_hint"))
+}
+
+// printAgents prints the agents of the controller (controller_dir) for --status: last contact and result of the
+// last reported run.
+func printAgents(dir string) {
+	fmt.Println(logger.Heading(i18n.T("section.agents"), colorOutput))
+	agents, err := fleet.Agents(dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.Tf("status.agents_error", err))
+		return
+	}
+	if len(agents) == 0 {
+		fmt.Println(i18n.T("status.no_agents"))
+		return
+	}
+	for _, a := range agents {
+		fmt.Println(i18n.Tf("status.agent", a.Name, a.Address, a.LastSeen.Local().Format("2006-01-02 15:04")))
+		if rep := a.LastRun; rep != nil {
+			fmt.Println("  " + i18n.Tf("status.agent_run", rep.Started.Local().Format("2006-01-02 15:04"),
+				i18n.T("status.run_"+rep.Status), len(rep.Databases), report.FormatSize(rep.TotalSize())))
+			if rep.Error != "" {
+				fmt.Println("  " + errcode.Tag(errcode.Code(rep.Code), rep.Error))
+			}
+		}
+	}
+}
+
+// typicalFinish returns the status line with the time a run starting at start typically finishes its dumps,
+// from the typical durations of the databases of the newest backup day; empty without history.
+func typicalFinish(cfg *config.Config, start time.Time) string {
+	cat, err := catalog.Load(cfg.BackupDir)
+	if err != nil {
+		return ""
+	}
+	d, _ := cat.Estimate(cat.LastDayDatabases())
+	if d <= 0 {
+		return ""
+	}
+	start, end := start.Local(), start.Add(d).Local()
+	format := "15:04"
+	if end.YearDay() != start.YearDay() || end.Year() != start.Year() {
+		format = "2006-01-02 15:04"
+	}
+	unit := time.Minute
+	if d < time.Minute {
+		unit = time.Second
+	}
+	return i18n.Tf("status.typical_finish", end.Format(format), d.Round(unit))
+}
+
+// printRunReport prints the report of the last run (last_run.json) for --status: result, failed step,
+// warnings and remote sync.
+func printRunReport(rep *runreport.Report) {
+	duration := time.Duration(rep.Duration * float64(time.Second)).Round(time.Second)
+	fmt.Println(i18n.Tf("status.last_report", rep.Started.Local().Format("2006-01-02 15:04"), i18n.T("status.run_"+rep.Status),
+		duration, len(rep.Databases), report.FormatSize(rep.TotalSize())))
+	for _, s := range rep.Steps {
+		if s.Status == runreport.StatusFailure {
+			fmt.Println(i18n.Tf("status.last_report_failed", i18n.T("email.step."+s.Name), errcode.Tag(errcode.Code(s.Code), s.Detail)))
+		}
+	}
+	if len(rep.Warnings) > 0 {
+		fmt.Println(i18n.Tf("status.last_report_warnings", len(rep.Warnings)))
+	}
+	if len(rep.Pruned) > 0 {
+		fmt.Println(i18n.Tf("status.last_report_pruned", len(rep.Pruned)))
+	}
+	if rep.Remote.Configured && rep.Remote.OK {
+		fmt.Println(i18n.Tf("status.last_report_remote", rep.Remote.Uploaded))
+	}
+}
+
+func runStatus(path string, verbose, noSchedule bool) {
 	printStartupHeader(path)
-	cfg, log, err := loadConfigAndLog(path, verbose)
+	cfg, log, err := loadConfigAndLogFile(path, verbose, false, true)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, i18n.T("error.config")+"\n", err)
 		os.Exit(1)
 	}
 	defer log.Close()
-	if runtime.GOOS == "windows" || runtime.GOOS == "linux" {
-		if err := schedule.EnsureInstalled(cfg, path, log); err != nil {
+	if cfg.ServerName() == "" && autoSchedule(cfg, noSchedule) && schedule.Supported() {
+		repaired, err := schedule.EnsureInstalled(cfg, path, log)
+		if err != nil {
 			log.Warn(i18n.Tf("log.warn.schedule_ensure", err))
 		}
+		run.CheckSchedule(cfg, backupTargets(cfg), repaired, log)
+	}
+	if cfg.ControllerDir != "" {
+		printAgents(cfg.ControllerDir)
+		fmt.Println()
 	}
-	fmt.Println(i18n.T("section.config"))
+	if servers := cfg.ServerConfigs(); len(servers) > 0 {
+		printServers(servers)
+		return
+	}
+	fmt.Println(logger.Heading(i18n.T("section.config"), colorOutput))
 	fmt.Println(i18n.Tf("section.config_file", path))
 	fmt.Println(i18n.Tf("section.mysql", cfg.MySQLHost, cfg.MySQLPort))
 	fmt.Println(i18n.Tf("section.backup_dir", cfg.BackupDir))
 	fmt.Println(i18n.Tf("section.retention", cfg.RetainDaily, cfg.RetainWeekly, cfg.RetainMonthly, cfg.RetainYearly))
-	fmt.Println(i18n.Tf("section.start_time", cfg.StartTime))
+	policy := retention.PolicyFromConfig(cfg)
+	fmt.Println(i18n.Tf("section.retention_anchors", i18n.T("weekday."+strings.ToLower(policy.WeeklyDay.String())), fmt.Sprintf("%02d.%02d", policy.YearlyDay, int(policy.YearlyMonth))))
+	if cfg.Schedule != "" {
+		fmt.Println(i18n.Tf("section.schedule", cfg.Schedule))
+	} else {
+		fmt.Println(i18n.Tf("section.start_time", cfg.StartTime))
+	}
+	if cfg.Timezone != "" {
+		fmt.Println(i18n.Tf("section.timezone", cfg.Timezone, cfg.Now().Format("2006-01-02 15:04 MST")))
+	}
+	if cfg.ArchiveDir != "" {
+		fmt.Println(i18n.Tf("section.archive", cfg.ArchiveDir, cfg.ArchiveRetainDays))
+	}
 	if cfg.RemoteBackupDir != "" && cfg.RemoteSSHHost != "" {
 		fmt.Println(i18n.Tf("section.remote", cfg.RemoteBackupDir, cfg.RemoteSSHHost))
 	}
+	if cfg.ControllerURL != "" {
+		fmt.Println(i18n.Tf("section.controller", cfg.ControllerURL, cfg.AgentID()))
+	}
 	fmt.Println()
-	fmt.Println(i18n.T("section.job"))
+	fmt.Println(logger.Heading(i18n.T("section.job"), colorOutput))
 	if key, args := schedule.Status(cfg, path); key != "" {
 		fmt.Println(i18n.Tf(key, args...))
+		info := schedule.Info(cfg, path)
+		if !info.Next.IsZero() {
+			fmt.Println(i18n.Tf("status.next_run", info.Next.Local().Format("2006-01-02 15:04")))
+			if line := typicalFinish(cfg, info.Next); line != "" {
+				fmt.Println(line)
+			}
+		}
+		if !info.LastRun.IsZero() {
+			fmt.Println(i18n.Tf("status.last_run", info.LastRun.Local().Format("2006-01-02 15:04"), info.LastResult))
+		}
 	} else {
 		fmt.Println(i18n.T("msg.no_job"))
 	}
+	// Letzter Lauf aus last_run.json; state.json nur noch für den letzten Erfolg (und ältere Installationen)
+	rep, err := runreport.Load(cfg.BackupDir)
+	if err != nil {
+		log.Warn(i18n.Tf("log.warn.run_report", err))
+	}
+	if st, err := state.Load(cfg.BackupDir); err == nil {
+		if !st.LastSuccess.IsZero() {
+			fmt.Println(i18n.Tf("status.last_success", st.LastSuccess.Local().Format("2006-01-02 15:04")))
+		}
+		if st.LastError != "" && rep == nil {
+			fmt.Println(i18n.Tf("status.last_error", st.LastStart.Local().Format("2006-01-02 15:04"), st.LastError))
+		}
+	}
+	if rep != nil {
+		printRunReport(rep)
+	}
 	fmt.Println()
-	fmt.Println(i18n.T("section.backups"))
-	files, err := retention.ListBackups(cfg.BackupDir)
+	fmt.Println(logger.Heading(i18n.T("section.volumes"), colorOutput))
+	printVolumes(cfg)
+	fmt.Println()
+	fmt.Println(logger.Heading(i18n.T("section.backups"), colorOutput))
+	// Backups aus dem Katalog (abgeglichen mit backup_dir); ohne lesbaren Katalog: Verzeichnis scannen
+	var files []retention.BackupFile
+	pinned := make(map[string]bool)
+	cat, err := catalog.Load(cfg.BackupDir)
+	if err == nil {
+		pinned = cat.PinnedSet()
+		cat.IncludeUndated = policy.Undated
+		if files, err = retention.ListCatalog(cat); err == nil {
+			if saveErr := cat.Save(); saveErr != nil {
+				log.Warn(i18n.Tf("log.warn.catalog_save", saveErr))
+			}
+		}
+	} else {
+		log.Warn(i18n.Tf("log.warn.catalog_load", err))
+		if policy.Undated {
+			files, err = retention.ListBackupsUndated(cfg.BackupDir)
+		} else {
+			files, err = retention.ListBackups(cfg.BackupDir)
+		}
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, i18n.Tf("section.backup_dir_error", err)+"\n")
 	} else if len(files) == 0 {
@@ -348,55 +1005,284 @@ func runStatus(path string, verbose bool) {
 			wKind = 12
 		)
 		var totalSize int64
+		undated := 0
 		for _, f := range files {
-			kind := retention.Classify(f.Date)
+			kind := policy.Classify(f.Date)
 			totalSize += f.Size
 			name := filepath.Base(f.Path)
+			if pinned[name] {
+				kind = i18n.T("status.pinned")
+			}
+			if f.Undated {
+				kind += "*"
+				undated++
+			}
 			if len(name) > wName {
 				name = name[:wName-1] + "…"
 			}
 			fmt.Printf("%-*s %*s %-*s %-*s\n",
 				wDate, f.ModTime.Format("2006-01-02 15:04:05"),
-				wSize, formatSize(f.Size),
+				wSize, report.FormatSize(f.Size),
 				wName, name,
 				wKind, "("+kind+")")
 		}
 		fmt.Printf("%-*s %*s %-*s\n",
 			wDate, i18n.T("status.summe"),
-			wSize, formatSize(totalSize),
+			wSize, report.FormatSize(totalSize),
 			wName, i18n.Tf("msg.files_count", len(files)))
+		if undated > 0 {
+			fmt.Println(i18n.Tf("status.undated_note", undated))
+		}
+		if policy.MaxDirSize > 0 {
+			fmt.Println(i18n.Tf("section.size_cap", report.FormatSize(policy.MaxDirSize), report.FormatSize(totalSize)))
+		}
 	}
 }
 
-// formatSize formats size: bytes without suffix; 1024*n as "nK", 1024²*n as "nM", 1024³*n as "nT"; one decimal if value < 10, else none.
-func formatSize(n int64) string {
-	const k = 1024
-	if n < k {
-		return strconv.FormatInt(n, 10)
-	}
-	if n < k*k {
-		v := float64(n) / k
-		if v < 10 {
-			return fmt.Sprintf("%.1fK", v)
+// printVolumes prints size and free space (and inodes) of the volumes of backup_dir, work_dir and, if reachable,
+// the remote target, plus the forecast from the catalog when the free space in backup_dir will not suffice.
+func printVolumes(cfg *config.Config) {
+	printVolume("backup_dir", cfg.BackupDir, func() (disk.Stats, error) { return disk.Stat(cfg.BackupDir) })
+	if cat, err := catalog.Load(cfg.BackupDir); err == nil {
+		need := disk.Required(cat.LastDaySize(), cfg.DiskSpaceFactor)
+		if full, _, ok := cat.Forecast(need); ok {
+			days := int(time.Until(full).Hours() / 24)
+			if days < 0 {
+				days = 0
+			}
+			fmt.Println(i18n.Tf("status.disk_forecast", report.FormatSize(int64(need)), days, full.Format("2006-01-02")))
 		}
-		return fmt.Sprintf("%dK", int64(v))
 	}
-	if n < k*k*k {
-		v := float64(n) / (k * k)
-		if v < 10 {
-			return fmt.Sprintf("%.1fM", v)
-		}
-		return fmt.Sprintf("%dM", int64(v))
+	if cfg.WorkDir != "" {
+		printVolume("work_dir", cfg.WorkDir, func() (disk.Stats, error) { return disk.Stat(cfg.WorkDir) })
 	}
-	v := float64(n) / (k * k * k)
-	if v < 10 {
-		return fmt.Sprintf("%.1fT", v)
+	if cfg.RemoteBackupDir != "" && cfg.RemoteSSHHost != "" {
+		printVolume("remote", cfg.RemoteSSHHost+":"+cfg.RemoteBackupDir, func() (disk.Stats, error) { return remote.Volume(cfg) })
 	}
-	return fmt.Sprintf("%dT", int64(v))
 }
 
-func runGetfile(path, filename string, verbose bool) {
-	printStartupHeader(path)
+// printVolume prints one volume line of --status (and its inodes, if the system counts them).
+func printVolume(name, path string, stat func() (disk.Stats, error)) {
+	s, err := stat()
+	if err != nil {
+		fmt.Println(i18n.Tf("status.volume_error", name, path, err))
+		return
+	}
+	var used uint64
+	if s.Total > 0 && s.Free <= s.Total {
+		used = (s.Total - s.Free) * 100 / s.Total
+	}
+	fmt.Println(i18n.Tf("status.volume", name, path, report.FormatSize(int64(s.Free)), report.FormatSize(int64(s.Total)), used))
+	if s.Inodes > 0 {
+		fmt.Println("  " + i18n.Tf("section.inodes", s.InodesFree, s.Inodes, (s.Inodes-s.InodesFree)*100/s.Inodes))
+	}
+}
+
+// runVerifyRestore test-restores the newest backup of each database into the sandbox instance (see verify.Run)
+// and prints one line per backup; the exit code is 1 if any backup failed.
+func runVerifyRestore(path string, verbose bool) {
+	printStartupHeader(path)
+	cfg, log, err := loadConfigAndLog(path, verbose)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.config")+"\n", err)
+		os.Exit(1)
+	}
+	defer log.Close()
+	results, err := verify.Run(cfg, log)
+	verify.Record(cfg, nil, results, log)
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Println(i18n.Tf("msg.verify_failed", r.File, r.Err))
+		} else {
+			fmt.Println(i18n.Tf("msg.verify_ok", r.File, r.Database, r.Tables, r.Rows, r.Duration.Round(time.Second)))
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.verify_restore")+"\n", err)
+		os.Exit(1)
+	}
+}
+
+// runVerify checks backup ZIPs (all in backup_dir and archive_dir, or the selection of arg as with --restore)
+// against their parity files and checksum sidecars and repairs damaged ones from the parity files. It prints one
+// line per ZIP; the exit code is 1 if a ZIP stays damaged. It runs under the run lock (exit code 3 if a backup
+// holds it longer than lock_wait_minutes), so repairs and the catalog update do not race a backup run.
+func runVerify(path, arg string, verbose bool) {
+	printStartupHeader(path)
+	cfg, log, err := loadConfigAndLog(path, verbose)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.config")+"\n", err)
+		os.Exit(1)
+	}
+	defer log.Close()
+	runLock, err := lock.Acquire(cfg.BackupDir, time.Duration(cfg.LockWaitMinutes)*time.Minute)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.verify")+"\n", err)
+		if errors.Is(err, lock.ErrLocked) {
+			os.Exit(exitLocked)
+		}
+		os.Exit(1)
+	}
+	defer runLock.Release()
+	var files []retention.BackupFile
+	if arg != "" {
+		files, err = restoreSelection(cfg, arg)
+	} else {
+		files, err = retention.ListBackupsUndated(cfg.BackupDir)
+		if err == nil && cfg.ArchiveDir != "" {
+			var archived []retention.BackupFile
+			archived, err = retention.ListBackupsUndated(cfg.ArchiveDir)
+			files = append(files, archived...)
+		}
+	}
+	var keys signing.Keys
+	if err == nil {
+		keys, err = signing.Trusted(cfg)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.verify")+"\n", err)
+		os.Exit(1)
+	}
+	// Ergebnisse für --history im Katalog festhalten (nur ZIPs in backup_dir haben einen Eintrag)
+	cat, err := catalog.Load(cfg.BackupDir)
+	if err != nil {
+		log.Warn(i18n.Tf("log.warn.catalog_load", err))
+	}
+	damaged := 0
+	for _, f := range files {
+		result := verifyFile(cfg, f.Path, keys, log)
+		if result == catalog.ResultFailed {
+			damaged++
+		}
+		if cat != nil && result != "" {
+			cat.MarkChecked(filepath.Base(f.Path), result, time.Now())
+		}
+	}
+	if cat != nil {
+		if err := cat.Save(); err != nil {
+			log.Warn(i18n.Tf("log.warn.catalog_save", err))
+		}
+	}
+	fmt.Println(i18n.Tf("msg.verify_summary", len(files), damaged))
+	if damaged > 0 {
+		runLock.Release() // os.Exit überspringt defer
+		os.Exit(1)
+	}
+}
+
+// verifyFile checks one ZIP for runVerify: with parity files slice by slice (and repairs it), then against
+// its SHA-256 sidecar and, with trusted keys, its signature. Damaged parity files of an intact ZIP are written
+// again. It returns catalog.ResultFailed if the ZIP is damaged or its signature missing or invalid,
+// catalog.ResultRepaired or catalog.ResultOK, and "" for a ZIP without anything to check it against.
+func verifyFile(cfg *config.Config, zipPath string, keys signing.Keys, log *logger.Logger) string {
+	name := filepath.Base(zipPath)
+	result := catalog.ResultOK
+	res, err := parity.Repair(zipPath)
+	hasParity := !errors.Is(err, parity.ErrNoParity)
+	switch {
+	case !hasParity:
+	case err != nil:
+		log.Error(i18n.Tf("log.error.verify", name, err))
+		fmt.Println(i18n.Tf("msg.verify_damaged", name, err))
+		return catalog.ResultFailed
+	case !res.OK():
+		log.Warn(i18n.Tf("log.warn.verify_repaired", name, len(res.Damaged)))
+		audit.Note(cfg.AuditFile, log, audit.ParityRepair, zipPath, fmt.Sprintf("%d/%d slices", len(res.Damaged), res.Slices))
+		fmt.Println(i18n.Tf("msg.verify_repaired", name, len(res.Damaged)))
+		result = catalog.ResultRepaired
+	}
+	ok, err := catalog.CheckSidecar(zipPath)
+	switch {
+	case err != nil && !os.IsNotExist(err):
+		fmt.Println(i18n.Tf("msg.verify_damaged", name, err))
+		return catalog.ResultFailed
+	case err == nil && !ok:
+		err = fmt.Errorf(i18n.T("err.verify_sha256"), name+catalog.SidecarExt)
+		log.Error(i18n.Tf("log.error.verify", name, err))
+		fmt.Println(i18n.Tf("msg.verify_damaged", name, err))
+		return catalog.ResultFailed
+	case err != nil && !hasParity && len(keys) == 0:
+		fmt.Println(i18n.Tf("msg.verify_unchecked", name))
+		return ""
+	}
+	if len(keys) > 0 {
+		if err := keys.VerifyFile(zipPath); err != nil {
+			log.Error(i18n.Tf("log.error.verify", name, err))
+			fmt.Println(i18n.Tf("msg.verify_damaged", name, err))
+			return catalog.ResultFailed
+		}
+	}
+	if hasParity && res.ParityDamaged {
+		if cfg.ParityPercent == 0 {
+			log.Warn(i18n.Tf("log.warn.parity_damaged", name))
+		} else if _, err := parity.Create(zipPath, cfg.ParityPercent); err != nil {
+			log.Warn(i18n.Tf("log.warn.parity", name, err))
+		} else {
+			log.Info(i18n.Tf("log.msg.parity_renewed", name))
+		}
+	}
+	if res.OK() {
+		fmt.Println(i18n.Tf("msg.verify_file_ok", name))
+	}
+	return result
+}
+
+// runHistory prints the backup history from the catalog of backup_dir (aligned with the ZIPs on disk): per
+// database the backups with size, dump duration, upload and the results of --verify and the test restores,
+// only of db if given; format csv or json writes one row per backup for further processing. Like --print-config
+// without logger, so stdout holds only the history.
+func runHistory(path, db, format string) {
+	printStartupHeader(path)
+	cfg, err := config.Load(path, false)
+	if err == nil {
+		cfg, err = selectServer(cfg, true)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.config")+"\n", err)
+		os.Exit(1)
+	}
+	cat, err := catalog.Load(cfg.BackupDir)
+	if err == nil {
+		cat.IncludeUndated = cfg.RetainUndatedByMtime
+		_, err = cat.Reconcile()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.history")+"\n", err)
+		os.Exit(1)
+	}
+	if err := report.History(os.Stdout, cat.History(db), cat.PinnedSet(), format); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.history")+"\n", err)
+		os.Exit(1)
+	}
+}
+
+// runPhysicalBackup copies the data directory of the instance to physical_backup_dir (see restore.PhysicalBackup)
+// under the run lock, so no backup run uses the server while it may be stopped.
+func runPhysicalBackup(path string, verbose bool) {
+	printStartupHeader(path)
+	cfg, log, err := loadConfigAndLog(path, verbose)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.config")+"\n", err)
+		os.Exit(1)
+	}
+	defer log.Close()
+	runLock, err := lock.Acquire(cfg.BackupDir, time.Duration(cfg.LockWaitMinutes)*time.Minute)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.physical_backup")+"\n", err)
+		os.Exit(1)
+	}
+	defer runLock.Release()
+	target, err := restore.PhysicalBackup(cfg, log)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.physical_backup")+"\n", err)
+		os.Exit(1)
+	}
+	log.Info(i18n.Tf("log.msg.physical_done", target))
+	fmt.Println(i18n.Tf("msg.saved", target))
+}
+
+func runGetfile(path, filename string, verbose bool) {
+	printStartupHeader(path)
 	if !validGetfilePattern(filename) {
 		fmt.Fprintln(os.Stderr, i18n.T("error.getfile_no_path"))
 		os.Exit(1)
@@ -442,78 +1328,903 @@ func containsPath(s string) bool {
 	return false
 }
 
-func runBackup(path string, verbose bool) {
+// runInspect prints the contents of a backup ZIP (path, or file name in backup_dir): entries with their
+// uncompressed size, the manifest, databases, tables, views and the users/grants block.
+func runInspect(path, zipPath string, verbose bool) {
+	printStartupHeader(path)
+	if !filepath.IsAbs(zipPath) {
+		cfg, log, err := loadConfigAndLog(path, verbose)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("error.config")+"\n", err)
+			os.Exit(1)
+		}
+		log.Close()
+		zipPath = filepath.Join(cfg.BackupDir, zipPath)
+	}
+	f, err := os.Open(zipPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.inspect")+"\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.inspect")+"\n", err)
+		os.Exit(1)
+	}
+	c, err := restore.Inspect(restore.Source{Name: filepath.Base(zipPath), ReaderAt: f, Size: info.Size()})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.inspect")+"\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(i18n.Tf("msg.inspect_file", zipPath, report.FormatSize(info.Size())))
+	for _, l := range inspectLines(c) {
+		fmt.Println(l)
+	}
+}
+
+// runDiff compares two backup ZIPs of the same database (paths or file names in backup_dir, older first) and
+// prints added and removed tables and views, changed columns, keys and table options, and the row counts.
+func runDiff(path string, zips []string, verbose bool) {
+	printStartupHeader(path)
+	if len(zips) != 2 {
+		fmt.Fprintln(os.Stderr, i18n.T("error.diff_args"))
+		os.Exit(1)
+	}
+	if !filepath.IsAbs(zips[0]) || !filepath.IsAbs(zips[1]) {
+		cfg, log, err := loadConfigAndLog(path, verbose)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("error.config")+"\n", err)
+			os.Exit(1)
+		}
+		log.Close()
+		for i, z := range zips {
+			if !filepath.IsAbs(z) {
+				zips[i] = filepath.Join(cfg.BackupDir, z)
+			}
+		}
+	}
+	var schemas [2]*restore.Schema
+	for i, z := range zips {
+		s, err := readSchema(z)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("error.diff")+"\n", filepath.Base(z), err)
+			os.Exit(1)
+		}
+		schemas[i] = s
+	}
+	a, b := schemas[0], schemas[1]
+	fmt.Println(i18n.Tf("msg.diff_files", zips[0], zips[1]))
+	if dbA, dbB := strings.Join(a.Databases, ", "), strings.Join(b.Databases, ", "); dbA != dbB {
+		fmt.Println(i18n.Tf("msg.diff_databases", dbA, dbB))
+	}
+	// ≈ vor den Zeilenzahlen, wenn sie nicht aus beiden Manifesten stammen (ältere Backups)
+	approx := ""
+	if !a.RowsExact || !b.RowsExact {
+		approx = "≈ "
+	}
+	rows := func(s *restore.Schema) (n int64) {
+		for _, t := range s.Tables {
+			n += t.Rows
+		}
+		return n
+	}
+	fmt.Println(i18n.Tf("msg.diff_summary", len(a.Tables), len(b.Tables), approx, rows(a), rows(b)))
+	fmt.Println()
+	d := restore.CompareSchemas(a, b)
+	if d.Empty() {
+		fmt.Println(i18n.T("msg.diff_none"))
+		return
+	}
+	for _, name := range d.AddedTables {
+		t := b.Tables[name]
+		fmt.Println(i18n.Tf("msg.diff_table_added", name, len(t.Columns), approx, t.Rows))
+	}
+	for _, name := range d.RemovedTables {
+		fmt.Println(i18n.Tf("msg.diff_table_removed", name, approx, a.Tables[name].Rows))
+	}
+	for _, t := range d.Tables {
+		if !t.StructureChanged() {
+			fmt.Println(i18n.Tf("msg.diff_rows", t.Name, approx, t.OldRows, t.NewRows, t.NewRows-t.OldRows))
+			continue
+		}
+		fmt.Println(i18n.Tf("msg.diff_table_changed", t.Name))
+		for _, c := range t.AddedColumns {
+			fmt.Println("    + " + i18n.Tf("msg.diff_column", c.Name, c.Definition))
+		}
+		for _, c := range t.RemovedColumns {
+			fmt.Println("    - " + i18n.Tf("msg.diff_column", c.Name, c.Definition))
+		}
+		for _, c := range t.ChangedColumns {
+			fmt.Println("    ~ " + i18n.Tf("msg.diff_column_changed", c.Name, c.Old, c.New))
+		}
+		for _, k := range t.AddedKeys {
+			fmt.Println("    + " + k)
+		}
+		for _, k := range t.RemovedKeys {
+			fmt.Println("    - " + k)
+		}
+		if t.OldOptions != t.NewOptions {
+			fmt.Println("    ~ " + i18n.Tf("msg.diff_options", t.OldOptions, t.NewOptions))
+		}
+		if t.OldRows != t.NewRows {
+			fmt.Println("    " + i18n.Tf("msg.diff_rows_changed", approx, t.OldRows, t.NewRows, t.NewRows-t.OldRows))
+		}
+	}
+	for _, v := range d.AddedViews {
+		fmt.Println(i18n.Tf("msg.diff_view_added", v))
+	}
+	for _, v := range d.RemovedViews {
+		fmt.Println(i18n.Tf("msg.diff_view_removed", v))
+	}
+	if approx != "" {
+		fmt.Println()
+		fmt.Println(i18n.T("msg.diff_rows_note"))
+	}
+}
+
+// readSchema reads the table structures and row counts of the backup ZIP at path (see restore.ReadSchema).
+func readSchema(path string) (*restore.Schema, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return restore.ReadSchema(restore.Source{Name: filepath.Base(path), ReaderAt: f, Size: info.Size()})
+}
+
+// inspectLines returns the output of --inspect after the file line (also the detail view of --tui).
+func inspectLines(c *restore.Contents) []string {
+	list := func(items []string) string {
+		if len(items) == 0 {
+			return "-"
+		}
+		return strings.Join(items, ", ")
+	}
+	lines := []string{i18n.T("msg.inspect_entries")}
+	for _, e := range c.Entries {
+		lines = append(lines, fmt.Sprintf("  %-40s %10s", e.Name, report.FormatSize(int64(e.Size))))
+	}
+	if c.Manifest != nil {
+		lines = append(lines, i18n.T("msg.inspect_manifest"))
+		lines = append(lines, strings.Split(strings.TrimRight(string(c.Manifest), "\n"), "\n")...)
+	}
+	lines = append(lines,
+		i18n.Tf("msg.inspect_databases", list(c.Databases)),
+		i18n.Tf("msg.inspect_tables", len(c.Tables), list(c.Tables)),
+		i18n.Tf("msg.inspect_views", len(c.Views), list(c.Views)),
+		i18n.Tf("msg.inspect_users", len(c.Users)))
+	for _, u := range c.Users {
+		lines = append(lines, "  "+u)
+	}
+	return lines
+}
+
+// runKeyringSet stores a secret under name in the credential store of the OS (keyring): entered without echo on a
+// terminal, otherwise read from stdin (e.g. piped from a password manager).
+func runKeyringSet(path, name string) {
 	printStartupHeader(path)
+	var secret string
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, i18n.Tf("prompt.keyring_secret", name))
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("error.keyring")+"\n", err)
+			os.Exit(1)
+		}
+		secret = string(b)
+	} else {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("error.keyring")+"\n", err)
+			os.Exit(1)
+		}
+		secret = strings.TrimRight(string(b), "\r\n")
+	}
+	if secret == "" {
+		fmt.Fprintln(os.Stderr, i18n.T("error.keyring_empty"))
+		os.Exit(1)
+	}
+	if err := keyring.Set(name, secret); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.keyring")+"\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(i18n.Tf("msg.keyring_set", name, keyring.Prefix+name))
+}
+
+// runPin pins (pin=true) or unpins a backup file in the catalog of backup_dir, holding the run lock while
+// the catalog is loaded and saved.
+func runPin(path, filename string, pin bool, verbose bool) {
+	printStartupHeader(path)
+	if !validGetfilePattern(filename) || strings.ContainsAny(filename, "*?") {
+		fmt.Fprintln(os.Stderr, i18n.T("error.pin_no_path"))
+		os.Exit(1)
+	}
 	cfg, log, err := loadConfigAndLog(path, verbose)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, i18n.T("error.config")+"\n", err)
 		os.Exit(1)
 	}
 	defer log.Close()
+	// Unter der Run-Sperre: ein laufendes Backup hält den Katalog bis zum Ende und würde den Pin überschreiben
+	runLock, err := lock.Acquire(cfg.BackupDir, time.Duration(cfg.LockWaitMinutes)*time.Minute)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.pin")+"\n", err)
+		if errors.Is(err, lock.ErrLocked) {
+			os.Exit(exitLocked)
+		}
+		os.Exit(1)
+	}
+	defer runLock.Release()
+	cat, err := catalog.Load(cfg.BackupDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.pin")+"\n", err)
+		os.Exit(1)
+	}
+	if pin {
+		if _, err := os.Stat(filepath.Join(cfg.BackupDir, filename)); err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("error.pin")+"\n", err)
+			os.Exit(1)
+		}
+		if !cat.Pin(filename) {
+			fmt.Println(i18n.Tf("msg.already_pinned", filename))
+			return
+		}
+	} else if !cat.Unpin(filename) {
+		fmt.Println(i18n.Tf("msg.not_pinned", filename))
+		return
+	}
+	if err := cat.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.pin")+"\n", err)
+		os.Exit(1)
+	}
+	if pin {
+		log.Info(i18n.Tf("log.msg.pinned", filename))
+		audit.Note(cfg.AuditFile, log, audit.Pin, filename, "")
+		fmt.Println(i18n.Tf("msg.pinned", filename))
+	} else {
+		log.Info(i18n.Tf("log.msg.unpinned", filename))
+		audit.Note(cfg.AuditFile, log, audit.Unpin, filename, "")
+		fmt.Println(i18n.Tf("msg.unpinned", filename))
+	}
+}
+
+// autoSchedule reports whether --backup/--status may check and (re)install the schedule:
+// not with auto_schedule false or --no-schedule (schedule managed externally, ad-hoc runs).
+func autoSchedule(cfg *config.Config, noSchedule bool) bool {
+	return cfg.AutoSchedule && !noSchedule
+}
+
+// runBackup runs the backup of the config, with servers of every server one after the other (only those due
+// reports true for, if due is set). The run fails when one of them fails.
+func runBackup(path string, verbose, noSchedule bool, opt run.Options, due func(*config.Config) bool) {
+	printStartupHeader(path)
+	cfg, log, err := loadConfigAndRunLog(path, verbose)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.config")+"\n", err)
+		os.Exit(1)
+	}
+	defer log.Close()
 
-	if runtime.GOOS != "windows" && runtime.GOOS != "linux" {
+	switch {
+	case cfg.ServerName() != "":
+		// einzelner Server per --server: der geplante Job gehört zur Hauptconfig
+	case !autoSchedule(cfg, noSchedule):
+		log.Info(i18n.T("log.msg.schedule_skipped"))
+	case !schedule.Supported():
 		log.Warn(i18n.T("log.warn.schedule_platform"))
-	} else {
-		if err := schedule.EnsureInstalled(cfg, path, log); err != nil {
+	default:
+		repaired, err := schedule.EnsureInstalled(cfg, path, log)
+		if err != nil {
 			log.Warn(i18n.Tf("log.warn.schedule_ensure", err))
 		}
+		run.CheckSchedule(cfg, backupTargets(cfg), repaired, log)
 	}
 
-	if err := run.Backup(cfg, log); err != nil {
-		log.Error(i18n.Tf("log.error.backup_failed", err))
+	// SIGINT/SIGTERM (z. B. systemctl stop) bricht Dump bzw. Upload ab und räumt halbe Dateien auf
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	targets := backupTargets(cfg)
+	failed := 0
+	var lastErr error
+	for _, t := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		if due != nil && !due(t) {
+			continue
+		}
+		if err := backupServer(ctx, t, log, opt); err != nil {
+			failed, lastErr = failed+1, err
+		}
+	}
+	syncPolicy(cfg, log)
+	if lastErr == nil {
+		return
+	}
+	if len(targets) > 1 {
+		log.Error(i18n.Tf("log.error.servers_failed", failed, len(targets)))
+	} else if errors.Is(lastErr, lock.ErrLocked) {
+		os.Exit(exitLocked)
+	}
+	os.Exit(1)
+}
+
+// backupServer runs one backup of cfg and logs its result, for a server of servers with its name.
+func backupServer(ctx context.Context, cfg *config.Config, log *logger.Logger, opt run.Options) error {
+	name := cfg.ServerName()
+	if name != "" {
+		log.Info(i18n.Tf("log.msg.server_backup", name))
+	}
+	err := run.Backup(ctx, cfg, log, opt)
+	if cfg.ControllerURL != "" && !errors.Is(err, lock.ErrLocked) {
+		reportRun(cfg, log)
+	}
+	switch {
+	case err == nil && name == "":
+		log.Info(i18n.T("log.msg.backup_ok"))
+	case err == nil:
+		log.Info(i18n.Tf("log.msg.server_backup_ok", name))
+	case errors.Is(err, lock.ErrLocked):
+		log.Error(i18n.Tf("log.error.locked", err))
+	case name == "":
+		log.ErrorCode(errcode.Of(err), i18n.Tf("log.error.backup_failed", err))
+	default:
+		log.ErrorCode(errcode.Of(err), i18n.Tf("log.error.server_backup_failed", name, err))
+	}
+	return err
+}
+
+// reportRun sends the report of the last run of cfg (last_run.json) to the controller (controller_url).
+func reportRun(cfg *config.Config, log *logger.Logger) {
+	rep, err := runreport.Load(cfg.BackupDir)
+	if err == nil && rep != nil {
+		err = fleet.Report(cfg, rep)
+	}
+	if err != nil {
+		log.Warn(i18n.Tf("log.warn.fleet_report", cfg.ControllerURL, err))
+	}
+}
+
+// ignoredPolicyKeys are the keys of the policy ignored at the last syncPolicy.
+var ignoredPolicyKeys string
+
+// syncPolicy fetches the policy of cfg from the controller into controller_policy.json (see fleet.Sync); it
+// takes effect with the next load of the config. Without controller_url (or for a server config) it does nothing.
+func syncPolicy(cfg *config.Config, log *logger.Logger) {
+	if cfg.ControllerURL == "" || cfg.PolicyPath() == "" {
+		return
+	}
+	changed, ignored, err := fleet.Sync(cfg)
+	if err != nil {
+		log.Warn(i18n.Tf("log.warn.fleet_sync", cfg.ControllerURL, err))
+		return
+	}
+	// nur bei Änderung warnen, der Daemon holt die Richtlinie alle paar Minuten
+	if keys := strings.Join(ignored, ", "); keys != ignoredPolicyKeys {
+		ignoredPolicyKeys = keys
+		if keys != "" {
+			log.Warn(i18n.Tf("log.warn.fleet_policy_ignored", keys))
+		}
+	}
+	if changed {
+		log.Info(i18n.Tf("log.msg.fleet_policy", cfg.PolicyPath()))
+		audit.Note(cfg.AuditFile, log, audit.PolicyUpdate, cfg.PolicyPath(), cfg.ControllerURL)
+	}
+}
+
+// exitLocked is the exit code of --backup when another run still holds the run lock.
+const exitLocked = 3
+
+// catchUpGrace: a missed scheduled run is caught up only this long after its start time, so the
+// hourly check does not race the regular run.
+const catchUpGrace = time.Hour
+
+// runCatchUp runs the backup only when catch_up is enabled and a scheduled run was missed (see
+// state.CatchUpDue), with servers for each server that missed its run. Called hourly from cron; stays silent
+// (no log) when nothing is due.
+func runCatchUp(path string, verbose, noSchedule bool) {
+	cfg, err := config.Load(path, false)
+	if err == nil {
+		cfg, err = selectServer(cfg, true)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.config")+"\n", err)
 		os.Exit(1)
 	}
-	log.Info(i18n.T("log.msg.backup_ok"))
+	due := func(c *config.Config) bool {
+		spec, err := c.ScheduleSpec()
+		if err != nil || !c.CatchUp {
+			return false
+		}
+		st, err := state.Load(c.BackupDir)
+		return err == nil && st.CatchUpDue(spec, time.Now(), catchUpGrace)
+	}
+	for _, t := range backupTargets(cfg) {
+		if due(t) {
+			fmt.Println(i18n.T("msg.catch_up"))
+			runBackup(path, verbose, noSchedule, run.Options{}, due)
+			return
+		}
+	}
 }
 
-func runRestore(path, dateStr string, full bool, verbose bool) {
+// runDaemon läuft im Vordergrund und führt die Backups selbst nach schedule/start_time aus (ohne geplanten Job,
+// z. B. als systemd-Dienst oder Container-Einstiegspunkt). Änderungen der Config-Datei werden übernommen
+// (Log-Einstellungen, Zeitplan, alle Laufeinstellungen); SIGINT/SIGTERM bricht ein laufendes Backup ab (wie bei
+// --backup) und beendet den Dienst. Mit api_listen läuft daneben die JSON-API (internal/api).
+func runDaemon(path string, verbose bool) {
 	printStartupHeader(path)
-	cfg, log, err := loadConfigAndLog(path, verbose)
+	cfg, log, err := loadConfigAndLogFile(path, verbose, false, true)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, i18n.T("error.config")+"\n", err)
 		os.Exit(1)
 	}
 	defer log.Close()
+	log.Info(i18n.T("log.msg.daemon_start"))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Die API liest eine Kopie der aktuellen Config (der Lauf ändert backup_dir bei {date}); Aufträge über trigger
+	// führt Serve nacheinander mit den geplanten Läufen aus.
+	var current atomic.Pointer[config.Config]
+	var running atomic.Bool
+	publish := func(cfg *config.Config) {
+		c := *cfg
+		current.Store(&c)
+	}
+	publish(cfg)
+	trigger := make(chan string, 1)
+	if cfg.APIListen != "" {
+		h := api.Handler(api.Options{
+			Config: current.Load,
+			Trigger: func(server string) bool {
+				select {
+				case trigger <- server:
+					return true
+				default:
+					return false
+				}
+			},
+			Running: running.Load,
+			Log:     log,
+		})
+		go func() {
+			log.Info(i18n.Tf("log.msg.api_listen", cfg.APIListen))
+			if err := api.ListenAndServe(ctx, cfg, h); err != nil {
+				log.Error(i18n.Tf("log.error.api", err))
+			}
+		}()
+	}
+	// Agent: Richtlinie regelmäßig holen; eine geänderte controller_policy.json lädt Serve wie ein include neu
+	go func() {
+		for {
+			syncPolicy(current.Load(), log)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(fleet.SyncInterval):
+			}
+		}
+	}()
+	listen := cfg.APIListen
+	daemon.Serve(cfg, log, daemon.Options{
+		Path: path,
+		Load: func() (*config.Config, error) {
+			cfg, err := config.Load(path, false)
+			if err != nil {
+				return nil, err
+			}
+			return selectServer(cfg, true)
+		},
+		Backup: func(cfg *config.Config) {
+			running.Store(true)
+			defer running.Store(false)
+			// Kopie: eine Server-Config gehört auch zur veröffentlichten Hauptconfig der API
+			c := *cfg
+			c.ExpandPaths(c.Now()) // {date} in backup_dir/remote_backup_dir gilt je Lauf
+			if c.ServerName() == "" || serverName != "" {
+				publish(&c)
+			}
+			_ = backupServer(ctx, &c, log, run.Options{})
+		},
+		Reload: func(cfg *config.Config) {
+			configureLog(log, cfg, verbose, false)
+			publish(cfg)
+			if cfg.APIListen != listen {
+				log.Warn(i18n.T("log.warn.api_restart"))
+			}
+		},
+		Run: trigger,
+	}, ctx.Done())
+}
 
+// confirmDrop returns the confirmation of --restore --force: the database name has to be typed (stdin) before
+// the existing database is dropped.
+func confirmDrop(in *bufio.Reader) func(db string) bool {
+	return func(db string) bool {
+		fmt.Fprint(os.Stderr, i18n.Tf("prompt.confirm_drop", db))
+		line, _ := in.ReadString('\n')
+		return strings.TrimSpace(line) == db
+	}
+}
+
+// isZipArg reports whether the argument of --restore names a backup ZIP instead of a date.
+func isZipArg(arg string) bool {
+	return strings.EqualFold(filepath.Ext(arg), ".zip")
+}
+
+// splitList splits a comma-separated flag value (e.g. --tables) and drops empty entries.
+func splitList(s string) []string {
+	var list []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// restoreSelection returns the backups to restore: the ZIP named by arg (path or file name in backup_dir), the
+// newest backup of one database for arg db=<name>, otherwise all database ZIPs of the last backup day, before the
+// date arg (YYYYMMDD) if given.
+func restoreSelection(cfg *config.Config, arg string) ([]retention.BackupFile, error) {
+	if db, ok := strings.CutPrefix(arg, "db="); ok {
+		files, err := retention.ListBackups(cfg.BackupDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range retention.ForDatabase(files, db) {
+			if name, err := restore.FileDatabase(f.Path); err == nil && name == db {
+				return []retention.BackupFile{f}, nil
+			}
+		}
+		return nil, fmt.Errorf(i18n.T("error.restore_db_not_found"), db, cfg.BackupDir)
+	}
+	if isZipArg(arg) {
+		p := arg
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(cfg.BackupDir, p)
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		return []retention.BackupFile{{Path: p, ModTime: info.ModTime(), Size: info.Size()}}, nil
+	}
 	var beforeDate *time.Time
-	if strings.TrimSpace(dateStr) != "" {
-		t, err := time.ParseInLocation("20060102", strings.TrimSpace(dateStr), time.Local)
+	if arg != "" {
+		t, err := time.ParseInLocation("20060102", arg, time.Local)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, i18n.T("error.restoredate_format")+"\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf(i18n.T("error.restoredate_format"), err)
 		}
 		beforeDate = &t
 	}
-
+	// ZIPs von file_backups enthalten kein SQL
 	files, err := retention.LastBackupBefore(cfg.BackupDir, beforeDate)
+	return retention.DatabaseBackups(files), err
+}
+
+// openRemoteSelection opens the ZIPs on the remote target for --from-remote: pattern (file name or wildcards)
+// or db=<name> for the newest backup of that database.
+func openRemoteSelection(cfg *config.Config, pattern string, log *logger.Logger) ([]remote.Backup, func() error, error) {
+	db, ok := strings.CutPrefix(pattern, "db=")
+	if !ok {
+		return remote.OpenBackups(cfg, pattern, log)
+	}
+	files, err := remote.ListBackups(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, f := range retention.ForDatabase(files, db) {
+		backups, closeRemote, err := remote.OpenBackups(cfg, f.Path, log)
+		if err != nil {
+			return nil, nil, err
+		}
+		b := backups[0]
+		if name, err := restore.Database(restore.Source{Name: b.Name, ReaderAt: b.ReaderAt, Size: b.Size}); err == nil && name == db {
+			return backups, closeRemote, nil
+		}
+		closeRemote()
+	}
+	return nil, nil, fmt.Errorf(i18n.T("error.restore_db_not_found"), db, cfg.RemoteBackupDir)
+}
+
+// restoreConn returns the connection for a restore as root into the instance of cfg.
+func restoreConn(cfg *config.Config, opt restore.Options, password string) *mysql.Conn {
+	return &mysql.Conn{
+		Host:     cfg.MySQLHost,
+		Port:     cfg.MySQLPort,
+		User:     "root",
+		Password: password,
+		BinDir:   cfg.MySQLBin,
+		TempDir:  cfg.WorkDir,
+
+		DefaultCharset: opt.TargetCharset(),
+	}
+}
+
+// runRestore restores the backups selected by arg (see restoreSelection) or, with fromRemote, the ZIPs on the
+// remote target selected by it (see openRemoteSelection); those are read and decrypted in place, without a local copy.
+func runRestore(path, arg, fromRemote string, full bool, opt restore.Options, verbose bool) {
+	printStartupHeader(path)
+	cfg, log, err := loadConfigAndLog(path, verbose)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, i18n.T("error.restore_select")+"\n", err)
+		fmt.Fprintf(os.Stderr, i18n.T("error.config")+"\n", err)
 		os.Exit(1)
 	}
-	if len(files) == 0 {
+	defer log.Close()
+
+	var files []retention.BackupFile
+	var backups []remote.Backup
+	var sources []restore.Source
+	if fromRemote != "" {
+		var closeRemote func() error
+		backups, closeRemote, err = openRemoteSelection(cfg, fromRemote, log)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("error.restore_select")+"\n", err)
+			os.Exit(1)
+		}
+		defer closeRemote()
+		for _, b := range backups {
+			sources = append(sources, restore.Source{Name: b.Name, ReaderAt: b.ReaderAt, Size: b.Size})
+		}
+	} else {
+		files, err = restoreSelection(cfg, arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("error.restore_select")+"\n", err)
+			os.Exit(1)
+		}
+	}
+	if len(files) == 0 && len(sources) == 0 {
 		fmt.Fprintln(os.Stderr, i18n.T("error.restore_no_backup_found"))
 		os.Exit(1)
 	}
+	backups, cleanupCopies, err := checkSignatures(cfg, files, backups, log)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.restore")+"\n", err)
+		os.Exit(1)
+	}
+	defer cleanupCopies()
+	if backups != nil {
+		// geprüfte Kopien statt der Remote-Dateien importieren
+		sources = sources[:0]
+		for _, b := range backups {
+			sources = append(sources, restore.Source{Name: b.Name, ReaderAt: b.ReaderAt, Size: b.Size})
+		}
+	}
 
+	opt.DataDir = cfg.MySQLDataDir
+	if opt.Charset == "" && opt.Collation == "" {
+		opt.Charset, opt.Collation = cfg.RestoreCharset, cfg.RestoreCollation
+	}
+	action := audit.Restore
+	if full {
+		action = audit.RestoreFull
+	} else if opt.UsersOnly {
+		action = audit.RestoreUsers
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f.Path))
+	}
+	for _, s := range sources {
+		names = append(names, s.Name)
+	}
 	password := cfg.RootPassword
 	if full {
 		if err := restore.FullReinit(cfg, log); err != nil {
+			auditRestore(cfg, log, action, names, err)
 			fmt.Fprintf(os.Stderr, i18n.T("error.restorefull")+"\n", err)
 			os.Exit(1)
 		}
 		password = ""
 	}
 
-	conn := &mysql.Conn{
-		Host:     cfg.MySQLHost,
-		Port:     cfg.MySQLPort,
-		User:     "root",
-		Password: password,
-		BinDir:   cfg.MySQLBin,
+	conn := restoreConn(cfg, opt, password)
+	if sources != nil {
+		err = restore.RestoreFromSources(conn, sources, opt, log)
+	} else {
+		err = restore.RestoreFromZips(conn, files, opt, log)
+	}
+	conn.Close() // vor os.Exit: temporäre Defaults-Datei entfernen
+	if !opt.DryRun {
+		auditRestore(cfg, log, action, names, err)
 	}
-	if err := restore.RestoreFromZips(conn, files, log); err != nil {
+	if err != nil {
 		fmt.Fprintf(os.Stderr, i18n.T("error.restore")+"\n", err)
 		os.Exit(1)
 	}
+	if opt.DryRun {
+		log.Info(i18n.T("log.msg.dryrun_done"))
+		return
+	}
+	log.Info(i18n.T("log.msg.restore_ok"))
+}
+
+// checkSignatures verifies the signatures of the backups selected for a restore against the trusted keys
+// (signing_key_file, signing_public_keys); without them nothing is checked and backups is returned as is. Remote
+// backups are copied to work_dir (system temp directory without it) and the copy is verified and returned in their
+// place, so the restore imports exactly the bytes that were checked (a compromised remote host could serve other
+// bytes to a second read). cleanup removes the copies.
+func checkSignatures(cfg *config.Config, files []retention.BackupFile, backups []remote.Backup, log *logger.Logger) (verified []remote.Backup, cleanup func(), err error) {
+	var copies []*os.File
+	cleanup = func() {
+		for _, f := range copies {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}
+	keys, err := signing.Trusted(cfg)
+	if err != nil || len(keys) == 0 {
+		return backups, cleanup, err
+	}
+	for _, f := range files {
+		if err := keys.VerifyFile(f.Path); err != nil {
+			return nil, cleanup, err
+		}
+		log.Info(i18n.Tf("log.msg.signature_ok", filepath.Base(f.Path)))
+	}
+	for _, b := range backups {
+		tmp, err := os.CreateTemp(cfg.WorkDir, "mysqlbackup-verify-*.zip")
+		if err != nil {
+			cleanup()
+			return nil, func() {}, err
+		}
+		copies = append(copies, tmp)
+		log.Info(i18n.Tf("log.msg.signature_copy", b.Name, tmp.Name()))
+		if _, err := io.Copy(tmp, io.NewSectionReader(b.ReaderAt, 0, b.Size)); err != nil {
+			cleanup()
+			return nil, func() {}, fmt.Errorf(i18n.T("err.decrypt_write"), err)
+		}
+		if err := keys.Verify(b.Name, io.NewSectionReader(tmp, 0, b.Size), b.Signature); err != nil {
+			cleanup()
+			return nil, func() {}, err
+		}
+		log.Info(i18n.Tf("log.msg.signature_ok", b.Name))
+		verified = append(verified, remote.Backup{Name: b.Name, ReaderAt: tmp, Size: b.Size, Signature: b.Signature})
+	}
+	return verified, cleanup, nil
+}
+
+// auditRestore records a restore of the backups names with its result in the audit file.
+func auditRestore(cfg *config.Config, log *logger.Logger, action string, names []string, err error) {
+	detail := "ok"
+	if err != nil {
+		detail = err.Error()
+	}
+	audit.Note(cfg.AuditFile, log, action, strings.Join(names, ", "), detail)
+}
+
+// runTUI startet die interaktive Backup-Übersicht (--tui): lokale und Remote-Backups in einer Liste, Details
+// (Katalog, Manifest, Inhalt wie --inspect), Test-Restore, Download nach backup_dir und Restore der gewählten ZIP.
+func runTUI(path string, verbose bool) {
+	cfg, log, err := loadConfigAndLog(path, verbose)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.config")+"\n", err)
+		os.Exit(1)
+	}
+	defer log.Close()
+	console := log.Level(logger.SinkConsole)
+	quiet := func() func() {
+		log.SetLevel(logger.SinkConsole, logger.LevelOff) // Log-Zeilen würden die Vollbildansicht überschreiben
+		return func() { log.SetLevel(logger.SinkConsole, console) }
+	}
+	err = tui.Run(tui.Options{
+		Load: func() ([]tui.Item, error) {
+			defer quiet()()
+			return backupItems(cfg)
+		},
+		Details: func(it tui.Item) ([]string, error) {
+			defer quiet()()
+			return inspectItem(cfg, it, log)
+		},
+		Verify: func(it tui.Item) error {
+			r, err := verify.File(cfg, it.Local, log)
+			if r.Database != "" {
+				verify.Record(cfg, nil, []verify.Result{r}, log)
+			}
+			if err == nil {
+				fmt.Println(i18n.Tf("msg.verify_ok", r.File, r.Database, r.Tables, r.Rows, r.Duration.Round(time.Second)))
+			}
+			return err
+		},
+		Download: func(it tui.Item) error {
+			saved, err := remote.GetFile(cfg, it.Name, cfg.BackupDir, log)
+			for _, p := range saved {
+				fmt.Println(i18n.Tf("msg.saved", p))
+			}
+			return err
+		},
+		Restore: func(it tui.Item) error {
+			return restoreItem(cfg, it, log)
+		},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("error.tui")+"\n", err)
+		os.Exit(1)
+	}
+}
+
+// backupItems lists the backups of backup_dir and, if configured, of the remote target for --tui. If the remote
+// target cannot be listed, the local backups are returned with the error.
+func backupItems(cfg *config.Config) ([]tui.Item, error) {
+	local, err := retention.ListBackups(cfg.BackupDir)
+	if err != nil {
+		return nil, err
+	}
+	cat, _ := catalog.Load(cfg.BackupDir) // ohne Katalog nur ohne Details
+	var remoteFiles []retention.BackupFile
+	if cfg.RemoteBackupDir != "" && cfg.RemoteSSHHost != "" {
+		remoteFiles, err = remote.ListBackups(cfg)
+	}
+	return tui.Merge(local, remoteFiles, cat), err
+}
+
+// inspectItem reads the contents of a backup for the detail view of --tui: the local ZIP, otherwise the one on the
+// remote target in place (decrypted like --restore --from-remote).
+func inspectItem(cfg *config.Config, it tui.Item, log *logger.Logger) ([]string, error) {
+	var src restore.Source
+	if it.Local != "" {
+		f, err := os.Open(it.Local)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		src = restore.Source{Name: it.Name, ReaderAt: f, Size: info.Size()}
+	} else {
+		backups, closeRemote, err := remote.OpenBackups(cfg, it.Name, log)
+		if err != nil {
+			return nil, err
+		}
+		defer closeRemote()
+		src = restore.Source{Name: backups[0].Name, ReaderAt: backups[0].ReaderAt, Size: backups[0].Size}
+	}
+	c, err := restore.Inspect(src)
+	if err != nil {
+		return nil, err
+	}
+	return inspectLines(c), nil
+}
+
+// restoreItem restores one backup chosen in --tui like --restore <zip> --force (local) or --from-remote: an
+// existing database is only dropped after its name was typed.
+func restoreItem(cfg *config.Config, it tui.Item, log *logger.Logger) error {
+	opt := restore.Options{Force: true, Confirm: confirmDrop(bufio.NewReader(os.Stdin)), DataDir: cfg.MySQLDataDir,
+		Charset: cfg.RestoreCharset, Collation: cfg.RestoreCollation}
+	conn := restoreConn(cfg, opt, cfg.RootPassword)
+	defer conn.Close()
+	var err error
+	if it.Local != "" {
+		files := []retention.BackupFile{{Path: it.Local, Date: it.Date, Size: it.Size}}
+		if _, _, err = checkSignatures(cfg, files, nil, log); err == nil {
+			err = restore.RestoreFromZips(conn, files, opt, log)
+		}
+	} else {
+		backups, closeRemote, openErr := remote.OpenBackups(cfg, it.Name, log)
+		if openErr != nil {
+			return openErr
+		}
+		defer closeRemote()
+		verified, cleanup, checkErr := checkSignatures(cfg, nil, backups, log)
+		defer cleanup()
+		if err = checkErr; err == nil {
+			b := verified[0]
+			err = restore.RestoreFromSources(conn, []restore.Source{{Name: b.Name, ReaderAt: b.ReaderAt, Size: b.Size}}, opt, log)
+		}
+	}
+	auditRestore(cfg, log, audit.Restore, []string{it.Name}, err)
+	if err != nil {
+		return err
+	}
 	log.Info(i18n.T("log.msg.restore_ok"))
+	return nil
 }
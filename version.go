@@ -2,6 +2,6 @@
 package main
 
 var (
-	Version   = "1.2.0.65" // Major, Minor, Patch, Build
+	Version   = "1.3.0.66" // Major, Minor, Patch, Build
 	BuildTime = "2026-02-11 10:51:32"
 )